@@ -0,0 +1,391 @@
+// Command replay re-runs a protocol execution recorded with protocol.MultiHandler.Record or
+// protocol.TwoPartyHandler.Record, and reports which party sent the first message the recording party rejected.
+//
+// Usage:
+//
+//	replay -transcript FILE -key FILE [-keystore FILE] [-input FILE]
+//
+// The key file contains the protocol.SnapshotKeySize bytes given to Record.
+// The parameters of the transcript must be the cbor encoding of Parameters.
+//
+// All the protocols of the cmp, frost and doerner packages whose rounds can be snapshotted are supported,
+// over any curve: keygen, refresh, reshare, import, presign and signing.
+// The batch presign of cmp, the preprocessed and ROAST signatures of frost, and musig2 cannot be recorded.
+//
+// Protocols starting from an existing key also need the config of the recording party, which is read from a keystore
+// created by the keystore package. Its passphrase is read from the REPLAY_PASSPHRASE environment variable.
+// The import of a key and the online phase of a cmp presignature also need the secret input of the recording party,
+// read from the input file: the binary encoding of its cmp.ImportedShare, or the cbor encoding of its ecdsa.PreSignature.
+//
+// The exit status is 1 if a message was rejected, and 2 if the execution could not be replayed.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/taurusgroup/multi-party-sig/pkg/ecdsa"
+	"github.com/taurusgroup/multi-party-sig/pkg/keystore"
+	"github.com/taurusgroup/multi-party-sig/pkg/math/curve"
+	"github.com/taurusgroup/multi-party-sig/pkg/party"
+	"github.com/taurusgroup/multi-party-sig/pkg/pool"
+	"github.com/taurusgroup/multi-party-sig/pkg/protocol"
+	"github.com/taurusgroup/multi-party-sig/protocols/cmp"
+	cmpconfig "github.com/taurusgroup/multi-party-sig/protocols/cmp/config"
+	"github.com/taurusgroup/multi-party-sig/protocols/cmp/presign"
+	"github.com/taurusgroup/multi-party-sig/protocols/doerner"
+	"github.com/taurusgroup/multi-party-sig/protocols/frost"
+)
+
+// Parameters are the public parameters which must be given to MultiHandler.Record.
+//
+// Only the fields used by the arguments of the recorded protocol need to be set.
+// Points and keys are stored in their binary encoding, and decoded over the group of the execution.
+type Parameters struct {
+	// Group is the name of the curve used by protocols which do not start from a config, secp256k1 if empty.
+	Group     string
+	SelfID    party.ID
+	PartyIDs  []party.ID
+	Threshold int
+	// Signers and MessageHash are the arguments of signing protocols.
+	Signers     []party.ID
+	MessageHash []byte
+	// OldParties and NewParties are the committees of a cmp reshare, whose new threshold is Threshold.
+	// PublicKey is the key kept by the reshare, which is only needed by parties joining the committee.
+	OldParties []party.ID
+	NewParties []party.ID
+	PublicKey  []byte
+	// BackupKey is the cmp.BackupKey of a cmp keygen or refresh with backup.
+	BackupKey []byte
+	// KeyPath is set when a taproot signature is a key path spend of the output key committing to MerkleRoot,
+	// which may be empty.
+	KeyPath    bool
+	MerkleRoot []byte
+	// AdaptorPoint is the point T of a frost pre-signature.
+	AdaptorPoint []byte
+	// OtherID is the other party of a doerner protocol, and Receiver the role of the recording party in a keygen.
+	OtherID  party.ID
+	Receiver bool
+}
+
+func main() {
+	transcriptPath := flag.String("transcript", "", "the recorded transcript")
+	keyPath := flag.String("key", "", "the file containing the key given to Record")
+	keystorePath := flag.String("keystore", "", "the keystore holding the config of the recording party, for protocols starting from one")
+	inputPath := flag.String("input", "", "the file holding the imported share or presignature of the recording party, for protocols starting from one")
+	flag.Parse()
+	if *transcriptPath == "" || *keyPath == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	err := run(*transcriptPath, *keyPath, *keystorePath, *inputPath, []byte(os.Getenv("REPLAY_PASSPHRASE")))
+	var protocolErr protocol.Error
+	switch {
+	case err == nil:
+		fmt.Println("all received messages were accepted")
+	case errors.As(err, &protocolErr):
+		fmt.Printf("rejected message from %v: %v\n", protocolErr.Culprits, protocolErr.Err)
+		os.Exit(1)
+	default:
+		fmt.Fprintln(os.Stderr, "replay:", err)
+		os.Exit(2)
+	}
+}
+
+// run replays the transcript stored at transcriptPath, and returns the error of protocol.Replay.
+func run(transcriptPath, keyPath, keystorePath, inputPath string, passphrase []byte) error {
+	data, err := os.ReadFile(transcriptPath)
+	if err != nil {
+		return err
+	}
+	var transcript protocol.Transcript
+	if err = transcript.UnmarshalBinary(data); err != nil {
+		return err
+	}
+	key, err := os.ReadFile(keyPath)
+	if err != nil {
+		return err
+	}
+	var parameters Parameters
+	if err = transcript.UnmarshalParameters(&parameters); err != nil {
+		return err
+	}
+	var config interface{}
+	if keystorePath != "" {
+		if data, err = os.ReadFile(keystorePath); err != nil {
+			return err
+		}
+		var k keystore.Keystore
+		if err = k.UnmarshalBinary(data); err != nil {
+			return err
+		}
+		if config, err = k.Open(passphrase); err != nil {
+			return err
+		}
+	}
+
+	var input []byte
+	if inputPath != "" {
+		if input, err = os.ReadFile(inputPath); err != nil {
+			return err
+		}
+	}
+
+	pl := pool.NewPool(0)
+	defer pl.TearDown()
+	create, err := startFunc(transcript.Protocol, &parameters, config, input, pl)
+	if err != nil {
+		return err
+	}
+	return protocol.Replay(&transcript, key, create)
+}
+
+// startFunc recreates the StartFunc of the given protocol.
+//
+// config is the config of the recording party, and input its secret input other than the config, if any.
+func startFunc(protocolID string, p *Parameters, config interface{}, input []byte, pl *pool.Pool) (protocol.StartFunc, error) {
+	switch protocolID {
+	case "cmp/keygen-threshold":
+		group, err := groupByName(p.Group)
+		if err != nil {
+			return nil, err
+		}
+		return cmp.Keygen(group, p.SelfID, p.PartyIDs, p.Threshold, pl), nil
+	case "cmp/keygen-backup-threshold":
+		group, err := groupByName(p.Group)
+		if err != nil {
+			return nil, err
+		}
+		backup, err := backupKey(p.BackupKey)
+		if err != nil {
+			return nil, err
+		}
+		return cmp.KeygenWithBackup(group, p.SelfID, p.PartyIDs, p.Threshold, backup, pl), nil
+	case "cmp/refresh-threshold":
+		c, err := cmpConfig(config)
+		if err != nil {
+			return nil, err
+		}
+		return cmp.Refresh(c, pl), nil
+	case "cmp/refresh-backup-threshold":
+		c, err := cmpConfig(config)
+		if err != nil {
+			return nil, err
+		}
+		backup, err := backupKey(p.BackupKey)
+		if err != nil {
+			return nil, err
+		}
+		return cmp.RefreshWithBackup(c, backup, pl), nil
+	case "cmp/import-threshold":
+		group, err := groupByName(p.Group)
+		if err != nil {
+			return nil, err
+		}
+		share := cmpconfig.EmptyImportedShare(group)
+		if err = share.UnmarshalBinary(input); err != nil {
+			return nil, fmt.Errorf("an input with the imported share is needed: %w", err)
+		}
+		return cmp.ImportRefresh(share, pl), nil
+	case "cmp/reshare-threshold":
+		// parties joining the committee have no config
+		if config == nil {
+			group, err := groupByName(p.Group)
+			if err != nil {
+				return nil, err
+			}
+			publicKey, err := point(group, p.PublicKey)
+			if err != nil {
+				return nil, fmt.Errorf("public key: %w", err)
+			}
+			return cmp.ReshareJoin(group, p.SelfID, publicKey, p.OldParties, p.NewParties, p.Threshold, pl), nil
+		}
+		c, err := cmpConfig(config)
+		if err != nil {
+			return nil, err
+		}
+		return cmp.Reshare(c, p.OldParties, p.NewParties, p.Threshold, pl), nil
+	case "cmp/presign-offline":
+		c, err := cmpConfig(config)
+		if err != nil {
+			return nil, err
+		}
+		return cmp.Presign(c, p.Signers, pl), nil
+	case "cmp/presign-full":
+		c, err := cmpConfig(config)
+		if err != nil {
+			return nil, err
+		}
+		return presign.StartPresign(c, p.Signers, p.MessageHash, pl), nil
+	case "cmp/presign-online":
+		c, err := cmpConfig(config)
+		if err != nil {
+			return nil, err
+		}
+		preSignature := ecdsa.EmptyPreSignature(c.Group)
+		if err = cbor.Unmarshal(input, preSignature); err != nil {
+			return nil, fmt.Errorf("an input with the presignature is needed: %w", err)
+		}
+		return cmp.PresignOnline(c, preSignature, p.MessageHash, pl), nil
+	case "cmp/sign":
+		c, err := cmpConfig(config)
+		if err != nil {
+			return nil, err
+		}
+		return cmp.Sign(c, p.Signers, p.MessageHash, pl), nil
+	case "frost/keygen-threshold":
+		// a refresh has the same protocol ID as a keygen, but starts from a config
+		if config != nil {
+			c, err := frostConfig(config)
+			if err != nil {
+				return nil, err
+			}
+			return frost.Refresh(c, p.PartyIDs), nil
+		}
+		group, err := groupByName(p.Group)
+		if err != nil {
+			return nil, err
+		}
+		return frost.Keygen(group, p.SelfID, p.PartyIDs, p.Threshold), nil
+	case "frost/keygen-threshold-taproot":
+		if config != nil {
+			c, err := taprootConfig(config)
+			if err != nil {
+				return nil, err
+			}
+			return frost.RefreshTaproot(c, p.PartyIDs), nil
+		}
+		return frost.KeygenTaproot(p.SelfID, p.PartyIDs, p.Threshold), nil
+	case "frost/sign-threshold":
+		c, err := frostConfig(config)
+		if err != nil {
+			return nil, err
+		}
+		return frost.Sign(c, p.Signers, p.MessageHash), nil
+	case "frost/sign-threshold-rfc9591":
+		c, err := frostConfig(config)
+		if err != nil {
+			return nil, err
+		}
+		return frost.SignRFC9591(c, p.Signers, p.MessageHash), nil
+	case "frost/sign-threshold-adaptor":
+		c, err := frostConfig(config)
+		if err != nil {
+			return nil, err
+		}
+		T, err := point(c.Curve(), p.AdaptorPoint)
+		if err != nil {
+			return nil, fmt.Errorf("adaptor point: %w", err)
+		}
+		return frost.SignAdaptor(c, p.Signers, p.MessageHash, T), nil
+	case "frost/sign-threshold-taproot":
+		c, err := taprootConfig(config)
+		if err != nil {
+			return nil, err
+		}
+		if p.KeyPath {
+			return frost.SignTaprootKeyPath(c, p.MerkleRoot, p.Signers, p.MessageHash), nil
+		}
+		return frost.SignTaproot(c, p.Signers, p.MessageHash), nil
+	case "frost/sign-threshold-taproot-adaptor":
+		c, err := taprootConfig(config)
+		if err != nil {
+			return nil, err
+		}
+		T, err := point(curve.Secp256k1{}, p.AdaptorPoint)
+		if err != nil {
+			return nil, fmt.Errorf("adaptor point: %w", err)
+		}
+		return frost.SignTaprootAdaptor(c, p.Signers, p.MessageHash, T), nil
+	case "doerner/keygen":
+		// the keygen, refresh and signing protocols of doerner share their protocol ID,
+		// and are told apart by the config and the message.
+		switch c := config.(type) {
+		case nil:
+			group, err := groupByName(p.Group)
+			if err != nil {
+				return nil, err
+			}
+			return doerner.Keygen(group, p.Receiver, p.SelfID, p.OtherID, pl), nil
+		case *doerner.ConfigReceiver:
+			if len(p.MessageHash) == 0 {
+				return doerner.RefreshReceiver(c, p.SelfID, p.OtherID, pl), nil
+			}
+			return doerner.SignReceiver(c, p.SelfID, p.OtherID, p.MessageHash, pl), nil
+		case *doerner.ConfigSender:
+			if len(p.MessageHash) == 0 {
+				return doerner.RefreshSender(c, p.SelfID, p.OtherID, pl), nil
+			}
+			return doerner.SignSender(c, p.SelfID, p.OtherID, p.MessageHash, pl), nil
+		default:
+			return nil, errors.New("a keystore with a doerner config is needed")
+		}
+	default:
+		return nil, fmt.Errorf("unsupported protocol %q", protocolID)
+	}
+}
+
+// cmpConfig returns config as a cmp config.
+func cmpConfig(config interface{}) (*cmp.Config, error) {
+	c, ok := config.(*cmp.Config)
+	if !ok {
+		return nil, errors.New("a keystore with a cmp config is needed")
+	}
+	return c, nil
+}
+
+// frostConfig returns config as a frost config.
+func frostConfig(config interface{}) (*frost.Config, error) {
+	c, ok := config.(*frost.Config)
+	if !ok {
+		return nil, errors.New("a keystore with a frost config is needed")
+	}
+	return c, nil
+}
+
+// taprootConfig returns config as a frost taproot config.
+func taprootConfig(config interface{}) (*frost.TaprootConfig, error) {
+	c, ok := config.(*frost.TaprootConfig)
+	if !ok {
+		return nil, errors.New("a keystore with a taproot config is needed")
+	}
+	return c, nil
+}
+
+// backupKey decodes a cmp.BackupKey.
+func backupKey(data []byte) (*cmp.BackupKey, error) {
+	backup := &cmp.BackupKey{}
+	if err := backup.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+	return backup, nil
+}
+
+// point decodes a point of group.
+func point(group curve.Curve, data []byte) (curve.Point, error) {
+	p := group.NewPoint()
+	if err := p.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// groupByName returns the curve.Curve whose Name is name, or secp256k1 if name is empty.
+func groupByName(name string) (curve.Curve, error) {
+	switch name {
+	case "", curve.Secp256k1{}.Name():
+		return curve.Secp256k1{}, nil
+	case curve.Ed25519{}.Name():
+		return curve.Ed25519{}, nil
+	case curve.P256{}.Name():
+		return curve.P256{}, nil
+	case curve.Ristretto255{}.Name():
+		return curve.Ristretto255{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported curve %q", name)
+	}
+}
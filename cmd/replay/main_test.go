@@ -0,0 +1,203 @@
+package main
+
+import (
+	"crypto/rand"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/taurusgroup/multi-party-sig/internal/test"
+	"github.com/taurusgroup/multi-party-sig/pkg/ecdsa"
+	"github.com/taurusgroup/multi-party-sig/pkg/keystore"
+	"github.com/taurusgroup/multi-party-sig/pkg/math/curve"
+	"github.com/taurusgroup/multi-party-sig/pkg/party"
+	"github.com/taurusgroup/multi-party-sig/pkg/pool"
+	"github.com/taurusgroup/multi-party-sig/pkg/protocol"
+	"github.com/taurusgroup/multi-party-sig/protocols/cmp"
+	"github.com/taurusgroup/multi-party-sig/protocols/doerner"
+	"github.com/taurusgroup/multi-party-sig/protocols/frost"
+)
+
+func TestRun(t *testing.T) {
+	partyIDs := test.PartyIDs(3)
+	recorder, cheater := partyIDs[0], partyIDs[2]
+	key := make([]byte, protocol.SnapshotKeySize)
+	_, _ = rand.Read(key)
+
+	handlers := make(map[party.ID]protocol.Handler, len(partyIDs))
+	for _, id := range partyIDs {
+		h, err := protocol.NewMultiHandler(frost.Keygen(curve.Ed25519{}, id, partyIDs, 1), nil)
+		require.NoError(t, err)
+		handlers[id] = h
+	}
+	require.NoError(t, handlers[recorder].(*protocol.MultiHandler).Record(&Parameters{
+		Group:     curve.Ed25519{}.Name(),
+		SelfID:    recorder,
+		PartyIDs:  partyIDs,
+		Threshold: 1,
+	}, key))
+	for test.Step(handlers) {
+	}
+	transcript := handlers[recorder].(*protocol.MultiHandler).Transcript()
+
+	dir := t.TempDir()
+	transcriptPath, keyPath := filepath.Join(dir, "transcript"), filepath.Join(dir, "key")
+	require.NoError(t, os.WriteFile(keyPath, key, 0o600))
+	write := func(transcript *protocol.Transcript) {
+		data, err := transcript.MarshalBinary()
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(transcriptPath, data, 0o600))
+	}
+
+	write(transcript)
+	assert.NoError(t, run(transcriptPath, keyPath, "", "", nil))
+
+	// tamper with the share the cheater sent to the recorder.
+	for _, m := range transcript.Messages {
+		if !m.Sent && m.Message.From == cheater && m.Message.RoundNumber == 3 && !m.Message.Broadcast {
+			m.Message.Data[len(m.Message.Data)-1] ^= 1
+		}
+	}
+	write(transcript)
+	err := run(transcriptPath, keyPath, "", "", nil)
+	var protocolErr protocol.Error
+	require.True(t, errors.As(err, &protocolErr))
+	assert.Equal(t, []party.ID{cheater}, protocolErr.Culprits)
+
+	assert.Error(t, run(transcriptPath, transcriptPath, "", "", nil))
+}
+
+// recordingHandler is a handler whose execution can be recorded.
+type recordingHandler interface {
+	protocol.Handler
+	Record(parameters interface{}, key []byte) error
+	Transcript() *protocol.Transcript
+}
+
+// record runs the executions of the given handlers, with a transcript recorded by recorder,
+// and writes the transcript and its key to dir.
+func record(t *testing.T, dir string, handlers map[party.ID]recordingHandler, recorder party.ID, parameters *Parameters) (transcriptPath, keyPath string, results map[party.ID]interface{}) {
+	key := make([]byte, protocol.SnapshotKeySize)
+	_, _ = rand.Read(key)
+
+	require.NoError(t, handlers[recorder].Record(parameters, key))
+	steps := make(map[party.ID]protocol.Handler, len(handlers))
+	for id, h := range handlers {
+		steps[id] = h
+	}
+	for test.Step(steps) {
+	}
+	results = make(map[party.ID]interface{}, len(handlers))
+	for id, h := range handlers {
+		r, err := h.Result()
+		require.NoError(t, err)
+		results[id] = r
+	}
+
+	data, err := handlers[recorder].Transcript().MarshalBinary()
+	require.NoError(t, err)
+	transcriptPath, keyPath = filepath.Join(dir, "transcript"), filepath.Join(dir, "key")
+	require.NoError(t, os.WriteFile(transcriptPath, data, 0o600))
+	require.NoError(t, os.WriteFile(keyPath, key, 0o600))
+	return transcriptPath, keyPath, results
+}
+
+// multiHandlers creates a MultiHandler for each party.
+func multiHandlers(t *testing.T, partyIDs []party.ID, create func(party.ID) protocol.StartFunc) map[party.ID]recordingHandler {
+	handlers := make(map[party.ID]recordingHandler, len(partyIDs))
+	for _, id := range partyIDs {
+		h, err := protocol.NewMultiHandler(create(id), []byte("session"))
+		require.NoError(t, err)
+		handlers[id] = h
+	}
+	return handlers
+}
+
+func TestRunPresign(t *testing.T) {
+	pl := pool.NewPool(0)
+	defer pl.TearDown()
+
+	configs, partyIDs := test.GenerateConfig(curve.Secp256k1{}, 3, 1, rand.Reader, pl)
+	signers := partyIDs[:2]
+	recorder := signers[0]
+	passphrase := []byte("passphrase")
+	dir := t.TempDir()
+
+	ks, err := keystore.Seal(configs[recorder], passphrase)
+	require.NoError(t, err)
+	data, err := ks.MarshalBinary()
+	require.NoError(t, err)
+	keystorePath := filepath.Join(dir, "keystore")
+	require.NoError(t, os.WriteFile(keystorePath, data, 0o600))
+
+	transcriptPath, keyPath, results := record(t, dir, multiHandlers(t, signers, func(id party.ID) protocol.StartFunc {
+		return cmp.Presign(configs[id], signers, pl)
+	}), recorder, &Parameters{
+		SelfID:  recorder,
+		Signers: signers,
+	})
+	assert.NoError(t, run(transcriptPath, keyPath, keystorePath, "", passphrase))
+	assert.Error(t, run(transcriptPath, keyPath, "", "", nil), "the config is needed")
+
+	// the online signature also needs the presignature of the recording party.
+	messageHash := make([]byte, 32)
+	_, _ = rand.Read(messageHash)
+	transcriptPath, keyPath, _ = record(t, dir, multiHandlers(t, signers, func(id party.ID) protocol.StartFunc {
+		return cmp.PresignOnline(configs[id], results[id].(*ecdsa.PreSignature), messageHash, pl)
+	}), recorder, &Parameters{
+		SelfID:      recorder,
+		MessageHash: messageHash,
+	})
+	data, err = cbor.Marshal(results[recorder])
+	require.NoError(t, err)
+	inputPath := filepath.Join(dir, "presignature")
+	require.NoError(t, os.WriteFile(inputPath, data, 0o600))
+	assert.NoError(t, run(transcriptPath, keyPath, keystorePath, inputPath, passphrase))
+	assert.Error(t, run(transcriptPath, keyPath, keystorePath, "", passphrase), "the presignature is needed")
+}
+
+func TestRunDoerner(t *testing.T) {
+	pl := pool.NewPool(0)
+	defer pl.TearDown()
+
+	group := curve.P256{}
+	partyIDs := test.PartyIDs(2)
+	receiver, sender := partyIDs[0], partyIDs[1]
+	handlers := make(map[party.ID]recordingHandler, 2)
+	var err error
+	handlers[receiver], err = protocol.NewTwoPartyHandler(doerner.Keygen(group, true, receiver, sender, pl), []byte("session"), true)
+	require.NoError(t, err)
+	handlers[sender], err = protocol.NewTwoPartyHandler(doerner.Keygen(group, false, sender, receiver, pl), []byte("session"), false)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	transcriptPath, keyPath, _ := record(t, dir, handlers, sender, &Parameters{
+		Group:   group.Name(),
+		SelfID:  sender,
+		OtherID: receiver,
+	})
+	assert.NoError(t, run(transcriptPath, keyPath, "", "", nil))
+
+	// tamper with a message the receiver sent to the recorder.
+	data, err := os.ReadFile(transcriptPath)
+	require.NoError(t, err)
+	var transcript protocol.Transcript
+	require.NoError(t, transcript.UnmarshalBinary(data))
+	for _, m := range transcript.Messages {
+		if !m.Sent && m.Message.From == receiver && m.Message.RoundNumber != 0 {
+			m.Message.Data[len(m.Message.Data)-1] ^= 1
+			break
+		}
+	}
+	data, err = transcript.MarshalBinary()
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(transcriptPath, data, 0o600))
+	err = run(transcriptPath, keyPath, "", "", nil)
+	var protocolErr protocol.Error
+	require.True(t, errors.As(err, &protocolErr))
+	assert.Equal(t, []party.ID{receiver}, protocolErr.Culprits)
+}
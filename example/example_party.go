@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"github.com/taurusgroup/multi-party-sig/internal/test"
 	"github.com/taurusgroup/multi-party-sig/pkg/ecdsa"
+	"github.com/taurusgroup/multi-party-sig/pkg/keystore"
 	"github.com/taurusgroup/multi-party-sig/pkg/math/curve"
 	"github.com/taurusgroup/multi-party-sig/pkg/party"
 	"github.com/taurusgroup/multi-party-sig/pkg/pool"
@@ -81,7 +82,7 @@ func mustCopy(dst net.Conn, src io.Reader) {
 }
 
 func processCmd(uid party.ID, tcp *test.NetworkBroadCast, ids party.IDSlice, threshold int, group curve.Secp256k1) {
-	f := fmt.Sprintf("config_%s.txt", uid)
+	f := configFile(uid)
 	pl := pool.NewPool(0)
 	defer pl.TearDown()
 	for cmd := range tcp.CMD() {
@@ -157,41 +158,114 @@ func genAddress(cfgOld *cmp.Config, path string) {
 	log.Printf("pubkey compress:%x", pubkey)
 }
 
+// configFile returns the file in which the config of party uid is stored.
+func configFile(uid party.ID) string {
+	return fmt.Sprintf("config_%s.txt", uid)
+}
+
+// passphrase returns the passphrase protecting the config files, read from the environment.
+// It exits if MPS_PASSPHRASE is not set, rather than storing key shares under an empty passphrase.
+func passphrase() []byte {
+	p := os.Getenv("MPS_PASSPHRASE")
+	if p == "" {
+		log.Fatal("MPS_PASSPHRASE must be set to the passphrase protecting the config files")
+	}
+	return []byte(p)
+}
+
+// migrateConfig encrypts the plaintext config file f written by a previous release, replacing it.
+func migrateConfig(f string, group curve.Secp256k1) error {
+	cfb, err := ioutil.ReadFile(f)
+	if err != nil {
+		return err
+	}
+	if err = new(keystore.Keystore).UnmarshalBinary(cfb); err == nil {
+		return fmt.Errorf("%s is already encrypted", f)
+	}
+	ks, err := keystore.Migrate(cfb, keystore.KindCMP, group, passphrase())
+	if err != nil {
+		return fmt.Errorf("%s: %w", f, err)
+	}
+	data, err := ks.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	// write the encrypted config next to the old one first, so that a crash cannot lose the share
+	tmp := f + ".tmp"
+	if err = ioutil.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	if err = os.Rename(tmp, f); err != nil {
+		return err
+	}
+	log.Printf("Encrypted %s", f)
+	return nil
+}
+
 func writeConfig(cfg *cmp.Config, f string) {
-	cfb, _ := cfg.MarshalBinary()
-	ioutil.WriteFile(f, cfb, 0644)
+	ks, err := keystore.Seal(cfg, passphrase())
+	if err != nil {
+		log.Println("Error", err)
+		return
+	}
+	cfb, _ := ks.MarshalBinary()
+	if err = ioutil.WriteFile(f, cfb, 0600); err != nil {
+		log.Println("Error", err)
+	}
 }
 
 func loadConfig(f string, group curve.Secp256k1) (*cmp.Config, error) {
 	cfb, err := ioutil.ReadFile(f)
 	if err != nil {
 		log.Println("Error", err)
+		return nil, err
+	}
+	var ks keystore.Keystore
+	if err = ks.UnmarshalBinary(cfb); err != nil {
+		if cmp.EmptyConfig(group).UnmarshalBinary(cfb) == nil {
+			err = fmt.Errorf("%s is a plaintext config of a previous release, encrypt it by running with -migrate", f)
+		}
+		log.Println("Error", err)
+		return nil, err
 	}
-	//debug
-	//log.Printf("Config Content:%s", cfb)
-	cfg := cmp.EmptyConfig(group)
-	err = cfg.UnmarshalBinary(cfb)
+	if ks.Kind != keystore.KindCMP || ks.Curve != group.Name() {
+		err = fmt.Errorf("unexpected %s config on %s", ks.Kind, ks.Curve)
+		log.Println("Error", err)
+		return nil, err
+	}
+	opened, err := ks.Open(passphrase())
 	if err != nil {
 		log.Println("Error", err)
+		return nil, err
 	}
-	return cfg, err
+	return opened.(*cmp.Config), nil
 }
 
 func main() {
 	id := flag.Int("id", 0, "party id")
 	srv := flag.String("srv", "localhost:8000", "connect to tcp server")
+	migrate := flag.Bool("migrate", false, "encrypt the plaintext config file of a previous release, and exit")
 	flag.Parse()
 
 	ids := party.IDSlice{"a", "b", "c"}
 	threshold := 2
 	group := curve.Secp256k1{}
 
+	uid := ids[*id]
+	// fail before connecting if the config files cannot be protected
+	passphrase()
+	if *migrate {
+		if err := migrateConfig(configFile(uid), group); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	conn, err := net.Dial("tcp", *srv)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	uid := ids[*id]
 	log.Println("Start party:", uid)
 
 	tcp := test.NewNetworkTcp(uid, conn)
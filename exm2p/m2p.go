@@ -9,6 +9,7 @@ import (
 	"github.com/taurusgroup/multi-party-sig/internal/btc"
 	"github.com/taurusgroup/multi-party-sig/internal/test"
 	"github.com/taurusgroup/multi-party-sig/pkg/ecdsa"
+	"github.com/taurusgroup/multi-party-sig/pkg/keystore"
 	"github.com/taurusgroup/multi-party-sig/pkg/math/curve"
 	"github.com/taurusgroup/multi-party-sig/pkg/party"
 	"github.com/taurusgroup/multi-party-sig/pkg/pool"
@@ -54,6 +55,7 @@ func main() {
 	flag.IntVar(&id, "id", 0, "party id")
 	flag.StringVar(&srv, "srv", "", "local server port")
 	flag.Var(&p2p, "p2p", "-p2p localhost:7000 -p2p localhost:8000 -p2p localhost:9000")
+	migrate := flag.Bool("migrate", false, "encrypt the plaintext config file of a previous release, and exit")
 	flag.Parse()
 
 	threshold := 2
@@ -65,6 +67,14 @@ func main() {
 	}
 	uid := ids[id]
 	idm := nm[uid]
+	// fail before connecting if the config files cannot be protected
+	passphrase()
+	if *migrate {
+		if err := migrateConfig(configFile(uid), group); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
 	log.Println("Start party:", uid)
 
 	network := test.NewNetworkP2P(uid, idm)
@@ -125,7 +135,7 @@ func mustCopy(dst net.Conn, src io.Reader) {
 }
 
 func processCmd(uid party.ID, tcp *test.NetworkP2P, ids party.IDSlice, threshold int, group curve.Secp256k1) {
-	f := fmt.Sprintf("config_%s.txt", uid)
+	f := configFile(uid)
 	//fr := fmt.Sprintf("config_new_%s.txt", uid)
 	pl := pool.NewPool(0)
 	defer pl.TearDown()
@@ -230,24 +240,87 @@ func genAddress(cfgOld *cmp.Config, path string) {
 	log.Printf("address: %s", btc.Address(pubkey.XBytes(), pubkey.YBytes()))
 }
 
+// configFile returns the file in which the config of party uid is stored.
+func configFile(uid party.ID) string {
+	return fmt.Sprintf("config_%s.txt", uid)
+}
+
+// passphrase returns the passphrase protecting the config files, read from the environment.
+// It exits if MPS_PASSPHRASE is not set, rather than storing key shares under an empty passphrase.
+func passphrase() []byte {
+	p := os.Getenv("MPS_PASSPHRASE")
+	if p == "" {
+		log.Fatal("MPS_PASSPHRASE must be set to the passphrase protecting the config files")
+	}
+	return []byte(p)
+}
+
+// migrateConfig encrypts the plaintext config file f written by a previous release, replacing it.
+func migrateConfig(f string, group curve.Secp256k1) error {
+	cfb, err := ioutil.ReadFile(f)
+	if err != nil {
+		return err
+	}
+	if err = new(keystore.Keystore).UnmarshalBinary(cfb); err == nil {
+		return fmt.Errorf("%s is already encrypted", f)
+	}
+	ks, err := keystore.Migrate(cfb, keystore.KindCMP, group, passphrase())
+	if err != nil {
+		return fmt.Errorf("%s: %w", f, err)
+	}
+	data, err := ks.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	// write the encrypted config next to the old one first, so that a crash cannot lose the share
+	tmp := f + ".tmp"
+	if err = ioutil.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	if err = os.Rename(tmp, f); err != nil {
+		return err
+	}
+	log.Printf("Encrypted %s", f)
+	return nil
+}
+
 func writeConfig(cfg *cmp.Config, f string) {
-	cfb, _ := cfg.MarshalBinary()
-	ioutil.WriteFile(f, cfb, 0644)
+	ks, err := keystore.Seal(cfg, passphrase())
+	if err != nil {
+		log.Println("Error", err)
+		return
+	}
+	cfb, _ := ks.MarshalBinary()
+	if err = ioutil.WriteFile(f, cfb, 0600); err != nil {
+		log.Println("Error", err)
+	}
 }
 
 func loadConfig(f string, group curve.Secp256k1) (*cmp.Config, error) {
 	cfb, err := ioutil.ReadFile(f)
 	if err != nil {
 		log.Println("Error", err)
+		return nil, err
+	}
+	var ks keystore.Keystore
+	if err = ks.UnmarshalBinary(cfb); err != nil {
+		if cmp.EmptyConfig(group).UnmarshalBinary(cfb) == nil {
+			err = fmt.Errorf("%s is a plaintext config of a previous release, encrypt it by running with -migrate", f)
+		}
+		log.Println("Error", err)
+		return nil, err
 	}
-	//debug
-	//log.Printf("Config Content:%s", cfb)
-	cfg := cmp.EmptyConfig(group)
-	err = cfg.UnmarshalBinary(cfb)
+	if ks.Kind != keystore.KindCMP || ks.Curve != group.Name() {
+		err = fmt.Errorf("unexpected %s config on %s", ks.Kind, ks.Curve)
+		log.Println("Error", err)
+		return nil, err
+	}
+	opened, err := ks.Open(passphrase())
 	if err != nil {
 		log.Println("Error", err)
+		return nil, err
 	}
-	return cfg, err
+	return opened.(*cmp.Config), nil
 }
 func CMPKeygen1(id party.ID, ids party.IDSlice, threshold int, n test.INetwork, pl *pool.Pool) (*cmp.Config, error) {
 	h, err := protocol.NewMultiHandler(cmp.Keygen(curve.Secp256k1{}, id, ids, threshold, pl), nil)
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"github.com/taurusgroup/multi-party-sig/internal/test"
 	"github.com/taurusgroup/multi-party-sig/pkg/ecdsa"
+	"github.com/taurusgroup/multi-party-sig/pkg/keystore"
 	"github.com/taurusgroup/multi-party-sig/pkg/math/curve"
 	"github.com/taurusgroup/multi-party-sig/pkg/party"
 	"github.com/taurusgroup/multi-party-sig/pkg/pool"
@@ -18,18 +19,30 @@ import (
 )
 
 type (
-	MPCDotLib struct{}
+	MPCDotLib struct {
+		// passphrase protects the key shares returned to and passed by the app.
+		passphrase []byte
+	}
 )
 
 const (
 	Timeout = 1 * time.Minute
 )
 
+// errNoPassphrase is returned when a key share is used before SetPassphrase was called.
+var errNoPassphrase = errors.New("no passphrase, SetPassphrase must be called first")
+
 func main() {
 }
+
+// SetPassphrase sets the passphrase under which the key shares are encrypted with keystore.
+func (p *MPCDotLib) SetPassphrase(passphrase []byte) {
+	p.passphrase = append([]byte{}, passphrase...)
+}
+
 func (p *MPCDotLib) PublicKey(keyShare []byte, path string) ([]byte, []byte, error) {
 	group := curve.Secp256k1{}
-	cfg, err := loadConfig(keyShare, group)
+	cfg, err := p.loadConfig(keyShare, group)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -52,7 +65,7 @@ func (p *MPCDotLib) Sign(p2p []string, id, threshold int, keyShare []byte, path
 	network.CmdBroadcast <- fmt.Sprintf("bcmd:sign:%s:%s", path, messageToSign)
 
 	group := curve.Secp256k1{}
-	cfg, err := loadConfig(keyShare, group)
+	cfg, err := p.loadConfig(keyShare, group)
 	bipCfg, err := cfg.DeriveBIP44(path)
 	if err != nil {
 		return nil, nil, 0, err
@@ -82,12 +95,12 @@ func (p *MPCDotLib) RefreshKey(p2p []string, id, threshold int, keyShare []byte)
 	time.Sleep(1 * time.Second)
 	network.CmdBroadcast <- "bcmd:refresh"
 	group := curve.Secp256k1{}
-	cfgOld, err := loadConfig(keyShare, group)
+	cfgOld, err := p.loadConfig(keyShare, group)
 	cfgNew, err := CMPRefresh1(cfgOld, network, pl)
 	if err != nil {
 		log.Println("load refresh error:", err)
 	}
-	return cfgNew.MarshalBinary()
+	return p.sealConfig(cfgNew)
 }
 
 func (p *MPCDotLib) GenKey(p2p []string, id, threshold int) ([]byte, error) {
@@ -102,11 +115,7 @@ func (p *MPCDotLib) GenKey(p2p []string, id, threshold int) ([]byte, error) {
 	if err != nil {
 		return nil, err
 	}
-	shareKey, err := cfg.MarshalBinary()
-	if err != nil {
-		return nil, err
-	}
-	return shareKey, nil
+	return p.sealConfig(cfg)
 }
 
 func (p *MPCDotLib) initParty(p2p []string, id int) (party.IDSlice, party.ID, *test.NetworkP2P) {
@@ -197,13 +206,57 @@ func waitForServer(host string) (net.Conn, error) {
 	}
 	return nil, fmt.Errorf("Server %s failed to respond after %s", host, Timeout)
 }
-func loadConfig(cfb []byte, group curve.Secp256k1) (*cmp.Config, error) {
-	cfg := cmp.EmptyConfig(group)
-	err := cfg.UnmarshalBinary(cfb)
+// MigrateKeyShare encrypts a plaintext key share returned by a previous release under the passphrase,
+// so that it can be passed to the other methods.
+func (p *MPCDotLib) MigrateKeyShare(keyShare []byte) ([]byte, error) {
+	if len(p.passphrase) == 0 {
+		return nil, errNoPassphrase
+	}
+	if err := new(keystore.Keystore).UnmarshalBinary(keyShare); err == nil {
+		return nil, errors.New("key share is already encrypted")
+	}
+	ks, err := keystore.Migrate(keyShare, keystore.KindCMP, curve.Secp256k1{}, p.passphrase)
 	if err != nil {
 		log.Println("Error", err)
+		return nil, err
+	}
+	return ks.MarshalBinary()
+}
+
+func (p *MPCDotLib) sealConfig(cfg *cmp.Config) ([]byte, error) {
+	if len(p.passphrase) == 0 {
+		return nil, errNoPassphrase
+	}
+	ks, err := keystore.Seal(cfg, p.passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return ks.MarshalBinary()
+}
+
+func (p *MPCDotLib) loadConfig(cfb []byte, group curve.Secp256k1) (*cmp.Config, error) {
+	if len(p.passphrase) == 0 {
+		return nil, errNoPassphrase
+	}
+	var ks keystore.Keystore
+	if err := ks.UnmarshalBinary(cfb); err != nil {
+		if cmp.EmptyConfig(group).UnmarshalBinary(cfb) == nil {
+			err = errors.New("key share is plaintext from a previous release, encrypt it with MigrateKeyShare")
+		}
+		log.Println("Error", err)
+		return nil, err
+	}
+	if ks.Kind != keystore.KindCMP || ks.Curve != group.Name() {
+		err := fmt.Errorf("unexpected %s config on %s", ks.Kind, ks.Curve)
+		log.Println("Error", err)
+		return nil, err
+	}
+	opened, err := ks.Open(p.passphrase)
+	if err != nil {
+		log.Println("Error", err)
+		return nil, err
 	}
-	return cfg, err
+	return opened.(*cmp.Config), nil
 }
 func writeFile(cfg []byte, f string) {
 	if err := ioutil.WriteFile(f, cfg, 0644); err != nil {
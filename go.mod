@@ -3,10 +3,13 @@ module github.com/taurusgroup/multi-party-sig
 go 1.20
 
 require (
+	filippo.io/edwards25519 v1.0.0
+	filippo.io/nistec v0.0.3
 	github.com/cronokirby/safenum v0.29.0
 	github.com/decred/dcrd/dcrec/secp256k1/v3 v3.0.0
 	github.com/ethereum/go-ethereum v1.10.20
 	github.com/fxamacker/cbor/v2 v2.3.0
+	github.com/gtank/ristretto255 v0.1.2
 	github.com/stretchr/testify v1.7.2
 	github.com/zeebo/blake3 v0.2.0
 	golang.org/x/crypto v0.0.0-20210921155107-089bfa567519
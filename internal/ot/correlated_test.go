@@ -128,3 +128,104 @@ func BenchmarkCorreOT(b *testing.B) {
 		runCorreOT(hash.New(), choices, sendSetup, receiveSetup)
 	}
 }
+
+func TestCorreOTSetupMarshal(t *testing.T) {
+	sendSetup, receiveSetup, err := runCorreOTSetup(nil, hash.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := sendSetup.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sendDecoded CorreOTSendSetup
+	if err = sendDecoded.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if sendDecoded != *sendSetup {
+		t.Error("decoded send setup doesn't match")
+	}
+
+	data, err = receiveSetup.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var receiveDecoded CorreOTReceiveSetup
+	if err = receiveDecoded.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if receiveDecoded != *receiveSetup {
+		t.Error("decoded receive setup doesn't match")
+	}
+	if receiveDecoded.UnmarshalBinary(data[1:]) == nil {
+		t.Error("truncated setup should be rejected")
+	}
+}
+
+func TestCorreOTSetupRestore(t *testing.T) {
+	pl := pool.NewPool(0)
+	defer pl.TearDown()
+
+	H := hash.New()
+	sender := NewCorreOTSetupSender(pl, H.Clone())
+	receiver := NewCorreOTSetupReceiver(pl, H.Clone(), testGroup)
+	if _, err := sender.MarshalBinary(); err == nil {
+		t.Error("marshalling before Round1 should fail")
+	}
+
+	// restore both parties from their encoding after each round
+	restore := func() {
+		data, err := sender.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		sender = NewCorreOTSetupSender(pl, H.Clone())
+		if err = sender.UnmarshalBinary(data); err != nil {
+			t.Fatal(err)
+		}
+		data, err = receiver.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		receiver = NewCorreOTSetupReceiver(pl, H.Clone(), testGroup)
+		if err = receiver.UnmarshalBinary(data); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	msgR1 := receiver.Round1()
+	msgS1, err := sender.Round1(msgR1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	restore()
+	msgR2, err := receiver.Round2(msgS1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msgS2 := sender.Round2(msgR2)
+	restore()
+	msgR3, receiveSetup, err := receiver.Round3(msgS2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sendSetup, err := sender.Round3(msgR3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < params.OTParam; i++ {
+		array := receiveSetup._K_0[i][:]
+		if bitAt(i, sendSetup._Delta[:]) == 1 {
+			array = receiveSetup._K_1[i][:]
+		}
+		if !bytes.Equal(sendSetup._K_Delta[i][:], array) {
+			t.Error("K_Delta doesn't match")
+		}
+	}
+
+	data, _ := receiver.MarshalBinary()
+	if NewCorreOTSetupReceiver(pl, H.Clone(), testGroup).UnmarshalBinary(data[:params.OTParam]) == nil {
+		t.Error("truncated state should be rejected")
+	}
+}
@@ -144,6 +144,9 @@ func ExtendedOTSend(ctxHash *hash.Hash, setup *CorreOTSendSetup, batchSize int,
 //
 // We receive the random vectors corresponding to our choice bits.
 type ExtendedOTReceiveResult struct {
+	// The columns of the U matrix, which were written to the hash.
+	// They are kept so that the hash state can be restored.
+	_U        [params.OTParam][]byte
 	_VChoices [][params.OTBytes]byte
 }
 
@@ -204,5 +207,5 @@ func ExtendedOTReceive(ctxHash *hash.Hash, setup *CorreOTReceiveSetup, choices [
 		_, _ = hasher.Digest().Read(VChoices[i][:])
 	}
 
-	return outMsg, &ExtendedOTReceiveResult{_VChoices: VChoices}
+	return outMsg, &ExtendedOTReceiveResult{_U: correMsg.U, _VChoices: VChoices}
 }
@@ -0,0 +1,239 @@
+package ot
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/cronokirby/safenum"
+	"github.com/fxamacker/cbor/v2"
+	"github.com/taurusgroup/multi-party-sig/internal/params"
+	"github.com/taurusgroup/multi-party-sig/pkg/hash"
+	"github.com/taurusgroup/multi-party-sig/pkg/math/curve"
+	"github.com/zeebo/blake3"
+)
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+//
+// The setup is encoded as Delta, followed by the rows of K_Delta.
+func (s *CorreOTSendSetup) MarshalBinary() ([]byte, error) {
+	out := make([]byte, 0, (params.OTParam+1)*params.OTBytes)
+	out = append(out, s._Delta[:]...)
+	for i := range s._K_Delta {
+		out = append(out, s._K_Delta[i][:]...)
+	}
+	return out, nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+func (s *CorreOTSendSetup) UnmarshalBinary(data []byte) error {
+	if len(data) != (params.OTParam+1)*params.OTBytes {
+		return errors.New("CorreOTSendSetup: incorrect length")
+	}
+	data = data[copy(s._Delta[:], data):]
+	for i := range s._K_Delta {
+		data = data[copy(s._K_Delta[i][:], data):]
+	}
+	return nil
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+//
+// The setup is encoded as the rows of K_0, followed by the rows of K_1.
+func (s *CorreOTReceiveSetup) MarshalBinary() ([]byte, error) {
+	out := make([]byte, 0, 2*params.OTParam*params.OTBytes)
+	for i := range s._K_0 {
+		out = append(out, s._K_0[i][:]...)
+	}
+	for i := range s._K_1 {
+		out = append(out, s._K_1[i][:]...)
+	}
+	return out, nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+func (s *CorreOTReceiveSetup) UnmarshalBinary(data []byte) error {
+	if len(data) != 2*params.OTParam*params.OTBytes {
+		return errors.New("CorreOTReceiveSetup: incorrect length")
+	}
+	for i := range s._K_0 {
+		data = data[copy(s._K_0[i][:], data):]
+	}
+	for i := range s._K_1 {
+		data = data[copy(s._K_1[i][:], data):]
+	}
+	return nil
+}
+
+// randomOTReceiverStateLen is the length of the encoded state of a RandomOTReceiever, after Round1.
+const randomOTReceiverStateLen = 32 + 3*params.OTBytes
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+//
+// This must only be called after Round1. The state is encoded as Delta, followed by
+// the key, random choice, received challenge, and H(H(random choice)) of each Random OT.
+// The pool and hash are not encoded, since they are not used after Round1.
+func (r *CorreOTSetupSender) MarshalBinary() ([]byte, error) {
+	if r.setup == nil {
+		return nil, errors.New("CorreOTSetupSender: Round1 has not been run")
+	}
+	out := make([]byte, 0, params.OTBytes+params.OTParam*randomOTReceiverStateLen)
+	out = append(out, r._Delta[:]...)
+	for i := range r.randomOTReceivers {
+		receiver := &r.randomOTReceivers[i]
+		out = append(out, receiver.key...)
+		out = append(out, receiver.randChoice[:]...)
+		out = append(out, receiver.receivedChallenge[:]...)
+		out = append(out, receiver.hh_randChoice[:]...)
+	}
+	return out, nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+//
+// r should be freshly created with NewCorreOTSetupSender, and can then continue with Round2.
+func (r *CorreOTSetupSender) UnmarshalBinary(data []byte) error {
+	if len(data) != params.OTBytes+params.OTParam*randomOTReceiverStateLen {
+		return errors.New("CorreOTSetupSender: incorrect length")
+	}
+	data = data[copy(r._Delta[:], data):]
+	// The public key of the Random OT setup is only needed in Round1, and isn't restored.
+	r.setup = &RandomOTReceiveSetup{}
+	for i := range r.randomOTReceivers {
+		receiver := &r.randomOTReceivers[i]
+		var err error
+		receiver.key = append([]byte{}, data[:32]...)
+		if receiver.hash, err = blake3.NewKeyed(receiver.key); err != nil {
+			return err
+		}
+		data = data[32:]
+		receiver.choice = safenum.Choice(bitAt(i, r._Delta[:]))
+		data = data[copy(receiver.randChoice[:], data):]
+		data = data[copy(receiver.receivedChallenge[:], data):]
+		data = data[copy(receiver.hh_randChoice[:], data):]
+	}
+	return nil
+}
+
+// randomOTSenderStateLen is the length of the encoded state of a RandomOTSender, without the setup.
+const randomOTSenderStateLen = 32 + 5*params.OTBytes
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+//
+// This must only be called after Round1. The state is encoded as the key, random pads,
+// and decommitments of each Random OT, followed by the secret key of the Random OT setup.
+// The pool and hash are not encoded, since they are not used after Round1.
+func (r *CorreOTSetupReceiver) MarshalBinary() ([]byte, error) {
+	if r.setup == nil {
+		return nil, errors.New("CorreOTSetupReceiver: Round1 has not been run")
+	}
+	b, err := r.setup.b.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, 0, params.OTParam*randomOTSenderStateLen+len(b))
+	for i := range r.randomOTSenders {
+		sender := &r.randomOTSenders[i]
+		out = append(out, sender.key...)
+		out = append(out, sender.rand0[:]...)
+		out = append(out, sender.rand1[:]...)
+		out = append(out, sender.decommit0[:]...)
+		out = append(out, sender.decommit1[:]...)
+		out = append(out, sender.h_decommit0[:]...)
+	}
+	return append(out, b...), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+//
+// r should be freshly created with NewCorreOTSetupReceiver, and can then continue with Round2.
+func (r *CorreOTSetupReceiver) UnmarshalBinary(data []byte) error {
+	if len(data) <= params.OTParam*randomOTSenderStateLen {
+		return errors.New("CorreOTSetupReceiver: incorrect length")
+	}
+	b := r.group.NewScalar()
+	if err := b.UnmarshalBinary(data[params.OTParam*randomOTSenderStateLen:]); err != nil {
+		return err
+	}
+	B := b.ActOnBase()
+	r.setup = &RandomOTSendSetup{b: b, _B: B, _bB: b.Act(B)}
+	for i := range r.randomOTSenders {
+		sender := &r.randomOTSenders[i]
+		*sender = NewRandomOTSender(data[:32], r.setup)
+		data = data[32:]
+		data = data[copy(sender.rand0[:], data):]
+		data = data[copy(sender.rand1[:], data):]
+		data = data[copy(sender.decommit0[:], data):]
+		data = data[copy(sender.decommit1[:], data):]
+		data = data[copy(sender.h_decommit0[:], data):]
+	}
+	return nil
+}
+
+// multiplyReceiverState is the encoding of a MultiplyReceiver.
+type multiplyReceiverState struct {
+	Beta    []byte
+	Choices []byte
+	// U are the columns written to the hash by the extended OT.
+	U        [][]byte
+	VChoices []byte
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+//
+// This must only be called after Round1. Use RestoreMultiplyReceiver to decode the result.
+func (r *MultiplyReceiver) MarshalBinary() ([]byte, error) {
+	result := r.receiver.result
+	if result == nil {
+		return nil, errors.New("MultiplyReceiver: Round1 has not been run")
+	}
+	beta, err := r.beta.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	state := multiplyReceiverState{
+		Beta:     beta,
+		Choices:  r.choices,
+		U:        result._U[:],
+		VChoices: make([]byte, 0, len(result._VChoices)*params.OTBytes),
+	}
+	for i := range result._VChoices {
+		state.VChoices = append(state.VChoices, result._VChoices[i][:]...)
+	}
+	return cbor.Marshal(&state)
+}
+
+// RestoreMultiplyReceiver recreates a MultiplyReceiver from the output of MarshalBinary.
+//
+// ctxHash and setup must be the same as the ones given to NewMultiplyReceiver.
+// The values written to ctxHash in Round1 are written again, so that the receiver can continue with Round2.
+func RestoreMultiplyReceiver(ctxHash *hash.Hash, setup *CorreOTReceiveSetup, group curve.Curve, data []byte) (*MultiplyReceiver, error) {
+	var state multiplyReceiverState
+	if err := cbor.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("MultiplyReceiver: %w", err)
+	}
+	beta := group.NewScalar()
+	if err := beta.UnmarshalBinary(state.Beta); err != nil {
+		return nil, fmt.Errorf("MultiplyReceiver: %w", err)
+	}
+	gadget := makeGadget(ctxHash, group)
+	if 8*len(state.Choices) != len(gadget) || len(state.VChoices) != len(gadget)*params.OTBytes || len(state.U) != params.OTParam {
+		return nil, errors.New("MultiplyReceiver: incorrect length")
+	}
+	result := &ExtendedOTReceiveResult{_VChoices: make([][params.OTBytes]byte, len(gadget))}
+	for i := range result._U {
+		result._U[i] = state.U[i]
+		ctxHash.WriteAny(result._U[i])
+	}
+	for i := range result._VChoices {
+		copy(result._VChoices[i][:], state.VChoices[i*params.OTBytes:])
+	}
+	return &MultiplyReceiver{
+		ctxHash:  ctxHash,
+		group:    group,
+		setup:    setup,
+		beta:     beta,
+		gadget:   gadget,
+		choices:  state.Choices,
+		receiver: &AdditiveOTReceiver{ctxHash: ctxHash, group: group, setup: setup, choices: state.Choices, result: result},
+	}, nil
+}
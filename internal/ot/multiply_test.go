@@ -63,3 +63,51 @@ func BenchmarkMultiply(b *testing.B) {
 		runMultiply(hash.New(), sendSetup, receiveSetup, alpha, beta)
 	}
 }
+
+func TestMultiplyRestore(t *testing.T) {
+	pl := pool.NewPool(0)
+	defer pl.TearDown()
+
+	sendSetup, receiveSetup, err := runCorreOTSetup(pl, hash.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	H := hash.New()
+	alpha := sample.Scalar(rand.Reader, testGroup)
+	beta := sample.Scalar(rand.Reader, testGroup)
+	sender := NewMultiplySender(H.Clone(), sendSetup, alpha)
+	receiver, err := NewMultiplyReceiver(H.Clone(), receiveSetup, beta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = receiver.MarshalBinary(); err == nil {
+		t.Error("marshalling before Round1 should fail")
+	}
+	msgR1 := receiver.Round1()
+	data, err := receiver.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	receiver, err = RestoreMultiplyReceiver(H.Clone(), receiveSetup, testGroup, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msgS1, a, err := sender.Round1(msgR1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := receiver.Round2(msgS1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	alphabeta := testGroup.NewScalar().Set(alpha).Mul(beta)
+	if !alphabeta.Equal(a.Add(b)) {
+		t.Error("multiply failed to produce valid shares")
+	}
+
+	if _, err = RestoreMultiplyReceiver(H.Clone(), receiveSetup, testGroup, data[:len(data)-1]); err == nil {
+		t.Error("truncated state should be rejected")
+	}
+}
@@ -76,7 +76,9 @@ func RandomOTSetupReceive(hash *hash.Hash, msg *RandomOTSetupSendMessage) (*Rand
 // This should be created from a saved setup, for each execution.
 type RandomOTReceiever struct {
 	// After setup
-	hash  *blake3.Hasher
+	hash *blake3.Hasher
+	// The key of hash, kept so that the state can be serialized.
+	key   []byte
 	group curve.Curve
 	// Which random message we want to receive.
 	choice safenum.Choice
@@ -106,6 +108,7 @@ func NewRandomOTReceiver(nonce []byte, result *RandomOTReceiveSetup, choice safe
 	if err != nil {
 		panic(err)
 	}
+	out.key = append([]byte{}, nonce...)
 	out.group = result._B.Curve()
 	out.choice = choice
 	out._B = result._B
@@ -218,7 +221,9 @@ func (r *RandomOTReceiever) Round3(msg *RandomOTSendRound2Message) ([params.OTBy
 // This should be created from a saved setup, for each execution.
 type RandomOTSender struct {
 	// After setup
-	hash  *blake3.Hasher
+	hash *blake3.Hasher
+	// The key of hash, kept so that the state can be serialized.
+	key   []byte
 	group curve.Curve
 	b     curve.Scalar
 	_B    curve.Point
@@ -243,6 +248,7 @@ func NewRandomOTSender(nonce []byte, result *RandomOTSendSetup) (out RandomOTSen
 	if err != nil {
 		panic(err)
 	}
+	out.key = append([]byte{}, nonce...)
 	out.group = result.b.Curve()
 	out.b = result.b
 	out._B = result._B
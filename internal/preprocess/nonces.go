@@ -0,0 +1,126 @@
+package preprocess
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/taurusgroup/multi-party-sig/pkg/math/curve"
+	"github.com/taurusgroup/multi-party-sig/pkg/party"
+)
+
+// Nonces holds the pairs of secret nonces a party committed to ahead of time, until they are used.
+// A pair is identified by the commitment to its first nonce.
+//
+// Each pair of nonces is deleted as soon as a signature using it is started, so that it can never
+// be used twice, which would reveal the secret key. For the same reason, Nonces which are stored
+// must be persisted again after every signature, and never restored from an older copy.
+//
+// It is safe for concurrent use.
+type Nonces struct {
+	mtx     sync.Mutex
+	group   curve.Curve
+	id      party.ID
+	pending map[string][2]curve.Scalar
+}
+
+// NewNonces creates an empty Nonces for the party id, ready for unmarshalling.
+func NewNonces(group curve.Curve, id party.ID) *Nonces {
+	return &Nonces{group: group, id: id, pending: map[string][2]curve.Scalar{}}
+}
+
+// key returns the key of the pair of nonces whose first nonce is committed to by commitment.
+func key(commitment curve.Point) string {
+	data, _ := commitment.MarshalBinary()
+	return string(data)
+}
+
+// Add stores a new pair of nonces, where commitment = first•G.
+func (n *Nonces) Add(commitment curve.Point, first, second curve.Scalar) {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	n.pending[key(commitment)] = [2]curve.Scalar{first, second}
+}
+
+// Len returns the number of unused pairs of nonces.
+func (n *Nonces) Len() int {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	return len(n.pending)
+}
+
+// Contains returns true if the nonces of id behind commitment are held, and unused.
+func (n *Nonces) Contains(id party.ID, commitment curve.Point) bool {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	_, ok := n.pending[key(commitment)]
+	return id == n.id && ok
+}
+
+// Take removes and returns the nonces of id behind commitment.
+func (n *Nonces) Take(id party.ID, commitment curve.Point) (first, second curve.Scalar, err error) {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	k := key(commitment)
+	pair, ok := n.pending[k]
+	if id != n.id || !ok {
+		return nil, nil, errors.New("unknown or already used nonces")
+	}
+	delete(n.pending, k)
+	return pair[0], pair[1], nil
+}
+
+// noncesData is the serialized form of Nonces.
+type noncesData struct {
+	ID     party.ID
+	Nonces [][]byte
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+func (n *Nonces) MarshalBinary() ([]byte, error) {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	data := noncesData{ID: n.id, Nonces: make([][]byte, 0, len(n.pending))}
+	for _, pair := range n.pending {
+		first, err := pair[0].MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		second, err := pair[1].MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		data.Nonces = append(data.Nonces, append(first, second...))
+	}
+	return cbor.Marshal(data)
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+//
+// The Nonces must have been created with NewNonces.
+func (n *Nonces) UnmarshalBinary(in []byte) error {
+	var data noncesData
+	if err := cbor.Unmarshal(in, &data); err != nil {
+		return err
+	}
+	pending := make(map[string][2]curve.Scalar, len(data.Nonces))
+	for _, pairBytes := range data.Nonces {
+		if len(pairBytes)%2 != 0 {
+			return errors.New("nonces: invalid length")
+		}
+		half := len(pairBytes) / 2
+		first, second := n.group.NewScalar(), n.group.NewScalar()
+		if err := first.UnmarshalBinary(pairBytes[:half]); err != nil {
+			return err
+		}
+		if err := second.UnmarshalBinary(pairBytes[half:]); err != nil {
+			return err
+		}
+		pending[key(first.ActOnBase())] = [2]curve.Scalar{first, second}
+	}
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	n.id = data.ID
+	n.pending = pending
+	return nil
+}
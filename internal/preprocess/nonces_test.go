@@ -0,0 +1,42 @@
+package preprocess
+
+import (
+	"crypto/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/taurusgroup/multi-party-sig/pkg/math/curve"
+	"github.com/taurusgroup/multi-party-sig/pkg/math/sample"
+)
+
+func TestNonces(t *testing.T) {
+	group := curve.Secp256k1{}
+	nonces := NewNonces(group, "a")
+	commitments := make([]curve.Point, 3)
+	for i := range commitments {
+		first, second := sample.Scalar(rand.Reader, group), sample.Scalar(rand.Reader, group)
+		commitments[i] = first.ActOnBase()
+		nonces.Add(commitments[i], first, second)
+	}
+	require.Equal(t, 3, nonces.Len())
+	assert.False(t, nonces.Contains("b", commitments[0]))
+
+	// nonces can only be taken once.
+	_, _, err := nonces.Take("b", commitments[0])
+	assert.Error(t, err)
+	first, _, err := nonces.Take("a", commitments[0])
+	require.NoError(t, err)
+	assert.True(t, first.ActOnBase().Equal(commitments[0]))
+	_, _, err = nonces.Take("a", commitments[0])
+	assert.Error(t, err)
+
+	data, err := nonces.MarshalBinary()
+	require.NoError(t, err)
+	decoded := NewNonces(group, "")
+	require.NoError(t, decoded.UnmarshalBinary(data))
+	assert.Equal(t, 2, decoded.Len())
+	assert.False(t, decoded.Contains("a", commitments[0]))
+	assert.True(t, decoded.Contains("a", commitments[1]))
+	assert.True(t, decoded.Contains("a", commitments[2]))
+}
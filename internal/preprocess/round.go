@@ -0,0 +1,33 @@
+package preprocess
+
+import "github.com/taurusgroup/multi-party-sig/internal/round"
+
+// Round replaces the rounds of a signature which exchange nonces, when all of them were published in advance.
+//
+// Since there are no nonces to exchange, it directly finalizes Next, the round which uses them,
+// so that the protocol only needs a single round of communication.
+type Round struct {
+	*round.Helper
+	Next round.Round
+}
+
+// VerifyMessage implements round.Round.
+func (Round) VerifyMessage(round.Message) error { return nil }
+
+// StoreMessage implements round.Round.
+func (Round) StoreMessage(round.Message) error { return nil }
+
+// Finalize implements round.Round.
+func (r *Round) Finalize(out chan<- *round.Message) (round.Session, error) {
+	next, err := r.Next.Finalize(out)
+	if err != nil {
+		return r, err
+	}
+	return next, nil
+}
+
+// MessageContent implements round.Round.
+func (Round) MessageContent() round.Content { return nil }
+
+// Number implements round.Round.
+func (Round) Number() round.Number { return 1 }
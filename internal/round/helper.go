@@ -1,6 +1,7 @@
 package round
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"math"
@@ -30,6 +31,8 @@ type Helper struct {
 	ssid []byte
 
 	hash *hash.Hash
+	// updates records the values written with UpdateHashState, so that the hash state can be restored.
+	updates []hash.BytesWithDomain
 
 	mtx sync.Mutex
 }
@@ -133,7 +136,14 @@ func (h *Helper) HashForID(id party.ID) *hash.Hash {
 func (h *Helper) UpdateHashState(value hash.WriterToWithDomain) {
 	h.mtx.Lock()
 	defer h.mtx.Unlock()
-	_ = h.hash.WriteAny(value)
+	var buf bytes.Buffer
+	_, _ = value.WriteTo(&buf)
+	update := hash.BytesWithDomain{
+		TheDomain: value.Domain(),
+		Bytes:     append([]byte{}, buf.Bytes()...),
+	}
+	h.updates = append(h.updates, update)
+	_ = h.hash.WriteAny(&update)
 }
 
 // BroadcastMessage constructs a Message from the broadcast Content, and sets the header correctly.
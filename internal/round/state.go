@@ -0,0 +1,232 @@
+package round
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/taurusgroup/multi-party-sig/pkg/hash"
+	"github.com/taurusgroup/multi-party-sig/pkg/math/curve"
+	"github.com/taurusgroup/multi-party-sig/pkg/party"
+)
+
+// Snapshotter is implemented by rounds whose state can be saved, so that the execution can be resumed
+// after a restart.
+type Snapshotter interface {
+	// MarshalState encodes everything this round (and the previous rounds it embeds) has accumulated,
+	// which cannot be recomputed from the protocol's StartFunc.
+	MarshalState() ([]byte, error)
+}
+
+// Restorer is implemented by the first round of a protocol.
+// It recreates a later round of the same execution from the output of Snapshotter.MarshalState.
+type Restorer interface {
+	RestoreState(number Number, data []byte) (Session, error)
+}
+
+// State is a collection of named values, which rounds use to encode their state.
+//
+// Errors are sticky: after the first failure, all further calls do nothing, and the error is returned by Err.
+// This lets rounds encode and decode many fields before checking for an error once.
+type State struct {
+	group  curve.Curve
+	values map[string]cbor.RawMessage
+	err    error
+}
+
+// NewState returns an empty State for the given group.
+func NewState(group curve.Curve) *State {
+	return &State{
+		group:  group,
+		values: map[string]cbor.RawMessage{},
+	}
+}
+
+// UnmarshalState decodes a State created by State.MarshalBinary.
+// The group is used to decode the points and scalars it contains.
+func UnmarshalState(group curve.Curve, data []byte) (*State, error) {
+	s := NewState(group)
+	if err := cbor.Unmarshal(data, &s.values); err != nil {
+		return nil, fmt.Errorf("state: %w", err)
+	}
+	return s, nil
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+func (s *State) MarshalBinary() ([]byte, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	return cbor.Marshal(s.values)
+}
+
+// Err returns the first error encountered while encoding or decoding.
+func (s *State) Err() error { return s.err }
+
+// Fail records an error encountered by the caller while decoding a value, unless an error was already recorded.
+func (s *State) Fail(err error) {
+	if s.err == nil {
+		s.err = err
+	}
+}
+
+// Put encodes v under the given name.
+// Nil values are skipped, so that decoding them leaves the corresponding field unset.
+func (s *State) Put(name string, v interface{}) {
+	if s.err != nil || v == nil {
+		return
+	}
+	data, err := cbor.Marshal(v)
+	if err != nil {
+		s.err = fmt.Errorf("state: %s: %w", name, err)
+		return
+	}
+	s.values[name] = data
+}
+
+// Has returns true if a value was stored under name.
+func (s *State) Has(name string) bool {
+	_, ok := s.values[name]
+	return ok
+}
+
+// Get decodes the value stored under name into v.
+// Fields which depend on the group, such as curve.Scalar, must already be initialized.
+func (s *State) Get(name string, v interface{}) {
+	if s.err != nil {
+		return
+	}
+	data, ok := s.values[name]
+	if !ok {
+		return
+	}
+	if err := cbor.Unmarshal(data, v); err != nil {
+		s.err = fmt.Errorf("state: %s: %w", name, err)
+	}
+}
+
+// Scalar returns the curve.Scalar stored under name, or nil if it is absent.
+func (s *State) Scalar(name string) curve.Scalar {
+	if !s.Has(name) {
+		return nil
+	}
+	scalar := s.group.NewScalar()
+	s.Get(name, scalar)
+	return scalar
+}
+
+// Point returns the curve.Point stored under name, or nil if it is absent.
+func (s *State) Point(name string) curve.Point {
+	if !s.Has(name) {
+		return nil
+	}
+	point := s.group.NewPoint()
+	s.Get(name, point)
+	return point
+}
+
+// Map decodes the map stored under name, calling value to obtain the value in which each entry is decoded.
+// The returned value is false if the map was absent.
+func (s *State) Map(name string, value func(id party.ID) interface{}) bool {
+	if !s.Has(name) {
+		return false
+	}
+	var raw map[party.ID]cbor.RawMessage
+	s.Get(name, &raw)
+	for id, data := range raw {
+		if s.err != nil {
+			break
+		}
+		if err := cbor.Unmarshal(data, value(id)); err != nil {
+			s.err = fmt.Errorf("state: %s: party %s: %w", name, id, err)
+		}
+	}
+	return true
+}
+
+// ScalarMap returns the map of curve.Scalar stored under name, or nil if it is absent.
+func (s *State) ScalarMap(name string) map[party.ID]curve.Scalar {
+	m := map[party.ID]curve.Scalar{}
+	if !s.Map(name, func(id party.ID) interface{} {
+		m[id] = s.group.NewScalar()
+		return m[id]
+	}) {
+		return nil
+	}
+	return m
+}
+
+// PointMap returns the map of curve.Point stored under name, or nil if it is absent.
+func (s *State) PointMap(name string) map[party.ID]curve.Point {
+	m := map[party.ID]curve.Point{}
+	if !s.Map(name, func(id party.ID) interface{} {
+		m[id] = s.group.NewPoint()
+		return m[id]
+	}) {
+		return nil
+	}
+	return m
+}
+
+// helperState is the part of a Helper which can change during the execution of a protocol.
+type helperState struct {
+	// Updates are the values written to the hash state with UpdateHashState.
+	Updates []hash.BytesWithDomain
+}
+
+// marshalState encodes the changes made to the session's hash state since it was created.
+func (h *Helper) marshalState() ([]byte, error) {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	return cbor.Marshal(&helperState{Updates: h.updates})
+}
+
+// restoreState replays the changes to the hash state encoded by marshalState.
+// It must only be called on a Helper freshly returned by NewSession.
+func (h *Helper) restoreState(data []byte) error {
+	var state helperState
+	if err := cbor.Unmarshal(data, &state); err != nil {
+		return fmt.Errorf("session: %w", err)
+	}
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	if len(h.updates) != 0 {
+		return errors.New("session: hash state was already updated")
+	}
+	for i := range state.Updates {
+		update := state.Updates[i]
+		if err := h.hash.WriteAny(&update); err != nil {
+			return fmt.Errorf("session: %w", err)
+		}
+		h.updates = append(h.updates, update)
+	}
+	return nil
+}
+
+// PutSession encodes the changes made to the hash state of the session h.
+func (s *State) PutSession(h *Helper) {
+	if s.err != nil {
+		return
+	}
+	data, err := h.marshalState()
+	if err != nil {
+		s.err = fmt.Errorf("state: session: %w", err)
+		return
+	}
+	s.values["session"] = data
+}
+
+// RestoreSession replays the changes made to the hash state of the session h, which must be freshly created.
+func (s *State) RestoreSession(h *Helper) {
+	if s.err != nil {
+		return
+	}
+	data, ok := s.values["session"]
+	if !ok {
+		s.err = errors.New("state: session: missing")
+		return
+	}
+	if err := h.restoreState(data); err != nil {
+		s.err = fmt.Errorf("state: %w", err)
+	}
+}
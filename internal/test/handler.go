@@ -1,6 +1,7 @@
 package test
 
 import (
+	"encoding/json"
 	"github.com/taurusgroup/multi-party-sig/pkg/party"
 	"github.com/taurusgroup/multi-party-sig/pkg/protocol"
 	"log"
@@ -21,16 +22,46 @@ func HandlerLoop(id party.ID, h protocol.Handler, network INetwork) {
 				// the channel was closed, indicating that the protocol is done executing.
 				return
 			}
-			ms, _ := msg.MarshalJson()
+			ms, _ := json.Marshal(msg)
 			log.Printf("Loop outgoing  id:%s msg:%s /n", id, ms)
 			go network.Send(msg)
 
 		// incoming messages
 		case msg := <-network.Next(id):
-			ms, _ := msg.MarshalJson()
+			ms, _ := json.Marshal(msg)
 			log.Printf("Loop incoming  id:%s msg:%s /n", id, ms)
 			h.Accept(msg)
 
 		}
 	}
 }
+
+// Step forwards the messages currently queued by each handler to the handlers of the other parties.
+// It returns false once there are no more messages to deliver, which happens when all handlers have finished.
+//
+// Unlike HandlerLoop, Step does not block, which lets tests interrupt an execution between rounds.
+func Step(handlers map[party.ID]protocol.Handler) bool {
+	var msgs []*protocol.Message
+	for _, h := range handlers {
+		for pending := true; pending; {
+			select {
+			case msg, ok := <-h.Listen():
+				if !ok {
+					pending = false
+					break
+				}
+				msgs = append(msgs, msg)
+			default:
+				pending = false
+			}
+		}
+	}
+	for _, msg := range msgs {
+		for id, h := range handlers {
+			if msg.IsFor(id) {
+				h.Accept(msg)
+			}
+		}
+	}
+	return len(msgs) > 0
+}
@@ -2,6 +2,7 @@ package test
 
 import (
 	"bufio"
+	"encoding/json"
 	"fmt"
 	"github.com/taurusgroup/multi-party-sig/pkg/party"
 	"github.com/taurusgroup/multi-party-sig/pkg/protocol"
@@ -82,7 +83,7 @@ func (n *NetworkBroadCast) processLine(line string) {
 
 func (n *NetworkBroadCast) processMsg(line string) {
 	msg := protocol.Message{}
-	err := msg.UnmarshalJson([]byte(line))
+	err := json.Unmarshal([]byte(line), &msg)
 	if err != nil {
 		log.Println(err)
 	} else {
@@ -94,7 +95,7 @@ func (n *NetworkBroadCast) processMsg(line string) {
 
 func (n *NetworkBroadCast) clientWriter() {
 	for msg := range n.writeChannel {
-		mj, err := msg.MarshalJson()
+		mj, err := json.Marshal(msg)
 		if err != nil {
 			log.Println(err)
 			continue
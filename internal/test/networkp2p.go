@@ -2,6 +2,7 @@ package test
 
 import (
 	"bufio"
+	"encoding/json"
 	"fmt"
 	"github.com/taurusgroup/multi-party-sig/pkg/party"
 	"github.com/taurusgroup/multi-party-sig/pkg/protocol"
@@ -91,7 +92,7 @@ func (n *NetworkP2P) broadcaster() {
 			//log.Printf("Party %s broad to party count:%d", n.id, len(clients))
 			for cli := range n.clients {
 				if msg.IsFor(cli.Id) {
-					if mj, err := msg.MarshalJson(); err == nil {
+					if mj, err := json.Marshal(msg); err == nil {
 						cli.Conn <- string(mj)
 					} else {
 						log.Printf("Send error:%s", err)
@@ -157,8 +158,8 @@ func (n *NetworkP2P) processMessage(conn net.Conn) {
 					n.cmd <- line
 				default:
 					msg := protocol.Message{}
-					if msg.UnmarshalJson([]byte(line)) != nil {
-						log.Printf("handle error:%s", msg.UnmarshalJson([]byte(line)))
+					if json.Unmarshal([]byte(line), &msg) != nil {
+						log.Printf("handle error:%s", json.Unmarshal([]byte(line), &msg))
 					} else {
 						n.messageIn <- &msg
 					}
@@ -0,0 +1,230 @@
+package export
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/taurusgroup/multi-party-sig/pkg/hash"
+	"github.com/taurusgroup/multi-party-sig/pkg/math/curve"
+	"github.com/taurusgroup/multi-party-sig/pkg/math/polynomial"
+	"github.com/taurusgroup/multi-party-sig/pkg/math/sample"
+	"github.com/taurusgroup/multi-party-sig/pkg/party"
+	"github.com/taurusgroup/multi-party-sig/pkg/protocol"
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+// Key is the public data of a threshold key, against which the exported shares are checked.
+type Key struct {
+	// Group is the curve over which the key is defined.
+	Group curve.Curve
+	// Threshold is the integer t such that t+1 shares are needed to reconstruct the secret.
+	Threshold int
+	// PublicKey is the public point X = x⋅G of the secret x being reconstructed.
+	PublicKey curve.Point
+	// PublicShares maps party.ID to the public share Xⱼ = xⱼ⋅G of that party.
+	PublicShares map[party.ID]curve.Point
+}
+
+// Contribution is the share of a single party, encrypted to the recipient of the export.
+//
+// To unmarshal this struct, EmptyContribution should be called first with a specific group.
+type Contribution struct {
+	// From is the party whose share is encrypted.
+	From party.ID
+	// Ephemeral is the point E = e⋅G, from which the encryption key e⋅R is derived.
+	Ephemeral curve.Point
+	// Ciphertext is the encryption of xᵢ.
+	Ciphertext []byte
+}
+
+// EmptyContribution creates an empty Contribution with a fixed group, ready for unmarshalling.
+func EmptyContribution(group curve.Curve) *Contribution {
+	return &Contribution{Ephemeral: group.NewPoint()}
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+func (c *Contribution) MarshalBinary() ([]byte, error) {
+	type contribution Contribution
+	return cbor.Marshal((*contribution)(c))
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+func (c *Contribution) UnmarshalBinary(data []byte) error {
+	if c.Ephemeral == nil {
+		return errors.New("export: contribution must be initialized using EmptyContribution")
+	}
+	type contribution Contribution
+	return cbor.Unmarshal(data, (*contribution)(c))
+}
+
+// Encrypt encrypts the share xᵢ of party self to the recipient with public key R.
+//
+// ceremonyID must be unique to this export, and is shared by all contributions.
+// It prevents a contribution from being replayed in a different export.
+//
+// The share is encrypted with ECIES: a key is derived from e⋅R for a fresh e,
+// and used to encrypt xᵢ with ChaCha20-Poly1305.
+func Encrypt(ceremonyID []byte, self party.ID, share curve.Scalar, recipient curve.Point) (*Contribution, error) {
+	if len(ceremonyID) == 0 {
+		return nil, errors.New("export: ceremony ID is empty")
+	}
+	if share == nil || share.IsZero() {
+		return nil, errors.New("export: share is zero")
+	}
+	if recipient == nil || recipient.IsIdentity() {
+		return nil, errors.New("export: recipient key is invalid")
+	}
+	group := share.Curve()
+	e := sample.Scalar(rand.Reader, group)
+	E := e.ActOnBase()
+	key, additionalData, err := deriveKey(ceremonyID, self, recipient, E, e.Act(recipient))
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := share.MarshalBinary()
+	if err != nil {
+		return nil, fmt.Errorf("export: %w", err)
+	}
+	aead, err := chacha20poly1305.New(key)
+	if err != nil {
+		return nil, fmt.Errorf("export: %w", err)
+	}
+	// the key is only used once, since e is fresh
+	nonce := make([]byte, aead.NonceSize())
+	return &Contribution{
+		From:       self,
+		Ephemeral:  E,
+		Ciphertext: aead.Seal(nil, nonce, plaintext, additionalData),
+	}, nil
+}
+
+// decrypt returns the share contained in c, using the recipient's secret r.
+func (c *Contribution) decrypt(ceremonyID []byte, recipientSecret curve.Scalar) (curve.Scalar, error) {
+	if c.Ephemeral == nil || c.Ephemeral.IsIdentity() {
+		return nil, errors.New("ephemeral point is invalid")
+	}
+	group := recipientSecret.Curve()
+	key, additionalData, err := deriveKey(ceremonyID, c.From, recipientSecret.ActOnBase(), c.Ephemeral, recipientSecret.Act(c.Ephemeral))
+	if err != nil {
+		return nil, err
+	}
+	aead, err := chacha20poly1305.New(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	plaintext, err := aead.Open(nil, nonce, c.Ciphertext, additionalData)
+	if err != nil {
+		return nil, err
+	}
+	share := group.NewScalar()
+	if err = share.UnmarshalBinary(plaintext); err != nil {
+		return nil, err
+	}
+	return share, nil
+}
+
+// deriveKey returns the symmetric key derived from the shared point e⋅R = r⋅E,
+// as well as the additional data binding the ciphertext to the ceremony and the sender.
+func deriveKey(ceremonyID []byte, from party.ID, R, E, shared curve.Point) (key, additionalData []byte, err error) {
+	h := hash.New(&hash.BytesWithDomain{TheDomain: "Key Export", Bytes: ceremonyID})
+	if err = h.WriteAny(from, R, E); err != nil {
+		return nil, nil, fmt.Errorf("export: %w", err)
+	}
+	additionalData = h.Sum()
+	if err = h.WriteAny(shared); err != nil {
+		return nil, nil, fmt.Errorf("export: %w", err)
+	}
+	key = make([]byte, chacha20poly1305.KeySize)
+	if _, err = io.ReadFull(h.Digest(), key); err != nil {
+		return nil, nil, fmt.Errorf("export: %w", err)
+	}
+	return key, additionalData, nil
+}
+
+// Reconstruct decrypts the contributions with the recipient's secret r, and interpolates the secret key x.
+//
+// Every decrypted share xⱼ is checked against the public share Xⱼ of its sender, and invalid contributions
+// are discarded, so that a faulty or malicious party cannot prevent the recovery of the key.
+// The parties which only sent invalid contributions are returned as culprits, along with the secret
+// if at least t+1 contributions from distinct parties are valid.
+// Otherwise, a protocol.Error is returned with the same culprits.
+func (k *Key) Reconstruct(recipientSecret curve.Scalar, ceremonyID []byte, contributions []*Contribution) (curve.Scalar, []party.ID, error) {
+	if recipientSecret == nil || recipientSecret.IsZero() {
+		return nil, nil, errors.New("export: recipient secret is zero")
+	}
+	shares := make(map[party.ID]curve.Scalar, len(contributions))
+	invalid := make(map[party.ID]error)
+	var invalidOrder []party.ID
+	for _, c := range contributions {
+		if c == nil {
+			return nil, nil, errors.New("export: contribution is nil")
+		}
+		if _, ok := shares[c.From]; ok {
+			continue
+		}
+		share, err := k.open(c, ceremonyID, recipientSecret)
+		if err != nil {
+			if _, ok := invalid[c.From]; !ok {
+				invalid[c.From] = err
+				invalidOrder = append(invalidOrder, c.From)
+			}
+			continue
+		}
+		shares[c.From] = share
+	}
+	// anyone can make an invalid contribution in the name of another party,
+	// so a party is only blamed if none of its contributions is valid.
+	var culprits []party.ID
+	var firstErr error
+	for _, j := range invalidOrder {
+		if _, ok := shares[j]; ok {
+			continue
+		}
+		culprits = append(culprits, j)
+		if firstErr == nil {
+			firstErr = fmt.Errorf("export: contribution from %s: %w", j, invalid[j])
+		}
+	}
+	if len(shares) < k.Threshold+1 {
+		err := fmt.Errorf("export: got %d valid contributions, but %d are required", len(shares), k.Threshold+1)
+		if culprits != nil {
+			return nil, culprits, protocol.Error{Culprits: culprits, Err: fmt.Errorf("%v: %w", err, firstErr)}
+		}
+		return nil, nil, err
+	}
+
+	ids := make([]party.ID, 0, len(shares))
+	for j := range shares {
+		ids = append(ids, j)
+	}
+	lagrange := polynomial.Lagrange(k.Group, ids)
+	secret := k.Group.NewScalar()
+	for j, xj := range shares {
+		secret.Add(lagrange[j].Mul(xj))
+	}
+	// the public shares may be inconsistent with the public key
+	if !secret.ActOnBase().Equal(k.PublicKey) {
+		return nil, culprits, errors.New("export: reconstructed secret does not match public key")
+	}
+	return secret, culprits, nil
+}
+
+// open decrypts the share in c, and checks it against the public share of its sender.
+func (k *Key) open(c *Contribution, ceremonyID []byte, recipientSecret curve.Scalar) (curve.Scalar, error) {
+	public, ok := k.PublicShares[c.From]
+	if !ok {
+		return nil, errors.New("unknown party")
+	}
+	share, err := c.decrypt(ceremonyID, recipientSecret)
+	if err != nil {
+		return nil, err
+	}
+	if !share.ActOnBase().Equal(public) {
+		return nil, errors.New("share does not match public share")
+	}
+	return share, nil
+}
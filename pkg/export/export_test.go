@@ -0,0 +1,84 @@
+package export
+
+import (
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/taurusgroup/multi-party-sig/internal/test"
+	"github.com/taurusgroup/multi-party-sig/pkg/math/curve"
+	"github.com/taurusgroup/multi-party-sig/pkg/math/polynomial"
+	"github.com/taurusgroup/multi-party-sig/pkg/math/sample"
+	"github.com/taurusgroup/multi-party-sig/pkg/party"
+	"github.com/taurusgroup/multi-party-sig/pkg/protocol"
+)
+
+func TestReconstruct(t *testing.T) {
+	group := curve.Secp256k1{}
+	partyIDs := test.PartyIDs(5)
+	threshold := 2
+
+	secret := sample.Scalar(rand.Reader, group)
+	f := polynomial.NewPolynomial(group, threshold, secret)
+	shares := make(map[party.ID]curve.Scalar, len(partyIDs))
+	key := &Key{
+		Group:        group,
+		Threshold:    threshold,
+		PublicKey:    secret.ActOnBase(),
+		PublicShares: make(map[party.ID]curve.Point, len(partyIDs)),
+	}
+	for _, j := range partyIDs {
+		shares[j] = f.Evaluate(j.Scalar(group))
+		key.PublicShares[j] = shares[j].ActOnBase()
+	}
+
+	r := sample.Scalar(rand.Reader, group)
+	R := r.ActOnBase()
+	ceremonyID := []byte("ceremony")
+	contributions := make([]*Contribution, 0, threshold+1)
+	for _, j := range partyIDs[1 : threshold+2] {
+		c, err := Encrypt(ceremonyID, j, shares[j], R)
+		require.NoError(t, err)
+		data, err := c.MarshalBinary()
+		require.NoError(t, err)
+		decoded := EmptyContribution(group)
+		require.NoError(t, decoded.UnmarshalBinary(data))
+		contributions = append(contributions, decoded)
+	}
+
+	reconstructed, culprits, err := key.Reconstruct(r, ceremonyID, contributions)
+	require.NoError(t, err)
+	assert.Empty(t, culprits)
+	assert.True(t, secret.Equal(reconstructed))
+
+	_, _, err = key.Reconstruct(r, ceremonyID, contributions[:threshold])
+	assert.Error(t, err, "t contributions should not be enough")
+
+	_, _, err = key.Reconstruct(r, []byte("other ceremony"), contributions)
+	assert.Error(t, err, "contributions should be bound to the ceremony")
+
+	// a share which does not match its public share is discarded, and its sender blamed
+	wrong := partyIDs[0]
+	bad, err := Encrypt(ceremonyID, wrong, shares[partyIDs[1]], R)
+	require.NoError(t, err)
+	reconstructed, culprits, err = key.Reconstruct(r, ceremonyID, append([]*Contribution{bad}, contributions...))
+	require.NoError(t, err, "t+1 valid contributions should be enough despite an invalid one")
+	assert.Equal(t, []party.ID{wrong}, culprits)
+	assert.True(t, secret.Equal(reconstructed))
+
+	// an invalid contribution in the name of a party which also sent a valid one is ignored
+	forged, err := Encrypt(ceremonyID, partyIDs[1], shares[partyIDs[2]], R)
+	require.NoError(t, err)
+	_, culprits, err = key.Reconstruct(r, ceremonyID, append(contributions, forged))
+	require.NoError(t, err)
+	assert.Empty(t, culprits)
+
+	// without t+1 valid contributions, the culprits are reported in a protocol.Error
+	_, culprits, err = key.Reconstruct(r, ceremonyID, append(contributions[:threshold], bad))
+	var protocolErr protocol.Error
+	require.True(t, errors.As(err, &protocolErr))
+	assert.Equal(t, []party.ID{wrong}, protocolErr.Culprits)
+	assert.Equal(t, []party.ID{wrong}, culprits)
+}
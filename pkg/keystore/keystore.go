@@ -0,0 +1,370 @@
+package keystore
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/taurusgroup/multi-party-sig/pkg/math/curve"
+	"github.com/taurusgroup/multi-party-sig/pkg/party"
+	"github.com/taurusgroup/multi-party-sig/protocols/cmp"
+	"github.com/taurusgroup/multi-party-sig/protocols/doerner"
+	"github.com/taurusgroup/multi-party-sig/protocols/frost"
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/scrypt"
+)
+
+// Version is the version of the keystore format produced by this package.
+const Version = 1
+
+// Kind identifies the type of config stored in a Keystore.
+type Kind string
+
+const (
+	KindCMP             Kind = "cmp"
+	KindFROST           Kind = "frost"
+	KindTaproot         Kind = "frost-taproot"
+	KindDoernerSender   Kind = "doerner-sender"
+	KindDoernerReceiver Kind = "doerner-receiver"
+)
+
+// KEKSize is the length in bytes of a key encryption key passed to SealWithKEK.
+const KEKSize = chacha20poly1305.KeySize
+
+// scrypt parameters used when sealing with a passphrase.
+const (
+	scryptName    = "scrypt"
+	scryptN       = 1 << 15
+	scryptR       = 8
+	scryptP       = 1
+	scryptSaltLen = 32
+)
+
+// Upper bounds on the scrypt parameters accepted by Open, since they are read from the keystore
+// before it is authenticated, and would otherwise let a tampered file exhaust memory or CPU.
+const (
+	scryptMaxN      = 1 << 20
+	scryptMaxR      = 32
+	scryptMaxP      = 16
+	scryptMaxMemory = 256 << 20
+)
+
+// Metadata is the public information about a stored config, which can be read without decrypting it.
+//
+// It is authenticated together with the ciphertext, so it cannot be modified without the secret.
+type Metadata struct {
+	// Kind is the type of the stored config.
+	Kind Kind
+	// Curve is the name of the group over which the key is defined.
+	Curve string
+	// ID is the identifier of the party owning the config, and empty for doerner configs.
+	ID party.ID
+	// PartyIDs are the parties sharing the key, and nil for doerner configs.
+	PartyIDs party.IDSlice
+	// Threshold is the number of accepted corruptions while still being able to sign.
+	Threshold int
+	// PublicKey is the encoding of the shared public key.
+	PublicKey []byte
+}
+
+// KDF holds the parameters used to derive the encryption key from a passphrase.
+type KDF struct {
+	// Name is the key derivation function, currently always "scrypt".
+	Name string
+	Salt []byte
+	N    int
+	R    int
+	P    int
+}
+
+// Keystore is a versioned container holding an encrypted config, along with its Metadata in the clear.
+//
+// The config is encrypted with XChaCha20-Poly1305, under a key derived from a passphrase with scrypt,
+// or directly under a key encryption key (KEK) provided by the caller.
+type Keystore struct {
+	// Version is the format version, and must be equal to Version.
+	Version int
+	Metadata
+	// KDF is nil if the config was sealed with a KEK.
+	KDF *KDF
+	// Nonce is the random nonce used for the encryption.
+	Nonce []byte
+	// Ciphertext is the encryption of the binary encoding of the config.
+	Ciphertext []byte
+}
+
+// Seal encrypts config under a key derived from passphrase.
+//
+// config must be one of *cmp.Config, *frost.Config, *frost.TaprootConfig,
+// *doerner.ConfigSender or *doerner.ConfigReceiver.
+//
+// An empty passphrase is rejected, since the key derived from it would be known to anyone.
+func Seal(config interface{}, passphrase []byte) (*Keystore, error) {
+	if len(passphrase) == 0 {
+		return nil, errors.New("keystore: passphrase is empty")
+	}
+	kdf := &KDF{
+		Name: scryptName,
+		Salt: make([]byte, scryptSaltLen),
+		N:    scryptN,
+		R:    scryptR,
+		P:    scryptP,
+	}
+	if _, err := rand.Read(kdf.Salt); err != nil {
+		return nil, fmt.Errorf("keystore: %w", err)
+	}
+	key, err := kdf.derive(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return seal(config, kdf, key)
+}
+
+// Migrate encrypts a config which was stored in plaintext, before this package existed, under a key derived from passphrase.
+//
+// plaintext must be the encoding of a config of the given kind over group, as produced by MarshalBinary for cmp configs,
+// and by cbor for the other kinds.
+func Migrate(plaintext []byte, kind Kind, group curve.Curve, passphrase []byte) (*Keystore, error) {
+	if group == nil {
+		return nil, errors.New("keystore: group is nil")
+	}
+	config, err := decode(&Metadata{Kind: kind, Curve: group.Name()}, plaintext)
+	if err != nil {
+		return nil, err
+	}
+	return Seal(config, passphrase)
+}
+
+// SealWithKEK encrypts config directly under kek, which must be KEKSize bytes long.
+//
+// This is meant for keys managed by an external system, such as an HSM or a KMS.
+func SealWithKEK(config interface{}, kek []byte) (*Keystore, error) {
+	if len(kek) != KEKSize {
+		return nil, fmt.Errorf("keystore: expected %d bytes for KEK, found %d", KEKSize, len(kek))
+	}
+	return seal(config, nil, kek)
+}
+
+// Open decrypts the config with a passphrase, returning one of the types accepted by Seal.
+func (k *Keystore) Open(passphrase []byte) (interface{}, error) {
+	if k.KDF == nil {
+		return nil, errors.New("keystore: sealed with a KEK, not a passphrase")
+	}
+	key, err := k.KDF.derive(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return k.open(key)
+}
+
+// OpenWithKEK decrypts the config with a KEK, returning one of the types accepted by Seal.
+func (k *Keystore) OpenWithKEK(kek []byte) (interface{}, error) {
+	if k.KDF != nil {
+		return nil, errors.New("keystore: sealed with a passphrase, not a KEK")
+	}
+	if len(kek) != KEKSize {
+		return nil, fmt.Errorf("keystore: expected %d bytes for KEK, found %d", KEKSize, len(kek))
+	}
+	return k.open(kek)
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+func (k *Keystore) MarshalBinary() ([]byte, error) {
+	type keystore Keystore
+	return cbor.Marshal((*keystore)(k))
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+//
+// Only the current Version is accepted.
+func (k *Keystore) UnmarshalBinary(data []byte) error {
+	type keystore Keystore
+	if err := cbor.Unmarshal(data, (*keystore)(k)); err != nil {
+		return fmt.Errorf("keystore: %w", err)
+	}
+	if k.Version != Version {
+		return fmt.Errorf("keystore: unsupported version %d", k.Version)
+	}
+	return nil
+}
+
+func (kdf *KDF) derive(passphrase []byte) ([]byte, error) {
+	if kdf.Name != scryptName {
+		return nil, fmt.Errorf("keystore: unsupported KDF %q", kdf.Name)
+	}
+	if kdf.N > scryptMaxN || kdf.R < 1 || kdf.R > scryptMaxR || kdf.P < 1 || kdf.P > scryptMaxP ||
+		kdf.N*kdf.R > scryptMaxMemory/128 {
+		return nil, fmt.Errorf("keystore: scrypt parameters N=%d, r=%d, p=%d are out of bounds", kdf.N, kdf.R, kdf.P)
+	}
+	key, err := scrypt.Key(passphrase, kdf.Salt, kdf.N, kdf.R, kdf.P, chacha20poly1305.KeySize)
+	if err != nil {
+		return nil, fmt.Errorf("keystore: %w", err)
+	}
+	return key, nil
+}
+
+// additionalData binds the Metadata and KDF parameters to the ciphertext.
+func (k *Keystore) additionalData() ([]byte, error) {
+	return cbor.Marshal(struct {
+		Version int
+		Metadata
+		KDF *KDF
+	}{k.Version, k.Metadata, k.KDF})
+}
+
+func seal(config interface{}, kdf *KDF, key []byte) (*Keystore, error) {
+	metadata, plaintext, err := encode(config)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := chacha20poly1305.NewX(key)
+	if err != nil {
+		return nil, fmt.Errorf("keystore: %w", err)
+	}
+	k := &Keystore{
+		Version:  Version,
+		Metadata: *metadata,
+		KDF:      kdf,
+		Nonce:    make([]byte, aead.NonceSize()),
+	}
+	if _, err = rand.Read(k.Nonce); err != nil {
+		return nil, fmt.Errorf("keystore: %w", err)
+	}
+	ad, err := k.additionalData()
+	if err != nil {
+		return nil, fmt.Errorf("keystore: %w", err)
+	}
+	k.Ciphertext = aead.Seal(nil, k.Nonce, plaintext, ad)
+	return k, nil
+}
+
+func (k *Keystore) open(key []byte) (interface{}, error) {
+	aead, err := chacha20poly1305.NewX(key)
+	if err != nil {
+		return nil, fmt.Errorf("keystore: %w", err)
+	}
+	if len(k.Nonce) != aead.NonceSize() {
+		return nil, errors.New("keystore: invalid nonce")
+	}
+	ad, err := k.additionalData()
+	if err != nil {
+		return nil, fmt.Errorf("keystore: %w", err)
+	}
+	plaintext, err := aead.Open(nil, k.Nonce, k.Ciphertext, ad)
+	if err != nil {
+		return nil, errors.New("keystore: wrong secret or corrupted data")
+	}
+	return decode(&k.Metadata, plaintext)
+}
+
+// encode returns the Metadata and binary encoding of config.
+func encode(config interface{}) (*Metadata, []byte, error) {
+	var (
+		metadata  Metadata
+		plaintext []byte
+		public    curve.Point
+		err       error
+	)
+	switch c := config.(type) {
+	case *cmp.Config:
+		metadata = Metadata{Kind: KindCMP, ID: c.ID, PartyIDs: c.PartyIDs(), Threshold: c.Threshold}
+		public = c.PublicPoint()
+		plaintext, err = c.MarshalBinary()
+	case *frost.Config:
+		partyIDs := make([]party.ID, 0, len(c.VerificationShares.Points))
+		for id := range c.VerificationShares.Points {
+			partyIDs = append(partyIDs, id)
+		}
+		metadata = Metadata{Kind: KindFROST, ID: c.ID, PartyIDs: party.NewIDSlice(partyIDs), Threshold: c.Threshold}
+		public = c.PublicKey
+		plaintext, err = cbor.Marshal(c)
+	case *frost.TaprootConfig:
+		partyIDs := make([]party.ID, 0, len(c.VerificationShares))
+		for id := range c.VerificationShares {
+			partyIDs = append(partyIDs, id)
+		}
+		metadata = Metadata{
+			Kind:      KindTaproot,
+			Curve:     curve.Secp256k1{}.Name(),
+			ID:        c.ID,
+			PartyIDs:  party.NewIDSlice(partyIDs),
+			Threshold: c.Threshold,
+			PublicKey: append([]byte{}, c.PublicKey...),
+		}
+		plaintext, err = cbor.Marshal(c)
+	case *doerner.ConfigSender:
+		metadata = Metadata{Kind: KindDoernerSender, Threshold: 1}
+		public = c.Public
+		plaintext, err = cbor.Marshal(c)
+	case *doerner.ConfigReceiver:
+		metadata = Metadata{Kind: KindDoernerReceiver, Threshold: 1}
+		public = c.Public
+		plaintext, err = cbor.Marshal(c)
+	default:
+		return nil, nil, fmt.Errorf("keystore: unsupported config type %T", config)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("keystore: %w", err)
+	}
+	if public != nil {
+		metadata.Curve = public.Curve().Name()
+		if metadata.PublicKey, err = public.MarshalBinary(); err != nil {
+			return nil, nil, fmt.Errorf("keystore: %w", err)
+		}
+	}
+	return &metadata, plaintext, nil
+}
+
+// decode unmarshals the config of the kind given in metadata.
+func decode(metadata *Metadata, plaintext []byte) (interface{}, error) {
+	group, err := groupByName(metadata.Curve)
+	if err != nil {
+		return nil, err
+	}
+	var config interface{}
+	switch metadata.Kind {
+	case KindCMP:
+		c := cmp.EmptyConfig(group)
+		err = c.UnmarshalBinary(plaintext)
+		config = c
+	case KindFROST:
+		c := frost.EmptyConfig(group)
+		err = cbor.Unmarshal(plaintext, c)
+		config = c
+	case KindTaproot:
+		c := &frost.TaprootConfig{}
+		err = cbor.Unmarshal(plaintext, c)
+		config = c
+	case KindDoernerSender:
+		c := doerner.EmptyConfigSender(group)
+		err = cbor.Unmarshal(plaintext, c)
+		config = c
+	case KindDoernerReceiver:
+		c := doerner.EmptyConfigReceiver(group)
+		err = cbor.Unmarshal(plaintext, c)
+		config = c
+	default:
+		return nil, fmt.Errorf("keystore: unsupported config kind %q", metadata.Kind)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("keystore: %w", err)
+	}
+	return config, nil
+}
+
+// groupByName returns the curve.Curve whose Name is name.
+func groupByName(name string) (curve.Curve, error) {
+	switch name {
+	case curve.Secp256k1{}.Name():
+		return curve.Secp256k1{}, nil
+	case curve.Ed25519{}.Name():
+		return curve.Ed25519{}, nil
+	case curve.P256{}.Name():
+		return curve.P256{}, nil
+	case curve.Ristretto255{}.Name():
+		return curve.Ristretto255{}, nil
+	default:
+		return nil, fmt.Errorf("keystore: unsupported curve %q", name)
+	}
+}
@@ -0,0 +1,190 @@
+package keystore
+
+import (
+	"crypto/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/taurusgroup/multi-party-sig/internal/ot"
+	"github.com/taurusgroup/multi-party-sig/internal/test"
+	"github.com/taurusgroup/multi-party-sig/pkg/math/curve"
+	"github.com/taurusgroup/multi-party-sig/pkg/math/polynomial"
+	"github.com/taurusgroup/multi-party-sig/pkg/math/sample"
+	"github.com/taurusgroup/multi-party-sig/pkg/party"
+	"github.com/taurusgroup/multi-party-sig/protocols/cmp"
+	"github.com/taurusgroup/multi-party-sig/protocols/doerner"
+	"github.com/taurusgroup/multi-party-sig/protocols/frost"
+)
+
+var testGroup = curve.Secp256k1{}
+
+func frostConfig(partyIDs party.IDSlice, threshold int) *frost.Config {
+	f := polynomial.NewPolynomial(testGroup, threshold, sample.Scalar(rand.Reader, testGroup))
+	shares := make(map[party.ID]curve.Point, len(partyIDs))
+	for _, j := range partyIDs {
+		shares[j] = f.Evaluate(j.Scalar(testGroup)).ActOnBase()
+	}
+	return &frost.Config{
+		ID:                 partyIDs[0],
+		Threshold:          threshold,
+		PrivateShare:       f.Evaluate(partyIDs[0].Scalar(testGroup)),
+		PublicKey:          f.Constant().ActOnBase(),
+		ChainKey:           make([]byte, 32),
+		VerificationShares: party.NewPointMap(shares),
+	}
+}
+
+func roundTrip(t *testing.T, config interface{}) (*Metadata, interface{}) {
+	ks, err := Seal(config, []byte("passphrase"))
+	require.NoError(t, err)
+	data, err := ks.MarshalBinary()
+	require.NoError(t, err)
+
+	decoded := &Keystore{}
+	require.NoError(t, decoded.UnmarshalBinary(data))
+	_, err = decoded.Open([]byte("wrong passphrase"))
+	assert.Error(t, err)
+	opened, err := decoded.Open([]byte("passphrase"))
+	require.NoError(t, err)
+	return &decoded.Metadata, opened
+}
+
+func TestKeystoreCMP(t *testing.T) {
+	configs, partyIDs := test.GenerateConfig(testGroup, 2, 1, rand.Reader, nil)
+	c := configs[partyIDs[0]]
+
+	metadata, opened := roundTrip(t, c)
+	assert.Equal(t, KindCMP, metadata.Kind)
+	assert.Equal(t, testGroup.Name(), metadata.Curve)
+	assert.Equal(t, c.ID, metadata.ID)
+	assert.Equal(t, partyIDs, metadata.PartyIDs)
+	assert.Equal(t, c.Threshold, metadata.Threshold)
+	public, err := c.PublicPoint().MarshalBinary()
+	require.NoError(t, err)
+	assert.Equal(t, public, metadata.PublicKey)
+
+	require.IsType(t, &cmp.Config{}, opened)
+	openedConfig := opened.(*cmp.Config)
+	assert.True(t, c.ECDSA.Equal(openedConfig.ECDSA))
+	assert.Equal(t, c.Paillier.P(), openedConfig.Paillier.P())
+	assert.True(t, c.PublicPoint().Equal(openedConfig.PublicPoint()))
+
+	// configs stored in plaintext by earlier releases are migrated explicitly
+	plaintext, err := c.MarshalBinary()
+	require.NoError(t, err)
+	ks, err := Migrate(plaintext, KindCMP, testGroup, []byte("passphrase"))
+	require.NoError(t, err)
+	opened, err = ks.Open([]byte("passphrase"))
+	require.NoError(t, err)
+	assert.True(t, c.ECDSA.Equal(opened.(*cmp.Config).ECDSA))
+	_, err = Migrate(plaintext, KindCMP, testGroup, nil)
+	assert.Error(t, err)
+}
+
+func TestKeystoreFROST(t *testing.T) {
+	partyIDs := test.PartyIDs(3)
+	c := frostConfig(partyIDs, 1)
+
+	metadata, opened := roundTrip(t, c)
+	assert.Equal(t, KindFROST, metadata.Kind)
+	assert.Equal(t, partyIDs, metadata.PartyIDs)
+	require.IsType(t, &frost.Config{}, opened)
+	assert.True(t, c.PrivateShare.Equal(opened.(*frost.Config).PrivateShare))
+
+	taproot := &frost.TaprootConfig{
+		ID:                 c.ID,
+		Threshold:          c.Threshold,
+		PrivateShare:       c.PrivateShare.(*curve.Secp256k1Scalar),
+		PublicKey:          c.PublicKey.(*curve.Secp256k1Point).XBytes(),
+		ChainKey:           c.ChainKey,
+		VerificationShares: make(map[party.ID]*curve.Secp256k1Point),
+	}
+	for id, X := range c.VerificationShares.Points {
+		taproot.VerificationShares[id] = X.(*curve.Secp256k1Point)
+	}
+	metadata, opened = roundTrip(t, taproot)
+	assert.Equal(t, KindTaproot, metadata.Kind)
+	assert.Equal(t, []byte(taproot.PublicKey), metadata.PublicKey)
+	require.IsType(t, &frost.TaprootConfig{}, opened)
+	assert.True(t, taproot.PrivateShare.Equal(opened.(*frost.TaprootConfig).PrivateShare))
+}
+
+func TestKeystoreDoerner(t *testing.T) {
+	secret := sample.Scalar(rand.Reader, testGroup)
+	sender := &doerner.ConfigSender{
+		Setup:       &ot.CorreOTSendSetup{},
+		SecretShare: secret,
+		Public:      secret.ActOnBase(),
+		ChainKey:    make([]byte, 32),
+	}
+	metadata, opened := roundTrip(t, sender)
+	assert.Equal(t, KindDoernerSender, metadata.Kind)
+	require.IsType(t, &doerner.ConfigSender{}, opened)
+	assert.True(t, secret.Equal(opened.(*doerner.ConfigSender).SecretShare))
+
+	receiver := &doerner.ConfigReceiver{
+		Setup:       &ot.CorreOTReceiveSetup{},
+		SecretShare: secret,
+		Public:      secret.ActOnBase(),
+		ChainKey:    make([]byte, 32),
+	}
+	metadata, opened = roundTrip(t, receiver)
+	assert.Equal(t, KindDoernerReceiver, metadata.Kind)
+	require.IsType(t, &doerner.ConfigReceiver{}, opened)
+	assert.True(t, secret.Equal(opened.(*doerner.ConfigReceiver).SecretShare))
+}
+
+func TestKeystoreEmptyPassphrase(t *testing.T) {
+	c := frostConfig(test.PartyIDs(2), 1)
+	_, err := Seal(c, nil)
+	assert.Error(t, err)
+	_, err = Seal(c, []byte{})
+	assert.Error(t, err)
+}
+
+func TestKeystoreKEK(t *testing.T) {
+	c := frostConfig(test.PartyIDs(2), 1)
+	kek := make([]byte, KEKSize)
+	_, _ = rand.Read(kek)
+
+	ks, err := SealWithKEK(c, kek)
+	require.NoError(t, err)
+	assert.Nil(t, ks.KDF)
+	_, err = ks.Open(kek)
+	assert.Error(t, err, "a KEK keystore should not open with a passphrase")
+
+	opened, err := ks.OpenWithKEK(kek)
+	require.NoError(t, err)
+	assert.True(t, c.PrivateShare.Equal(opened.(*frost.Config).PrivateShare))
+
+	ks.Threshold++
+	_, err = ks.OpenWithKEK(kek)
+	assert.Error(t, err, "modified metadata should be detected")
+	ks.Threshold--
+
+	ks.Version = Version + 1
+	data, err := ks.MarshalBinary()
+	require.NoError(t, err)
+	assert.Error(t, (&Keystore{}).UnmarshalBinary(data), "unknown versions should be rejected")
+}
+
+func TestKeystoreKDFBounds(t *testing.T) {
+	c := frostConfig(test.PartyIDs(2), 1)
+	ks, err := Seal(c, []byte("passphrase"))
+	require.NoError(t, err)
+
+	for _, kdf := range []KDF{
+		{N: 1 << 30, R: scryptR, P: scryptP},
+		{N: scryptN, R: 1 << 20, P: scryptP},
+		{N: scryptN, R: scryptR, P: 1 << 20},
+		{N: scryptN, R: 0, P: scryptP},
+		{N: scryptMaxN, R: scryptMaxR, P: scryptP},
+	} {
+		tampered := *ks
+		kdf.Name, kdf.Salt = scryptName, ks.KDF.Salt
+		tampered.KDF = &kdf
+		_, err = tampered.Open([]byte("passphrase"))
+		assert.ErrorContains(t, err, "out of bounds")
+	}
+}
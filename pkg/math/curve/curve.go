@@ -148,6 +148,12 @@ type Point interface {
 	YBytes() []byte
 }
 
+// SupportsECDSA returns true if ECDSA signatures can be produced over group,
+// meaning that its points implement XScalar.
+func SupportsECDSA(group Curve) bool {
+	return group != nil && group.NewBasePoint().XScalar() != nil
+}
+
 // MakeInt converts a scalar into an Int.
 func MakeInt(s Scalar) *safenum.Int {
 	bytes, err := s.MarshalBinary()
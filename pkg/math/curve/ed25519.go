@@ -1,5 +1,305 @@
 package curve
 
-func Sign() {
-	//ed25519.Sign()
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"filippo.io/edwards25519"
+	"github.com/cronokirby/safenum"
+)
+
+// Ed25519 is the prime order subgroup of the twisted Edwards curve edwards25519, as used in RFC 8032.
+//
+// Points are encoded in the canonical 32 byte format of RFC 8032, and decoding rejects
+// non-canonical encodings, as well as points outside the prime order subgroup.
+// Scalars are encoded as 32 big endian bytes, like the other curves, and unlike RFC 8032.
+type Ed25519 struct{}
+
+var ed25519OrderNat, _ = new(safenum.Nat).SetHex("1000000000000000000000000000000014DEF9DEA2F79CD65812631A5CF5D3ED")
+var ed25519Order = safenum.ModulusFromNat(ed25519OrderNat)
+var ed25519HalfOrder = new(safenum.Nat).Rsh(ed25519OrderNat, 1, -1)
+
+// ed25519InverseCofactor = 8⁻¹ mod ℓ, used to check that points lie in the prime order subgroup.
+var ed25519InverseCofactor = func() *edwards25519.Scalar {
+	var eight [32]byte
+	eight[0] = 8
+	s, _ := edwards25519.NewScalar().SetCanonicalBytes(eight[:])
+	return s.Invert(s)
+}()
+
+func (Ed25519) NewPoint() Point {
+	return &Ed25519Point{value: *edwards25519.NewIdentityPoint()}
+}
+
+func (Ed25519) NewBasePoint() Point {
+	return &Ed25519Point{value: *edwards25519.NewGeneratorPoint()}
+}
+
+func (Ed25519) NewScalar() Scalar {
+	return new(Ed25519Scalar)
+}
+
+func (Ed25519) Name() string {
+	return "ed25519"
+}
+
+func (Ed25519) ScalarBits() int {
+	return 253
+}
+
+// SafeScalarBytes returns 64, since the order ℓ ≈ 2²⁵² is far from 2²⁵⁶,
+// and reducing 32 random bytes modulo ℓ would introduce a noticeable bias.
+func (Ed25519) SafeScalarBytes() int {
+	return 64
+}
+
+func (Ed25519) Order() *safenum.Modulus {
+	return ed25519Order
+}
+
+// reverse32 converts between the big endian encoding of scalars and the little endian one of edwards25519.
+func reverse32(data []byte) []byte {
+	out := make([]byte, 32)
+	for i := range data {
+		out[len(data)-1-i] = data[i]
+	}
+	return out
+}
+
+type Ed25519Scalar struct {
+	value edwards25519.Scalar
+}
+
+func ed25519CastScalar(generic Scalar) *Ed25519Scalar {
+	out, ok := generic.(*Ed25519Scalar)
+	if !ok {
+		panic(fmt.Sprintf("failed to convert to ed25519Scalar: %v", generic))
+	}
+	return out
+}
+
+func (*Ed25519Scalar) Curve() Curve {
+	return Ed25519{}
+}
+
+func (s *Ed25519Scalar) MarshalBinary() ([]byte, error) {
+	return reverse32(s.value.Bytes()), nil
+}
+
+func (s *Ed25519Scalar) UnmarshalBinary(data []byte) error {
+	if len(data) != 32 {
+		return fmt.Errorf("invalid length for ed25519 scalar: %d", len(data))
+	}
+	if _, err := s.value.SetCanonicalBytes(reverse32(data)); err != nil {
+		return errors.New("invalid bytes for ed25519 scalar")
+	}
+	return nil
+}
+
+func (s *Ed25519Scalar) Add(that Scalar) Scalar {
+	other := ed25519CastScalar(that)
+
+	s.value.Add(&s.value, &other.value)
+	return s
+}
+
+func (s *Ed25519Scalar) Sub(that Scalar) Scalar {
+	other := ed25519CastScalar(that)
+
+	s.value.Subtract(&s.value, &other.value)
+	return s
+}
+
+func (s *Ed25519Scalar) Mul(that Scalar) Scalar {
+	other := ed25519CastScalar(that)
+
+	s.value.Multiply(&s.value, &other.value)
+	return s
+}
+
+func (s *Ed25519Scalar) Invert() Scalar {
+	s.value.Invert(&s.value)
+	return s
+}
+
+func (s *Ed25519Scalar) Negate() Scalar {
+	s.value.Negate(&s.value)
+	return s
+}
+
+func (s *Ed25519Scalar) Equal(that Scalar) bool {
+	other := ed25519CastScalar(that)
+
+	return s.value.Equal(&other.value) == 1
+}
+
+func (s *Ed25519Scalar) IsZero() bool {
+	return s.value.Equal(edwards25519.NewScalar()) == 1
+}
+
+func (s *Ed25519Scalar) Set(that Scalar) Scalar {
+	other := ed25519CastScalar(that)
+
+	s.value.Set(&other.value)
+	return s
+}
+
+func (s *Ed25519Scalar) SetNat(x *safenum.Nat) Scalar {
+	reduced := new(safenum.Nat).Mod(x, ed25519Order)
+	data := make([]byte, 32)
+	reduced.FillBytes(data)
+	if _, err := s.value.SetCanonicalBytes(reverse32(data)); err != nil {
+		panic(err)
+	}
+	return s
+}
+
+func (s *Ed25519Scalar) Act(that Point) Point {
+	other := ed25519CastPoint(that)
+	out := new(Ed25519Point)
+	out.value.ScalarMult(&s.value, &other.value)
+	return out
+}
+
+func (s *Ed25519Scalar) ActOnBase() Point {
+	out := new(Ed25519Point)
+	out.value.ScalarBaseMult(&s.value)
+	return out
+}
+
+func (s *Ed25519Scalar) IsOverHalfOrder() bool {
+	data, _ := s.MarshalBinary()
+	gt, _, _ := new(safenum.Nat).SetBytes(data).Cmp(ed25519HalfOrder)
+	return gt == 1
+}
+
+func (s *Ed25519Scalar) PutBytesUnchecked(b []byte) {
+	data, _ := s.MarshalBinary()
+	copy(b, data)
+}
+
+type Ed25519Point struct {
+	value edwards25519.Point
+}
+
+func ed25519CastPoint(generic Point) *Ed25519Point {
+	out, ok := generic.(*Ed25519Point)
+	if !ok {
+		panic(fmt.Sprintf("failed to convert to ed25519Point: %v", generic))
+	}
+	return out
+}
+
+func (*Ed25519Point) Curve() Curve {
+	return Ed25519{}
+}
+
+// MarshalBinary returns the 32 byte encoding of RFC 8032.
+func (p *Ed25519Point) MarshalBinary() ([]byte, error) {
+	return p.value.Bytes(), nil
+}
+
+// UnmarshalBinary decodes a point encoded as in RFC 8032.
+//
+// Non-canonical encodings are rejected, as well as points with a component of small order,
+// so that every point is in the prime order subgroup.
+func (p *Ed25519Point) UnmarshalBinary(data []byte) error {
+	if len(data) != 32 {
+		return fmt.Errorf("invalid length for ed25519Point: %d", len(data))
+	}
+	var value edwards25519.Point
+	if _, err := value.SetBytes(data); err != nil {
+		return fmt.Errorf("ed25519Point.UnmarshalBinary: %w", err)
+	}
+	if !bytes.Equal(value.Bytes(), data) {
+		return errors.New("ed25519Point.UnmarshalBinary: non-canonical encoding")
+	}
+	// P is in the prime order subgroup if and only if [8]([8⁻¹ mod ℓ]P) = P.
+	var check edwards25519.Point
+	check.ScalarMult(ed25519InverseCofactor, &value)
+	check.MultByCofactor(&check)
+	if check.Equal(&value) != 1 {
+		return errors.New("ed25519Point.UnmarshalBinary: point not in prime order subgroup")
+	}
+	p.value.Set(&value)
+	return nil
+}
+
+func (p *Ed25519Point) Add(that Point) Point {
+	other := ed25519CastPoint(that)
+
+	out := new(Ed25519Point)
+	out.value.Add(&p.value, &other.value)
+	return out
+}
+
+func (p *Ed25519Point) Sub(that Point) Point {
+	other := ed25519CastPoint(that)
+
+	out := new(Ed25519Point)
+	out.value.Subtract(&p.value, &other.value)
+	return out
+}
+
+func (p *Ed25519Point) Set(that Point) Point {
+	other := ed25519CastPoint(that)
+
+	p.value.Set(&other.value)
+	return p
+}
+
+func (p *Ed25519Point) Negate() Point {
+	out := new(Ed25519Point)
+	out.value.Negate(&p.value)
+	return out
+}
+
+// Equal compares [8]P and [8]Q in constant time, so that points differing by a small order component
+// are never considered different.
+func (p *Ed25519Point) Equal(that Point) bool {
+	other := ed25519CastPoint(that)
+
+	var a, b edwards25519.Point
+	a.MultByCofactor(&p.value)
+	b.MultByCofactor(&other.value)
+	return a.Equal(&b) == 1
+}
+
+func (p *Ed25519Point) IsIdentity() bool {
+	return p == nil || p.value.Equal(edwards25519.NewIdentityPoint()) == 1
+}
+
+// XScalar returns nil, since ECDSA is not defined over Ed25519.
+func (p *Ed25519Point) XScalar() Scalar {
+	return nil
+}
+
+// XOverflow returns 0, since ECDSA is not defined over Ed25519.
+func (p *Ed25519Point) XOverflow() uint32 {
+	return 0
+}
+
+// affine returns the affine coordinates of this point.
+func (p *Ed25519Point) affine() (x, y []byte) {
+	X, Y, Z, _ := p.value.ExtendedCoordinates()
+	zInv := Z.Invert(Z)
+	return X.Multiply(X, zInv).Bytes(), Y.Multiply(Y, zInv).Bytes()
+}
+
+// XBytes returns the affine x coordinate as 32 big endian bytes.
+func (p *Ed25519Point) XBytes() []byte {
+	x, _ := p.affine()
+	return reverse32(x)
+}
+
+// YBytes returns the affine y coordinate as 32 big endian bytes.
+func (p *Ed25519Point) YBytes() []byte {
+	_, y := p.affine()
+	return reverse32(y)
+}
+
+func (p *Ed25519Point) YOddBit() uint32 {
+	_, y := p.affine()
+	return uint32(y[0] & 1)
 }
@@ -0,0 +1,44 @@
+package curve_test
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/taurusgroup/multi-party-sig/pkg/math/curve"
+	"github.com/taurusgroup/multi-party-sig/pkg/math/sample"
+)
+
+func TestEd25519Encoding(t *testing.T) {
+	group := curve.Ed25519{}
+	x := sample.Scalar(rand.Reader, group)
+	X := x.ActOnBase()
+
+	data, err := x.MarshalBinary()
+	require.NoError(t, err)
+	decodedScalar := group.NewScalar()
+	require.NoError(t, decodedScalar.UnmarshalBinary(data))
+	assert.True(t, x.Equal(decodedScalar))
+
+	data, err = X.MarshalBinary()
+	require.NoError(t, err)
+	decodedPoint := group.NewPoint()
+	require.NoError(t, decodedPoint.UnmarshalBinary(data))
+	assert.True(t, X.Equal(decodedPoint))
+	assert.True(t, X.Sub(decodedPoint).IsIdentity())
+
+	// y = 1 + p is a non-canonical encoding of the identity
+	nonCanonical, _ := hex.DecodeString("eeffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff7f")
+	assert.Error(t, group.NewPoint().UnmarshalBinary(nonCanonical))
+
+	// a point of order 8
+	torsion, _ := hex.DecodeString("c7176a703d4dd84fba3c0b760d10670f2a2053fa2c39ccc64ec7fd7792ac037a")
+	assert.Error(t, group.NewPoint().UnmarshalBinary(torsion))
+
+	// the order ℓ is not a canonical scalar
+	order := make([]byte, 32)
+	group.Order().Nat().FillBytes(order)
+	assert.Error(t, group.NewScalar().UnmarshalBinary(order))
+}
@@ -0,0 +1,265 @@
+package curve
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+
+	"filippo.io/nistec"
+	"github.com/cronokirby/safenum"
+)
+
+// P256 is the NIST P-256 curve, also known as secp256r1 or prime256v1.
+//
+// Points are encoded in the compressed format of SEC 1, like curve.Secp256k1,
+// with the identity encoded as a single 0 byte.
+type P256 struct{}
+
+func (P256) NewPoint() Point {
+	return &P256Point{value: nistec.NewP256Point()}
+}
+
+func (P256) NewBasePoint() Point {
+	return &P256Point{value: nistec.NewP256Point().SetGenerator()}
+}
+
+func (P256) NewScalar() Scalar {
+	return new(P256Scalar)
+}
+
+func (P256) Name() string {
+	return "P-256"
+}
+
+func (P256) ScalarBits() int {
+	return 256
+}
+
+// SafeScalarBytes returns 48, since the order n ≈ 2²⁵⁶ - 2²²⁴ is not close enough to 2²⁵⁶
+// for 32 random bytes reduced modulo n to be uniform: small scalars would be twice as likely.
+func (P256) SafeScalarBytes() int {
+	return 48
+}
+
+var p256OrderNat, _ = new(safenum.Nat).SetHex("FFFFFFFF00000000FFFFFFFFFFFFFFFFBCE6FAADA7179E84F3B9CAC2FC632551")
+var p256Order = safenum.ModulusFromNat(p256OrderNat)
+var p256HalfOrder = new(safenum.Nat).Rsh(p256OrderNat, 1, -1)
+
+func (P256) Order() *safenum.Modulus {
+	return p256Order
+}
+
+// P256Scalar is an integer modulo the order of P-256.
+//
+// All operations are done in constant time by safenum.
+type P256Scalar struct {
+	value safenum.Nat
+}
+
+func p256CastScalar(generic Scalar) *P256Scalar {
+	out, ok := generic.(*P256Scalar)
+	if !ok {
+		panic(fmt.Sprintf("failed to convert to p256Scalar: %v", generic))
+	}
+	return out
+}
+
+func (*P256Scalar) Curve() Curve {
+	return P256{}
+}
+
+func (s *P256Scalar) MarshalBinary() ([]byte, error) {
+	out := make([]byte, 32)
+	s.value.FillBytes(out)
+	return out, nil
+}
+
+func (s *P256Scalar) UnmarshalBinary(data []byte) error {
+	if len(data) != 32 {
+		return fmt.Errorf("invalid length for p256 scalar: %d", len(data))
+	}
+	value := new(safenum.Nat).SetBytes(data)
+	if _, _, lt := value.CmpMod(p256Order); lt != 1 {
+		return errors.New("invalid bytes for p256 scalar")
+	}
+	s.value.SetNat(value)
+	return nil
+}
+
+func (s *P256Scalar) Add(that Scalar) Scalar {
+	other := p256CastScalar(that)
+
+	s.value.ModAdd(&s.value, &other.value, p256Order)
+	return s
+}
+
+func (s *P256Scalar) Sub(that Scalar) Scalar {
+	other := p256CastScalar(that)
+
+	s.value.ModSub(&s.value, &other.value, p256Order)
+	return s
+}
+
+func (s *P256Scalar) Mul(that Scalar) Scalar {
+	other := p256CastScalar(that)
+
+	s.value.ModMul(&s.value, &other.value, p256Order)
+	return s
+}
+
+func (s *P256Scalar) Invert() Scalar {
+	s.value.ModInverse(&s.value, p256Order)
+	return s
+}
+
+func (s *P256Scalar) Negate() Scalar {
+	s.value.ModNeg(&s.value, p256Order)
+	return s
+}
+
+func (s *P256Scalar) Equal(that Scalar) bool {
+	other := p256CastScalar(that)
+
+	return s.value.Eq(&other.value) == 1
+}
+
+func (s *P256Scalar) IsZero() bool {
+	return s.value.EqZero() == 1
+}
+
+func (s *P256Scalar) Set(that Scalar) Scalar {
+	other := p256CastScalar(that)
+
+	s.value.SetNat(&other.value)
+	return s
+}
+
+func (s *P256Scalar) SetNat(x *safenum.Nat) Scalar {
+	s.value.Mod(x, p256Order)
+	return s
+}
+
+func (s *P256Scalar) Act(that Point) Point {
+	other := p256CastPoint(that)
+	data, _ := s.MarshalBinary()
+	out, err := nistec.NewP256Point().ScalarMult(other.value, data)
+	if err != nil {
+		panic(err)
+	}
+	return &P256Point{value: out}
+}
+
+func (s *P256Scalar) ActOnBase() Point {
+	data, _ := s.MarshalBinary()
+	out, err := nistec.NewP256Point().ScalarBaseMult(data)
+	if err != nil {
+		panic(err)
+	}
+	return &P256Point{value: out}
+}
+
+func (s *P256Scalar) IsOverHalfOrder() bool {
+	gt, _, _ := s.value.Cmp(p256HalfOrder)
+	return gt == 1
+}
+
+func (s *P256Scalar) PutBytesUnchecked(b []byte) {
+	s.value.FillBytes(b[:32])
+}
+
+// P256Point is a point on P-256, or the identity.
+type P256Point struct {
+	value *nistec.P256Point
+}
+
+func p256CastPoint(generic Point) *P256Point {
+	out, ok := generic.(*P256Point)
+	if !ok {
+		panic(fmt.Sprintf("failed to convert to p256Point: %v", generic))
+	}
+	return out
+}
+
+func (*P256Point) Curve() Curve {
+	return P256{}
+}
+
+func (p *P256Point) MarshalBinary() ([]byte, error) {
+	return p.value.BytesCompressed(), nil
+}
+
+func (p *P256Point) UnmarshalBinary(data []byte) error {
+	if len(data) != 33 && !(len(data) == 1 && data[0] == 0) {
+		return fmt.Errorf("invalid length for p256Point: %d", len(data))
+	}
+	value, err := nistec.NewP256Point().SetBytes(data)
+	if err != nil {
+		return fmt.Errorf("p256Point.UnmarshalBinary: %w", err)
+	}
+	p.value = value
+	return nil
+}
+
+func (p *P256Point) Add(that Point) Point {
+	other := p256CastPoint(that)
+
+	return &P256Point{value: nistec.NewP256Point().Add(p.value, other.value)}
+}
+
+func (p *P256Point) Sub(that Point) Point {
+	return p.Add(that.Negate())
+}
+
+func (p *P256Point) Set(that Point) Point {
+	other := p256CastPoint(that)
+
+	p.value = nistec.NewP256Point().Set(other.value)
+	return p
+}
+
+func (p *P256Point) Negate() Point {
+	return &P256Point{value: nistec.NewP256Point().Negate(p.value)}
+}
+
+// Equal compares the uncompressed encodings of both points in constant time.
+func (p *P256Point) Equal(that Point) bool {
+	other := p256CastPoint(that)
+
+	return subtle.ConstantTimeCompare(p.value.Bytes(), other.value.Bytes()) == 1
+}
+
+func (p *P256Point) IsIdentity() bool {
+	return p == nil || p.value == nil || len(p.value.Bytes()) == 1
+}
+
+func (p *P256Point) XScalar() Scalar {
+	return P256{}.NewScalar().SetNat(new(safenum.Nat).SetBytes(p.XBytes()))
+}
+
+// XOverflow returns 1 if the x coordinate is greater than or equal to the order of the group.
+func (p *P256Point) XOverflow() uint32 {
+	_, _, lt := new(safenum.Nat).SetBytes(p.XBytes()).CmpMod(p256Order)
+	return uint32(1 ^ lt)
+}
+
+// XBytes returns the affine x coordinate as 32 big endian bytes, which are all 0 for the identity.
+func (p *P256Point) XBytes() []byte {
+	x, err := p.value.BytesX()
+	if err != nil {
+		return make([]byte, 32)
+	}
+	return x
+}
+
+// YBytes returns the affine y coordinate as 32 big endian bytes, which are all 0 for the identity.
+func (p *P256Point) YBytes() []byte {
+	data := p.value.Bytes()
+	if len(data) != 65 {
+		return make([]byte, 32)
+	}
+	return data[33:]
+}
+
+func (p *P256Point) YOddBit() uint32 {
+	return uint32(p.YBytes()[31] & 1)
+}
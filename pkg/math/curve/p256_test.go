@@ -0,0 +1,81 @@
+package curve_test
+
+import (
+	"bytes"
+	"crypto/elliptic"
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/cronokirby/safenum"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/taurusgroup/multi-party-sig/pkg/math/curve"
+	"github.com/taurusgroup/multi-party-sig/pkg/math/sample"
+)
+
+func TestP256(t *testing.T) {
+	group := curve.P256{}
+	params := elliptic.P256().Params()
+	toInt := func(s curve.Scalar) *big.Int {
+		data, err := s.MarshalBinary()
+		require.NoError(t, err)
+		return new(big.Int).SetBytes(data)
+	}
+
+	x := sample.Scalar(rand.Reader, group)
+	y := sample.Scalar(rand.Reader, group)
+	xInt, yInt := toInt(x), toInt(y)
+
+	expected := new(big.Int).Mul(xInt, yInt)
+	expected.Add(expected, xInt).Sub(expected, yInt).Mod(expected, params.N)
+	actual := group.NewScalar().Set(x).Mul(y).Add(x).Sub(y)
+	assert.Equal(t, expected, toInt(actual))
+
+	inverse := group.NewScalar().Set(x).Invert()
+	assert.True(t, inverse.Mul(x).Equal(group.NewScalar().SetNat(new(safenum.Nat).SetUint64(1))))
+	assert.True(t, group.NewScalar().Set(x).Negate().Add(x).IsZero())
+
+	X := x.ActOnBase()
+	expectedX, expectedY := params.ScalarBaseMult(xInt.Bytes())
+	assert.Equal(t, expectedX, new(big.Int).SetBytes(X.XBytes()))
+	assert.Equal(t, expectedY, new(big.Int).SetBytes(X.YBytes()))
+	assert.True(t, y.Act(X).Equal(x.Act(y.ActOnBase())))
+	assert.True(t, X.Add(X.Negate()).IsIdentity())
+
+	for _, P := range []curve.Point{X, group.NewPoint()} {
+		data, err := P.MarshalBinary()
+		require.NoError(t, err)
+		decoded := group.NewPoint()
+		require.NoError(t, decoded.UnmarshalBinary(data))
+		assert.True(t, P.Equal(decoded))
+	}
+
+	data, err := x.MarshalBinary()
+	require.NoError(t, err)
+	decoded := group.NewScalar()
+	require.NoError(t, decoded.UnmarshalBinary(data))
+	assert.True(t, x.Equal(decoded))
+	assert.Error(t, decoded.UnmarshalBinary(params.N.Bytes()), "the order is not a canonical scalar")
+}
+
+func TestP256SafeScalarBytes(t *testing.T) {
+	group := curve.P256{}
+	n := group.Order().Big()
+
+	// reducing a uniform value in [0, 2ᵏ) modulo n makes the scalars below 2ᵏ mod n more likely,
+	// so that remainder must be negligible compared to 2ᵏ.
+	bound := new(big.Int).Lsh(big.NewInt(1), uint(8*group.SafeScalarBytes()))
+	r := new(big.Int).Mod(bound, n)
+	assert.LessOrEqual(t, r.BitLen(), bound.BitLen()-1-128, "sampled scalars are skewed towards small values")
+
+	// the largest random input must not wrap around to a small scalar.
+	max := make([]byte, group.SafeScalarBytes())
+	for i := range max {
+		max[i] = 0xff
+	}
+	s := sample.Scalar(bytes.NewReader(max), group)
+	data, err := s.MarshalBinary()
+	require.NoError(t, err)
+	assert.Greater(t, new(big.Int).SetBytes(data).BitLen(), 224)
+}
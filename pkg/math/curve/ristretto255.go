@@ -0,0 +1,258 @@
+package curve
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/cronokirby/safenum"
+	"github.com/gtank/ristretto255"
+)
+
+// Ristretto255 is the prime order group ristretto255, built on top of edwards25519,
+// as specified in RFC 9496.
+//
+// Unlike Ed25519, there are no small order elements to worry about: every valid encoding
+// corresponds to a unique element of a group of prime order ℓ.
+// Elements are encoded in the canonical 32 byte format of RFC 9496.
+// Scalars are encoded as 32 big endian bytes, like the other curves, and unlike RFC 9496.
+type Ristretto255 struct{}
+
+func (Ristretto255) NewPoint() Point {
+	out := new(Ristretto255Point)
+	out.value.Zero()
+	return out
+}
+
+func (Ristretto255) NewBasePoint() Point {
+	out := new(Ristretto255Point)
+	out.value.Base()
+	return out
+}
+
+func (Ristretto255) NewScalar() Scalar {
+	return new(Ristretto255Scalar)
+}
+
+func (Ristretto255) Name() string {
+	return "ristretto255"
+}
+
+func (Ristretto255) ScalarBits() int {
+	return 253
+}
+
+// SafeScalarBytes returns 64, for the same reasons as Ed25519, whose order is shared with this group.
+func (Ristretto255) SafeScalarBytes() int {
+	return 64
+}
+
+func (Ristretto255) Order() *safenum.Modulus {
+	return ed25519Order
+}
+
+type Ristretto255Scalar struct {
+	value ristretto255.Scalar
+}
+
+func ristretto255CastScalar(generic Scalar) *Ristretto255Scalar {
+	out, ok := generic.(*Ristretto255Scalar)
+	if !ok {
+		panic(fmt.Sprintf("failed to convert to ristretto255Scalar: %v", generic))
+	}
+	return out
+}
+
+func (*Ristretto255Scalar) Curve() Curve {
+	return Ristretto255{}
+}
+
+func (s *Ristretto255Scalar) MarshalBinary() ([]byte, error) {
+	return reverse32(s.value.Encode(nil)), nil
+}
+
+func (s *Ristretto255Scalar) UnmarshalBinary(data []byte) error {
+	if len(data) != 32 {
+		return fmt.Errorf("invalid length for ristretto255 scalar: %d", len(data))
+	}
+	if err := s.value.Decode(reverse32(data)); err != nil {
+		return errors.New("invalid bytes for ristretto255 scalar")
+	}
+	return nil
+}
+
+func (s *Ristretto255Scalar) Add(that Scalar) Scalar {
+	other := ristretto255CastScalar(that)
+
+	s.value.Add(&s.value, &other.value)
+	return s
+}
+
+func (s *Ristretto255Scalar) Sub(that Scalar) Scalar {
+	other := ristretto255CastScalar(that)
+
+	s.value.Subtract(&s.value, &other.value)
+	return s
+}
+
+func (s *Ristretto255Scalar) Mul(that Scalar) Scalar {
+	other := ristretto255CastScalar(that)
+
+	s.value.Multiply(&s.value, &other.value)
+	return s
+}
+
+func (s *Ristretto255Scalar) Invert() Scalar {
+	s.value.Invert(&s.value)
+	return s
+}
+
+func (s *Ristretto255Scalar) Negate() Scalar {
+	s.value.Negate(&s.value)
+	return s
+}
+
+func (s *Ristretto255Scalar) Equal(that Scalar) bool {
+	other := ristretto255CastScalar(that)
+
+	return s.value.Equal(&other.value) == 1
+}
+
+func (s *Ristretto255Scalar) IsZero() bool {
+	return s.value.Equal(ristretto255.NewScalar()) == 1
+}
+
+func (s *Ristretto255Scalar) Set(that Scalar) Scalar {
+	other := ristretto255CastScalar(that)
+
+	s.value = other.value
+	return s
+}
+
+func (s *Ristretto255Scalar) SetNat(x *safenum.Nat) Scalar {
+	reduced := new(safenum.Nat).Mod(x, ed25519Order)
+	data := make([]byte, 32)
+	reduced.FillBytes(data)
+	if err := s.value.Decode(reverse32(data)); err != nil {
+		panic(err)
+	}
+	return s
+}
+
+func (s *Ristretto255Scalar) Act(that Point) Point {
+	other := ristretto255CastPoint(that)
+	out := new(Ristretto255Point)
+	out.value.ScalarMult(&s.value, &other.value)
+	return out
+}
+
+func (s *Ristretto255Scalar) ActOnBase() Point {
+	out := new(Ristretto255Point)
+	out.value.ScalarBaseMult(&s.value)
+	return out
+}
+
+func (s *Ristretto255Scalar) IsOverHalfOrder() bool {
+	data, _ := s.MarshalBinary()
+	gt, _, _ := new(safenum.Nat).SetBytes(data).Cmp(ed25519HalfOrder)
+	return gt == 1
+}
+
+func (s *Ristretto255Scalar) PutBytesUnchecked(b []byte) {
+	data, _ := s.MarshalBinary()
+	copy(b, data)
+}
+
+type Ristretto255Point struct {
+	value ristretto255.Element
+}
+
+func ristretto255CastPoint(generic Point) *Ristretto255Point {
+	out, ok := generic.(*Ristretto255Point)
+	if !ok {
+		panic(fmt.Sprintf("failed to convert to ristretto255Point: %v", generic))
+	}
+	return out
+}
+
+func (*Ristretto255Point) Curve() Curve {
+	return Ristretto255{}
+}
+
+// MarshalBinary returns the canonical 32 byte encoding of RFC 9496.
+func (p *Ristretto255Point) MarshalBinary() ([]byte, error) {
+	return p.value.Encode(nil), nil
+}
+
+// UnmarshalBinary decodes an element encoded as in RFC 9496, rejecting non-canonical encodings.
+func (p *Ristretto255Point) UnmarshalBinary(data []byte) error {
+	if len(data) != 32 {
+		return fmt.Errorf("invalid length for ristretto255Point: %d", len(data))
+	}
+	if err := p.value.Decode(data); err != nil {
+		return fmt.Errorf("ristretto255Point.UnmarshalBinary: %w", err)
+	}
+	return nil
+}
+
+func (p *Ristretto255Point) Add(that Point) Point {
+	other := ristretto255CastPoint(that)
+
+	out := new(Ristretto255Point)
+	out.value.Add(&p.value, &other.value)
+	return out
+}
+
+func (p *Ristretto255Point) Sub(that Point) Point {
+	other := ristretto255CastPoint(that)
+
+	out := new(Ristretto255Point)
+	out.value.Subtract(&p.value, &other.value)
+	return out
+}
+
+func (p *Ristretto255Point) Set(that Point) Point {
+	other := ristretto255CastPoint(that)
+
+	p.value = other.value
+	return p
+}
+
+func (p *Ristretto255Point) Negate() Point {
+	out := new(Ristretto255Point)
+	out.value.Negate(&p.value)
+	return out
+}
+
+func (p *Ristretto255Point) Equal(that Point) bool {
+	other := ristretto255CastPoint(that)
+
+	return p.value.Equal(&other.value) == 1
+}
+
+func (p *Ristretto255Point) IsIdentity() bool {
+	return p == nil || p.value.Equal(ristretto255.NewElement()) == 1
+}
+
+// XScalar returns nil, since ECDSA is not defined over Ristretto255.
+func (p *Ristretto255Point) XScalar() Scalar {
+	return nil
+}
+
+// XOverflow returns 0, since ECDSA is not defined over Ristretto255.
+func (p *Ristretto255Point) XOverflow() uint32 {
+	return 0
+}
+
+// XBytes returns nil, since an element is a class of curve points with no canonical coordinates.
+func (p *Ristretto255Point) XBytes() []byte {
+	return nil
+}
+
+// YBytes returns nil, since an element is a class of curve points with no canonical coordinates.
+func (p *Ristretto255Point) YBytes() []byte {
+	return nil
+}
+
+func (p *Ristretto255Point) YOddBit() uint32 {
+	return 0
+}
@@ -0,0 +1,60 @@
+package curve_test
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"testing"
+
+	"github.com/cronokirby/safenum"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/taurusgroup/multi-party-sig/pkg/math/curve"
+	"github.com/taurusgroup/multi-party-sig/pkg/math/sample"
+)
+
+func TestRistretto255Encoding(t *testing.T) {
+	group := curve.Ristretto255{}
+	x := sample.Scalar(rand.Reader, group)
+	X := x.ActOnBase()
+
+	data, err := x.MarshalBinary()
+	require.NoError(t, err)
+	decodedScalar := group.NewScalar()
+	require.NoError(t, decodedScalar.UnmarshalBinary(data))
+	assert.True(t, x.Equal(decodedScalar))
+
+	data, err = X.MarshalBinary()
+	require.NoError(t, err)
+	decodedPoint := group.NewPoint()
+	require.NoError(t, decodedPoint.UnmarshalBinary(data))
+	assert.True(t, X.Equal(decodedPoint))
+	assert.True(t, X.Sub(decodedPoint).IsIdentity())
+
+	// multiples of the base point, from Appendix A.1 of RFC 9496
+	multiples := []string{
+		"0000000000000000000000000000000000000000000000000000000000000000",
+		"e2f2ae0a6abc4e71a884a961c500515f58e30b6aa582dd8db6a65945e08d2d76",
+		"6a493210f7499cd17fecb510ae0cea23a110e8d5b901f8acadd3095c73a3b919",
+		"94741f5d5d52755ece4f23f044ee27d5d1ea1e2bd196b462166b16152a9d0259",
+	}
+	for i, expected := range multiples {
+		P := group.NewScalar().SetNat(new(safenum.Nat).SetUint64(uint64(i))).ActOnBase()
+		data, err = P.MarshalBinary()
+		require.NoError(t, err)
+		assert.Equal(t, expected, hex.EncodeToString(data))
+	}
+
+	// a non-canonical field element, and a negative one, from Appendix A.2 of RFC 9496
+	for _, invalid := range []string{
+		"ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff7f",
+		"0100000000000000000000000000000000000000000000000000000000000000",
+	} {
+		data, _ = hex.DecodeString(invalid)
+		assert.Error(t, group.NewPoint().UnmarshalBinary(data))
+	}
+
+	// the order ℓ is not a canonical scalar
+	order := make([]byte, 32)
+	group.Order().Nat().FillBytes(order)
+	assert.Error(t, group.NewScalar().UnmarshalBinary(order))
+}
@@ -95,16 +95,20 @@ func (p *Exponent) Degree() int {
 }
 
 func (p *Exponent) add(q *Exponent) error {
-	if len(p.coefficients) != len(q.coefficients) {
+	if p.Degree() != q.Degree() {
 		return errors.New("q is not the same length as p")
 	}
 
-	if p.IsConstant != q.IsConstant {
-		return errors.New("p and q differ in 'IsConstant'")
+	// if only p has a zero constant, the sum does not
+	if p.IsConstant && !q.IsConstant {
+		p.coefficients = append([]curve.Point{p.group.NewPoint()}, p.coefficients...)
+		p.IsConstant = false
 	}
 
-	for i := 0; i < len(p.coefficients); i++ {
-		p.coefficients[i] = p.coefficients[i].Add(q.coefficients[i])
+	// the coefficients of q are shifted if only q has a zero constant
+	offset := len(p.coefficients) - len(q.coefficients)
+	for i := 0; i < len(q.coefficients); i++ {
+		p.coefficients[i+offset] = p.coefficients[i+offset].Add(q.coefficients[i])
 	}
 
 	return nil
@@ -117,7 +121,8 @@ func Sum(polynomials []*Exponent) (*Exponent, error) {
 	// Create the new polynomial by copying the first one given
 	summed := polynomials[0].copy()
 
-	// we assume all polynomials have the same degree as the first
+	// we assume all polynomials have the same degree as the first,
+	// but their constant coefficients may be the identity
 	for j := 1; j < len(polynomials); j++ {
 		err = summed.add(polynomials[j])
 		if err != nil {
@@ -2,7 +2,9 @@ package polynomial
 
 import (
 	"crypto/rand"
+	"errors"
 
+	"github.com/fxamacker/cbor/v2"
 	"github.com/taurusgroup/multi-party-sig/pkg/math/curve"
 	"github.com/taurusgroup/multi-party-sig/pkg/math/sample"
 )
@@ -59,3 +61,38 @@ func (p *Polynomial) Constant() curve.Scalar {
 func (p *Polynomial) Degree() uint32 {
 	return uint32(len(p.coefficients)) - 1
 }
+
+// EmptyPolynomial creates an empty Polynomial with a fixed group, ready for unmarshalling.
+func EmptyPolynomial(group curve.Curve) *Polynomial {
+	return &Polynomial{group: group}
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+//
+// The coefficients of the polynomial are secret, the output should be handled accordingly.
+func (p *Polynomial) MarshalBinary() ([]byte, error) {
+	return cbor.Marshal(p.coefficients)
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+func (p *Polynomial) UnmarshalBinary(data []byte) error {
+	if p == nil || p.group == nil {
+		return errors.New("can't unmarshal Polynomial with no group")
+	}
+	var raw []cbor.RawMessage
+	if err := cbor.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	if len(raw) == 0 {
+		return errors.New("polynomial has no coefficients")
+	}
+	coefficients := make([]curve.Scalar, len(raw))
+	for i := range raw {
+		coefficients[i] = p.group.NewScalar()
+		if err := cbor.Unmarshal(raw[i], coefficients[i]); err != nil {
+			return err
+		}
+	}
+	p.coefficients = coefficients
+	return nil
+}
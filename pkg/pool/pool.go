@@ -135,7 +135,9 @@ func (p *Pool) Search(count int, f func() interface{}) []interface{} {
 	results := make([]interface{}, count)
 
 	ctr := int64(count)
-	ctrChanged := make(chan struct{})
+	// Workers decrement ctr before reporting on ctrChanged, so we might stop listening before
+	// they report. The buffer holds one report per success, and one per worker overshooting.
+	ctrChanged := make(chan struct{}, count+p.workerCount)
 	cmd := command{
 		search:     true,
 		ctr:        &ctr,
@@ -169,7 +171,8 @@ func (p *Pool) Parallelize(count int, f func(int) interface{}) []interface{} {
 	results := make([]interface{}, count)
 
 	ctr := int64(count)
-	ctrChanged := make(chan struct{})
+	// As in Search, the buffer lets workers report after we have stopped listening.
+	ctrChanged := make(chan struct{}, count)
 	cmdI := 0
 	for cmdI < count {
 		cmd := command{
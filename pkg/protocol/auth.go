@@ -0,0 +1,242 @@
+package protocol
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/taurusgroup/multi-party-sig/pkg/party"
+	"github.com/taurusgroup/multi-party-sig/pkg/taproot"
+)
+
+// IdentitySecretKey is the long-term key with which a party signs the messages it sends.
+type IdentitySecretKey interface {
+	// Sign returns a signature of digest, which is the output of Message.Hash.
+	Sign(digest []byte) ([]byte, error)
+}
+
+// IdentityPublicKey verifies the signatures of the messages sent by a party.
+type IdentityPublicKey interface {
+	// Verify returns true if signature is a valid signature of digest.
+	Verify(digest, signature []byte) bool
+}
+
+// Ed25519SecretKey is an IdentitySecretKey producing Ed25519 signatures.
+type Ed25519SecretKey ed25519.PrivateKey
+
+// Sign implements IdentitySecretKey.
+func (sk Ed25519SecretKey) Sign(digest []byte) ([]byte, error) {
+	if len(sk) != ed25519.PrivateKeySize {
+		return nil, errors.New("protocol: invalid ed25519 secret key")
+	}
+	return ed25519.Sign(ed25519.PrivateKey(sk), digest), nil
+}
+
+// Ed25519PublicKey is an IdentityPublicKey verifying Ed25519 signatures.
+type Ed25519PublicKey ed25519.PublicKey
+
+// Verify implements IdentityPublicKey.
+func (pk Ed25519PublicKey) Verify(digest, signature []byte) bool {
+	if len(pk) != ed25519.PublicKeySize {
+		return false
+	}
+	return ed25519.Verify(ed25519.PublicKey(pk), digest, signature)
+}
+
+// SchnorrSecretKey is an IdentitySecretKey producing BIP-340 Schnorr signatures over secp256k1.
+type SchnorrSecretKey taproot.SecretKey
+
+// Sign implements IdentitySecretKey.
+func (sk SchnorrSecretKey) Sign(digest []byte) ([]byte, error) {
+	return taproot.SecretKey(sk).Sign(rand.Reader, digest)
+}
+
+// SchnorrPublicKey is an IdentityPublicKey verifying BIP-340 Schnorr signatures over secp256k1.
+type SchnorrPublicKey taproot.PublicKey
+
+// Verify implements IdentityPublicKey.
+func (pk SchnorrPublicKey) Verify(digest, signature []byte) bool {
+	return taproot.PublicKey(pk).Verify(signature, digest)
+}
+
+// ForgedMessageError is returned when a message does not carry a valid signature from the party it claims to be from.
+type ForgedMessageError struct {
+	// From is the claimed sender of the message.
+	From party.ID
+	// Err describes why the signature was rejected.
+	Err error
+}
+
+// Error implements error.
+func (e ForgedMessageError) Error() string {
+	return fmt.Sprintf("protocol: forged message from %s: %s", e.From, e.Err)
+}
+
+// Unwrap implements errors.Wrapper.
+func (e ForgedMessageError) Unwrap() error {
+	return e.Err
+}
+
+// Authenticator signs the messages we send, and verifies the messages we receive
+// against the identity keys of the other parties.
+type Authenticator struct {
+	selfID party.ID
+	secret IdentitySecretKey
+	public map[party.ID]IdentityPublicKey
+}
+
+// NewAuthenticator returns an Authenticator for selfID, whose identity key is secret.
+// public must contain the identity keys of all parties we expect to receive messages from.
+func NewAuthenticator(selfID party.ID, secret IdentitySecretKey, public map[party.ID]IdentityPublicKey) *Authenticator {
+	keys := make(map[party.ID]IdentityPublicKey, len(public))
+	for id, key := range public {
+		keys[id] = key
+	}
+	return &Authenticator{
+		selfID: selfID,
+		secret: secret,
+		public: keys,
+	}
+}
+
+// Sign sets the signature of a message we send.
+// msg is modified in place, so it must not be shared with a Handler which may still read it.
+func (a *Authenticator) Sign(msg *Message) error {
+	if msg.From != a.selfID {
+		return fmt.Errorf("protocol: cannot sign message from %s", msg.From)
+	}
+	signature, err := a.secret.Sign(msg.Hash())
+	if err != nil {
+		return fmt.Errorf("protocol: failed to sign message: %w", err)
+	}
+	msg.Signature = signature
+	return nil
+}
+
+// Verify returns a ForgedMessageError if msg is not signed by the identity key of msg.From.
+func (a *Authenticator) Verify(msg *Message) error {
+	if msg == nil {
+		return errors.New("protocol: nil message")
+	}
+	return a.verifyDigest(msg.From, msg.Hash(), msg.Signature)
+}
+
+// verifyDigest returns a ForgedMessageError if signature is not a signature of digest by the identity key of from.
+func (a *Authenticator) verifyDigest(from party.ID, digest, signature []byte) error {
+	public, ok := a.public[from]
+	if !ok {
+		return ForgedMessageError{From: from, Err: errors.New("unknown sender")}
+	}
+	if len(signature) == 0 {
+		return ForgedMessageError{From: from, Err: errors.New("missing signature")}
+	}
+	if !public.Verify(digest, signature) {
+		return ForgedMessageError{From: from, Err: errors.New("invalid signature")}
+	}
+	return nil
+}
+
+// AuthenticatedHandler wraps a Handler so that all outgoing messages are signed,
+// and incoming messages without a valid signature are dropped before reaching the Handler.
+//
+// Each dropped message is reported as a ForgedMessageError on the channel returned by Forgeries.
+type AuthenticatedHandler struct {
+	Handler
+	auth *Authenticator
+	out  chan *Message
+
+	forgeries chan ForgedMessageError
+	mtx       sync.Mutex
+	closed    bool
+}
+
+// NewAuthenticatedHandler returns a Handler which authenticates the messages of h with auth.
+//
+// Messages must be read from the Listen channel of the returned handler, and not from h directly.
+func NewAuthenticatedHandler(h Handler, auth *Authenticator) *AuthenticatedHandler {
+	a := &AuthenticatedHandler{
+		Handler:   h,
+		auth:      auth,
+		out:       make(chan *Message, cap(h.Listen())),
+		forgeries: make(chan ForgedMessageError, cap(h.Listen())),
+	}
+	go a.sign()
+	return a
+}
+
+// sign forwards signed copies of the messages of the underlying Handler.
+// The messages themselves are left untouched, since the Handler may still be using them.
+// If a message cannot be signed, the protocol is stopped, and the remaining messages are dropped.
+func (a *AuthenticatedHandler) sign() {
+	defer func() {
+		close(a.out)
+		a.mtx.Lock()
+		a.closed = true
+		close(a.forgeries)
+		a.mtx.Unlock()
+	}()
+	failed := false
+	for msg := range a.Handler.Listen() {
+		if failed {
+			continue
+		}
+		signed := *msg
+		if err := a.auth.Sign(&signed); err != nil {
+			failed = true
+			// the Handler may be blocked sending to the channel we are draining, so it is stopped concurrently.
+			go a.Handler.Stop()
+			continue
+		}
+		a.out <- &signed
+	}
+}
+
+// Listen returns a channel with the signed outgoing messages.
+func (a *AuthenticatedHandler) Listen() <-chan *Message {
+	return a.out
+}
+
+// Forgeries returns a channel with an error for every message dropped by Accept because of its signature.
+// Errors are discarded when the channel is full, so that reading it is optional.
+// It is closed along with the Listen channel.
+func (a *AuthenticatedHandler) Forgeries() <-chan ForgedMessageError {
+	return a.forgeries
+}
+
+// Verify returns a ForgedMessageError if the message is not correctly signed by its sender.
+func (a *AuthenticatedHandler) Verify(msg *Message) error {
+	return a.auth.Verify(msg)
+}
+
+// CanAccept returns false if the message is not correctly signed, or if the Handler cannot accept it.
+func (a *AuthenticatedHandler) CanAccept(msg *Message) bool {
+	return a.auth.Verify(msg) == nil && a.Handler.CanAccept(msg)
+}
+
+// Accept passes the message to the underlying Handler if it is correctly signed,
+// and reports it on the Forgeries channel otherwise.
+func (a *AuthenticatedHandler) Accept(msg *Message) {
+	if err := a.auth.Verify(msg); err != nil {
+		var forged ForgedMessageError
+		if errors.As(err, &forged) {
+			a.report(forged)
+		}
+		return
+	}
+	a.Handler.Accept(msg)
+}
+
+// report sends forged on the Forgeries channel, unless it is full or closed.
+func (a *AuthenticatedHandler) report(forged ForgedMessageError) {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	if a.closed {
+		return
+	}
+	select {
+	case a.forgeries <- forged:
+	default:
+	}
+}
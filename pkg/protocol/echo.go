@@ -0,0 +1,295 @@
+package protocol
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"sync"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/taurusgroup/multi-party-sig/internal/round"
+	"github.com/taurusgroup/multi-party-sig/pkg/party"
+)
+
+// ErrEquivocation is the error returned by an EchoBroadcastHandler when a party sent
+// different broadcast messages to different parties.
+var ErrEquivocation = errors.New("protocol: equivocation in broadcast")
+
+// echoSuffix is appended to the protocol ID of echo messages, so that they are not mistaken for protocol messages.
+const echoSuffix = "/echo"
+
+// echo is the content of an echo message.
+// It contains the hash and signature of the broadcast message received from Sender.
+type echo struct {
+	Sender    party.ID
+	Hash      []byte
+	Signature []byte
+}
+
+// echoState tracks the broadcast message received from a party in a given round, and its echoes.
+type echoState struct {
+	msg       *Message
+	hash      []byte
+	echoes    map[party.ID]*echo
+	delivered bool
+}
+
+// EchoBroadcastHandler wraps a Handler so that broadcast messages are reliably broadcast over point-to-point channels.
+//
+// When a broadcast message is received, its hash is echoed to all other parties.
+// The message is only delivered to the underlying Handler after every other party has echoed the same hash.
+// If two parties received different messages, the execution is aborted with an Error wrapping ErrEquivocation.
+//
+// If an Authenticator is given, it is used to decide who is to blame:
+// an echo carrying a valid signature of the sender for a different message proves that the sender equivocated,
+// while an echo with an invalid signature is blamed on the party that sent it.
+// Without an Authenticator, a dishonest echo cannot be told apart from an equivocating sender,
+// so the execution is aborted without blaming anyone.
+// The same Authenticator should then be used to wrap this handler in an AuthenticatedHandler.
+type EchoBroadcastHandler struct {
+	Handler
+	selfID   party.ID
+	partyIDs party.IDSlice
+	auth     *Authenticator
+
+	out    chan *Message
+	closed bool
+	err    *Error
+	states map[round.Number]map[party.ID]*echoState
+	mtx    sync.Mutex
+	// sending counts the echoes being sent on out by Accept, which must not be closed until they are sent.
+	sending sync.WaitGroup
+}
+
+// NewEchoBroadcastHandler returns a Handler which reliably broadcasts the messages of h,
+// where selfID and partyIDs are those given to the protocol's StartFunc. auth may be nil.
+//
+// Messages must be read from the Listen channel of the returned handler, and not from h directly.
+func NewEchoBroadcastHandler(h Handler, selfID party.ID, partyIDs []party.ID, auth *Authenticator) *EchoBroadcastHandler {
+	ids := party.NewIDSlice(partyIDs)
+	e := &EchoBroadcastHandler{
+		Handler:  h,
+		selfID:   selfID,
+		partyIDs: ids,
+		auth:     auth,
+		// each received broadcast message results in an echo being sent, in addition to our own messages.
+		out:    make(chan *Message, cap(h.Listen())+2*len(ids)),
+		states: map[round.Number]map[party.ID]*echoState{},
+	}
+	go e.forward()
+	return e
+}
+
+// forward sends the messages of the underlying Handler.
+//
+// The lock is not held while sending, since the channel may be full until the caller of Listen calls Accept or Result.
+func (e *EchoBroadcastHandler) forward() {
+	for msg := range e.Handler.Listen() {
+		e.out <- msg
+	}
+	e.mtx.Lock()
+	e.closed = true
+	e.mtx.Unlock()
+	e.sending.Wait()
+	close(e.out)
+}
+
+// Listen returns a channel with the outgoing messages, including echoes.
+func (e *EchoBroadcastHandler) Listen() <-chan *Message {
+	return e.out
+}
+
+// Result returns the error caused by an equivocation, or the result of the underlying Handler.
+func (e *EchoBroadcastHandler) Result() (interface{}, error) {
+	e.mtx.Lock()
+	err := e.err
+	e.mtx.Unlock()
+	if err != nil {
+		return nil, *err
+	}
+	return e.Handler.Result()
+}
+
+// CanAccept returns true for echo messages of this execution, and for messages the underlying Handler can accept.
+func (e *EchoBroadcastHandler) CanAccept(msg *Message) bool {
+	if msg == nil {
+		return false
+	}
+	if protocol, ok := echoProtocol(msg); ok {
+		original := *msg
+		original.Protocol = protocol
+		original.Broadcast = true
+		return e.Handler.CanAccept(&original)
+	}
+	return e.Handler.CanAccept(msg)
+}
+
+// Accept processes echoes and broadcast messages, and forwards all other messages to the underlying Handler.
+func (e *EchoBroadcastHandler) Accept(msg *Message) {
+	if msg == nil {
+		return
+	}
+	if _, ok := echoProtocol(msg); !ok && !msg.Broadcast {
+		e.Handler.Accept(msg)
+		return
+	}
+	if !e.CanAccept(msg) {
+		return
+	}
+
+	e.mtx.Lock()
+	echoMsg, deliver := e.accept(msg)
+	if echoMsg != nil {
+		e.sending.Add(1)
+	}
+	e.mtx.Unlock()
+
+	// send and deliver outside the lock, since the channel may be full,
+	// and the Handler may block while we forward its messages.
+	if echoMsg != nil {
+		e.out <- echoMsg
+		e.sending.Done()
+	}
+	if deliver != nil {
+		e.Handler.Accept(deliver)
+	}
+}
+
+// accept handles a broadcast or echo message.
+// It returns the echo to send for a new broadcast message, and a broadcast message which can be delivered, if any.
+func (e *EchoBroadcastHandler) accept(msg *Message) (echoMsg, deliver *Message) {
+	if e.err != nil || e.closed {
+		return nil, nil
+	}
+
+	if _, ok := echoProtocol(msg); ok {
+		var content echo
+		if err := cbor.Unmarshal(msg.Data, &content); err != nil || content.Sender == e.selfID ||
+			content.Sender == msg.From || !e.partyIDs.Contains(content.Sender) {
+			return nil, nil
+		}
+		state := e.state(msg.RoundNumber, content.Sender)
+		if _, ok = state.echoes[msg.From]; ok {
+			return nil, nil
+		}
+		state.echoes[msg.From] = &content
+		if state.msg != nil && !bytes.Equal(state.hash, content.Hash) {
+			e.equivocation(content.Sender, msg.From, &content)
+			return nil, nil
+		}
+		return nil, e.deliverable(state)
+	}
+
+	state := e.state(msg.RoundNumber, msg.From)
+	hash := msg.Hash()
+	if state.msg != nil {
+		if !bytes.Equal(state.hash, hash) {
+			e.conflict(state.msg, msg)
+		}
+		return nil, nil
+	}
+	state.msg = msg
+	state.hash = hash
+
+	data, err := cbor.Marshal(&echo{
+		Sender:    msg.From,
+		Hash:      hash,
+		Signature: msg.Signature,
+	})
+	if err != nil {
+		return nil, nil
+	}
+	echoMsg = &Message{
+		SSID:        msg.SSID,
+		From:        e.selfID,
+		Protocol:    msg.Protocol + echoSuffix,
+		RoundNumber: msg.RoundNumber,
+		Data:        data,
+		Curve:       msg.Curve,
+	}
+
+	for echoer, content := range state.echoes {
+		if !bytes.Equal(hash, content.Hash) {
+			e.equivocation(msg.From, echoer, content)
+			return echoMsg, nil
+		}
+	}
+	return echoMsg, e.deliverable(state)
+}
+
+// deliverable returns the broadcast message of state, if all other parties echoed it and it was not yet delivered.
+func (e *EchoBroadcastHandler) deliverable(state *echoState) *Message {
+	if state.msg == nil || state.delivered {
+		return nil
+	}
+	for _, id := range e.partyIDs {
+		if id == e.selfID || id == state.msg.From {
+			continue
+		}
+		if _, ok := state.echoes[id]; !ok {
+			return nil
+		}
+	}
+	state.delivered = true
+	return state.msg
+}
+
+// equivocation aborts after echoer reported a different message from sender than the one we received.
+func (e *EchoBroadcastHandler) equivocation(sender, echoer party.ID, content *echo) {
+	if e.auth == nil {
+		// either the sender or the echoer is lying, and we cannot tell which one.
+		e.abort()
+		return
+	}
+	if e.auth.verifyDigest(sender, content.Hash, content.Signature) != nil {
+		e.abort(echoer)
+		return
+	}
+	e.abort(sender)
+}
+
+// conflict aborts after receiving two different broadcast messages directly from the same sender.
+//
+// Only two validly signed messages prove that the sender equivocated.
+// Without an Authenticator, anyone may have sent either of them, so no one is blamed,
+// and a message with an invalid signature is a forgery which is ignored.
+func (e *EchoBroadcastHandler) conflict(first, second *Message) {
+	if e.auth == nil {
+		e.abort()
+		return
+	}
+	if e.auth.Verify(first) != nil || e.auth.Verify(second) != nil {
+		return
+	}
+	e.abort(second.From)
+}
+
+// abort ends the execution with ErrEquivocation, blaming the given culprits, if any.
+func (e *EchoBroadcastHandler) abort(culprits ...party.ID) {
+	e.err = &Error{
+		Culprits: culprits,
+		Err:      ErrEquivocation,
+	}
+	// Stop closes the Listen channel of the Handler, which closes ours.
+	go e.Handler.Stop()
+}
+
+func (e *EchoBroadcastHandler) state(number round.Number, from party.ID) *echoState {
+	if e.states[number] == nil {
+		e.states[number] = map[party.ID]*echoState{}
+	}
+	state, ok := e.states[number][from]
+	if !ok {
+		state = &echoState{echoes: map[party.ID]*echo{}}
+		e.states[number][from] = state
+	}
+	return state
+}
+
+// echoProtocol returns the protocol ID of the broadcast message echoed by msg, if it is an echo.
+func echoProtocol(msg *Message) (string, bool) {
+	if msg.Broadcast || !strings.HasSuffix(msg.Protocol, echoSuffix) {
+		return "", false
+	}
+	return strings.TrimSuffix(msg.Protocol, echoSuffix), true
+}
@@ -0,0 +1,235 @@
+package protocol_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/taurusgroup/multi-party-sig/internal/test"
+	"github.com/taurusgroup/multi-party-sig/pkg/math/curve"
+	"github.com/taurusgroup/multi-party-sig/pkg/party"
+	"github.com/taurusgroup/multi-party-sig/pkg/protocol"
+	"github.com/taurusgroup/multi-party-sig/protocols/frost"
+)
+
+func newEchoHandlers(t *testing.T, partyIDs party.IDSlice) (map[party.ID]protocol.Handler, map[party.ID]*protocol.Authenticator) {
+	publics := make(map[party.ID]protocol.IdentityPublicKey, len(partyIDs))
+	secrets := make(map[party.ID]protocol.IdentitySecretKey, len(partyIDs))
+	for _, id := range partyIDs {
+		public, secret, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+		publics[id], secrets[id] = protocol.Ed25519PublicKey(public), protocol.Ed25519SecretKey(secret)
+	}
+	handlers := make(map[party.ID]protocol.Handler, len(partyIDs))
+	auths := make(map[party.ID]*protocol.Authenticator, len(partyIDs))
+	for _, id := range partyIDs {
+		h, err := protocol.NewMultiHandler(frost.Keygen(curve.Secp256k1{}, id, partyIDs, len(partyIDs)-1), nil)
+		require.NoError(t, err)
+		auths[id] = protocol.NewAuthenticator(id, secrets[id], publics)
+		echo := protocol.NewEchoBroadcastHandler(h, id, partyIDs, auths[id])
+		handlers[id] = protocol.NewAuthenticatedHandler(echo, auths[id])
+	}
+	return handlers, auths
+}
+
+func TestEchoBroadcastHandler(t *testing.T) {
+	partyIDs := test.PartyIDs(4)
+	handlers, _ := newEchoHandlers(t, partyIDs)
+
+	require.Eventually(t, func() bool {
+		test.Step(handlers)
+		for _, h := range handlers {
+			if _, err := h.Result(); err != nil {
+				return false
+			}
+		}
+		return true
+	}, 5*time.Second, time.Millisecond)
+
+	var public curve.Point
+	for _, h := range handlers {
+		r, err := h.Result()
+		require.NoError(t, err)
+		require.IsType(t, &frost.Config{}, r)
+		if public == nil {
+			public = r.(*frost.Config).PublicKey
+		}
+		assert.True(t, public.Equal(r.(*frost.Config).PublicKey))
+	}
+}
+
+func TestEchoBroadcastEquivocation(t *testing.T) {
+	partyIDs := test.PartyIDs(3)
+	handlers, auths := newEchoHandlers(t, partyIDs)
+	cheater, victim := partyIDs[0], partyIDs[2]
+
+	// the cheater sends a different, correctly signed, broadcast message to the victim.
+	equivocated := false
+	deliver := func(msg *protocol.Message) {
+		for id, h := range handlers {
+			if !msg.IsFor(id) {
+				continue
+			}
+			if msg.From == cheater && msg.Broadcast && id == victim && !equivocated {
+				tampered := *msg
+				tampered.Data = append([]byte{}, msg.Data...)
+				tampered.Data[len(tampered.Data)-1] ^= 1
+				require.NoError(t, auths[cheater].Sign(&tampered))
+				equivocated = true
+				h.Accept(&tampered)
+				continue
+			}
+			h.Accept(msg)
+		}
+	}
+
+	require.Eventually(t, func() bool {
+		for _, h := range handlers {
+			for pending := true; pending; {
+				select {
+				case msg, ok := <-h.Listen():
+					if !ok {
+						pending = false
+						break
+					}
+					deliver(msg)
+				default:
+					pending = false
+				}
+			}
+		}
+		for id, h := range handlers {
+			if id == cheater {
+				continue
+			}
+			if _, err := h.Result(); err == nil || errors.Is(err, protocol.ErrEquivocation) {
+				continue
+			}
+			return false
+		}
+		return true
+	}, 5*time.Second, time.Millisecond)
+
+	require.True(t, equivocated)
+	for id, h := range handlers {
+		if id == cheater {
+			continue
+		}
+		_, err := h.Result()
+		require.Error(t, err)
+		assert.ErrorIs(t, err, protocol.ErrEquivocation)
+		var protocolErr protocol.Error
+		require.True(t, errors.As(err, &protocolErr))
+		assert.Equal(t, []party.ID{cheater}, protocolErr.Culprits)
+	}
+}
+
+func TestEchoBroadcastDishonestEcho(t *testing.T) {
+	partyIDs := test.PartyIDs(3)
+	sender, liar, victim := partyIDs[0], partyIDs[1], partyIDs[2]
+
+	// without an Authenticator, the victim cannot tell whether the sender or the echoer is lying.
+	handlers := make(map[party.ID]protocol.Handler, len(partyIDs))
+	for _, id := range partyIDs {
+		h, err := protocol.NewMultiHandler(frost.Keygen(curve.Secp256k1{}, id, partyIDs, len(partyIDs)-1), nil)
+		require.NoError(t, err)
+		handlers[id] = protocol.NewEchoBroadcastHandler(h, id, partyIDs, nil)
+	}
+
+	// the liar echoes a different hash of the sender's broadcast message to the victim.
+	lied := false
+	deliver := func(msg *protocol.Message) {
+		for id, h := range handlers {
+			if !msg.IsFor(id) {
+				continue
+			}
+			if msg.From == liar && id == victim && strings.HasSuffix(msg.Protocol, "/echo") && !lied {
+				var content struct {
+					Sender    party.ID
+					Hash      []byte
+					Signature []byte
+				}
+				require.NoError(t, cbor.Unmarshal(msg.Data, &content))
+				if content.Sender == sender {
+					content.Hash[0] ^= 1
+					tampered := *msg
+					var err error
+					tampered.Data, err = cbor.Marshal(&content)
+					require.NoError(t, err)
+					lied = true
+					h.Accept(&tampered)
+					continue
+				}
+			}
+			h.Accept(msg)
+		}
+	}
+
+	require.Eventually(t, func() bool {
+		for _, h := range handlers {
+			for pending := true; pending; {
+				select {
+				case msg, ok := <-h.Listen():
+					if !ok {
+						pending = false
+						break
+					}
+					deliver(msg)
+				default:
+					pending = false
+				}
+			}
+		}
+		_, err := handlers[victim].Result()
+		return errors.Is(err, protocol.ErrEquivocation)
+	}, 5*time.Second, time.Millisecond)
+
+	require.True(t, lied)
+	_, err := handlers[victim].Result()
+	var protocolErr protocol.Error
+	require.True(t, errors.As(err, &protocolErr))
+	assert.Empty(t, protocolErr.Culprits)
+}
+
+func TestEchoBroadcastSpoofedSender(t *testing.T) {
+	partyIDs := test.PartyIDs(3)
+	sender, victim := partyIDs[0], partyIDs[2]
+
+	handlers := make(map[party.ID]protocol.Handler, len(partyIDs))
+	for _, id := range partyIDs {
+		h, err := protocol.NewMultiHandler(frost.Keygen(curve.Secp256k1{}, id, partyIDs, len(partyIDs)-1), nil)
+		require.NoError(t, err)
+		handlers[id] = protocol.NewEchoBroadcastHandler(h, id, partyIDs, nil)
+	}
+
+	var broadcast *protocol.Message
+	for msg := range handlers[sender].Listen() {
+		if msg.Broadcast {
+			broadcast = msg
+			break
+		}
+	}
+	require.NotNil(t, broadcast)
+
+	// anyone can claim to be the sender on an unauthenticated transport.
+	spoofed := *broadcast
+	spoofed.Data = append([]byte{}, broadcast.Data...)
+	spoofed.Data[len(spoofed.Data)-1] ^= 1
+	handlers[victim].Accept(broadcast)
+	handlers[victim].Accept(&spoofed)
+
+	require.Eventually(t, func() bool {
+		_, err := handlers[victim].Result()
+		return errors.Is(err, protocol.ErrEquivocation)
+	}, 5*time.Second, time.Millisecond)
+	_, err := handlers[victim].Result()
+	var protocolErr protocol.Error
+	require.True(t, errors.As(err, &protocolErr))
+	assert.Empty(t, protocolErr.Culprits)
+}
@@ -2,9 +2,11 @@ package protocol
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/fxamacker/cbor/v2"
 	"github.com/taurusgroup/multi-party-sig/internal/round"
@@ -45,24 +47,70 @@ type MultiHandler struct {
 	broadcastHashes map[round.Number][]byte
 	out             chan *Message
 	mtx             sync.Mutex
+
+	// sessionID is the argument given to the StartFunc, and sent contains the messages sent when entering
+	// the current round. Along with snapshotKey, they are used to create snapshots.
+	// generation is the number of times the execution was restored from a snapshot.
+	sessionID   []byte
+	sent        []*Message
+	snapshotKey []byte
+	generation  uint32
+
+	// recorder records the transcript of the execution, once Record was called.
+	recorder
+
+	// timeouts, timer and done are only set when the handler was created with a context.
+	timeouts Timeouts
+	timer    *time.Timer
+	done     chan struct{}
 }
 
 // NewMultiHandler expects a StartFunc for the desired protocol. It returns a handler that the user can interact with.
 func NewMultiHandler(create StartFunc, sessionID []byte) (*MultiHandler, error) {
+	h, err := newMultiHandler(create, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	h.finalize()
+	return h, nil
+}
+
+// NewMultiHandlerWithContext is like NewMultiHandler, but the execution is bound to ctx and to the given timeouts.
+//
+// If ctx is cancelled, or if the messages for a round are not all received before that round's deadline,
+// the protocol aborts with an Error whose Culprits are the parties that did not deliver their messages
+// for the current round.
+func NewMultiHandlerWithContext(ctx context.Context, create StartFunc, sessionID []byte, timeouts Timeouts) (*MultiHandler, error) {
+	h, err := newMultiHandler(create, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	h.timeouts = timeouts
+	h.done = make(chan struct{})
+
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	h.finalize()
+	if h.err == nil && h.result == nil {
+		go h.watch(ctx)
+	}
+	return h, nil
+}
+
+func newMultiHandler(create StartFunc, sessionID []byte) (*MultiHandler, error) {
 	r, err := create(sessionID)
 	if err != nil {
 		return nil, fmt.Errorf("protocol: failed to create round: %w", err)
 	}
-	h := &MultiHandler{
+	return &MultiHandler{
 		currentRound:    r,
 		rounds:          map[round.Number]round.Session{r.Number(): r},
 		messages:        newQueue(r.OtherPartyIDs(), r.FinalRoundNumber()),
 		broadcast:       newQueue(r.OtherPartyIDs(), r.FinalRoundNumber()),
 		broadcastHashes: map[round.Number][]byte{},
 		out:             make(chan *Message, 2*r.N()),
-	}
-	h.finalize()
-	return h, nil
+		sessionID:       sessionID,
+	}, nil
 }
 
 // Result returns the protocol result if the protocol completed successfully. Otherwise an error is returned.
@@ -79,7 +127,8 @@ func (h *MultiHandler) Result() (interface{}, error) {
 }
 
 // Listen returns a channel with outgoing messages that must be sent to other parties.
-// The message received should be _reliably_ broadcast if msg.Broadcast is true.
+// The message received should be _reliably_ broadcast if msg.Broadcast is true,
+// which can be achieved over point-to-point channels by wrapping the handler in an EchoBroadcastHandler.
 // The channel is closed when either an error occurs or the protocol detects an error.
 func (h *MultiHandler) Listen() <-chan *Message {
 	h.mtx.Lock()
@@ -115,6 +164,11 @@ func (h *MultiHandler) CanAccept(msg *Message) bool {
 		return false
 	}
 
+	// is the message for the same curve
+	if msg.Curve != curveName(r) {
+		return false
+	}
+
 	// check if message for unexpected round
 	if msg.RoundNumber > r.FinalRoundNumber() {
 		return false
@@ -139,6 +193,7 @@ func (h *MultiHandler) Accept(msg *Message) {
 	if !h.CanAccept(msg) || h.err != nil || h.result != nil || h.duplicate(msg) {
 		return
 	}
+	h.recordMessage(msg, false)
 
 	// a msg with roundNumber 0 is considered an abort from another party
 	if msg.RoundNumber == 0 {
@@ -252,6 +307,7 @@ func (h *MultiHandler) finalize() {
 	}
 
 	// forward messages with the correct header.
+	sent := make([]*Message, 0, len(out))
 	for roundMsg := range out {
 		data, err := cbor.Marshal(roundMsg.Content)
 		if err != nil {
@@ -266,10 +322,13 @@ func (h *MultiHandler) finalize() {
 			Data:                  data,
 			Broadcast:             roundMsg.Broadcast,
 			BroadcastVerification: h.broadcastHashes[r.Number()-1],
+			Curve:                 curveName(r),
 		}
 		if msg.Broadcast {
 			h.store(msg)
 		}
+		sent = append(sent, msg)
+		h.recordMessage(msg, true)
 		h.out <- msg
 	}
 
@@ -280,6 +339,12 @@ func (h *MultiHandler) finalize() {
 	}
 	h.rounds[roundNumber] = r
 	h.currentRound = r
+	h.sent = sent
+	h.resetTimer()
+	if err = h.recordRound(r); err != nil {
+		h.abort(err, r.SelfID())
+		return
+	}
 
 	// either we get the current round, the next one, or one of the two final ones
 	switch R := r.(type) {
@@ -331,23 +396,91 @@ func (h *MultiHandler) abort(err error, culprits ...party.ID) {
 			Culprits: culprits,
 			Err:      err,
 		}
-		select {
-		case h.out <- &Message{
+		msg := &Message{
 			SSID:     h.currentRound.SSID(),
 			From:     h.currentRound.SelfID(),
 			Protocol: h.currentRound.ProtocolID(),
 			Data:     []byte(h.err.Error()),
-		}:
+			Curve:    curveName(h.currentRound),
+		}
+		h.recordMessage(msg, true)
+		select {
+		case h.out <- msg:
 		default:
 		}
 
 	}
 	close(h.out)
+	if h.done != nil {
+		close(h.done)
+		if h.timer != nil {
+			h.timer.Stop()
+		}
+	}
+}
+
+// watch aborts the protocol if ctx is cancelled before the execution finishes.
+func (h *MultiHandler) watch(ctx context.Context) {
+	select {
+	case <-ctx.Done():
+		h.mtx.Lock()
+		defer h.mtx.Unlock()
+		if h.err != nil || h.result != nil {
+			return
+		}
+		h.abort(fmt.Errorf("round %d: %w", h.currentRound.Number(), ctx.Err()), h.missing()...)
+	case <-h.done:
+	}
+}
+
+// resetTimer starts the deadline for the current round, replacing the one of the previous round.
+func (h *MultiHandler) resetTimer() {
+	if h.done == nil {
+		return
+	}
+	if h.timer != nil {
+		h.timer.Stop()
+	}
+	number := h.currentRound.Number()
+	d := h.timeouts.For(number)
+	if d <= 0 {
+		h.timer = nil
+		return
+	}
+	h.timer = time.AfterFunc(d, func() {
+		h.mtx.Lock()
+		defer h.mtx.Unlock()
+		// the round may have been finalized while we were waiting for the lock
+		if h.err != nil || h.result != nil || h.currentRound.Number() != number {
+			return
+		}
+		h.abort(fmt.Errorf("round %d: %w", number, ErrRoundTimeout), h.missing()...)
+	})
+}
+
+// missing returns the parties whose messages for the current round have not yet been received.
+func (h *MultiHandler) missing() []party.ID {
+	r := h.currentRound
+	number := r.Number()
+	_, isBroadcast := r.(round.BroadcastRound)
+	culprits := make([]party.ID, 0, len(r.OtherPartyIDs()))
+	for _, id := range r.OtherPartyIDs() {
+		if isBroadcast && h.broadcast[number] != nil && h.broadcast[number][id] == nil {
+			culprits = append(culprits, id)
+			continue
+		}
+		if expectsNormalMessage(r) && h.messages[number] != nil && h.messages[number][id] == nil {
+			culprits = append(culprits, id)
+		}
+	}
+	return culprits
 }
 
 // Stop cancels the current execution of the protocol, and alerts the other users.
 func (h *MultiHandler) Stop() {
-	if h.err != nil || h.result != nil {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	if h.err == nil && h.result == nil {
 		h.abort(errors.New("aborted by user"), h.currentRound.SelfID())
 	}
 }
@@ -480,6 +613,14 @@ func (h *MultiHandler) checkBroadcastHash() bool {
 	return true
 }
 
+// curveName returns the name of the curve used by r, or "" if it does not use one.
+func curveName(r round.Session) string {
+	if r.Group() == nil {
+		return ""
+	}
+	return r.Group().Name()
+}
+
 func newQueue(senders []party.ID, rounds round.Number) map[round.Number]map[party.ID]*Message {
 	n := len(senders)
 	q := make(map[round.Number]map[party.ID]*Message, rounds)
@@ -0,0 +1,199 @@
+package protocol_test
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/taurusgroup/multi-party-sig/internal/test"
+	"github.com/taurusgroup/multi-party-sig/pkg/math/curve"
+	"github.com/taurusgroup/multi-party-sig/pkg/party"
+	"github.com/taurusgroup/multi-party-sig/pkg/protocol"
+	"github.com/taurusgroup/multi-party-sig/pkg/taproot"
+	"github.com/taurusgroup/multi-party-sig/protocols/example"
+	"github.com/taurusgroup/multi-party-sig/protocols/frost"
+)
+
+func TestMultiHandlerTimeout(t *testing.T) {
+	partyIDs := test.PartyIDs(3)
+	online := partyIDs[:2]
+	offline := partyIDs[2]
+
+	handlers := make(map[party.ID]*protocol.MultiHandler, len(online))
+	for _, id := range online {
+		h, err := protocol.NewMultiHandlerWithContext(context.Background(), example.StartXOR(id, partyIDs), nil, protocol.Timeouts{
+			Default: 50 * time.Millisecond,
+		})
+		require.NoError(t, err)
+		handlers[id] = h
+	}
+
+	// deliver the first message of each online party, the offline party never sends anything.
+	for _, h := range handlers {
+		msg := <-h.Listen()
+		for _, other := range handlers {
+			other.Accept(msg)
+		}
+	}
+
+	for _, h := range handlers {
+		for range h.Listen() {
+		}
+		_, err := h.Result()
+		require.Error(t, err)
+		var protocolErr protocol.Error
+		require.True(t, errors.As(err, &protocolErr))
+		assert.Equal(t, []party.ID{offline}, protocolErr.Culprits)
+		assert.ErrorIs(t, err, protocol.ErrRoundTimeout)
+	}
+}
+
+func TestMultiHandlerContextCancel(t *testing.T) {
+	partyIDs := test.PartyIDs(2)
+	ctx, cancel := context.WithCancel(context.Background())
+	h, err := protocol.NewMultiHandlerWithContext(ctx, example.StartXOR(partyIDs[0], partyIDs), nil, protocol.Timeouts{})
+	require.NoError(t, err)
+	<-h.Listen()
+
+	cancel()
+	for range h.Listen() {
+	}
+	_, err = h.Result()
+	require.Error(t, err)
+	assert.ErrorIs(t, err, context.Canceled)
+	var protocolErr protocol.Error
+	require.True(t, errors.As(err, &protocolErr))
+	assert.Equal(t, []party.ID{partyIDs[1]}, protocolErr.Culprits)
+}
+
+func TestMultiHandlerStop(t *testing.T) {
+	partyIDs := test.PartyIDs(2)
+	h, err := protocol.NewMultiHandler(example.StartXOR(partyIDs[0], partyIDs), nil)
+	require.NoError(t, err)
+	<-h.Listen()
+
+	h.Stop()
+	for range h.Listen() {
+	}
+	_, err = h.Result()
+	require.Error(t, err)
+	var protocolErr protocol.Error
+	require.True(t, errors.As(err, &protocolErr))
+	assert.Equal(t, []party.ID{partyIDs[0]}, protocolErr.Culprits)
+}
+
+func TestAuthenticatedHandler(t *testing.T) {
+	partyIDs := test.PartyIDs(3)
+
+	secrets := make(map[party.ID]protocol.IdentitySecretKey, len(partyIDs))
+	publics := make(map[party.ID]protocol.IdentityPublicKey, len(partyIDs))
+	for i, id := range partyIDs {
+		// mix both kinds of identity keys
+		if i%2 == 0 {
+			public, secret, err := ed25519.GenerateKey(rand.Reader)
+			require.NoError(t, err)
+			secrets[id], publics[id] = protocol.Ed25519SecretKey(secret), protocol.Ed25519PublicKey(public)
+		} else {
+			secret, public, err := taproot.GenKey(rand.Reader)
+			require.NoError(t, err)
+			secrets[id], publics[id] = protocol.SchnorrSecretKey(secret), protocol.SchnorrPublicKey(public)
+		}
+	}
+
+	handlers := make(map[party.ID]protocol.Handler, len(partyIDs))
+	for _, id := range partyIDs {
+		h, err := protocol.NewMultiHandler(example.StartXOR(id, partyIDs), nil)
+		require.NoError(t, err)
+		handlers[id] = protocol.NewAuthenticatedHandler(h, protocol.NewAuthenticator(id, secrets[id], publics))
+	}
+
+	// intercept the first message of a, and check that a tampered copy is rejected
+	a, b := partyIDs[0], partyIDs[1]
+	msg := <-handlers[a].Listen()
+	require.NoError(t, handlers[b].(*protocol.AuthenticatedHandler).Verify(msg))
+	forged := *msg
+	forged.Data = append([]byte{}, msg.Data...)
+	forged.Data[0] ^= 1
+	err := handlers[b].(*protocol.AuthenticatedHandler).Verify(&forged)
+	var forgedErr protocol.ForgedMessageError
+	require.True(t, errors.As(err, &forgedErr))
+	assert.Equal(t, a, forgedErr.From)
+	assert.False(t, handlers[b].CanAccept(&forged))
+	handlers[b].Accept(&forged)
+	select {
+	case reported := <-handlers[b].(*protocol.AuthenticatedHandler).Forgeries():
+		assert.Equal(t, a, reported.From)
+	default:
+		t.Error("forged message was not reported")
+	}
+
+	// a message claiming to be from b, but signed by a
+	impersonated := *msg
+	impersonated.From = b
+	assert.Error(t, handlers[partyIDs[2]].(*protocol.AuthenticatedHandler).Verify(&impersonated))
+
+	for id, h := range handlers {
+		if msg.IsFor(id) {
+			h.Accept(msg)
+		}
+	}
+	// the signed messages are forwarded asynchronously, so we step until all parties are done.
+	require.Eventually(t, func() bool {
+		test.Step(handlers)
+		for _, h := range handlers {
+			if _, err := h.Result(); err != nil {
+				return false
+			}
+		}
+		return true
+	}, time.Second, time.Millisecond)
+}
+
+// failingSecretKey is an IdentitySecretKey which cannot sign anything.
+type failingSecretKey struct{}
+
+func (failingSecretKey) Sign([]byte) ([]byte, error) { return nil, errors.New("signer unavailable") }
+
+func TestAuthenticatedHandlerSignFailure(t *testing.T) {
+	partyIDs := test.PartyIDs(2)
+	h, err := protocol.NewMultiHandler(example.StartXOR(partyIDs[0], partyIDs), nil)
+	require.NoError(t, err)
+	auth := protocol.NewAuthenticator(partyIDs[0], failingSecretKey{}, nil)
+	a := protocol.NewAuthenticatedHandler(h, auth)
+
+	// no unsigned message may be sent, and the execution must terminate.
+	for msg := range a.Listen() {
+		t.Errorf("unexpected message %v", msg)
+	}
+	_, err = a.Result()
+	require.Error(t, err)
+	var protocolErr protocol.Error
+	require.True(t, errors.As(err, &protocolErr))
+	assert.Equal(t, []party.ID{partyIDs[0]}, protocolErr.Culprits)
+}
+
+func TestMultiHandlerCurve(t *testing.T) {
+	partyIDs := test.PartyIDs(2)
+	handlers := make(map[party.ID]*protocol.MultiHandler, len(partyIDs))
+	for _, id := range partyIDs {
+		h, err := protocol.NewMultiHandler(frost.Keygen(curve.Secp256k1{}, id, partyIDs, 1), nil)
+		require.NoError(t, err)
+		handlers[id] = h
+	}
+
+	msg := <-handlers[partyIDs[0]].Listen()
+	receiver := handlers[partyIDs[1]]
+	require.Equal(t, curve.Secp256k1{}.Name(), msg.Curve)
+	assert.True(t, receiver.CanAccept(msg))
+
+	for _, name := range []string{"", curve.Ed25519{}.Name()} {
+		other := *msg
+		other.Curve = name
+		assert.False(t, receiver.CanAccept(&other), "curve %q", name)
+	}
+}
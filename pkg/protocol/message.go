@@ -1,7 +1,9 @@
 package protocol
 
 import (
+	"encoding/binary"
 	"encoding/json"
+	"errors"
 	"fmt"
 
 	"github.com/fxamacker/cbor/v2"
@@ -28,6 +30,10 @@ type Message struct {
 	// BroadcastVerification is the hash of all messages broadcast by the parties,
 	// and is included in all messages in the round following a broadcast round.
 	BroadcastVerification []byte
+	// Signature is the sender's signature of Hash(), set when the message was sent by an AuthenticatedHandler.
+	Signature []byte
+	// Curve is the name of the curve used by the protocol, and is empty if the protocol does not use one.
+	Curve string
 }
 
 // String implements fmt.Stringer.
@@ -45,12 +51,18 @@ func (m Message) IsFor(id party.ID) bool {
 
 // Hash returns a 64 byte hash of the message content, including the headers.
 // Can be used to produce a signature for the message.
+//
+// The major version of the envelope is included, so that a signature is never accepted
+// for a message decoded under a different wire format.
 func (m *Message) Hash() []byte {
 	var broadcast byte
 	if m.Broadcast {
 		broadcast = 1
 	}
+	version := make([]byte, 4)
+	binary.BigEndian.PutUint32(version, MessageVersionMajor)
 	h := hash.New(
+		hash.BytesWithDomain{TheDomain: "Version", Bytes: version},
 		hash.BytesWithDomain{TheDomain: "SSID", Bytes: m.SSID},
 		m.From,
 		m.To,
@@ -59,23 +71,70 @@ func (m *Message) Hash() []byte {
 		hash.BytesWithDomain{TheDomain: "Content", Bytes: m.Data},
 		hash.BytesWithDomain{TheDomain: "Broadcast", Bytes: []byte{broadcast}},
 		hash.BytesWithDomain{TheDomain: "BroadcastVerification", Bytes: m.BroadcastVerification},
+		hash.BytesWithDomain{TheDomain: "Curve", Bytes: []byte(m.Curve)},
 	)
 	return h.Sum()
 }
 
-// marshallableMessage is a copy of message for the purpose of cbor marshalling.
+// MessageVersionMajor and MessageVersionMinor are the version of the wire format produced by
+// Message.MarshalBinary and Message.MarshalJSON.
+//
+// Messages with a different major version are rejected.
+// A newer minor version may only add fields, which are ignored by older decoders.
+const (
+	MessageVersionMajor = 1
+	MessageVersionMinor = 0
+)
+
+// messageMagic identifies an encoded Message.
+const messageMagic = "MPSM"
+
+var (
+	// ErrInvalidMessageFormat is returned when decoding data which is not an encoded Message,
+	// for example because it was produced by a release without versioned messages.
+	ErrInvalidMessageFormat = errors.New("protocol: invalid message format")
+	// ErrUnsupportedMessageVersion is returned when decoding a Message whose major version is not MessageVersionMajor.
+	ErrUnsupportedMessageVersion = errors.New("protocol: unsupported message version")
+)
+
+// messageVersion is the version of the wire format of a Message.
+type messageVersion struct {
+	Major uint32 `json:"major"`
+	Minor uint32 `json:"minor"`
+}
+
+// messageEnvelope is the wire format of a Message.
+//
+// The header identifies the format, the protocol and the curve,
+// so that it can be checked before the body is decoded.
+// The json tags are also used by cbor as the keys of the encoded map.
+type messageEnvelope struct {
+	Magic    string          `json:"magic"`
+	Version  messageVersion  `json:"version"`
+	Protocol string          `json:"protocol"`
+	Curve    string          `json:"curve,omitempty"`
+	Body     cbor.RawMessage `json:"-" cbor:"body"`
+}
+
+// marshallableMessage is the body of a messageEnvelope.
 //
-// This is a workaround to use cbor's default marshalling for Message, all while providing
-// a MarshalBinary method
+// This is a copy of Message, so that cbor's default marshalling can be used
+// all while Message provides a MarshalBinary method.
 type marshallableMessage struct {
-	SSID                  []byte
-	From                  party.ID
-	To                    party.ID
-	Protocol              string
-	RoundNumber           round.Number
-	Data                  []byte
-	Broadcast             bool
-	BroadcastVerification []byte
+	SSID                  []byte       `json:"ssid"`
+	From                  party.ID     `json:"from"`
+	To                    party.ID     `json:"to,omitempty"`
+	RoundNumber           round.Number `json:"round"`
+	Data                  []byte       `json:"data"`
+	Broadcast             bool         `json:"broadcast,omitempty"`
+	BroadcastVerification []byte       `json:"broadcast_verification,omitempty"`
+	Signature             []byte       `json:"signature,omitempty"`
+}
+
+// jsonEnvelope is the JSON encoding of a messageEnvelope, where the body is a JSON object.
+type jsonEnvelope struct {
+	messageEnvelope
+	Message *marshallableMessage `json:"message"`
 }
 
 func (m *Message) toMarshallable() *marshallableMessage {
@@ -83,37 +142,119 @@ func (m *Message) toMarshallable() *marshallableMessage {
 		SSID:                  m.SSID,
 		From:                  m.From,
 		To:                    m.To,
-		Protocol:              m.Protocol,
 		RoundNumber:           m.RoundNumber,
 		Data:                  m.Data,
 		Broadcast:             m.Broadcast,
 		BroadcastVerification: m.BroadcastVerification,
+		Signature:             m.Signature,
+	}
+}
+
+func (m *Message) fromMarshallable(header *messageEnvelope, body *marshallableMessage) {
+	m.SSID = body.SSID
+	m.From = body.From
+	m.To = body.To
+	m.Protocol = header.Protocol
+	m.RoundNumber = body.RoundNumber
+	m.Data = body.Data
+	m.Broadcast = body.Broadcast
+	m.BroadcastVerification = body.BroadcastVerification
+	m.Signature = body.Signature
+	m.Curve = header.Curve
+}
+
+func (m *Message) header() messageEnvelope {
+	return messageEnvelope{
+		Magic: messageMagic,
+		Version: messageVersion{
+			Major: MessageVersionMajor,
+			Minor: MessageVersionMinor,
+		},
+		Protocol: m.Protocol,
+		Curve:    m.Curve,
 	}
 }
 
+// check returns an error if the envelope was not produced by a compatible version.
+func (e *messageEnvelope) check() error {
+	if e.Magic != messageMagic {
+		return ErrInvalidMessageFormat
+	}
+	if e.Version.Major != MessageVersionMajor {
+		return fmt.Errorf("%w: got %d.%d, expected %d.x", ErrUnsupportedMessageVersion,
+			e.Version.Major, e.Version.Minor, MessageVersionMajor)
+	}
+	return nil
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+// The message is encoded as CBOR, in a versioned envelope described by internal/grpc/message.cddl.
 func (m *Message) MarshalBinary() ([]byte, error) {
-	return cbor.Marshal(m.toMarshallable())
+	body, err := cbor.Marshal(m.toMarshallable())
+	if err != nil {
+		return nil, fmt.Errorf("protocol: failed to marshal message: %w", err)
+	}
+	envelope := m.header()
+	envelope.Body = body
+	return cbor.Marshal(&envelope)
 }
 
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+// It returns an error wrapping ErrInvalidMessageFormat or ErrUnsupportedMessageVersion
+// if data was not produced by a compatible version.
 func (m *Message) UnmarshalBinary(data []byte) error {
-	deserialized := m.toMarshallable()
-	if err := cbor.Unmarshal(data, deserialized); err != nil {
-		return nil
+	var envelope messageEnvelope
+	if err := cbor.Unmarshal(data, &envelope); err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidMessageFormat, err)
+	}
+	if err := envelope.check(); err != nil {
+		return err
+	}
+	var body marshallableMessage
+	if err := cbor.Unmarshal(envelope.Body, &body); err != nil {
+		return fmt.Errorf("protocol: failed to unmarshal message: %w", err)
 	}
-	m.SSID = deserialized.SSID
-	m.From = deserialized.From
-	m.To = deserialized.To
-	m.Protocol = deserialized.Protocol
-	m.RoundNumber = deserialized.RoundNumber
-	m.Data = deserialized.Data
-	m.Broadcast = deserialized.Broadcast
-	m.BroadcastVerification = deserialized.BroadcastVerification
+	m.fromMarshallable(&envelope, &body)
 	return nil
 }
 
+// MarshalJSON implements json.Marshaler.
+// The message is encoded in the same versioned envelope as MarshalBinary.
+func (m Message) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&jsonEnvelope{
+		messageEnvelope: m.header(),
+		Message:         m.toMarshallable(),
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+// It returns an error wrapping ErrInvalidMessageFormat or ErrUnsupportedMessageVersion
+// if data was not produced by a compatible version.
+func (m *Message) UnmarshalJSON(data []byte) error {
+	var envelope jsonEnvelope
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidMessageFormat, err)
+	}
+	if err := envelope.check(); err != nil {
+		return err
+	}
+	if envelope.Message == nil {
+		return fmt.Errorf("%w: missing message", ErrInvalidMessageFormat)
+	}
+	m.fromMarshallable(&envelope.messageEnvelope, envelope.Message)
+	return nil
+}
+
+// MarshalJson returns the JSON encoding of the message.
+//
+// Deprecated: use json.Marshal, which calls MarshalJSON.
 func (m *Message) MarshalJson() ([]byte, error) {
 	return json.Marshal(m)
 }
+
+// UnmarshalJson decodes the JSON encoding of a message.
+//
+// Deprecated: use json.Unmarshal, which calls UnmarshalJSON.
 func (m *Message) UnmarshalJson(data []byte) error {
 	return json.Unmarshal(data, m)
 }
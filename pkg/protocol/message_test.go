@@ -0,0 +1,96 @@
+package protocol_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/taurusgroup/multi-party-sig/pkg/protocol"
+)
+
+func testMessage() *protocol.Message {
+	return &protocol.Message{
+		SSID:                  []byte("ssid"),
+		From:                  "a",
+		To:                    "b",
+		Protocol:              "frost/keygen-threshold",
+		RoundNumber:           2,
+		Data:                  []byte{1, 2, 3},
+		Broadcast:             true,
+		BroadcastVerification: []byte{4, 5},
+		Signature:             []byte{6},
+		Curve:                 "secp256k1",
+	}
+}
+
+func TestMessageMarshalBinary(t *testing.T) {
+	msg := testMessage()
+	data, err := msg.MarshalBinary()
+	require.NoError(t, err)
+
+	var decoded protocol.Message
+	require.NoError(t, decoded.UnmarshalBinary(data))
+	assert.Equal(t, msg, &decoded)
+
+	// the header can be read without knowing the format of the body.
+	var header map[string]interface{}
+	require.NoError(t, cbor.Unmarshal(data, &header))
+	assert.Equal(t, "MPSM", header["magic"])
+	assert.Equal(t, msg.Protocol, header["protocol"])
+	assert.Equal(t, msg.Curve, header["curve"])
+}
+
+func TestMessageMarshalJSON(t *testing.T) {
+	msg := testMessage()
+	data, err := json.Marshal(msg)
+	require.NoError(t, err)
+
+	var decoded protocol.Message
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, msg, &decoded)
+
+	// messages nested by value use the same encoding.
+	nested, err := json.Marshal(struct{ Msg protocol.Message }{*msg})
+	require.NoError(t, err)
+	assert.Contains(t, string(nested), string(data))
+}
+
+func TestMessageVersion(t *testing.T) {
+	data, err := json.Marshal(testMessage())
+	require.NoError(t, err)
+	var envelope map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &envelope))
+
+	envelope["version"] = map[string]interface{}{"major": protocol.MessageVersionMajor, "minor": protocol.MessageVersionMinor + 1}
+	data, err = json.Marshal(envelope)
+	require.NoError(t, err)
+	var msg protocol.Message
+	assert.NoError(t, json.Unmarshal(data, &msg), "newer minor versions are accepted")
+
+	envelope["version"] = map[string]interface{}{"major": protocol.MessageVersionMajor + 1, "minor": 0}
+	data, err = json.Marshal(envelope)
+	require.NoError(t, err)
+	assert.ErrorIs(t, json.Unmarshal(data, &msg), protocol.ErrUnsupportedMessageVersion)
+
+	delete(envelope, "magic")
+	data, err = json.Marshal(envelope)
+	require.NoError(t, err)
+	assert.ErrorIs(t, json.Unmarshal(data, &msg), protocol.ErrInvalidMessageFormat)
+
+	// messages encoded before versioning was introduced are rejected.
+	legacy, err := cbor.Marshal(map[string]interface{}{"SSID": []byte("ssid"), "From": "a", "Data": []byte{1}})
+	require.NoError(t, err)
+	assert.ErrorIs(t, msg.UnmarshalBinary(legacy), protocol.ErrInvalidMessageFormat)
+}
+
+func TestMessageHash(t *testing.T) {
+	msg := testMessage()
+	other := testMessage()
+	assert.Equal(t, msg.Hash(), other.Hash())
+
+	// the signature covers every header, including the curve.
+	other.Curve = "ed25519"
+	assert.NotEqual(t, msg.Hash(), other.Hash())
+}
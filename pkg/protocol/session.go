@@ -0,0 +1,322 @@
+package protocol
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultPendingTTL is the PendingLimits.TTL used by NewSessionManager.
+const DefaultPendingTTL = time.Minute
+
+var (
+	// ErrPendingQueueFull is returned by SessionManager.Accept when a message for a session which has not started
+	// cannot be buffered, because too many such messages are already waiting.
+	ErrPendingQueueFull = errors.New("protocol: pending message queue is full")
+	// ErrSessionManagerClosed is returned when starting a session after SessionManager.Close was called.
+	ErrSessionManagerClosed = errors.New("protocol: session manager is closed")
+)
+
+// sessionKey identifies a protocol execution.
+type sessionKey struct {
+	protocol string
+	ssid     string
+}
+
+func (k sessionKey) String() string {
+	return fmt.Sprintf("%s (ssid %x)", k.protocol, k.ssid)
+}
+
+// PendingLimits bounds the messages a SessionManager buffers for sessions which have not started.
+type PendingLimits struct {
+	// Total is the maximum number of messages buffered over all sessions.
+	Total int
+	// PerSession is the maximum number of messages buffered for a single session.
+	// A zero value means Total.
+	PerSession int
+	// TTL is how long the messages of a session are buffered, counted from the first one.
+	// Finished sessions are remembered for as long, so that their late messages are dropped.
+	// A zero value means DefaultPendingTTL.
+	TTL time.Duration
+}
+
+// pendingSession contains the messages received for a session which has not been added yet.
+type pendingSession struct {
+	messages []*Message
+	expires  time.Time
+}
+
+// expiringKey is an entry of a queue of sessions, ordered by the time they expire.
+type expiringKey struct {
+	key     sessionKey
+	expires time.Time
+}
+
+// messageKey returns the key of the session a message belongs to.
+// Echoes sent by an EchoBroadcastHandler are routed to the session of the message they echo.
+func messageKey(msg *Message) sessionKey {
+	protocol := msg.Protocol
+	if p, ok := echoProtocol(msg); ok {
+		protocol = p
+	}
+	return sessionKey{protocol: protocol, ssid: string(msg.SSID)}
+}
+
+// SessionManager runs many protocol executions over a single transport.
+//
+// Incoming messages are given to Accept, which routes them to the Handler of the matching session,
+// identified by its protocol ID and SSID. Messages for sessions which have not yet been started locally
+// are buffered within the PendingLimits, and delivered once the session is added.
+// Buffered messages are discarded once they are older than PendingLimits.TTL, or when DropPending is called,
+// so that messages for sessions which never start cannot fill the buffer for good.
+// The outgoing messages of all sessions are sent on the single channel returned by Listen.
+//
+// Finished sessions are removed as soon as their Handler's Listen channel is closed.
+// Late messages for these sessions are dropped.
+type SessionManager struct {
+	mtx      sync.Mutex
+	sessions map[sessionKey]Handler
+	limits   PendingLimits
+	// pending contains the messages received for sessions which have not been added yet,
+	// and pendingOrder the same sessions, in the order they expire.
+	pending      map[sessionKey]*pendingSession
+	pendingOrder []expiringKey
+	pendingCount int
+	// finished contains the time until which a finished session is remembered,
+	// and finishedOrder the same sessions, in the order they are forgotten.
+	finished      map[sessionKey]time.Time
+	finishedOrder []expiringKey
+
+	out    chan *Message
+	wg     sync.WaitGroup
+	closed bool
+}
+
+// NewSessionManager returns a SessionManager which buffers at most maxPending messages
+// for sessions which have not started, for DefaultPendingTTL.
+func NewSessionManager(maxPending int) *SessionManager {
+	return NewSessionManagerWithLimits(PendingLimits{Total: maxPending})
+}
+
+// NewSessionManagerWithLimits returns a SessionManager which buffers the messages for sessions
+// which have not started within the given limits.
+func NewSessionManagerWithLimits(limits PendingLimits) *SessionManager {
+	if limits.PerSession <= 0 || limits.PerSession > limits.Total {
+		limits.PerSession = limits.Total
+	}
+	if limits.TTL <= 0 {
+		limits.TTL = DefaultPendingTTL
+	}
+	return &SessionManager{
+		sessions: map[sessionKey]Handler{},
+		limits:   limits,
+		pending:  map[sessionKey]*pendingSession{},
+		finished: map[sessionKey]time.Time{},
+		out:      make(chan *Message, limits.Total),
+	}
+}
+
+// Start creates a MultiHandler for the protocol, and adds it to the managed sessions.
+func (m *SessionManager) Start(create StartFunc, sessionID []byte) (*MultiHandler, error) {
+	h, err := NewMultiHandler(create, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	r := h.currentRound
+	if err = m.Add(r.ProtocolID(), r.SSID(), h); err != nil {
+		h.Stop()
+		return nil, err
+	}
+	return h, nil
+}
+
+// Add adds a Handler for the session with the given protocol ID and SSID.
+// This allows managing handlers which were wrapped, for example by an AuthenticatedHandler.
+//
+// The messages of h must only be read from the channel returned by SessionManager.Listen.
+func (m *SessionManager) Add(protocolID string, ssid []byte, h Handler) error {
+	key := sessionKey{protocol: protocolID, ssid: string(ssid)}
+
+	m.mtx.Lock()
+	if m.closed {
+		m.mtx.Unlock()
+		return ErrSessionManagerClosed
+	}
+	if _, ok := m.sessions[key]; ok {
+		m.mtx.Unlock()
+		return fmt.Errorf("protocol: session %s already exists", key)
+	}
+	if _, ok := m.finished[key]; ok {
+		m.mtx.Unlock()
+		return fmt.Errorf("protocol: session %s already finished", key)
+	}
+	m.sessions[key] = h
+	pending := m.removePending(key)
+	m.wg.Add(1)
+	m.mtx.Unlock()
+
+	go m.forward(key, h)
+	for _, msg := range pending {
+		h.Accept(msg)
+	}
+	return nil
+}
+
+// DropPending discards the messages buffered for the session with the given protocol ID and SSID,
+// which has not been added yet. It returns the number of discarded messages.
+func (m *SessionManager) DropPending(protocolID string, ssid []byte) int {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	return len(m.removePending(sessionKey{protocol: protocolID, ssid: string(ssid)}))
+}
+
+// removePending removes the messages buffered for a session and returns them.
+// The entry in pendingOrder is left in place, and skipped by expire.
+//
+// m.mtx must be held.
+func (m *SessionManager) removePending(key sessionKey) []*Message {
+	p, ok := m.pending[key]
+	if !ok {
+		return nil
+	}
+	delete(m.pending, key)
+	m.pendingCount -= len(p.messages)
+	return p.messages
+}
+
+// expire discards the buffered messages and the finished sessions which are older than the TTL.
+//
+// m.mtx must be held.
+func (m *SessionManager) expire(now time.Time) {
+	for len(m.pendingOrder) > 0 {
+		e := m.pendingOrder[0]
+		p, ok := m.pending[e.key]
+		// the entry is stale if the session was added or dropped, and possibly buffered again since.
+		if ok && p.expires.Equal(e.expires) {
+			if now.Before(e.expires) {
+				break
+			}
+			m.removePending(e.key)
+		}
+		m.pendingOrder = m.pendingOrder[1:]
+	}
+	for len(m.finishedOrder) > 0 {
+		e := m.finishedOrder[0]
+		if now.Before(e.expires) {
+			break
+		}
+		if m.finished[e.key].Equal(e.expires) {
+			delete(m.finished, e.key)
+		}
+		m.finishedOrder = m.finishedOrder[1:]
+	}
+}
+
+// forward sends the messages of a session on the shared channel, and removes the session once it is done.
+func (m *SessionManager) forward(key sessionKey, h Handler) {
+	defer m.wg.Done()
+	for msg := range h.Listen() {
+		m.out <- msg
+	}
+
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	delete(m.sessions, key)
+	now := time.Now()
+	m.expire(now)
+	expires := now.Add(m.limits.TTL)
+	m.finished[key] = expires
+	m.finishedOrder = append(m.finishedOrder, expiringKey{key: key, expires: expires})
+}
+
+// Accept routes msg to the Handler of its session.
+//
+// If the session has not been added yet, the message is buffered,
+// and ErrPendingQueueFull is returned if there is no more room for it, in total or for this session.
+// Messages for sessions which finished less than PendingLimits.TTL ago are dropped.
+func (m *SessionManager) Accept(msg *Message) error {
+	if msg == nil {
+		return errors.New("protocol: nil message")
+	}
+	key := messageKey(msg)
+
+	m.mtx.Lock()
+	if m.closed {
+		m.mtx.Unlock()
+		return ErrSessionManagerClosed
+	}
+	h, ok := m.sessions[key]
+	if !ok {
+		defer m.mtx.Unlock()
+		now := time.Now()
+		m.expire(now)
+		if _, ok = m.finished[key]; ok {
+			return nil
+		}
+		p := m.pending[key]
+		if m.pendingCount >= m.limits.Total || (p != nil && len(p.messages) >= m.limits.PerSession) {
+			return ErrPendingQueueFull
+		}
+		if p == nil {
+			p = &pendingSession{expires: now.Add(m.limits.TTL)}
+			m.pending[key] = p
+			m.pendingOrder = append(m.pendingOrder, expiringKey{key: key, expires: p.expires})
+		}
+		p.messages = append(p.messages, msg)
+		m.pendingCount++
+		return nil
+	}
+	m.mtx.Unlock()
+
+	h.Accept(msg)
+	return nil
+}
+
+// Handler returns the Handler of a running session.
+func (m *SessionManager) Handler(protocolID string, ssid []byte) (Handler, bool) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	h, ok := m.sessions[sessionKey{protocol: protocolID, ssid: string(ssid)}]
+	return h, ok
+}
+
+// Sessions returns the number of running sessions.
+func (m *SessionManager) Sessions() int {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	return len(m.sessions)
+}
+
+// Listen returns the channel of outgoing messages of all sessions.
+// It is closed once Close was called and all sessions have stopped.
+func (m *SessionManager) Listen() <-chan *Message {
+	return m.out
+}
+
+// Close stops all running sessions and discards the pending messages.
+// No sessions can be added after Close.
+func (m *SessionManager) Close() {
+	m.mtx.Lock()
+	if m.closed {
+		m.mtx.Unlock()
+		return
+	}
+	m.closed = true
+	sessions := make([]Handler, 0, len(m.sessions))
+	for _, h := range m.sessions {
+		sessions = append(sessions, h)
+	}
+	m.pending = map[sessionKey]*pendingSession{}
+	m.pendingOrder = nil
+	m.pendingCount = 0
+	m.mtx.Unlock()
+
+	for _, h := range sessions {
+		h.Stop()
+	}
+	go func() {
+		m.wg.Wait()
+		close(m.out)
+	}()
+}
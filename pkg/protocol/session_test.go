@@ -0,0 +1,154 @@
+package protocol_test
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/taurusgroup/multi-party-sig/internal/test"
+	"github.com/taurusgroup/multi-party-sig/pkg/party"
+	"github.com/taurusgroup/multi-party-sig/pkg/protocol"
+	"github.com/taurusgroup/multi-party-sig/protocols/example"
+)
+
+func TestSessionManager(t *testing.T) {
+	partyIDs := test.PartyIDs(3)
+	sessions := 5
+
+	managers := make(map[party.ID]*protocol.SessionManager, len(partyIDs))
+	for _, id := range partyIDs {
+		managers[id] = protocol.NewSessionManager(100)
+	}
+	var (
+		mtx  sync.Mutex
+		sent []*protocol.Message
+	)
+	for _, m := range managers {
+		go func(m *protocol.SessionManager) {
+			for msg := range m.Listen() {
+				mtx.Lock()
+				sent = append(sent, msg)
+				mtx.Unlock()
+				for id, other := range managers {
+					if msg.IsFor(id) {
+						assert.NoError(t, other.Accept(msg))
+					}
+				}
+			}
+		}(m)
+	}
+
+	// the last party starts its sessions after the others, so that their messages must be buffered.
+	late := partyIDs[len(partyIDs)-1]
+	handlers := make([]*protocol.MultiHandler, 0, sessions*len(partyIDs))
+	start := func(id party.ID) {
+		for i := 0; i < sessions; i++ {
+			h, err := managers[id].Start(example.StartXOR(id, partyIDs), []byte(fmt.Sprintf("session %d", i)))
+			require.NoError(t, err)
+			handlers = append(handlers, h)
+		}
+	}
+	for _, id := range partyIDs[:len(partyIDs)-1] {
+		start(id)
+	}
+	time.Sleep(10 * time.Millisecond)
+	start(late)
+
+	require.Eventually(t, func() bool {
+		for _, m := range managers {
+			if m.Sessions() != 0 {
+				return false
+			}
+		}
+		return true
+	}, time.Second, time.Millisecond)
+	for _, h := range handlers {
+		_, err := h.Result()
+		assert.NoError(t, err)
+	}
+
+	// messages for finished sessions are dropped instead of being buffered.
+	mtx.Lock()
+	for _, msg := range sent {
+		for id, m := range managers {
+			if msg.IsFor(id) {
+				require.NoError(t, m.Accept(msg))
+				assert.Zero(t, m.DropPending(msg.Protocol, msg.SSID))
+			}
+		}
+	}
+	mtx.Unlock()
+
+	for _, m := range managers {
+		m.Close()
+	}
+}
+
+func TestSessionManagerPendingLimit(t *testing.T) {
+	partyIDs := test.PartyIDs(2)
+	m := protocol.NewSessionManager(1)
+	defer m.Close()
+
+	msg := &protocol.Message{
+		SSID:        []byte("ssid"),
+		From:        partyIDs[1],
+		Protocol:    "example/xor",
+		RoundNumber: 2,
+		Data:        []byte{},
+	}
+	require.NoError(t, m.Accept(msg))
+	assert.ErrorIs(t, m.Accept(msg), protocol.ErrPendingQueueFull)
+}
+
+func pendingMessage(ssid string) *protocol.Message {
+	return &protocol.Message{
+		SSID:        []byte(ssid),
+		From:        "b",
+		Protocol:    "example/xor",
+		RoundNumber: 2,
+		Data:        []byte{},
+	}
+}
+
+func TestSessionManagerPendingPerSession(t *testing.T) {
+	m := protocol.NewSessionManagerWithLimits(protocol.PendingLimits{Total: 3, PerSession: 2})
+	defer m.Close()
+
+	require.NoError(t, m.Accept(pendingMessage("a")))
+	require.NoError(t, m.Accept(pendingMessage("a")))
+	assert.ErrorIs(t, m.Accept(pendingMessage("a")), protocol.ErrPendingQueueFull)
+	require.NoError(t, m.Accept(pendingMessage("b")))
+	assert.ErrorIs(t, m.Accept(pendingMessage("c")), protocol.ErrPendingQueueFull)
+}
+
+func TestSessionManagerPendingExpires(t *testing.T) {
+	ttl := 20 * time.Millisecond
+	m := protocol.NewSessionManagerWithLimits(protocol.PendingLimits{Total: 2, TTL: ttl})
+	defer m.Close()
+
+	// messages for sessions which are never started fill the queue.
+	require.NoError(t, m.Accept(pendingMessage("a")))
+	require.NoError(t, m.Accept(pendingMessage("b")))
+	assert.ErrorIs(t, m.Accept(pendingMessage("c")), protocol.ErrPendingQueueFull)
+
+	time.Sleep(2 * ttl)
+	require.NoError(t, m.Accept(pendingMessage("c")))
+	assert.Zero(t, m.DropPending("example/xor", []byte("a")))
+	assert.Equal(t, 1, m.DropPending("example/xor", []byte("c")))
+}
+
+func TestSessionManagerDropPending(t *testing.T) {
+	m := protocol.NewSessionManager(1)
+	defer m.Close()
+
+	require.NoError(t, m.Accept(pendingMessage("a")))
+	assert.ErrorIs(t, m.Accept(pendingMessage("b")), protocol.ErrPendingQueueFull)
+	assert.Equal(t, 1, m.DropPending("example/xor", []byte("a")))
+	require.NoError(t, m.Accept(pendingMessage("b")))
+	// the session can be buffered again after being dropped.
+	assert.Equal(t, 1, m.DropPending("example/xor", []byte("b")))
+	require.NoError(t, m.Accept(pendingMessage("a")))
+}
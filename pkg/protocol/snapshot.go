@@ -0,0 +1,368 @@
+package protocol
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/taurusgroup/multi-party-sig/internal/round"
+	"github.com/taurusgroup/multi-party-sig/pkg/hash"
+	"github.com/taurusgroup/multi-party-sig/pkg/party"
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+// snapshotVersion is incremented whenever the snapshot format changes in an incompatible way.
+const snapshotVersion = 1
+
+// SnapshotKeySize is the size in bytes of the key used to encrypt snapshots.
+const SnapshotKeySize = chacha20poly1305.KeySize
+
+// snapshotHeader is the unencrypted part of a snapshot.
+// It is authenticated as additional data when encrypting the state.
+type snapshotHeader struct {
+	Version     uint32
+	Protocol    string
+	SSID        []byte
+	RoundNumber round.Number
+	// Generation is the number of times the execution was restored before the snapshot was taken.
+	Generation uint32
+}
+
+// id returns the identifier under which a snapshot of self with this header is consumed.
+//
+// It is the same for all snapshots taken by the same handler, so that only one of them can be restored.
+// The restored handler has the next generation, and its own snapshots can be restored once in turn.
+func (header *snapshotHeader) id(self party.ID) []byte {
+	h := hash.New(&hash.BytesWithDomain{TheDomain: "Snapshot ID", Bytes: header.SSID})
+	_ = h.WriteAny(&hash.BytesWithDomain{TheDomain: "Protocol", Bytes: []byte(header.Protocol)}, self, generation(header.Generation))
+	return h.Sum()
+}
+
+// generation is the Generation of a snapshotHeader, as hashed in its id.
+type generation uint32
+
+// WriteTo implements io.WriterTo.
+func (g generation) WriteTo(w io.Writer) (int64, error) {
+	var buf [4]byte
+	binary.BigEndian.PutUint32(buf[:], uint32(g))
+	n, err := w.Write(buf[:])
+	return int64(n), err
+}
+
+// Domain implements hash.WriterToWithDomain.
+func (generation) Domain() string { return "Snapshot Generation" }
+
+type snapshotEnvelope struct {
+	Header     snapshotHeader
+	Nonce      []byte
+	Ciphertext []byte
+}
+
+// snapshotState is the encrypted part of a snapshot, which contains the secrets of the current round.
+type snapshotState struct {
+	SessionID []byte
+	// Round is the output of round.Snapshotter.MarshalState for the current round.
+	Round []byte
+	// Messages contains all messages stored in the queues, including our own broadcast messages.
+	Messages []*Message
+	// BroadcastHashes are the hashes of the broadcast messages of the previous rounds.
+	BroadcastHashes map[round.Number][]byte
+	// Sent are the messages we sent when entering the current round.
+	Sent []*Message
+}
+
+// SetSnapshotKey sets the key used by Snapshot to encrypt the state of the protocol.
+// The key must be SnapshotKeySize bytes long, and the same key must be given to RestoreMultiHandler.
+func (h *MultiHandler) SetSnapshotKey(key []byte) error {
+	if len(key) != SnapshotKeySize {
+		return fmt.Errorf("protocol: snapshot key must be %d bytes", SnapshotKeySize)
+	}
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	h.snapshotKey = append([]byte{}, key...)
+	return nil
+}
+
+// Snapshot serializes the current state of the protocol execution,
+// so that it can be resumed with RestoreMultiHandler after a restart.
+//
+// The state of the round contains secrets, and is encrypted with the key given to SetSnapshotKey.
+// Only the protocol ID, SSID, and current round number can be read without the key.
+//
+// The round state contains the nonces of the current round, so a handler must never be restored twice:
+// both copies would send different messages built from the same nonces, which leaks the secret key.
+// RestoreMultiHandler therefore records every restored handler in a SnapshotLog, and only one of the snapshots
+// taken by a handler can ever be restored. The restored handler can itself be snapshotted and restored once.
+//
+// Only protocols whose rounds implement round.Snapshotter are supported, which is the case for cmp and frost.
+// The two-party doerner protocols are supported by TwoPartyHandler.Snapshot.
+func (h *MultiHandler) Snapshot() ([]byte, error) {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+
+	if h.snapshotKey == nil {
+		return nil, errors.New("protocol: snapshot key not set")
+	}
+	if h.err != nil || h.result != nil {
+		return nil, errors.New("protocol: execution has finished")
+	}
+	r, ok := h.currentRound.(round.Snapshotter)
+	if !ok {
+		return nil, fmt.Errorf("protocol: %s does not support snapshots", h.currentRound.ProtocolID())
+	}
+	roundState, err := r.MarshalState()
+	if err != nil {
+		return nil, fmt.Errorf("protocol: failed to marshal round %d: %w", h.currentRound.Number(), err)
+	}
+
+	state := snapshotState{
+		SessionID:       h.sessionID,
+		Round:           roundState,
+		BroadcastHashes: h.broadcastHashes,
+		Sent:            h.sent,
+	}
+	for _, q := range []map[round.Number]map[party.ID]*Message{h.broadcast, h.messages} {
+		for _, msgs := range q {
+			for _, msg := range msgs {
+				if msg != nil {
+					state.Messages = append(state.Messages, msg)
+				}
+			}
+		}
+	}
+	return sealSnapshot(h.snapshotKey, h.currentRound, h.generation, &state)
+}
+
+// sealSnapshot encrypts state under key, and encodes it with the header describing r.
+func sealSnapshot(key []byte, r round.Session, generation uint32, state *snapshotState) ([]byte, error) {
+	plaintext, err := cbor.Marshal(state)
+	if err != nil {
+		return nil, fmt.Errorf("protocol: failed to marshal snapshot: %w", err)
+	}
+
+	header := snapshotHeader{
+		Version:     snapshotVersion,
+		Protocol:    r.ProtocolID(),
+		SSID:        r.SSID(),
+		RoundNumber: r.Number(),
+		Generation:  generation,
+	}
+	additionalData, err := cbor.Marshal(&header)
+	if err != nil {
+		return nil, fmt.Errorf("protocol: failed to marshal snapshot: %w", err)
+	}
+	nonce, ciphertext, err := seal(key, plaintext, additionalData)
+	if err != nil {
+		return nil, err
+	}
+	return cbor.Marshal(&snapshotEnvelope{
+		Header:     header,
+		Nonce:      nonce,
+		Ciphertext: ciphertext,
+	})
+}
+
+// seal encrypts plaintext with XChaCha20-Poly1305 under a random nonce.
+func seal(key, plaintext, additionalData []byte) (nonce, ciphertext []byte, err error) {
+	aead, err := chacha20poly1305.NewX(key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("protocol: %w", err)
+	}
+	nonce = make([]byte, aead.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return nil, nil, fmt.Errorf("protocol: failed to sample nonce: %w", err)
+	}
+	return nonce, aead.Seal(nil, nonce, plaintext, additionalData), nil
+}
+
+// open decrypts a ciphertext created by seal.
+func open(key, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	aead, err := chacha20poly1305.NewX(key)
+	if err != nil {
+		return nil, fmt.Errorf("protocol: %w", err)
+	}
+	if len(nonce) != aead.NonceSize() {
+		return nil, errors.New("protocol: invalid nonce")
+	}
+	plaintext, err := aead.Open(nil, nonce, ciphertext, additionalData)
+	if err != nil {
+		return nil, fmt.Errorf("protocol: failed to decrypt: %w", err)
+	}
+	return plaintext, nil
+}
+
+// RestoreMultiHandler resumes a protocol execution from the output of MultiHandler.Snapshot.
+//
+// create must be the StartFunc with which the execution was started, called with the same arguments.
+// The messages sent when entering the snapshot's round are sent again by the restored handler,
+// so that they reach parties which may have missed them. Duplicates are ignored by the recipients.
+//
+// The snapshot is recorded as consumed in log before the handler is returned, and ErrSnapshotConsumed
+// is returned if this or another snapshot of the same handler was already restored.
+func RestoreMultiHandler(data, key []byte, create StartFunc, log SnapshotLog) (*MultiHandler, error) {
+	r, header, state, err := openSnapshot(data, key, create, log)
+	if err != nil {
+		return nil, err
+	}
+
+	h := &MultiHandler{
+		currentRound:    r,
+		rounds:          map[round.Number]round.Session{r.Number(): r},
+		messages:        newQueue(r.OtherPartyIDs(), r.FinalRoundNumber()),
+		broadcast:       newQueue(r.OtherPartyIDs(), r.FinalRoundNumber()),
+		broadcastHashes: state.BroadcastHashes,
+		out:             make(chan *Message, 2*r.N()),
+		sessionID:       state.SessionID,
+		sent:            state.Sent,
+		snapshotKey:     append([]byte{}, key...),
+		generation:      header.Generation + 1,
+	}
+	if h.broadcastHashes == nil {
+		h.broadcastHashes = map[round.Number][]byte{}
+	}
+	for _, msg := range state.Messages {
+		if msg != nil {
+			h.store(msg)
+		}
+	}
+	for _, msg := range state.Sent {
+		h.out <- msg
+	}
+	return h, nil
+}
+
+// openSnapshot decrypts a snapshot created by sealSnapshot, restores its round from the first round returned by create,
+// and records it as consumed in log.
+func openSnapshot(data, key []byte, create StartFunc, log SnapshotLog) (round.Session, *snapshotHeader, *snapshotState, error) {
+	if log == nil {
+		return nil, nil, nil, errors.New("protocol: snapshot log is nil")
+	}
+	var envelope snapshotEnvelope
+	if err := cbor.Unmarshal(data, &envelope); err != nil {
+		return nil, nil, nil, fmt.Errorf("protocol: failed to unmarshal snapshot: %w", err)
+	}
+	header := envelope.Header
+	if header.Version != snapshotVersion {
+		return nil, nil, nil, fmt.Errorf("protocol: unsupported snapshot version %d", header.Version)
+	}
+	additionalData, err := cbor.Marshal(&header)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("protocol: failed to marshal snapshot: %w", err)
+	}
+	plaintext, err := open(key, envelope.Nonce, envelope.Ciphertext, additionalData)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	var state snapshotState
+	if err = cbor.Unmarshal(plaintext, &state); err != nil {
+		return nil, nil, nil, fmt.Errorf("protocol: failed to unmarshal snapshot: %w", err)
+	}
+
+	first, err := create(state.SessionID)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("protocol: failed to create round: %w", err)
+	}
+	if first.ProtocolID() != header.Protocol || !bytes.Equal(first.SSID(), header.SSID) {
+		return nil, nil, nil, errors.New("protocol: snapshot does not match the given StartFunc")
+	}
+	restorer, ok := first.(round.Restorer)
+	if !ok {
+		return nil, nil, nil, fmt.Errorf("protocol: %s does not support snapshots", header.Protocol)
+	}
+	r, err := restorer.RestoreState(header.RoundNumber, state.Round)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("protocol: failed to restore round %d: %w", header.RoundNumber, err)
+	}
+	if err = log.Consume(header.id(r.SelfID())); err != nil {
+		return nil, nil, nil, err
+	}
+	return r, &header, &state, nil
+}
+
+// SetSnapshotKey sets the key used by Snapshot to encrypt the state of the protocol.
+// The key must be SnapshotKeySize bytes long, and the same key must be given to RestoreTwoPartyHandler.
+func (h *TwoPartyHandler) SetSnapshotKey(key []byte) error {
+	if len(key) != SnapshotKeySize {
+		return fmt.Errorf("protocol: snapshot key must be %d bytes", SnapshotKeySize)
+	}
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	h.snapshotKey = append([]byte{}, key...)
+	return nil
+}
+
+// Snapshot serializes the current state of the protocol execution,
+// so that it can be resumed with RestoreTwoPartyHandler after a restart.
+//
+// It works like MultiHandler.Snapshot. Since a TwoPartyHandler processes the other party's message
+// as soon as it arrives, the snapshot always contains the state at the start of the current round.
+func (h *TwoPartyHandler) Snapshot() ([]byte, error) {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+
+	if h.snapshotKey == nil {
+		return nil, errors.New("protocol: snapshot key not set")
+	}
+	if h.err != nil || h.result != nil {
+		return nil, errors.New("protocol: execution has finished")
+	}
+	r, ok := h.round.(round.Snapshotter)
+	if !ok {
+		return nil, fmt.Errorf("protocol: %s does not support snapshots", h.round.ProtocolID())
+	}
+	roundState, err := r.MarshalState()
+	if err != nil {
+		return nil, fmt.Errorf("protocol: failed to marshal round %d: %w", h.round.Number(), err)
+	}
+
+	state := snapshotState{
+		SessionID: h.sessionID,
+		Round:     roundState,
+		Sent:      h.sent,
+	}
+	for number, msg := range h.messages {
+		if number >= h.round.Number() {
+			state.Messages = append(state.Messages, msg)
+		}
+	}
+	return sealSnapshot(h.snapshotKey, h.round, h.generation, &state)
+}
+
+// RestoreTwoPartyHandler resumes a protocol execution from the output of TwoPartyHandler.Snapshot.
+//
+// create must be the StartFunc with which the execution was started, called with the same arguments.
+// As with RestoreMultiHandler, the messages sent when entering the snapshot's round are sent again,
+// and the snapshot is recorded as consumed in log.
+func RestoreTwoPartyHandler(data, key []byte, create StartFunc, log SnapshotLog) (*TwoPartyHandler, error) {
+	r, header, state, err := openSnapshot(data, key, create, log)
+	if err != nil {
+		return nil, err
+	}
+
+	h := &TwoPartyHandler{
+		round:       r,
+		messages:    map[round.Number]*Message{},
+		out:         make(chan *Message, 2),
+		sessionID:   state.SessionID,
+		sent:        state.Sent,
+		snapshotKey: append([]byte{}, key...),
+		generation:  header.Generation + 1,
+	}
+	for _, msg := range state.Messages {
+		if msg != nil {
+			h.messages[msg.RoundNumber] = msg
+		}
+	}
+	for _, msg := range state.Sent {
+		h.out <- msg
+	}
+
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	h.advance()
+	return h, nil
+}
@@ -0,0 +1,85 @@
+package protocol
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// ErrSnapshotConsumed is returned when restoring a handler which was already restored from a snapshot.
+var ErrSnapshotConsumed = errors.New("protocol: snapshot already restored")
+
+// SnapshotLog records the handlers which were restored from a snapshot, so that none of them is restored twice.
+type SnapshotLog interface {
+	// Consume records id as restored, and returns ErrSnapshotConsumed if it already was.
+	// The record must be durable when Consume returns, so that id is never restored again after a crash.
+	Consume(id []byte) error
+}
+
+// memorySnapshotLog is a SnapshotLog which keeps the restored IDs in memory.
+type memorySnapshotLog struct {
+	mtx      sync.Mutex
+	consumed map[string]bool
+}
+
+// NewMemorySnapshotLog returns a SnapshotLog which keeps the restored IDs in memory.
+// It only protects against restoring a snapshot twice in the same process.
+func NewMemorySnapshotLog() SnapshotLog {
+	return &memorySnapshotLog{consumed: map[string]bool{}}
+}
+
+// Consume implements SnapshotLog.
+func (l *memorySnapshotLog) Consume(id []byte) error {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	if l.consumed[string(id)] {
+		return ErrSnapshotConsumed
+	}
+	l.consumed[string(id)] = true
+	return nil
+}
+
+// fileSnapshotLog is a SnapshotLog which creates an empty file in a directory for each restored ID.
+type fileSnapshotLog struct {
+	dir string
+}
+
+// NewFileSnapshotLog returns a SnapshotLog which records the restored IDs as files in dir, which is created if necessary.
+// The files must be kept for as long as the snapshots can be restored.
+func NewFileSnapshotLog(dir string) (SnapshotLog, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("snapshot log: %w", err)
+	}
+	return &fileSnapshotLog{dir: dir}, nil
+}
+
+// Consume implements SnapshotLog.
+func (l *fileSnapshotLog) Consume(id []byte) error {
+	// O_EXCL makes the check and the record atomic, even between processes sharing the directory
+	f, err := os.OpenFile(filepath.Join(l.dir, hex.EncodeToString(id)), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if errors.Is(err, os.ErrExist) {
+		return ErrSnapshotConsumed
+	}
+	if err != nil {
+		return fmt.Errorf("snapshot log: %w", err)
+	}
+	if err = f.Close(); err != nil {
+		return fmt.Errorf("snapshot log: %w", err)
+	}
+	// the new entry must be durable before the handler is restored
+	dir, err := os.Open(l.dir)
+	if err != nil {
+		return fmt.Errorf("snapshot log: %w", err)
+	}
+	err = dir.Sync()
+	if closeErr := dir.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("snapshot log: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,27 @@
+package protocol
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshotLog(t *testing.T) {
+	dir := t.TempDir()
+	fileLog, err := NewFileSnapshotLog(dir)
+	require.NoError(t, err)
+
+	for name, log := range map[string]SnapshotLog{"memory": NewMemorySnapshotLog(), "file": fileLog} {
+		t.Run(name, func(t *testing.T) {
+			require.NoError(t, log.Consume([]byte("a")))
+			require.NoError(t, log.Consume([]byte("b")))
+			assert.ErrorIs(t, log.Consume([]byte("a")), ErrSnapshotConsumed)
+		})
+	}
+
+	// the consumed IDs survive a restart
+	reopened, err := NewFileSnapshotLog(dir)
+	require.NoError(t, err)
+	assert.ErrorIs(t, reopened.Consume([]byte("b")), ErrSnapshotConsumed)
+}
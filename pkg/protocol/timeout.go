@@ -0,0 +1,29 @@
+package protocol
+
+import (
+	"errors"
+	"time"
+
+	"github.com/taurusgroup/multi-party-sig/internal/round"
+)
+
+// ErrRoundTimeout is returned (wrapped in an Error) when the messages for a round were not all received
+// before the round's deadline expired.
+var ErrRoundTimeout = errors.New("protocol: round timed out")
+
+// Timeouts configures how long a handler waits for the messages of each round.
+type Timeouts struct {
+	// Default is the time allotted to any round which does not appear in Rounds.
+	// A zero value disables the timeout for those rounds.
+	Default time.Duration
+	// Rounds optionally overrides Default for specific rounds.
+	Rounds map[round.Number]time.Duration
+}
+
+// For returns the duration allotted to the given round, or 0 if the round has no deadline.
+func (t Timeouts) For(number round.Number) time.Duration {
+	if d, ok := t.Rounds[number]; ok {
+		return d
+	}
+	return t.Default
+}
@@ -0,0 +1,298 @@
+package protocol
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/taurusgroup/multi-party-sig/internal/round"
+	"github.com/taurusgroup/multi-party-sig/pkg/party"
+)
+
+// transcriptVersion is incremented whenever the transcript format changes in an incompatible way.
+const transcriptVersion = 1
+
+// Transcript is a record of a protocol execution from the point of view of a single party.
+//
+// It contains every message the party sent and received, the public parameters of the execution,
+// and the encrypted state of each round the party entered.
+// It is created by MultiHandler.Record or TwoPartyHandler.Record, and can be checked offline with Replay,
+// or with the cmd/replay command for the cmp, frost and doerner protocols.
+type Transcript struct {
+	Version   uint32
+	Protocol  string
+	SSID      []byte
+	SessionID []byte
+	SelfID    party.ID
+	PartyIDs  []party.ID
+	// Parameters is the cbor encoding of the public parameters given to Record.
+	Parameters []byte
+	// Rounds contains the state of each round, in the order they were entered.
+	Rounds []TranscriptRound
+	// Messages contains the messages sent and received, in the order they were handled.
+	Messages []TranscriptMessage
+}
+
+// TranscriptRound is the state of a round at the moment it was entered, before any messages for it were stored.
+type TranscriptRound struct {
+	Number round.Number
+	// Nonce and State are the output of round.Snapshotter.MarshalState, encrypted like a snapshot.
+	Nonce []byte
+	State []byte
+}
+
+// TranscriptMessage is a message recorded in a Transcript.
+type TranscriptMessage struct {
+	// Sent is true if the message was sent by the party who recorded the transcript.
+	Sent    bool
+	Message *Message
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+func (t *Transcript) MarshalBinary() ([]byte, error) {
+	return cbor.Marshal((*marshallableTranscript)(t))
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+func (t *Transcript) UnmarshalBinary(data []byte) error {
+	if err := cbor.Unmarshal(data, (*marshallableTranscript)(t)); err != nil {
+		return fmt.Errorf("protocol: failed to unmarshal transcript: %w", err)
+	}
+	if t.Version != transcriptVersion {
+		return fmt.Errorf("protocol: unsupported transcript version %d", t.Version)
+	}
+	return nil
+}
+
+// marshallableTranscript avoids infinite recursion when marshalling a Transcript.
+type marshallableTranscript Transcript
+
+// UnmarshalParameters decodes the public parameters of the execution into v.
+func (t *Transcript) UnmarshalParameters(v interface{}) error {
+	if err := cbor.Unmarshal(t.Parameters, v); err != nil {
+		return fmt.Errorf("protocol: failed to unmarshal transcript parameters: %w", err)
+	}
+	return nil
+}
+
+// additionalData returns the data authenticated along with the state of round number.
+func (t *Transcript) additionalData(number round.Number) ([]byte, error) {
+	return cbor.Marshal(&snapshotHeader{
+		Version:     transcriptVersion,
+		Protocol:    t.Protocol,
+		SSID:        t.SSID,
+		RoundNumber: number,
+	})
+}
+
+// recorder records the Transcript of the execution of a handler.
+type recorder struct {
+	// transcript and transcriptKey are only set when the execution is being recorded.
+	transcript    *Transcript
+	transcriptKey []byte
+}
+
+// Record starts recording a Transcript of this execution, which can be obtained with MultiHandler.Transcript.
+//
+// parameters should contain the public arguments given to the StartFunc, which are needed to recreate it,
+// such as the party IDs, threshold, public key shares, or message to sign.
+// It must not contain secrets, since it is stored unencrypted.
+// The state of each round is encrypted with key, which must be SnapshotKeySize bytes long.
+//
+// Record must be called right after the handler was created, before any messages are accepted.
+// Only protocols whose rounds implement round.Snapshotter are supported.
+func (h *MultiHandler) Record(parameters interface{}, key []byte) error {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	return h.record(h.currentRound, h.sessionID, h.sent, parameters, key)
+}
+
+// Transcript returns a copy of the transcript recorded so far, or nil if MultiHandler.Record was not called.
+func (h *MultiHandler) Transcript() *Transcript {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	return h.copyTranscript()
+}
+
+// Record is like MultiHandler.Record, for a two party protocol.
+func (h *TwoPartyHandler) Record(parameters interface{}, key []byte) error {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	return h.record(h.round, h.sessionID, h.sent, parameters, key)
+}
+
+// Transcript returns a copy of the transcript recorded so far, or nil if TwoPartyHandler.Record was not called.
+func (h *TwoPartyHandler) Transcript() *Transcript {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	return h.copyTranscript()
+}
+
+// record starts recording the execution from r, the current round, which sent the messages in sent when it was entered.
+func (rec *recorder) record(r round.Session, sessionID []byte, sent []*Message, parameters interface{}, key []byte) error {
+	if len(key) != SnapshotKeySize {
+		return fmt.Errorf("protocol: transcript key must be %d bytes", SnapshotKeySize)
+	}
+	encodedParameters, err := cbor.Marshal(parameters)
+	if err != nil {
+		return fmt.Errorf("protocol: failed to marshal transcript parameters: %w", err)
+	}
+
+	if rec.transcript != nil {
+		return errors.New("protocol: transcript is already being recorded")
+	}
+	rec.transcript = &Transcript{
+		Version:    transcriptVersion,
+		Protocol:   r.ProtocolID(),
+		SSID:       r.SSID(),
+		SessionID:  sessionID,
+		SelfID:     r.SelfID(),
+		PartyIDs:   r.PartyIDs(),
+		Parameters: encodedParameters,
+	}
+	rec.transcriptKey = append([]byte{}, key...)
+	if err = rec.recordRound(r); err != nil {
+		rec.transcript = nil
+		return err
+	}
+	for _, msg := range sent {
+		rec.recordMessage(msg, true)
+	}
+	return nil
+}
+
+// copyTranscript returns a copy of the transcript recorded so far, or nil if none is being recorded.
+func (rec *recorder) copyTranscript() *Transcript {
+	if rec.transcript == nil {
+		return nil
+	}
+	t := *rec.transcript
+	t.Rounds = append([]TranscriptRound{}, rec.transcript.Rounds...)
+	t.Messages = append([]TranscriptMessage{}, rec.transcript.Messages...)
+	return &t
+}
+
+// recordRound adds the encrypted state of r to the transcript.
+func (rec *recorder) recordRound(r round.Session) error {
+	if rec.transcript == nil {
+		return nil
+	}
+	switch r.(type) {
+	case *round.Abort, *round.Output:
+		return nil
+	}
+	s, ok := r.(round.Snapshotter)
+	if !ok {
+		return fmt.Errorf("protocol: %s does not support transcripts", r.ProtocolID())
+	}
+	state, err := s.MarshalState()
+	if err != nil {
+		return fmt.Errorf("protocol: failed to marshal round %d: %w", r.Number(), err)
+	}
+	additionalData, err := rec.transcript.additionalData(r.Number())
+	if err != nil {
+		return fmt.Errorf("protocol: failed to marshal transcript: %w", err)
+	}
+	nonce, ciphertext, err := seal(rec.transcriptKey, state, additionalData)
+	if err != nil {
+		return err
+	}
+	rec.transcript.Rounds = append(rec.transcript.Rounds, TranscriptRound{
+		Number: r.Number(),
+		Nonce:  nonce,
+		State:  ciphertext,
+	})
+	return nil
+}
+
+// recordMessage adds msg to the transcript.
+func (rec *recorder) recordMessage(msg *Message, sent bool) {
+	if rec.transcript == nil {
+		return
+	}
+	rec.transcript.Messages = append(rec.transcript.Messages, TranscriptMessage{
+		Sent:    sent,
+		Message: msg,
+	})
+}
+
+// Replay re-runs the execution recorded in t, and returns the first error caused by a received message.
+//
+// create must be the StartFunc with which the execution was started, usually recreated from
+// Transcript.UnmarshalParameters, and key must be the key given to Record.
+//
+// Each recorded round is restored, and the messages received for it are verified and stored again,
+// broadcast messages first. If a message is rejected, an Error is returned whose culprit is the sender of
+// that message, and which wraps the error returned by the round.
+// If all messages are accepted, nil is returned.
+func Replay(t *Transcript, key []byte, create StartFunc) error {
+	if t.Version != transcriptVersion {
+		return fmt.Errorf("protocol: unsupported transcript version %d", t.Version)
+	}
+	for _, recorded := range t.Rounds {
+		r, err := t.restore(recorded, key, create)
+		if err != nil {
+			return err
+		}
+		for _, broadcast := range []bool{true, false} {
+			for _, m := range t.Messages {
+				msg := m.Message
+				if m.Sent || msg == nil || msg.RoundNumber != r.Number() || msg.Broadcast != broadcast ||
+					!msg.IsFor(t.SelfID) {
+					continue
+				}
+				if err = replayMessage(r, msg); err != nil {
+					return Error{
+						Culprits: []party.ID{msg.From},
+						Err:      fmt.Errorf("round %d: %w", r.Number(), err),
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// restore creates the round recorded in the transcript.
+func (t *Transcript) restore(recorded TranscriptRound, key []byte, create StartFunc) (round.Session, error) {
+	additionalData, err := t.additionalData(recorded.Number)
+	if err != nil {
+		return nil, fmt.Errorf("protocol: failed to marshal transcript: %w", err)
+	}
+	state, err := open(key, recorded.Nonce, recorded.State, additionalData)
+	if err != nil {
+		return nil, err
+	}
+	first, err := create(t.SessionID)
+	if err != nil {
+		return nil, fmt.Errorf("protocol: failed to create round: %w", err)
+	}
+	if first.ProtocolID() != t.Protocol || !bytes.Equal(first.SSID(), t.SSID) || first.SelfID() != t.SelfID {
+		return nil, errors.New("protocol: transcript does not match the given StartFunc")
+	}
+	restorer, ok := first.(round.Restorer)
+	if !ok {
+		return nil, fmt.Errorf("protocol: %s does not support transcripts", t.Protocol)
+	}
+	r, err := restorer.RestoreState(recorded.Number, state)
+	if err != nil {
+		return nil, fmt.Errorf("protocol: failed to restore round %d: %w", recorded.Number, err)
+	}
+	return r, nil
+}
+
+// replayMessage verifies and stores msg in r, in the same way as a MultiHandler.
+func replayMessage(r round.Session, msg *Message) error {
+	roundMsg, err := getRoundMessage(msg, r)
+	if err != nil {
+		return err
+	}
+	if msg.Broadcast {
+		return r.(round.BroadcastRound).StoreBroadcastMessage(roundMsg)
+	}
+	if err = r.VerifyMessage(roundMsg); err != nil {
+		return err
+	}
+	return r.StoreMessage(roundMsg)
+}
@@ -0,0 +1,109 @@
+package protocol_test
+
+import (
+	"crypto/rand"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/taurusgroup/multi-party-sig/internal/round"
+	"github.com/taurusgroup/multi-party-sig/internal/test"
+	"github.com/taurusgroup/multi-party-sig/pkg/math/curve"
+	"github.com/taurusgroup/multi-party-sig/pkg/party"
+	"github.com/taurusgroup/multi-party-sig/pkg/protocol"
+	"github.com/taurusgroup/multi-party-sig/protocols/frost"
+)
+
+type keygenParameters struct {
+	SelfID    party.ID
+	PartyIDs  []party.ID
+	Threshold int
+}
+
+func TestTranscriptReplay(t *testing.T) {
+	partyIDs := test.PartyIDs(3)
+	recorder, cheater := partyIDs[0], partyIDs[2]
+	key := make([]byte, protocol.SnapshotKeySize)
+	_, _ = rand.Read(key)
+
+	handlers := make(map[party.ID]*protocol.MultiHandler, len(partyIDs))
+	for _, id := range partyIDs {
+		h, err := protocol.NewMultiHandler(frost.Keygen(curve.Secp256k1{}, id, partyIDs, 1), nil)
+		require.NoError(t, err)
+		handlers[id] = h
+	}
+	require.NoError(t, handlers[recorder].Record(keygenParameters{
+		SelfID:    recorder,
+		PartyIDs:  partyIDs,
+		Threshold: 1,
+	}, key))
+
+	// the cheater sends an invalid share to the recorder in the last round.
+	tampered := false
+	require.Eventually(t, func() bool {
+		for _, h := range handlers {
+			for pending := true; pending; {
+				select {
+				case msg, ok := <-h.Listen():
+					if !ok {
+						pending = false
+						break
+					}
+					for id, other := range handlers {
+						if !msg.IsFor(id) {
+							continue
+						}
+						if msg.From == cheater && id == recorder && msg.RoundNumber == 3 && !msg.Broadcast {
+							bad := *msg
+							bad.Data = append([]byte{}, msg.Data...)
+							bad.Data[len(bad.Data)-1] ^= 1
+							msg, tampered = &bad, true
+						}
+						other.Accept(msg)
+					}
+				default:
+					pending = false
+				}
+			}
+		}
+		_, err := handlers[recorder].Result()
+		return err != nil && err.Error() != "protocol: not finished"
+	}, 5*time.Second, time.Millisecond)
+	require.True(t, tampered)
+
+	_, err := handlers[recorder].Result()
+	var protocolErr protocol.Error
+	require.True(t, errors.As(err, &protocolErr))
+	require.Equal(t, []party.ID{cheater}, protocolErr.Culprits)
+
+	data, err := handlers[recorder].Transcript().MarshalBinary()
+	require.NoError(t, err)
+	var transcript protocol.Transcript
+	require.NoError(t, transcript.UnmarshalBinary(data))
+
+	var parameters keygenParameters
+	require.NoError(t, transcript.UnmarshalParameters(&parameters))
+	create := frost.Keygen(curve.Secp256k1{}, parameters.SelfID, parameters.PartyIDs, parameters.Threshold)
+
+	err = protocol.Replay(&transcript, key, create)
+	require.Error(t, err)
+	require.True(t, errors.As(err, &protocolErr))
+	assert.Equal(t, []party.ID{cheater}, protocolErr.Culprits)
+	assert.Contains(t, err.Error(), "round 3")
+
+	// replaying without the tampered message succeeds.
+	honest := transcript
+	honest.Messages = nil
+	for _, m := range transcript.Messages {
+		if !m.Sent && m.Message.From == cheater && m.Message.RoundNumber == round.Number(3) {
+			continue
+		}
+		honest.Messages = append(honest.Messages, m)
+	}
+	assert.NoError(t, protocol.Replay(&honest, key, create))
+
+	wrongKey := make([]byte, protocol.SnapshotKeySize)
+	assert.Error(t, protocol.Replay(&transcript, wrongKey, create))
+}
@@ -2,9 +2,11 @@ package protocol
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/fxamacker/cbor/v2"
 	"github.com/taurusgroup/multi-party-sig/internal/round"
@@ -19,6 +21,22 @@ type TwoPartyHandler struct {
 	messages map[round.Number]*Message
 	out      chan *Message
 	mtx      sync.Mutex
+
+	// timeouts, timer and done are only set when the handler was created with a context.
+	timeouts Timeouts
+	timer    *time.Timer
+	done     chan struct{}
+
+	// sessionID is the argument given to the StartFunc, and sent contains the messages sent when entering
+	// the current round. Along with snapshotKey, they are used to create snapshots.
+	// generation is the number of times the execution was restored from a snapshot.
+	sessionID   []byte
+	sent        []*Message
+	snapshotKey []byte
+	generation  uint32
+
+	// recorder records the transcript of the execution, once Record was called.
+	recorder
 }
 
 func NewTwoPartyHandler(create StartFunc, sessionID []byte, leader bool) (*TwoPartyHandler, error) {
@@ -27,13 +45,14 @@ func NewTwoPartyHandler(create StartFunc, sessionID []byte, leader bool) (*TwoPa
 		return nil, fmt.Errorf("protocol: failed to create round: %w", err)
 	}
 	handler := &TwoPartyHandler{
-		round:    r,
-		leader:   leader,
-		err:      nil,
-		result:   nil,
-		messages: map[round.Number]*Message{},
-		out:      make(chan *Message, 2),
-		mtx:      sync.Mutex{},
+		round:     r,
+		leader:    leader,
+		err:       nil,
+		result:    nil,
+		messages:  map[round.Number]*Message{},
+		out:       make(chan *Message, 2),
+		mtx:       sync.Mutex{},
+		sessionID: sessionID,
 	}
 	if leader {
 		handler.advance()
@@ -41,6 +60,38 @@ func NewTwoPartyHandler(create StartFunc, sessionID []byte, leader bool) (*TwoPa
 	return handler, nil
 }
 
+// NewTwoPartyHandlerWithContext is like NewTwoPartyHandler, but the execution is bound to ctx and to the given timeouts.
+//
+// If ctx is cancelled, or if the other party's message for a round is not received before that round's deadline,
+// the protocol aborts with an Error naming the other party as culprit.
+func NewTwoPartyHandlerWithContext(ctx context.Context, create StartFunc, sessionID []byte, leader bool, timeouts Timeouts) (*TwoPartyHandler, error) {
+	r, err := create(sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("protocol: failed to create round: %w", err)
+	}
+	handler := &TwoPartyHandler{
+		round:     r,
+		leader:    leader,
+		messages:  map[round.Number]*Message{},
+		out:       make(chan *Message, 2),
+		timeouts:  timeouts,
+		done:      make(chan struct{}),
+		sessionID: sessionID,
+	}
+
+	handler.mtx.Lock()
+	defer handler.mtx.Unlock()
+	if leader {
+		handler.advance()
+	} else {
+		handler.resetTimer()
+	}
+	if handler.err == nil && handler.result == nil {
+		go handler.watch(ctx)
+	}
+	return handler, nil
+}
+
 func (h *TwoPartyHandler) Result() (interface{}, error) {
 	h.mtx.Lock()
 	defer h.mtx.Unlock()
@@ -60,7 +111,9 @@ func (h *TwoPartyHandler) Listen() <-chan *Message {
 }
 
 func (h *TwoPartyHandler) Stop() {
-	if h.err != nil || h.result != nil {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	if h.err == nil && h.result == nil {
 		h.abort(errors.New("aborted by user"))
 	}
 }
@@ -72,17 +125,74 @@ func (h *TwoPartyHandler) String() string {
 func (h *TwoPartyHandler) abort(err error) {
 	if err != nil {
 		h.err = err
-		select {
-		case h.out <- &Message{
+		msg := &Message{
 			SSID:     h.round.SSID(),
 			From:     h.round.SelfID(),
 			Protocol: h.round.ProtocolID(),
 			Data:     []byte(h.err.Error()),
-		}:
+			Curve:    curveName(h.round),
+		}
+		h.recordMessage(msg, true)
+		select {
+		case h.out <- msg:
 		default:
 		}
 	}
 	close(h.out)
+	if h.done != nil {
+		close(h.done)
+		if h.timer != nil {
+			h.timer.Stop()
+		}
+	}
+}
+
+// watch aborts the protocol if ctx is cancelled before the execution finishes.
+func (h *TwoPartyHandler) watch(ctx context.Context) {
+	select {
+	case <-ctx.Done():
+		h.mtx.Lock()
+		defer h.mtx.Unlock()
+		if h.err != nil || h.result != nil {
+			return
+		}
+		h.abort(h.blame(ctx.Err()))
+	case <-h.done:
+	}
+}
+
+// resetTimer starts the deadline for the current round, replacing the one of the previous round.
+func (h *TwoPartyHandler) resetTimer() {
+	if h.done == nil {
+		return
+	}
+	if h.timer != nil {
+		h.timer.Stop()
+	}
+	number := h.round.Number()
+	d := h.timeouts.For(number)
+	if d <= 0 {
+		h.timer = nil
+		return
+	}
+	h.timer = time.AfterFunc(d, func() {
+		h.mtx.Lock()
+		defer h.mtx.Unlock()
+		// the round may have advanced while we were waiting for the lock
+		if h.err != nil || h.result != nil || h.round.Number() != number {
+			return
+		}
+		h.abort(h.blame(ErrRoundTimeout))
+	})
+}
+
+// blame wraps err in an Error naming the other party as culprit.
+// Since advance processes messages as soon as they arrive, an unfinished execution is always waiting on the other party.
+func (h *TwoPartyHandler) blame(err error) Error {
+	return Error{
+		Culprits: h.round.OtherPartyIDs(),
+		Err:      fmt.Errorf("round %d: %w", h.round.Number(), err),
+	}
 }
 
 func (h *TwoPartyHandler) canAdvance() bool {
@@ -144,6 +254,7 @@ func (h *TwoPartyHandler) advance() {
 			return
 		}
 		close(out)
+		sent := make([]*Message, 0, len(out))
 		for roundMsg := range out {
 			data, err := cbor.Marshal(roundMsg.Content)
 			if err != nil {
@@ -158,10 +269,19 @@ func (h *TwoPartyHandler) advance() {
 				Data:                  data,
 				Broadcast:             roundMsg.Broadcast,
 				BroadcastVerification: nil,
+				Curve:                 curveName(newRound),
 			}
 			h.out <- msg
+			sent = append(sent, msg)
+			h.recordMessage(msg, true)
 		}
 		h.round = newRound
+		h.sent = sent
+		h.resetTimer()
+		if err = h.recordRound(newRound); err != nil {
+			h.abort(err)
+			return
+		}
 		switch R := newRound.(type) {
 		// An abort happened
 		case *round.Abort:
@@ -197,6 +317,9 @@ func (h *TwoPartyHandler) CanAccept(msg *Message) bool {
 	if msg.Data == nil {
 		return false
 	}
+	if msg.Curve != curveName(r) {
+		return false
+	}
 	if msg.RoundNumber > r.FinalRoundNumber() {
 		return false
 	}
@@ -210,6 +333,7 @@ func (h *TwoPartyHandler) Accept(msg *Message) {
 	if !h.CanAccept(msg) || h.err != nil || h.result != nil {
 		return
 	}
+	h.recordMessage(msg, false)
 
 	if msg.RoundNumber == 0 {
 		h.abort(fmt.Errorf("aborted by other party with error: \"%s\"", msg.Data))
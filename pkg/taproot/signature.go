@@ -36,6 +36,9 @@ const SecretKeyLength = 32
 // This is simply an array of 32 bytes.
 type SecretKey []byte
 
+// PublicKeyLength is the number of bytes in a PublicKey.
+const PublicKeyLength = 32
+
 // PublicKey represents a public key for BIP-340 signatures.
 //
 // This key allows verifying signatures produced with the corresponding secret key.
@@ -0,0 +1,74 @@
+package taproot
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/taurusgroup/multi-party-sig/pkg/math/curve"
+)
+
+// MerkleRootLen is the number of bytes in the merkle root of a script tree.
+const MerkleRootLen = 32
+
+// TweakScalar calculates the tweak t = hash_TapTweak(P || merkleRoot) of an internal key P,
+// such that the output key is Q = P + t * G.
+//
+// merkleRoot is the root of the script tree committed to by the output key. If it is empty,
+// the output key commits to no scripts, as recommended by BIP-86 for key path spending only.
+//
+// See: https://github.com/bitcoin/bips/blob/master/bip-0341.mediawiki#constructing-and-spending-taproot-outputs
+func TweakScalar(internal PublicKey, merkleRoot []byte) (*curve.Secp256k1Scalar, error) {
+	if len(internal) != PublicKeyLength {
+		return nil, fmt.Errorf("taproot: invalid public key length %d", len(internal))
+	}
+	if len(merkleRoot) != 0 && len(merkleRoot) != MerkleRootLen {
+		return nil, fmt.Errorf("taproot: invalid merkle root length %d", len(merkleRoot))
+	}
+	t := new(curve.Secp256k1Scalar)
+	if err := t.UnmarshalBinary(TaggedHash("TapTweak", internal, merkleRoot)); err != nil {
+		return nil, errors.New("taproot: tweak is not smaller than the order")
+	}
+	return t, nil
+}
+
+// Tweak returns the output key Q = P + hash_TapTweak(P || merkleRoot) * G of the internal key P = pk.
+//
+// oddY is true if Q has an odd y coordinate, which is needed to spend the output through the script path.
+func (pk PublicKey) Tweak(merkleRoot []byte) (output PublicKey, oddY bool, err error) {
+	t, err := TweakScalar(pk, merkleRoot)
+	if err != nil {
+		return nil, false, err
+	}
+	P, err := curve.Secp256k1{}.LiftX(pk)
+	if err != nil {
+		return nil, false, fmt.Errorf("taproot: %w", err)
+	}
+	Q := P.Add(t.ActOnBase()).(*curve.Secp256k1Point)
+	if Q.IsIdentity() {
+		return nil, false, errors.New("taproot: output key is the identity")
+	}
+	return Q.XBytes(), !Q.HasEvenY(), nil
+}
+
+// Tweak returns the secret key of the output key committing to merkleRoot, as returned by PublicKey.Tweak.
+//
+// The resulting key can directly sign for a key path spend of the output.
+func (sk SecretKey) Tweak(merkleRoot []byte) (SecretKey, error) {
+	d := new(curve.Secp256k1Scalar)
+	if err := d.UnmarshalBinary(sk); err != nil || d.IsZero() {
+		return nil, fmt.Errorf("invalid secret key")
+	}
+	P := d.ActOnBase().(*curve.Secp256k1Point)
+	if !P.HasEvenY() {
+		d.Negate()
+	}
+	t, err := TweakScalar(P.XBytes(), merkleRoot)
+	if err != nil {
+		return nil, err
+	}
+	d.Add(t)
+	if d.IsZero() {
+		return nil, errors.New("taproot: output key is the identity")
+	}
+	return d.MarshalBinary()
+}
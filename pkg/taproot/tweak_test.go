@@ -0,0 +1,71 @@
+package taproot
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTweakVectors(t *testing.T) {
+	// Taken from the scriptPubKey test vectors of BIP-341:
+	// https://github.com/bitcoin/bips/blob/master/bip-0341/wallet-test-vectors.json
+	vectors := []struct {
+		internal, merkleRoot, tweak, output string
+	}{
+		{
+			internal: "d6889cb081036e0faefa3a35157ad71086b123b2b144b649798b494c300a961d",
+			tweak:    "b86e7be8f39bab32a6f2c0443abbc210f0edac0e2c53d501b36b64437d9c6c70",
+			output:   "53a1f6e454df1aa2776a2814a721372d6258050de330b3c6d10ee8f4e0dda343",
+		},
+		{
+			internal:   "187791b6f712a8ea41c8ecdd0ee77fab3e85263b37e1ec18a3651926b3a6cf27",
+			merkleRoot: "5b75adecf53548f3ec6ad7d78383bf84cc57b55a3127c72b9a2481752dd88b21",
+			tweak:      "cbd8679ba636c1110ea247542cfbd964131a6be84f873f7f3b62a777528ed001",
+			output:     "147c9c57132f6e7ecddba9800bb0c4449251c92a1e60371ee77557b6620f3ea3",
+		},
+	}
+	for _, v := range vectors {
+		internal, _ := hex.DecodeString(v.internal)
+		merkleRoot, _ := hex.DecodeString(v.merkleRoot)
+
+		tweak, err := TweakScalar(internal, merkleRoot)
+		require.NoError(t, err)
+		tweakBytes, err := tweak.MarshalBinary()
+		require.NoError(t, err)
+		assert.Equal(t, v.tweak, hex.EncodeToString(tweakBytes))
+
+		output, _, err := PublicKey(internal).Tweak(merkleRoot)
+		require.NoError(t, err)
+		assert.Equal(t, v.output, hex.EncodeToString(output))
+	}
+
+	_, err := TweakScalar(make([]byte, 32), make([]byte, 31))
+	assert.Error(t, err)
+}
+
+func TestTweakSign(t *testing.T) {
+	merkleRoot := make([]byte, MerkleRootLen)
+	_, _ = rand.Read(merkleRoot)
+	m := []byte{0xDE, 0xAD, 0xBE, 0xEF}
+	for i := 0; i < 10; i++ {
+		sk, pk, err := GenKey(rand.Reader)
+		require.NoError(t, err)
+		for _, root := range [][]byte{nil, merkleRoot} {
+			tweakedSK, err := sk.Tweak(root)
+			require.NoError(t, err)
+			tweakedPK, _, err := pk.Tweak(root)
+			require.NoError(t, err)
+			public, err := tweakedSK.Public()
+			require.NoError(t, err)
+			assert.Equal(t, tweakedPK, public)
+
+			sig, err := tweakedSK.Sign(rand.Reader, m)
+			require.NoError(t, err)
+			assert.True(t, tweakedPK.Verify(sig, m))
+			assert.False(t, pk.Verify(sig, m))
+		}
+	}
+}
@@ -2,8 +2,10 @@ package zksch
 
 import (
 	"crypto/rand"
+	"errors"
 	"io"
 
+	"github.com/fxamacker/cbor/v2"
 	"github.com/taurusgroup/multi-party-sig/pkg/hash"
 	"github.com/taurusgroup/multi-party-sig/pkg/math/curve"
 	"github.com/taurusgroup/multi-party-sig/pkg/math/sample"
@@ -164,3 +166,34 @@ func EmptyResponse(group curve.Curve) *Response {
 func EmptyCommitment(group curve.Curve) *Commitment {
 	return &Commitment{C: group.NewPoint()}
 }
+
+// EmptyRandomness creates an empty Randomness with a fixed group, ready for unmarshalling.
+func EmptyRandomness(group curve.Curve) *Randomness {
+	return &Randomness{
+		a:          group.NewScalar(),
+		commitment: Commitment{C: group.NewPoint()},
+	}
+}
+
+// rawRandomness is used to marshal the unexported fields of Randomness.
+type rawRandomness struct {
+	A curve.Scalar
+	C curve.Point
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+//
+// The output contains the secret nonce a, and must not be revealed.
+func (r *Randomness) MarshalBinary() ([]byte, error) {
+	return cbor.Marshal(&rawRandomness{A: r.a, C: r.commitment.C})
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+// The Randomness must have been created with EmptyRandomness.
+func (r *Randomness) UnmarshalBinary(data []byte) error {
+	if r.a == nil || r.commitment.C == nil {
+		return errors.New("zksch: can't unmarshal Randomness with no group")
+	}
+	raw := &rawRandomness{A: r.a, C: r.commitment.C}
+	return cbor.Unmarshal(data, raw)
+}
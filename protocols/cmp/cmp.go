@@ -1,9 +1,13 @@
 package cmp
 
 import (
+	"errors"
+
 	"github.com/taurusgroup/multi-party-sig/internal/round"
 	"github.com/taurusgroup/multi-party-sig/pkg/ecdsa"
+	"github.com/taurusgroup/multi-party-sig/pkg/export"
 	"github.com/taurusgroup/multi-party-sig/pkg/math/curve"
+	"github.com/taurusgroup/multi-party-sig/pkg/paillier"
 	"github.com/taurusgroup/multi-party-sig/pkg/party"
 	"github.com/taurusgroup/multi-party-sig/pkg/pool"
 	"github.com/taurusgroup/multi-party-sig/pkg/protocol"
@@ -59,8 +63,134 @@ func Refresh(config *Config, pl *pool.Pool) protocol.StartFunc {
 	return keygen.Start(info, pl, config)
 }
 
+type (
+	// BackupKey is the public key of an offline backup of the shares, created with NewBackupKey.
+	BackupKey = config.BackupKey
+	// Backup is a share encrypted under a BackupKey, which can be decrypted with Backup.Restore.
+	Backup = config.Backup
+	// BackupResult is the output of KeygenWithBackup and RefreshWithBackup.
+	BackupResult = keygen.BackupResult
+)
+
+// NewBackupKey generates a new key for an offline backup of the shares.
+// The returned Paillier secret key should be stored offline, and is only needed to restore a share.
+func NewBackupKey(pl *pool.Pool) (*paillier.SecretKey, *BackupKey) {
+	return config.NewBackupKey(pl)
+}
+
+// KeygenWithBackup is like Keygen, but every party also encrypts its share under the backup key,
+// with a proof that the other parties verify. This guarantees that backup can restore the share of any party.
+// Returns *cmp.BackupResult if successful, which contains the Config and the backups of all shares.
+func KeygenWithBackup(group curve.Curve, selfID party.ID, participants []party.ID, threshold int, backup *BackupKey, pl *pool.Pool) protocol.StartFunc {
+	info := round.Info{
+		ProtocolID:       "cmp/keygen-backup-threshold",
+		FinalRoundNumber: keygen.Rounds,
+		SelfID:           selfID,
+		PartyIDs:         participants,
+		Threshold:        threshold,
+		Group:            group,
+	}
+	return keygen.StartWithBackup(info, pl, nil, backup)
+}
+
+// RefreshWithBackup is like Refresh, but every party also encrypts its new share under the backup key,
+// as in KeygenWithBackup.
+// Returns *cmp.BackupResult if successful.
+func RefreshWithBackup(config *Config, backup *BackupKey, pl *pool.Pool) protocol.StartFunc {
+	info := round.Info{
+		ProtocolID:       "cmp/refresh-backup-threshold",
+		FinalRoundNumber: keygen.Rounds,
+		SelfID:           config.ID,
+		PartyIDs:         config.PartyIDs(),
+		Threshold:        config.Threshold,
+		Group:            config.Group,
+	}
+	return keygen.StartWithBackup(info, pl, config, backup)
+}
+
+// ImportedShare is a share of an existing key produced by ImportKey, which must be converted with ImportRefresh.
+type ImportedShare = config.ImportedShare
+
+// ImportKey splits an existing ECDSA secret key among the parties, so that any threshold + 1 of them can sign.
+// The dealer calling this function knows the secret and all shares, and should be trusted.
+//
+// The returned shares cannot be used for signing: each party must run ImportRefresh with its share,
+// which generates its auxiliary parameters and re-randomizes all shares so that the dealer does not learn them.
+func ImportKey(secret curve.Scalar, parties []party.ID, threshold int) (map[party.ID]*ImportedShare, error) {
+	return keygen.SplitKey(secret, parties, threshold)
+}
+
+// ImportRefresh turns a share produced by ImportKey into a Config, in an interactive protocol with the other parties.
+// The group's ECDSA public key is the one of the imported secret.
+// Returns *cmp.Config if successful.
+func ImportRefresh(share *ImportedShare, pl *pool.Pool) protocol.StartFunc {
+	return func(sessionID []byte) (round.Session, error) {
+		if share == nil {
+			return nil, errors.New("cmp: imported share is nil")
+		}
+		info := round.Info{
+			ProtocolID:       "cmp/import-threshold",
+			FinalRoundNumber: keygen.Rounds,
+			SelfID:           share.ID,
+			PartyIDs:         share.PartyIDs(),
+			Threshold:        share.Threshold,
+			Group:            share.Group,
+		}
+		return keygen.StartImport(info, pl, share)(sessionID)
+	}
+}
+
+// Reshare transfers the key of a previously generated Config to a new committee, possibly with a different threshold.
+// The group's ECDSA public key remains the same, and all parties in newParties obtain fresh auxiliary parameters.
+//
+// The parties in oldParties must be able to sign with the previous Config, and each of them contributes
+// its Lagrange-weighted share of the key. Parties joining the committee use ReshareJoin instead.
+// All parties in oldParties and newParties must participate.
+//
+// Returns *cmp.Config if the party is in newParties, and the unchanged public key as curve.Point otherwise.
+func Reshare(config *Config, oldParties, newParties []party.ID, newThreshold int, pl *pool.Pool) protocol.StartFunc {
+	info := round.Info{
+		ProtocolID:       "cmp/reshare-threshold",
+		FinalRoundNumber: keygen.Rounds,
+		SelfID:           config.ID,
+		PartyIDs:         reshareParties(oldParties, newParties),
+		Threshold:        newThreshold,
+		Group:            config.Group,
+	}
+	return keygen.StartReshare(info, pl, config, config.PublicPoint(), oldParties, newParties)
+}
+
+// ReshareJoin allows a party without a previous Config to join the committee of a Reshare of publicKey.
+// Returns *cmp.Config if successful.
+func ReshareJoin(group curve.Curve, selfID party.ID, publicKey curve.Point, oldParties, newParties []party.ID, newThreshold int, pl *pool.Pool) protocol.StartFunc {
+	info := round.Info{
+		ProtocolID:       "cmp/reshare-threshold",
+		FinalRoundNumber: keygen.Rounds,
+		SelfID:           selfID,
+		PartyIDs:         reshareParties(oldParties, newParties),
+		Threshold:        newThreshold,
+		Group:            group,
+	}
+	return keygen.StartReshare(info, pl, nil, publicKey, oldParties, newParties)
+}
+
+// reshareParties returns the parties participating in a Reshare.
+func reshareParties(oldParties, newParties []party.ID) []party.ID {
+	old := party.NewIDSlice(oldParties)
+	parties := append([]party.ID{}, oldParties...)
+	for _, j := range newParties {
+		if !old.Contains(j) {
+			parties = append(parties, j)
+		}
+	}
+	return parties
+}
+
 // Sign generates an ECDSA signature for `messageHash` among the given `signers`.
 // Returns *ecdsa.Signature if successful.
+//
+// If a party sends an inconsistent share, the protocol aborts with an error whose culprits are the parties
+// which could not prove that their shares were computed correctly.
 func Sign(config *Config, signers []party.ID, messageHash []byte, pl *pool.Pool) protocol.StartFunc {
 	return sign.StartSign(config, signers, messageHash, pl)
 }
@@ -68,14 +198,47 @@ func Sign(config *Config, signers []party.ID, messageHash []byte, pl *pool.Pool)
 // Presign generates a preprocessed signature that does not depend on the message being signed.
 // When the message becomes available, the same participants can efficiently combine their shares
 // to produce a full signature with the PresignOnline protocol.
-// Note: the PreSignatures should be treated as secret key material.
+// Note: the PreSignatures should be treated as secret key material, and must only be used once.
+// A PresignStore can be used to keep them until they are consumed.
 // Returns *ecdsa.PreSignature if successful.
 func Presign(config *Config, signers []party.ID, pl *pool.Pool) protocol.StartFunc {
 	return presign.StartPresign(config, signers, nil, pl)
 }
 
+// PresignBatch generates k preprocessed signatures in the same number of rounds as Presign,
+// by packing the messages for all of them together. This amortizes the latency of the network over the batch.
+// The presignatures are computed in parallel on pl, so that the pool is only fully used when k is at least its size.
+// Note: the PreSignatures should be treated as secret key material, and must only be used once.
+// Returns []*ecdsa.PreSignature if successful.
+func PresignBatch(config *Config, signers []party.ID, k int, pl *pool.Pool) protocol.StartFunc {
+	return presign.StartPresignBatch(config, signers, k, pl)
+}
+
 // PresignOnline efficiently generates an ECDSA signature for `messageHash` given a preprocessed `PreSignature`.
 // Returns *ecdsa.Signature if successful.
 func PresignOnline(config *Config, preSignature *ecdsa.PreSignature, messageHash []byte, pl *pool.Pool) protocol.StartFunc {
 	return presign.StartPresignOnline(config, preSignature, messageHash, pl)
 }
+
+// ExportShare encrypts the ECDSA share of config to the public key recipient,
+// as this party's contribution to an export of the full secret key.
+//
+// ceremonyID must be unique to this export, and agreed upon by all contributing parties.
+// The holder of the secret key behind recipient passes t+1 contributions to ExportKey(config).Reconstruct.
+func ExportShare(config *Config, ceremonyID []byte, recipient curve.Point) (*export.Contribution, error) {
+	return export.Encrypt(ceremonyID, config.ID, config.ECDSA, recipient)
+}
+
+// ExportKey returns the public data of the key of config, against which exported shares are verified.
+func ExportKey(config *Config) *export.Key {
+	shares := make(map[party.ID]curve.Point, len(config.Public))
+	for j, public := range config.Public {
+		shares[j] = public.ECDSA
+	}
+	return &export.Key{
+		Group:        config.Group,
+		Threshold:    config.Threshold,
+		PublicKey:    config.PublicPoint(),
+		PublicShares: shares,
+	}
+}
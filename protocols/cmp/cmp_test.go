@@ -6,14 +6,18 @@ import (
 	"sync"
 	"testing"
 
+	"github.com/fxamacker/cbor/v2"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/taurusgroup/multi-party-sig/internal/test"
 	"github.com/taurusgroup/multi-party-sig/pkg/ecdsa"
+	"github.com/taurusgroup/multi-party-sig/pkg/export"
 	"github.com/taurusgroup/multi-party-sig/pkg/math/curve"
+	"github.com/taurusgroup/multi-party-sig/pkg/math/sample"
 	"github.com/taurusgroup/multi-party-sig/pkg/party"
 	"github.com/taurusgroup/multi-party-sig/pkg/pool"
 	"github.com/taurusgroup/multi-party-sig/pkg/protocol"
+	"github.com/taurusgroup/multi-party-sig/protocols/cmp/config"
 )
 
 func do(t *testing.T, id party.ID, ids []party.ID, threshold int, message []byte, pl *pool.Pool, n *test.Network, wg *sync.WaitGroup) {
@@ -161,3 +165,296 @@ func TestStart(t *testing.T) {
 		})
 	}
 }
+
+func TestUnsupportedCurve(t *testing.T) {
+	pl := pool.NewPool(0)
+	defer pl.TearDown()
+	configs, partyIDs := test.GenerateConfig(curve.Secp256k1{}, 3, 1, rand.Reader, pl)
+	selfID := partyIDs[0]
+	m := []byte("HELLO")
+
+	for _, group := range []curve.Curve{curve.Ed25519{}, curve.Ristretto255{}} {
+		t.Run(group.Name(), func(t *testing.T) {
+			_, err := Keygen(group, selfID, partyIDs, 1, pl)(nil)
+			assert.ErrorContains(t, err, "ECDSA")
+
+			c := *configs[selfID]
+			c.Group = group
+			_, err = Sign(&c, partyIDs, m, pl)(nil)
+			assert.ErrorContains(t, err, "ECDSA")
+			_, err = Presign(&c, partyIDs, pl)(nil)
+			assert.ErrorContains(t, err, "ECDSA")
+		})
+	}
+}
+
+func TestExportShare(t *testing.T) {
+	group := curve.Secp256k1{}
+	N, T := 4, 2
+	pl := pool.NewPool(0)
+	defer pl.TearDown()
+	configs, partyIDs := test.GenerateConfig(group, N, T, rand.Reader, pl)
+
+	ceremonyID := []byte("export")
+	recipientSecret := sample.Scalar(rand.Reader, group)
+	contributions := make([]*export.Contribution, 0, T+1)
+	for _, id := range partyIDs[:T+1] {
+		c, err := ExportShare(configs[id], ceremonyID, recipientSecret.ActOnBase())
+		require.NoError(t, err)
+		contributions = append(contributions, c)
+	}
+	key := ExportKey(configs[partyIDs[0]])
+	secret, culprits, err := key.Reconstruct(recipientSecret, ceremonyID, contributions)
+	require.NoError(t, err)
+	assert.Empty(t, culprits)
+	assert.True(t, secret.ActOnBase().Equal(configs[partyIDs[0]].PublicPoint()))
+
+	_, _, err = key.Reconstruct(recipientSecret, ceremonyID, contributions[:T])
+	assert.Error(t, err)
+}
+
+// runRestarting runs the protocol created by start, while restarting the first party from a snapshot after every step.
+func runRestarting(t *testing.T, partyIDs party.IDSlice, start func(id party.ID) protocol.StartFunc) map[party.ID]interface{} {
+	key := make([]byte, protocol.SnapshotKeySize)
+	_, _ = rand.Read(key)
+
+	handlers := make(map[party.ID]protocol.Handler, len(partyIDs))
+	for _, id := range partyIDs {
+		h, err := protocol.NewMultiHandler(start(id), nil)
+		require.NoError(t, err)
+		require.NoError(t, h.SetSnapshotKey(key))
+		handlers[id] = h
+	}
+
+	restart := partyIDs[0]
+	log := protocol.NewMemorySnapshotLog()
+	for test.Step(handlers) {
+		if _, err := handlers[restart].Result(); err == nil {
+			continue
+		}
+		snapshot, err := handlers[restart].(*protocol.MultiHandler).Snapshot()
+		require.NoError(t, err)
+		handlers[restart], err = protocol.RestoreMultiHandler(snapshot, key, start(restart), log)
+		require.NoError(t, err)
+	}
+
+	results := make(map[party.ID]interface{}, len(partyIDs))
+	for id, h := range handlers {
+		r, err := h.Result()
+		require.NoError(t, err)
+		results[id] = r
+	}
+	return results
+}
+
+func TestSnapshot(t *testing.T) {
+	group := curve.Secp256k1{}
+	N := 3
+	T := N - 1
+	message := []byte("hello")
+	pl := pool.NewPool(0)
+	defer pl.TearDown()
+	configs, partyIDs := test.GenerateConfig(group, N, T, rand.Reader, pl)
+
+	results := runRestarting(t, partyIDs, func(id party.ID) protocol.StartFunc {
+		return Refresh(configs[id], pl)
+	})
+	for id, r := range results {
+		require.IsType(t, &Config{}, r)
+		configs[id] = r.(*Config)
+		assert.True(t, configs[id].PublicPoint().Equal(configs[partyIDs[0]].PublicPoint()))
+	}
+	publicKey := configs[partyIDs[0]].PublicPoint()
+
+	results = runRestarting(t, partyIDs, func(id party.ID) protocol.StartFunc {
+		return Sign(configs[id], partyIDs, message, pl)
+	})
+	for _, r := range results {
+		require.IsType(t, &ecdsa.Signature{}, r)
+		assert.True(t, r.(*ecdsa.Signature).Verify(publicKey, message))
+	}
+
+	results = runRestarting(t, partyIDs, func(id party.ID) protocol.StartFunc {
+		return Presign(configs[id], partyIDs, pl)
+	})
+	preSignatures := make(map[party.ID]*ecdsa.PreSignature, N)
+	for id, r := range results {
+		require.IsType(t, &ecdsa.PreSignature{}, r)
+		preSignatures[id] = r.(*ecdsa.PreSignature)
+		require.NoError(t, preSignatures[id].Validate())
+	}
+
+	results = runRestarting(t, partyIDs, func(id party.ID) protocol.StartFunc {
+		return PresignOnline(configs[id], preSignatures[id], message, pl)
+	})
+	for _, r := range results {
+		require.IsType(t, &ecdsa.Signature{}, r)
+		assert.True(t, r.(*ecdsa.Signature).Verify(publicKey, message))
+	}
+}
+
+func TestReshare(t *testing.T) {
+	group := curve.Secp256k1{}
+	message := []byte("hello")
+	pl := pool.NewPool(0)
+	defer pl.TearDown()
+
+	// a 2-of-3 key held by a, b, c is reshared by a and b to a 3-of-5 committee without a.
+	configs, _ := test.GenerateConfig(group, 3, 1, rand.Reader, pl)
+	publicKey := configs["a"].PublicPoint()
+	oldParties := []party.ID{"a", "b"}
+	newParties := test.PartyIDs(6)[1:]
+	partyIDs := test.PartyIDs(6)
+
+	results := runRestarting(t, partyIDs, func(id party.ID) protocol.StartFunc {
+		if c, ok := configs[id]; ok {
+			return Reshare(c, oldParties, newParties, 2, pl)
+		}
+		return ReshareJoin(group, id, publicKey, oldParties, newParties, 2, pl)
+	})
+	newConfigs := make(map[party.ID]*Config, len(newParties))
+	for id, r := range results {
+		if !newParties.Contains(id) {
+			require.Implements(t, (*curve.Point)(nil), r)
+			assert.True(t, publicKey.Equal(r.(curve.Point)))
+			continue
+		}
+		require.IsType(t, &Config{}, r)
+		c := r.(*Config)
+		assert.Equal(t, 2, c.Threshold)
+		assert.Equal(t, newParties, c.PartyIDs())
+		assert.Equal(t, configs["a"].ChainKey, c.ChainKey)
+		assert.True(t, publicKey.Equal(c.PublicPoint()))
+		newConfigs[id] = c
+	}
+
+	signers := newParties[2:]
+	results = runRestarting(t, signers, func(id party.ID) protocol.StartFunc {
+		return Sign(newConfigs[id], signers, message, pl)
+	})
+	for _, r := range results {
+		require.IsType(t, &ecdsa.Signature{}, r)
+		assert.True(t, r.(*ecdsa.Signature).Verify(publicKey, message))
+	}
+}
+
+func TestPresignBatch(t *testing.T) {
+	group := curve.Secp256k1{}
+	N, k := 3, 2
+	message := []byte("hello")
+	pl := pool.NewPool(0)
+	defer pl.TearDown()
+	configs, partyIDs := test.GenerateConfig(group, N, N-1, rand.Reader, pl)
+
+	handlers := make(map[party.ID]protocol.Handler, N)
+	for _, id := range partyIDs {
+		h, err := protocol.NewMultiHandler(PresignBatch(configs[id], partyIDs, k, pl), nil)
+		require.NoError(t, err)
+		handlers[id] = h
+	}
+	for test.Step(handlers) {
+	}
+
+	preSignatures := make(map[party.ID][]*ecdsa.PreSignature, N)
+	for id, h := range handlers {
+		r, err := h.Result()
+		require.NoError(t, err)
+		require.IsType(t, []*ecdsa.PreSignature{}, r)
+		preSignatures[id] = r.([]*ecdsa.PreSignature)
+		require.Len(t, preSignatures[id], k)
+	}
+
+	for i := 0; i < k; i++ {
+		results := runRestarting(t, partyIDs, func(id party.ID) protocol.StartFunc {
+			return PresignOnline(configs[id], preSignatures[id][i], message, pl)
+		})
+		for _, r := range results {
+			require.IsType(t, &ecdsa.Signature{}, r)
+			assert.True(t, r.(*ecdsa.Signature).Verify(configs[partyIDs[0]].PublicPoint(), message))
+		}
+	}
+}
+
+func TestImportKey(t *testing.T) {
+	group := curve.Secp256k1{}
+	N, T := 3, 1
+	message := []byte("hello")
+	pl := pool.NewPool(0)
+	defer pl.TearDown()
+
+	secret := sample.Scalar(rand.Reader, group)
+	partyIDs := test.PartyIDs(N)
+	shares, err := ImportKey(secret, partyIDs, T)
+	require.NoError(t, err)
+	for id, share := range shares {
+		data, err := share.MarshalBinary()
+		require.NoError(t, err)
+		shares[id] = config.EmptyImportedShare(group)
+		require.NoError(t, shares[id].UnmarshalBinary(data))
+	}
+
+	results := runRestarting(t, partyIDs, func(id party.ID) protocol.StartFunc {
+		return ImportRefresh(shares[id], pl)
+	})
+	configs := make(map[party.ID]*Config, N)
+	for id, r := range results {
+		require.IsType(t, &Config{}, r)
+		configs[id] = r.(*Config)
+		assert.True(t, secret.ActOnBase().Equal(configs[id].PublicPoint()))
+		assert.False(t, shares[id].ECDSA.Equal(configs[id].ECDSA), "the dealer should not know the final share")
+	}
+
+	signers := partyIDs[1:]
+	results = runRestarting(t, signers, func(id party.ID) protocol.StartFunc {
+		return Sign(configs[id], signers, message, pl)
+	})
+	for _, r := range results {
+		require.IsType(t, &ecdsa.Signature{}, r)
+		assert.True(t, r.(*ecdsa.Signature).Verify(secret.ActOnBase(), message))
+	}
+}
+
+func TestImportRefreshNil(t *testing.T) {
+	pl := pool.NewPool(0)
+	defer pl.TearDown()
+
+	start := ImportRefresh(nil, pl)
+	_, err := start(nil)
+	assert.Error(t, err)
+}
+
+func TestRefreshWithBackup(t *testing.T) {
+	group := curve.Secp256k1{}
+	N, T := 3, 1
+	pl := pool.NewPool(0)
+	defer pl.TearDown()
+	configs, partyIDs := test.GenerateConfig(group, N, T, rand.Reader, pl)
+
+	secret, backupKey := NewBackupKey(pl)
+	data, err := backupKey.MarshalBinary()
+	require.NoError(t, err)
+	backupKey = &BackupKey{}
+	require.NoError(t, backupKey.UnmarshalBinary(data))
+
+	results := runRestarting(t, partyIDs, func(id party.ID) protocol.StartFunc {
+		return RefreshWithBackup(configs[id], backupKey, pl)
+	})
+	for _, r := range results {
+		require.IsType(t, &BackupResult{}, r)
+		result := r.(*BackupResult)
+		require.Len(t, result.Backups, N)
+		for _, j := range partyIDs {
+			data, err := cbor.Marshal(result.Backups[j])
+			require.NoError(t, err)
+			backup := config.EmptyBackup(group)
+			require.NoError(t, cbor.Unmarshal(data, backup))
+
+			share, err := backup.Restore(secret)
+			require.NoError(t, err)
+			assert.True(t, share.ActOnBase().Equal(result.Config.Public[j].ECDSA))
+		}
+		restored, err := result.Backups[result.Config.ID].Restore(secret)
+		require.NoError(t, err)
+		assert.True(t, restored.Equal(result.Config.ECDSA))
+	}
+}
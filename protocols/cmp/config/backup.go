@@ -0,0 +1,176 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/cronokirby/safenum"
+	"github.com/fxamacker/cbor/v2"
+	"github.com/taurusgroup/multi-party-sig/pkg/hash"
+	"github.com/taurusgroup/multi-party-sig/pkg/math/curve"
+	"github.com/taurusgroup/multi-party-sig/pkg/paillier"
+	"github.com/taurusgroup/multi-party-sig/pkg/party"
+	"github.com/taurusgroup/multi-party-sig/pkg/pedersen"
+	"github.com/taurusgroup/multi-party-sig/pkg/pool"
+	zklogstar "github.com/taurusgroup/multi-party-sig/pkg/zk/logstar"
+	zkprm "github.com/taurusgroup/multi-party-sig/pkg/zk/prm"
+)
+
+// BackupKey is the public key of an offline backup, under which the parties encrypt their ECDSA shares
+// at the end of a keygen or refresh.
+//
+// The shares are encrypted with Paillier, since an ElGamal ciphertext of xᵢ⋅G cannot be decrypted to xᵢ.
+// Each party proves with zklogstar that its ciphertext decrypts to the discrete log of its public share,
+// using the Pedersen parameters of the backup key.
+type BackupKey struct {
+	// Pedersen contains the Paillier modulus N of the backup key, as well as the parameters s, t.
+	Pedersen *pedersen.Parameters
+	// Prm proves that s and t were generated correctly, so that the parties cannot forge proofs.
+	Prm *zkprm.Proof
+}
+
+// NewBackupKey generates a new backup key, whose secret must be kept offline.
+//
+// The secret key can be stored as its primes P(), Q(), and recovered with paillier.NewSecretKeyFromPrimes.
+func NewBackupKey(pl *pool.Pool) (*paillier.SecretKey, *BackupKey) {
+	secret := paillier.NewSecretKey(pl)
+	ped, lambda := secret.GeneratePedersen()
+	prm := zkprm.NewProof(zkprm.Private{
+		Lambda: lambda,
+		Phi:    secret.Phi(),
+		P:      secret.P(),
+		Q:      secret.Q(),
+	}, backupHash(ped), zkprm.Public{N: ped.N(), S: ped.S(), T: ped.T()}, pl)
+	return secret, &BackupKey{Pedersen: ped, Prm: prm}
+}
+
+func backupHash(ped *pedersen.Parameters) *hash.Hash {
+	return hash.New(&hash.BytesWithDomain{TheDomain: "Backup Key", Bytes: ped.N().Bytes()})
+}
+
+// Paillier returns the Paillier public key of the backup.
+func (k *BackupKey) Paillier() *paillier.PublicKey {
+	return paillier.NewPublicKey(k.Pedersen.N())
+}
+
+// Validate checks that the modulus and the Pedersen parameters are valid.
+func (k *BackupKey) Validate(pl *pool.Pool) error {
+	if k == nil || k.Pedersen == nil || k.Prm == nil {
+		return errors.New("backup key: missing fields")
+	}
+	if err := paillier.ValidateN(k.Pedersen.N()); err != nil {
+		return fmt.Errorf("backup key: %w", err)
+	}
+	if err := pedersen.ValidateParameters(k.Pedersen.N(), k.Pedersen.S(), k.Pedersen.T()); err != nil {
+		return fmt.Errorf("backup key: %w", err)
+	}
+	if !k.Prm.Verify(zkprm.Public{N: k.Pedersen.N(), S: k.Pedersen.S(), T: k.Pedersen.T()}, backupHash(k.Pedersen), pl) {
+		return errors.New("backup key: failed to validate prm proof")
+	}
+	return nil
+}
+
+// Domain implements hash.WriterToWithDomain.
+func (*BackupKey) Domain() string {
+	return "CMP Backup Key"
+}
+
+// WriteTo implements io.WriterTo interface.
+func (k *BackupKey) WriteTo(w io.Writer) (int64, error) {
+	if k == nil || k.Pedersen == nil {
+		return 0, io.ErrUnexpectedEOF
+	}
+	return k.Pedersen.WriteTo(w)
+}
+
+type backupKeyMarshal struct {
+	N    *safenum.Modulus
+	S, T *safenum.Nat
+	Prm  *zkprm.Proof
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+func (k *BackupKey) MarshalBinary() ([]byte, error) {
+	return cbor.Marshal(&backupKeyMarshal{
+		N:   k.Pedersen.N(),
+		S:   k.Pedersen.S(),
+		T:   k.Pedersen.T(),
+		Prm: k.Prm,
+	})
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+//
+// The key should be validated with Validate before being used.
+func (k *BackupKey) UnmarshalBinary(data []byte) error {
+	var km backupKeyMarshal
+	if err := cbor.Unmarshal(data, &km); err != nil {
+		return fmt.Errorf("backup key: %w", err)
+	}
+	if km.N == nil || km.S == nil || km.T == nil {
+		return errors.New("backup key: missing fields")
+	}
+	k.Pedersen = pedersen.New(paillier.NewPublicKey(km.N).Modulus(), km.S, km.T)
+	k.Prm = km.Prm
+	return nil
+}
+
+// Backup is the encryption of the ECDSA share of a party under a BackupKey.
+//
+// To unmarshal this struct, EmptyBackup should be called first with a specific group.
+type Backup struct {
+	// ID is the party whose share is encrypted.
+	ID party.ID
+	// ECDSA is the public share Xᵢ = xᵢ⋅G.
+	ECDSA curve.Point
+	// Share = Enc(xᵢ) under the backup key.
+	Share *paillier.Ciphertext
+	// Proof shows that Share decrypts to the discrete log of ECDSA.
+	Proof *zklogstar.Proof
+}
+
+// EmptyBackup creates an empty Backup with a fixed group, ready for unmarshalling.
+func EmptyBackup(group curve.Curve) *Backup {
+	return &Backup{
+		ECDSA: group.NewPoint(),
+		Proof: zklogstar.Empty(group),
+	}
+}
+
+// Verify checks the proof that the backup decrypts to the discrete log of the public share.
+//
+// h must be the hash state under which the proof was created.
+func (b *Backup) Verify(h *hash.Hash, key *BackupKey) bool {
+	if b == nil || b.ECDSA == nil || b.ECDSA.IsIdentity() || b.Share == nil || b.Proof == nil {
+		return false
+	}
+	prover := key.Paillier()
+	if !prover.ValidateCiphertexts(b.Share) {
+		return false
+	}
+	return b.Proof.Verify(h, zklogstar.Public{
+		C:      b.Share,
+		X:      b.ECDSA,
+		Prover: prover,
+		Aux:    key.Pedersen,
+	})
+}
+
+// Restore decrypts the share of the backup with the secret of the backup key,
+// and checks that it matches the public share.
+func (b *Backup) Restore(secret *paillier.SecretKey) (curve.Scalar, error) {
+	if b == nil || b.ECDSA == nil || b.Share == nil {
+		return nil, errors.New("backup: missing fields")
+	}
+	group := b.ECDSA.Curve()
+	decrypted, err := secret.Dec(b.Share)
+	if err != nil {
+		return nil, fmt.Errorf("backup: %w", err)
+	}
+	share := group.NewScalar().SetNat(decrypted.Mod(group.Order()))
+	if !share.ActOnBase().Equal(b.ECDSA) {
+		return nil, errors.New("backup: decrypted share does not match public share")
+	}
+	return share, nil
+}
@@ -0,0 +1,154 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/taurusgroup/multi-party-sig/internal/types"
+	"github.com/taurusgroup/multi-party-sig/pkg/math/curve"
+	"github.com/taurusgroup/multi-party-sig/pkg/math/polynomial"
+	"github.com/taurusgroup/multi-party-sig/pkg/party"
+)
+
+// ImportedShare is a share of an existing key, produced by a trusted dealer.
+//
+// It does not contain the auxiliary parameters required for signing,
+// and must be turned into a Config by refreshing it together with the other parties.
+//
+// To unmarshal this struct, EmptyImportedShare should be called first with a specific group.
+type ImportedShare struct {
+	// Group returns the Elliptic Curve Group associated with this share.
+	Group curve.Curve
+	// ID is the identifier of the party this share belongs to.
+	ID party.ID
+	// Threshold is the integer t which defines the maximum number of corruptions tolerated.
+	Threshold int
+	// ECDSA is this party's share xᵢ of the secret ECDSA x.
+	ECDSA curve.Scalar
+	// Public maps party.ID to the public share Xⱼ of that party.
+	Public map[party.ID]curve.Point
+}
+
+// EmptyImportedShare creates an empty ImportedShare with a fixed group, ready for unmarshalling.
+func EmptyImportedShare(group curve.Curve) *ImportedShare {
+	return &ImportedShare{Group: group}
+}
+
+// PublicPoint returns the group's public ECC point.
+func (s *ImportedShare) PublicPoint() curve.Point {
+	sum := s.Group.NewPoint()
+	l := polynomial.Lagrange(s.Group, s.PartyIDs())
+	for j, X := range s.Public {
+		sum = sum.Add(l[j].Act(X))
+	}
+	return sum
+}
+
+// PartyIDs returns a sorted slice of party IDs.
+func (s *ImportedShare) PartyIDs() party.IDSlice {
+	ids := make([]party.ID, 0, len(s.Public))
+	for j := range s.Public {
+		ids = append(ids, j)
+	}
+	return party.NewIDSlice(ids)
+}
+
+// Validate checks that the share is consistent with the public shares.
+func (s *ImportedShare) Validate() error {
+	if !ValidThreshold(s.Threshold, len(s.Public)) {
+		return fmt.Errorf("imported share: threshold %d is invalid for %d parties", s.Threshold, len(s.Public))
+	}
+	X, ok := s.Public[s.ID]
+	if !ok {
+		return errors.New("imported share: no public share for self")
+	}
+	if s.ECDSA == nil || s.ECDSA.IsZero() || !s.ECDSA.ActOnBase().Equal(X) {
+		return errors.New("imported share: secret share does not match public share")
+	}
+	for j, Xj := range s.Public {
+		if Xj == nil || Xj.IsIdentity() {
+			return fmt.Errorf("imported share: party %s: public share is invalid", j)
+		}
+	}
+	return nil
+}
+
+// Domain implements hash.WriterToWithDomain.
+func (s *ImportedShare) Domain() string {
+	return "CMP Imported Share"
+}
+
+// WriteTo implements io.WriterTo interface.
+func (s *ImportedShare) WriteTo(w io.Writer) (total int64, err error) {
+	if s == nil {
+		return 0, io.ErrUnexpectedEOF
+	}
+	var n int64
+
+	// write t
+	n, err = types.ThresholdWrapper(s.Threshold).WriteTo(w)
+	total += n
+	if err != nil {
+		return
+	}
+
+	// write partyIDs
+	partyIDs := s.PartyIDs()
+	n, err = partyIDs.WriteTo(w)
+	total += n
+	if err != nil {
+		return
+	}
+
+	// write all Xⱼ
+	for _, j := range partyIDs {
+		data, err := s.Public[j].MarshalBinary()
+		if err != nil {
+			return total, err
+		}
+		m, err := w.Write(data)
+		total += int64(m)
+		if err != nil {
+			return total, err
+		}
+	}
+	return
+}
+
+type importedShareMarshal struct {
+	ID        party.ID
+	Threshold int
+	ECDSA     curve.Scalar
+	Public    *party.PointMap
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+func (s *ImportedShare) MarshalBinary() ([]byte, error) {
+	return cbor.Marshal(&importedShareMarshal{
+		ID:        s.ID,
+		Threshold: s.Threshold,
+		ECDSA:     s.ECDSA,
+		Public:    party.NewPointMap(s.Public),
+	})
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+func (s *ImportedShare) UnmarshalBinary(data []byte) error {
+	if s.Group == nil {
+		return errors.New("imported share must be initialized using EmptyImportedShare")
+	}
+	sm := &importedShareMarshal{
+		ECDSA:  s.Group.NewScalar(),
+		Public: party.EmptyPointMap(s.Group),
+	}
+	if err := cbor.Unmarshal(data, sm); err != nil {
+		return fmt.Errorf("imported share: %w", err)
+	}
+	s.ID = sm.ID
+	s.Threshold = sm.Threshold
+	s.ECDSA = sm.ECDSA
+	s.Public = sm.Public.Points
+	return s.Validate()
+}
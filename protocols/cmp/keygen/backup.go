@@ -0,0 +1,32 @@
+package keygen
+
+import (
+	"errors"
+
+	"github.com/taurusgroup/multi-party-sig/internal/round"
+	"github.com/taurusgroup/multi-party-sig/pkg/party"
+	"github.com/taurusgroup/multi-party-sig/pkg/pool"
+	"github.com/taurusgroup/multi-party-sig/pkg/protocol"
+	"github.com/taurusgroup/multi-party-sig/protocols/cmp/config"
+)
+
+// BackupResult is the output of a keygen or refresh started with StartWithBackup.
+type BackupResult struct {
+	// Config is the new Config of this party.
+	Config *config.Config
+	// Backups[j] is the encryption of the new share of party j under the backup key,
+	// whose proof was verified by this party.
+	Backups map[party.ID]*config.Backup
+}
+
+// StartWithBackup is like Start, but all parties also encrypt their new share under backup,
+// and prove that the ciphertext decrypts to the discrete log of their public share.
+// The protocol aborts if a party's backup is invalid.
+func StartWithBackup(info round.Info, pl *pool.Pool, c *config.Config, backup *config.BackupKey) protocol.StartFunc {
+	if backup == nil {
+		return func([]byte) (round.Session, error) {
+			return nil, errors.New("keygen: backup key is nil")
+		}
+	}
+	return start(info, pl, c, backup)
+}
@@ -0,0 +1,84 @@
+package keygen
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/taurusgroup/multi-party-sig/internal/round"
+	"github.com/taurusgroup/multi-party-sig/pkg/math/curve"
+	"github.com/taurusgroup/multi-party-sig/pkg/math/polynomial"
+	"github.com/taurusgroup/multi-party-sig/pkg/party"
+	"github.com/taurusgroup/multi-party-sig/pkg/pool"
+	"github.com/taurusgroup/multi-party-sig/pkg/protocol"
+	"github.com/taurusgroup/multi-party-sig/protocols/cmp/config"
+)
+
+// SplitKey shares secret among partyIDs with threshold t, acting as a trusted dealer.
+//
+// The shares must be refreshed with StartImport before they can be used,
+// so that the dealer does not learn the final shares.
+func SplitKey(secret curve.Scalar, partyIDs []party.ID, threshold int) (map[party.ID]*config.ImportedShare, error) {
+	ids := party.NewIDSlice(partyIDs)
+	if !ids.Valid() {
+		return nil, errors.New("keygen: partyIDs invalid")
+	}
+	if !config.ValidThreshold(threshold, len(ids)) {
+		return nil, fmt.Errorf("keygen: threshold %d is invalid for number of parties %d", threshold, len(ids))
+	}
+	if secret == nil || secret.IsZero() {
+		return nil, errors.New("keygen: secret is zero")
+	}
+	group := secret.Curve()
+
+	// f(X) deg(f) = t, f(0) = secret
+	f := polynomial.NewPolynomial(group, threshold, secret)
+	secrets := make(map[party.ID]curve.Scalar, len(ids))
+	public := make(map[party.ID]curve.Point, len(ids))
+	for _, j := range ids {
+		secrets[j] = f.Evaluate(j.Scalar(group))
+		public[j] = secrets[j].ActOnBase()
+	}
+
+	shares := make(map[party.ID]*config.ImportedShare, len(ids))
+	for _, j := range ids {
+		shares[j] = &config.ImportedShare{
+			Group:     group,
+			ID:        j,
+			Threshold: threshold,
+			ECDSA:     secrets[j],
+			Public:    public,
+		}
+	}
+	return shares, nil
+}
+
+// StartImport returns the StartFunc of a refresh of an ImportedShare.
+// All parties obtain a new share, so that the dealer learns nothing about the resulting Config,
+// as well as their auxiliary parameters.
+func StartImport(info round.Info, pl *pool.Pool, s *config.ImportedShare) protocol.StartFunc {
+	return func(sessionID []byte) (round.Session, error) {
+		if s == nil {
+			return nil, errors.New("keygen: imported share is nil")
+		}
+		if err := s.Validate(); err != nil {
+			return nil, fmt.Errorf("keygen: %w", err)
+		}
+		helper, err := round.NewSession(info, sessionID, pl, s)
+		if err != nil {
+			return nil, fmt.Errorf("keygen: %w", err)
+		}
+		if !curve.SupportsECDSA(helper.Group()) {
+			return nil, fmt.Errorf("keygen: ECDSA is not supported over %s", helper.Group().Name())
+		}
+		group := helper.Group()
+
+		// the chain key is not set, so that it is generated by all parties
+		return &round1{
+			Helper:                    helper,
+			PreviousSecretECDSA:       s.ECDSA,
+			PreviousPublicSharesECDSA: s.Public,
+			VSSSecret:                 polynomial.NewPolynomial(group, helper.Threshold(), group.NewScalar()), // fᵢ(X) deg(fᵢ) = t, fᵢ(0) = 0
+			Receivers:                 helper.PartyIDs(),
+		}, nil
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/taurusgroup/multi-party-sig/internal/round"
+	"github.com/taurusgroup/multi-party-sig/pkg/hash"
 	"github.com/taurusgroup/multi-party-sig/pkg/math/curve"
 	"github.com/taurusgroup/multi-party-sig/pkg/math/polynomial"
 	"github.com/taurusgroup/multi-party-sig/pkg/math/sample"
@@ -17,16 +18,30 @@ import (
 const Rounds round.Number = 5
 
 func Start(info round.Info, pl *pool.Pool, c *config.Config) protocol.StartFunc {
+	return start(info, pl, c, nil)
+}
+
+// start returns the StartFunc of a keygen, or a refresh of c if it is not nil.
+// If backup is not nil, all parties verifiably encrypt their new share under it.
+func start(info round.Info, pl *pool.Pool, c *config.Config, backup *config.BackupKey) protocol.StartFunc {
 	return func(sessionID []byte) (_ round.Session, err error) {
-		var helper *round.Helper
-		if c == nil {
-			helper, err = round.NewSession(info, sessionID, pl)
-		} else {
-			helper, err = round.NewSession(info, sessionID, pl, c)
+		var aux []hash.WriterToWithDomain
+		if c != nil {
+			aux = append(aux, c)
 		}
+		if backup != nil {
+			if err = backup.Validate(pl); err != nil {
+				return nil, fmt.Errorf("keygen: %w", err)
+			}
+			aux = append(aux, backup)
+		}
+		helper, err := round.NewSession(info, sessionID, pl, aux...)
 		if err != nil {
 			return nil, fmt.Errorf("keygen: %w", err)
 		}
+		if !curve.SupportsECDSA(helper.Group()) {
+			return nil, fmt.Errorf("keygen: ECDSA is not supported over %s", helper.Group().Name())
+		}
 
 		group := helper.Group()
 
@@ -40,6 +55,8 @@ func Start(info round.Info, pl *pool.Pool, c *config.Config) protocol.StartFunc
 				PreviousSecretECDSA:       c.ECDSA,
 				PreviousPublicSharesECDSA: PublicSharesECDSA,
 				PreviousChainKey:          c.ChainKey,
+				Receivers:                 helper.PartyIDs(),
+				BackupKey:                 backup,
 				VSSSecret:                 polynomial.NewPolynomial(group, helper.Threshold(), group.NewScalar()), // fᵢ(X) deg(fᵢ) = t, fᵢ(0) = 0
 			}, nil
 		}
@@ -50,6 +67,8 @@ func Start(info round.Info, pl *pool.Pool, c *config.Config) protocol.StartFunc
 		return &round1{
 			Helper:    helper,
 			VSSSecret: VSSSecret,
+			Receivers: helper.PartyIDs(),
+			BackupKey: backup,
 		}, nil
 
 	}
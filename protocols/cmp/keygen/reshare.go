@@ -0,0 +1,103 @@
+package keygen
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/taurusgroup/multi-party-sig/internal/round"
+	"github.com/taurusgroup/multi-party-sig/pkg/hash"
+	"github.com/taurusgroup/multi-party-sig/pkg/math/curve"
+	"github.com/taurusgroup/multi-party-sig/pkg/math/polynomial"
+	"github.com/taurusgroup/multi-party-sig/pkg/party"
+	"github.com/taurusgroup/multi-party-sig/pkg/pool"
+	"github.com/taurusgroup/multi-party-sig/pkg/protocol"
+	"github.com/taurusgroup/multi-party-sig/protocols/cmp/config"
+)
+
+// StartReshare returns a StartFunc which reshares publicKey, previously shared among oldParties,
+// to the parties in newParties with threshold info.Threshold.
+//
+// info.PartyIDs must be the union of oldParties and newParties.
+// c is the previous Config of this party, and is nil if it is joining the committee.
+//
+// Parties in newParties obtain a *config.Config, the others only obtain the unchanged public key.
+func StartReshare(info round.Info, pl *pool.Pool, c *config.Config, publicKey curve.Point, oldParties, newParties []party.ID) protocol.StartFunc {
+	return func(sessionID []byte) (_ round.Session, err error) {
+		dealers, receivers := party.NewIDSlice(oldParties), party.NewIDSlice(newParties)
+		if !dealers.Valid() || !receivers.Valid() {
+			return nil, errors.New("keygen: invalid committee")
+		}
+		if !config.ValidThreshold(info.Threshold, len(receivers)) {
+			return nil, fmt.Errorf("keygen: threshold %d is invalid for %d new parties", info.Threshold, len(receivers))
+		}
+		if publicKey == nil || publicKey.IsIdentity() {
+			return nil, errors.New("keygen: invalid public key")
+		}
+		publicKeyBytes, err := publicKey.MarshalBinary()
+		if err != nil {
+			return nil, fmt.Errorf("keygen: %w", err)
+		}
+
+		helper, err := round.NewSession(info, sessionID, pl, &hash.BytesWithDomain{
+			TheDomain: "Public Key",
+			Bytes:     publicKeyBytes,
+		}, dealers, receivers)
+		if err != nil {
+			return nil, fmt.Errorf("keygen: %w", err)
+		}
+		if !curve.SupportsECDSA(helper.Group()) {
+			return nil, fmt.Errorf("keygen: ECDSA is not supported over %s", helper.Group().Name())
+		}
+
+		// every participant is either a dealer or a receiver
+		for _, j := range helper.PartyIDs() {
+			if !dealers.Contains(j) && !receivers.Contains(j) {
+				return nil, fmt.Errorf("keygen: party %s is neither in the old nor the new committee", j)
+			}
+		}
+		if !helper.PartyIDs().Contains(dealers...) || !helper.PartyIDs().Contains(receivers...) {
+			return nil, errors.New("keygen: committees are not included in partyIDs")
+		}
+
+		group := helper.Group()
+		self := helper.SelfID()
+
+		// fᵢ(0) = 0 unless we are a dealer
+		constant := group.NewScalar()
+		r := &round1{
+			Helper:    helper,
+			Dealers:   dealers,
+			Receivers: receivers,
+			PublicKey: publicKey,
+		}
+
+		if c != nil {
+			if c.ID != self || !c.PublicPoint().Equal(publicKey) {
+				return nil, errors.New("keygen: config does not match the reshared key")
+			}
+			if !config.ValidThreshold(c.Threshold, len(dealers)) {
+				return nil, errors.New("keygen: not enough previous holders to reshare the key")
+			}
+			// the dealers' public shares are used to check their contributions
+			r.PreviousPublicSharesECDSA = make(map[party.ID]curve.Point, len(dealers))
+			for _, j := range dealers {
+				public, ok := c.Public[j]
+				if !ok {
+					return nil, fmt.Errorf("keygen: party %s did not hold a share of the key", j)
+				}
+				r.PreviousPublicSharesECDSA[j] = public.ECDSA
+			}
+			r.PreviousChainKey = c.ChainKey
+			if dealers.Contains(self) {
+				// fᵢ(0) = λᵢ⋅x'ᵢ
+				constant = polynomial.LagrangeSingle(group, dealers, self).Mul(c.ECDSA)
+			}
+		} else if dealers.Contains(self) {
+			return nil, errors.New("keygen: previous config is required to reshare our share")
+		}
+
+		// sample fᵢ(X) deg(fᵢ) = t
+		r.VSSSecret = polynomial.NewPolynomial(group, helper.Threshold(), constant)
+		return r, nil
+	}
+}
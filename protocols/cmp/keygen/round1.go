@@ -14,6 +14,7 @@ import (
 	"github.com/taurusgroup/multi-party-sig/pkg/paillier"
 	"github.com/taurusgroup/multi-party-sig/pkg/party"
 	zksch "github.com/taurusgroup/multi-party-sig/pkg/zk/sch"
+	"github.com/taurusgroup/multi-party-sig/protocols/cmp/config"
 )
 
 var _ round.Round = (*round1)(nil)
@@ -41,7 +42,22 @@ type round1 struct {
 	// Polynomial from which the new secret shares are computed.
 	// Keygen:  fᵢ(0) = xⁱ
 	// Refresh: fᵢ(0) = 0
+	// Reshare: fᵢ(0) = λᵢ⋅x'ᵢ if i is a dealer, and 0 otherwise
 	VSSSecret *polynomial.Polynomial
+
+	// Dealers are the previous holders whose shares are reshared, and nil unless resharing.
+	Dealers party.IDSlice
+
+	// Receivers are the parties who obtain a share of the new key.
+	// Keygen/Refresh: all parties
+	// Reshare: the new committee
+	Receivers party.IDSlice
+
+	// PublicKey is the public key being reshared, and nil unless resharing.
+	PublicKey curve.Point
+
+	// BackupKey is the key under which all new shares are verifiably encrypted, and nil if no backup is made.
+	BackupKey *config.BackupKey
 }
 
 // VerifyMessage implements round.Round.
@@ -86,6 +102,10 @@ func (r *round1) Finalize(out chan<- *round.Message) (round.Session, error) {
 	if err != nil {
 		return r, errors.New("failed to sample c")
 	}
+	// dealers reveal the previous chain key to the parties joining the committee
+	if r.resharing() && r.Dealers.Contains(r.SelfID()) {
+		chainKey = r.PreviousChainKey.Copy()
+	}
 
 	// commit to data in message 2
 	SelfCommitment, Decommitment, err := r.HashForID(r.SelfID()).Commit(
@@ -123,6 +143,9 @@ func (r *round1) Finalize(out chan<- *round.Message) (round.Session, error) {
 	return nextRound, nil
 }
 
+// resharing returns true if the key is being reshared to a new committee.
+func (r *round1) resharing() bool { return r.Dealers != nil }
+
 // PreviousRound implements round.Round.
 func (round1) PreviousRound() round.Round { return nil }
 
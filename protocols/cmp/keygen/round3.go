@@ -1,6 +1,7 @@
 package keygen
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 
@@ -53,6 +54,7 @@ type broadcast3 struct {
 // - verify degree of VSS polynomial Fⱼ "in-the-exponent"
 //   - if keygen, verify Fⱼ(0) != ∞
 //   - if refresh, verify Fⱼ(0) == ∞
+//   - if reshare, verify Fⱼ(0) == λⱼ⋅X'ⱼ for dealers, and Fⱼ(0) == ∞ otherwise
 // - validate Paillier
 // - validate Pedersen
 // - validate commitments.
@@ -82,9 +84,13 @@ func (r *round3) StoreBroadcastMessage(msg round.Message) error {
 
 	// Save all X, VSSCommitments
 	VSSPolynomial := body.VSSPolynomial
-	// check that the constant coefficient is 0
-	// if refresh then the polynomial is constant
-	if !(r.VSSSecret.Constant().IsZero() == VSSPolynomial.IsConstant) {
+	if r.resharing() {
+		if err := r.validateReshare(from, VSSPolynomial, body.C); err != nil {
+			return err
+		}
+	} else if !(r.VSSSecret.Constant().IsZero() == VSSPolynomial.IsConstant) {
+		// check that the constant coefficient is 0
+		// if refresh then the polynomial is constant
 		return errors.New("vss polynomial has incorrect constant")
 	}
 	// check deg(Fⱼ) = t
@@ -133,10 +139,20 @@ func (round3) StoreMessage(round.Message) error { return nil }
 // - prove Schnorr for all coefficients of fᵢ(X)
 //   - if refresh skip constant coefficient
 //
+// - if reshare, use the chain key revealed by the dealers
 // - send proofs and encryption of share for Pⱼ.
 func (r *round3) Finalize(out chan<- *round.Message) (round.Session, error) {
 	// c = ⊕ⱼ cⱼ
 	chainKey := r.PreviousChainKey
+	if chainKey == nil && r.resharing() {
+		// joining parties use the previous chain key revealed by the dealers
+		chainKey = r.ChainKeys[r.Dealers[0]]
+		for _, j := range r.Dealers {
+			if !bytes.Equal(chainKey, r.ChainKeys[j]) {
+				return r, errors.New("dealers sent different chain keys")
+			}
+		}
+	}
 	if chainKey == nil {
 		chainKey = types.EmptyRID()
 		for _, j := range r.PartyIDs() {
@@ -177,8 +193,11 @@ func (r *round3) Finalize(out chan<- *round.Message) (round.Session, error) {
 
 	// create messages with encrypted shares
 	for _, j := range r.OtherPartyIDs() {
-		// compute fᵢ(j)
-		share := r.VSSSecret.Evaluate(j.Scalar(r.Group()))
+		// compute fᵢ(j), or 0 if j is leaving the committee
+		share := r.Group().NewScalar()
+		if r.Receivers.Contains(j) {
+			share = r.VSSSecret.Evaluate(j.Scalar(r.Group()))
+		}
 		// Encrypt share
 		C, _ := r.PaillierPublic[j].Enc(curve.MakeInt(share))
 
@@ -199,6 +218,31 @@ func (r *round3) Finalize(out chan<- *round.Message) (round.Session, error) {
 	}, nil
 }
 
+// validateReshare checks that the VSS polynomial Fⱼ(X) of j shares λⱼ⋅x'ⱼ if j is a dealer, and 0 otherwise.
+// Parties who held a share verify Fⱼ(0) against X'ⱼ and the chain key, others only check the sum in the next round.
+func (r *round3) validateReshare(j party.ID, VSSPolynomial *polynomial.Exponent, chainKey types.RID) error {
+	if !r.Dealers.Contains(j) {
+		if !VSSPolynomial.IsConstant {
+			return errors.New("vss polynomial has incorrect constant")
+		}
+		return nil
+	}
+	if VSSPolynomial.IsConstant {
+		return errors.New("vss polynomial has incorrect constant")
+	}
+	if r.PreviousPublicSharesECDSA == nil {
+		return nil
+	}
+	lambda := polynomial.LagrangeSingle(r.Group(), r.Dealers, j)
+	if !VSSPolynomial.Constant().Equal(lambda.Act(r.PreviousPublicSharesECDSA[j])) {
+		return errors.New("vss polynomial does not share the previous secret")
+	}
+	if !bytes.Equal(chainKey, r.PreviousChainKey) {
+		return errors.New("invalid chain key")
+	}
+	return nil
+}
+
 // MessageContent implements round.Round.
 func (round3) MessageContent() round.Content { return nil }
 
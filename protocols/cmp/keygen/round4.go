@@ -5,13 +5,16 @@ import (
 
 	"github.com/taurusgroup/multi-party-sig/internal/round"
 	"github.com/taurusgroup/multi-party-sig/internal/types"
+	"github.com/taurusgroup/multi-party-sig/pkg/hash"
 	"github.com/taurusgroup/multi-party-sig/pkg/math/curve"
 	"github.com/taurusgroup/multi-party-sig/pkg/math/polynomial"
 	"github.com/taurusgroup/multi-party-sig/pkg/paillier"
 	"github.com/taurusgroup/multi-party-sig/pkg/party"
 	"github.com/taurusgroup/multi-party-sig/pkg/pedersen"
+	zklogstar "github.com/taurusgroup/multi-party-sig/pkg/zk/logstar"
 	zkmod "github.com/taurusgroup/multi-party-sig/pkg/zk/mod"
 	zkprm "github.com/taurusgroup/multi-party-sig/pkg/zk/prm"
+	zksch "github.com/taurusgroup/multi-party-sig/pkg/zk/sch"
 	"github.com/taurusgroup/multi-party-sig/protocols/cmp/config"
 )
 
@@ -85,6 +88,11 @@ func (r *round4) VerifyMessage(msg round.Message) error {
 func (r *round4) StoreMessage(msg round.Message) error {
 	from, body := msg.From, msg.Content.(*message4)
 
+	// parties leaving the committee receive no share
+	if !r.Receivers.Contains(r.SelfID()) {
+		return nil
+	}
+
 	// decrypt share
 	DecryptedShare, err := r.PaillierSecret.Dec(body.Share)
 	if err != nil {
@@ -111,20 +119,91 @@ func (r *round4) StoreMessage(msg round.Message) error {
 //
 // - sum of all received shares
 // - compute group public key and individual public keys
+// - if reshare, verify that the public key is unchanged
 // - recompute config SSID
 // - validate Config
 // - write new ssid hash to old hash state
-// - create proof of knowledge of secret.
+// - create proof of knowledge of secret, unless leaving the committee.
+// - if making a backup, encrypt the secret under the backup key and prove it with zklogstar.
 func (r *round4) Finalize(out chan<- *round.Message) (round.Session, error) {
-	// add all shares to our secret
-	UpdatedSecretECDSA := r.Group().NewScalar()
-	if r.PreviousSecretECDSA != nil {
-		UpdatedSecretECDSA.Set(r.PreviousSecretECDSA)
+	var UpdatedSecretECDSA curve.Scalar
+	if r.Receivers.Contains(r.SelfID()) {
+		// add all shares to our secret
+		UpdatedSecretECDSA = r.Group().NewScalar()
+		if r.PreviousSecretECDSA != nil {
+			UpdatedSecretECDSA.Set(r.PreviousSecretECDSA)
+		}
+		for _, j := range r.PartyIDs() {
+			UpdatedSecretECDSA.Add(r.ShareReceived[j])
+		}
+	}
+
+	UpdatedConfig, err := r.updatedConfig(UpdatedSecretECDSA)
+	if err != nil {
+		return r, err
 	}
-	for _, j := range r.PartyIDs() {
-		UpdatedSecretECDSA.Add(r.ShareReceived[j])
+
+	// write new ssid to hash, to bind the Schnorr proof to this new config
+	// Write SSID, selfID to temporary hash
+	h := r.Hash()
+	_ = h.WriteAny(UpdatedConfig, r.SelfID())
+
+	var backup *config.Backup
+	if r.BackupKey != nil && UpdatedSecretECDSA != nil {
+		backup = r.encryptBackup(h.Clone(), UpdatedSecretECDSA, UpdatedConfig.Public[r.SelfID()].ECDSA)
 	}
 
+	var proof *zksch.Response
+	if UpdatedSecretECDSA != nil {
+		proof = r.SchnorrRand.Prove(h, UpdatedConfig.Public[r.SelfID()].ECDSA, UpdatedSecretECDSA, nil)
+	}
+
+	msg := &broadcast5{SchnorrResponse: proof}
+	var backups map[party.ID]*config.Backup
+	if backup != nil {
+		msg.BackupShare, msg.BackupProof = backup.Share, backup.Proof
+		backups = map[party.ID]*config.Backup{r.SelfID(): backup}
+	}
+
+	// send to all
+	err = r.BroadcastMessage(out, msg)
+	if err != nil {
+		return r, err
+	}
+
+	r.UpdateHashState(UpdatedConfig)
+	return &round5{
+		round4:        r,
+		UpdatedConfig: UpdatedConfig,
+		Backups:       backups,
+	}, nil
+}
+
+// encryptBackup encrypts the new secret share under the backup key,
+// and proves that it is the discrete log of the new public share.
+func (r *round4) encryptBackup(h *hash.Hash, secret curve.Scalar, public curve.Point) *config.Backup {
+	prover := r.BackupKey.Paillier()
+	x := curve.MakeInt(secret)
+	share, nonce := prover.Enc(x)
+	proof := zklogstar.NewProof(r.Group(), h, zklogstar.Public{
+		C:      share,
+		X:      public,
+		Prover: prover,
+		Aux:    r.BackupKey.Pedersen,
+	}, zklogstar.Private{
+		X:   x,
+		Rho: nonce,
+	})
+	return &config.Backup{
+		ID:    r.SelfID(),
+		ECDSA: public,
+		Share: share,
+		Proof: proof,
+	}
+}
+
+// updatedConfig returns the Config containing the new secret share, which is nil if we are leaving the committee.
+func (r *round4) updatedConfig(UpdatedSecretECDSA curve.Scalar) (*config.Config, error) {
 	// [F₁(X), …, Fₙ(X)]
 	ShamirPublicPolynomials := make([]*polynomial.Exponent, 0, len(r.VSSPolynomials))
 	for _, VSSPolynomial := range r.VSSPolynomials {
@@ -134,14 +213,19 @@ func (r *round4) Finalize(out chan<- *round.Message) (round.Session, error) {
 	// ShamirPublicPolynomial = F(X) = ∑Fⱼ(X)
 	ShamirPublicPolynomial, err := polynomial.Sum(ShamirPublicPolynomials)
 	if err != nil {
-		return r, err
+		return nil, err
+	}
+
+	// when resharing, F(0) = ∑ⱼ λⱼ⋅X'ⱼ must be the previous public key
+	if r.resharing() && !ShamirPublicPolynomial.Constant().Equal(r.PublicKey) {
+		return nil, errors.New("reshared public key does not match the previous one")
 	}
 
 	// compute the new public key share Xⱼ = F(j) (+X'ⱼ if doing a refresh)
-	PublicData := make(map[party.ID]*config.Public, len(r.PartyIDs()))
-	for _, j := range r.PartyIDs() {
+	PublicData := make(map[party.ID]*config.Public, len(r.Receivers))
+	for _, j := range r.Receivers {
 		PublicECDSAShare := ShamirPublicPolynomial.Evaluate(j.Scalar(r.Group()))
-		if r.PreviousPublicSharesECDSA != nil {
+		if r.PreviousPublicSharesECDSA != nil && !r.resharing() {
 			PublicECDSAShare = PublicECDSAShare.Add(r.PreviousPublicSharesECDSA[j])
 		}
 		PublicData[j] = &config.Public{
@@ -152,7 +236,7 @@ func (r *round4) Finalize(out chan<- *round.Message) (round.Session, error) {
 		}
 	}
 
-	UpdatedConfig := &config.Config{
+	return &config.Config{
 		Group:     r.Group(),
 		ID:        r.SelfID(),
 		Threshold: r.Threshold(),
@@ -162,25 +246,6 @@ func (r *round4) Finalize(out chan<- *round.Message) (round.Session, error) {
 		RID:       r.RID.Copy(),
 		ChainKey:  r.ChainKey.Copy(),
 		Public:    PublicData,
-	}
-
-	// write new ssid to hash, to bind the Schnorr proof to this new config
-	// Write SSID, selfID to temporary hash
-	h := r.Hash()
-	_ = h.WriteAny(UpdatedConfig, r.SelfID())
-
-	proof := r.SchnorrRand.Prove(h, PublicData[r.SelfID()].ECDSA, UpdatedSecretECDSA, nil)
-
-	// send to all
-	err = r.BroadcastMessage(out, &broadcast5{SchnorrResponse: proof})
-	if err != nil {
-		return r, err
-	}
-
-	r.UpdateHashState(UpdatedConfig)
-	return &round5{
-		round4:        r,
-		UpdatedConfig: UpdatedConfig,
 	}, nil
 }
 
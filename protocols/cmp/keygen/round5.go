@@ -4,6 +4,9 @@ import (
 	"errors"
 
 	"github.com/taurusgroup/multi-party-sig/internal/round"
+	"github.com/taurusgroup/multi-party-sig/pkg/paillier"
+	"github.com/taurusgroup/multi-party-sig/pkg/party"
+	zklogstar "github.com/taurusgroup/multi-party-sig/pkg/zk/logstar"
 	sch "github.com/taurusgroup/multi-party-sig/pkg/zk/sch"
 	"github.com/taurusgroup/multi-party-sig/protocols/cmp/config"
 )
@@ -13,17 +16,25 @@ var _ round.Round = (*round5)(nil)
 type round5 struct {
 	*round4
 	UpdatedConfig *config.Config
+
+	// Backups[j] is the verified backup of the new share of party j, and nil if no backup is made.
+	Backups map[party.ID]*config.Backup
 }
 
 type broadcast5 struct {
 	round.NormalBroadcastContent
 	// SchnorrResponse is the Schnorr proof of knowledge of the new secret share
 	SchnorrResponse *sch.Response
+	// BackupShare = Enc(xᵢ) under the backup key, if a backup is made.
+	BackupShare *paillier.Ciphertext
+	// BackupProof proves that BackupShare decrypts to the discrete log of Xᵢ.
+	BackupProof *zklogstar.Proof
 }
 
 // StoreBroadcastMessage implements round.BroadcastRound.
 //
-// - verify all Schnorr proof for the new ecdsa share.
+// - verify all Schnorr proof for the new ecdsa share, of the parties in the new committee.
+// - if making a backup, verify the encryption of the new share under the backup key.
 func (r *round5) StoreBroadcastMessage(msg round.Message) error {
 	from := msg.From
 	body, ok := msg.Content.(*broadcast5)
@@ -31,6 +42,11 @@ func (r *round5) StoreBroadcastMessage(msg round.Message) error {
 		return round.ErrInvalidContent
 	}
 
+	// parties leaving the committee have no share to prove knowledge of
+	if !r.Receivers.Contains(from) {
+		return nil
+	}
+
 	if !body.SchnorrResponse.IsValid() {
 		return round.ErrNilFields
 	}
@@ -40,6 +56,19 @@ func (r *round5) StoreBroadcastMessage(msg round.Message) error {
 		r.SchnorrCommitments[from], nil) {
 		return errors.New("failed to validate schnorr proof for received share")
 	}
+
+	if r.BackupKey != nil {
+		backup := &config.Backup{
+			ID:    from,
+			ECDSA: r.UpdatedConfig.Public[from].ECDSA,
+			Share: body.BackupShare,
+			Proof: body.BackupProof,
+		}
+		if !backup.Verify(r.HashForID(from), r.BackupKey) {
+			return errors.New("failed to validate backup of new share")
+		}
+		r.Backups[from] = backup
+	}
 	return nil
 }
 
@@ -50,7 +79,19 @@ func (round5) VerifyMessage(round.Message) error { return nil }
 func (r *round5) StoreMessage(round.Message) error { return nil }
 
 // Finalize implements round.Round.
+//
+// - if we are leaving the committee, output the public key instead of a Config.
+// - if making a backup, output the backups of all parties along with the Config.
 func (r *round5) Finalize(chan<- *round.Message) (round.Session, error) {
+	if !r.Receivers.Contains(r.SelfID()) {
+		return r.ResultRound(r.PublicKey), nil
+	}
+	if r.BackupKey != nil {
+		return r.ResultRound(&BackupResult{
+			Config:  r.UpdatedConfig,
+			Backups: r.Backups,
+		}), nil
+	}
 	return r.ResultRound(r.UpdatedConfig), nil
 }
 
@@ -62,9 +103,13 @@ func (broadcast5) RoundNumber() round.Number { return 5 }
 
 // BroadcastContent implements round.BroadcastRound.
 func (r *round5) BroadcastContent() round.BroadcastContent {
-	return &broadcast5{
+	body := &broadcast5{
 		SchnorrResponse: sch.EmptyResponse(r.Group()),
 	}
+	if r.BackupKey != nil {
+		body.BackupProof = zklogstar.Empty(r.Group())
+	}
+	return body
 }
 
 // Number implements round.Round.
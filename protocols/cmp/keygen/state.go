@@ -0,0 +1,224 @@
+package keygen
+
+import (
+	"fmt"
+
+	"github.com/cronokirby/safenum"
+	"github.com/taurusgroup/multi-party-sig/internal/round"
+	"github.com/taurusgroup/multi-party-sig/internal/types"
+	"github.com/taurusgroup/multi-party-sig/pkg/hash"
+	"github.com/taurusgroup/multi-party-sig/pkg/math/polynomial"
+	"github.com/taurusgroup/multi-party-sig/pkg/paillier"
+	"github.com/taurusgroup/multi-party-sig/pkg/party"
+	zksch "github.com/taurusgroup/multi-party-sig/pkg/zk/sch"
+	"github.com/taurusgroup/multi-party-sig/protocols/cmp/config"
+)
+
+// These assert that our rounds can be snapshot and restored.
+var (
+	_ round.Restorer    = (*round1)(nil)
+	_ round.Snapshotter = (*round1)(nil)
+	_ round.Snapshotter = (*round2)(nil)
+	_ round.Snapshotter = (*round3)(nil)
+	_ round.Snapshotter = (*round4)(nil)
+	_ round.Snapshotter = (*round5)(nil)
+)
+
+// The previous shares are derived from the arguments of the StartFunc, but fᵢ(X) is sampled by it.
+func (r *round1) putState(s *round.State) {
+	s.PutSession(r.Helper)
+	s.Put("VSSSecret", r.VSSSecret)
+}
+
+func (r *round1) getState(s *round.State) {
+	if s.Has("VSSSecret") {
+		r.VSSSecret = polynomial.EmptyPolynomial(r.Group())
+		s.Get("VSSSecret", r.VSSSecret)
+	}
+}
+
+func (r *round2) putState(s *round.State) {
+	r.round1.putState(s)
+	s.Put("VSSPolynomials", r.VSSPolynomials)
+	s.Put("Commitments", r.Commitments)
+	s.Put("RIDs", r.RIDs)
+	s.Put("ChainKeys", r.ChainKeys)
+	s.Put("ShareReceived", r.ShareReceived)
+	s.Put("ElGamalPublic", r.ElGamalPublic)
+	s.Put("NModulus", r.NModulus)
+	s.Put("S", r.S)
+	s.Put("T", r.T)
+	s.Put("ElGamalSecret", r.ElGamalSecret)
+	s.Put("P", r.PaillierSecret.P())
+	s.Put("Q", r.PaillierSecret.Q())
+	s.Put("PedersenSecret", r.PedersenSecret)
+	s.Put("SchnorrRand", r.SchnorrRand)
+	s.Put("Decommitment", r.Decommitment)
+}
+
+func (r *round2) getState(s *round.State) {
+	r.VSSPolynomials = map[party.ID]*polynomial.Exponent{}
+	s.Map("VSSPolynomials", func(id party.ID) interface{} {
+		r.VSSPolynomials[id] = polynomial.EmptyExponent(r.Group())
+		return r.VSSPolynomials[id]
+	})
+	r.Commitments = map[party.ID]hash.Commitment{}
+	s.Get("Commitments", &r.Commitments)
+	r.RIDs = map[party.ID]types.RID{}
+	s.Get("RIDs", &r.RIDs)
+	r.ChainKeys = map[party.ID]types.RID{}
+	s.Get("ChainKeys", &r.ChainKeys)
+	r.ShareReceived = s.ScalarMap("ShareReceived")
+	r.ElGamalPublic = s.PointMap("ElGamalPublic")
+
+	// the Paillier public keys are recomputed from the moduli, as is done when receiving them.
+	r.NModulus = map[party.ID]*safenum.Modulus{}
+	s.Get("NModulus", &r.NModulus)
+	r.PaillierPublic = make(map[party.ID]*paillier.PublicKey, len(r.NModulus))
+	for id, n := range r.NModulus {
+		r.PaillierPublic[id] = paillier.NewPublicKey(n)
+	}
+	r.S = map[party.ID]*safenum.Nat{}
+	s.Get("S", &r.S)
+	r.T = map[party.ID]*safenum.Nat{}
+	s.Get("T", &r.T)
+
+	r.ElGamalSecret = s.Scalar("ElGamalSecret")
+	p, q := new(safenum.Nat), new(safenum.Nat)
+	s.Get("P", p)
+	s.Get("Q", q)
+	if s.Err() == nil {
+		r.PaillierSecret = paillier.NewSecretKeyFromPrimes(p, q)
+	}
+	r.PedersenSecret = new(safenum.Nat)
+	s.Get("PedersenSecret", r.PedersenSecret)
+	r.SchnorrRand = zksch.EmptyRandomness(r.Group())
+	s.Get("SchnorrRand", r.SchnorrRand)
+	s.Get("Decommitment", &r.Decommitment)
+}
+
+func (r *round3) putState(s *round.State) {
+	r.round2.putState(s)
+	s.Put("SchnorrCommitments", r.SchnorrCommitments)
+}
+
+func (r *round3) getState(s *round.State) {
+	r.SchnorrCommitments = map[party.ID]*zksch.Commitment{}
+	s.Map("SchnorrCommitments", func(id party.ID) interface{} {
+		r.SchnorrCommitments[id] = zksch.EmptyCommitment(r.Group())
+		return r.SchnorrCommitments[id]
+	})
+}
+
+func (r *round4) putState(s *round.State) {
+	r.round3.putState(s)
+	s.Put("RID", r.RID)
+	s.Put("ChainKey", r.ChainKey)
+}
+
+func (r *round4) getState(s *round.State) {
+	s.Get("RID", &r.RID)
+	s.Get("ChainKey", &r.ChainKey)
+}
+
+// Parties leaving the committee hold no share, so their public Config is recomputed when restoring.
+// Our own backup is saved since its encryption is randomized, the others are stored again from the messages.
+func (r *round5) putState(s *round.State) {
+	r.round4.putState(s)
+	if r.UpdatedConfig.ECDSA != nil {
+		s.Put("UpdatedConfig", r.UpdatedConfig)
+	}
+	if r.Backups != nil {
+		s.Put("Backup", r.Backups[r.SelfID()])
+	}
+}
+
+func (r *round5) getState(s *round.State) error {
+	if s.Has("Backup") {
+		backup := config.EmptyBackup(r.Group())
+		s.Get("Backup", backup)
+		r.Backups = map[party.ID]*config.Backup{r.SelfID(): backup}
+	}
+	if !s.Has("UpdatedConfig") {
+		var err error
+		r.UpdatedConfig, err = r.updatedConfig(nil)
+		return err
+	}
+	r.UpdatedConfig = config.EmptyConfig(r.Group())
+	s.Get("UpdatedConfig", r.UpdatedConfig)
+	return nil
+}
+
+type stateRound interface {
+	round.Session
+	putState(s *round.State)
+}
+
+func marshalState(r stateRound) ([]byte, error) {
+	s := round.NewState(r.Group())
+	r.putState(s)
+	return s.MarshalBinary()
+}
+
+// MarshalState implements round.Snapshotter.
+func (r *round1) MarshalState() ([]byte, error) { return marshalState(r) }
+
+// MarshalState implements round.Snapshotter.
+func (r *round2) MarshalState() ([]byte, error) { return marshalState(r) }
+
+// MarshalState implements round.Snapshotter.
+func (r *round3) MarshalState() ([]byte, error) { return marshalState(r) }
+
+// MarshalState implements round.Snapshotter.
+func (r *round4) MarshalState() ([]byte, error) { return marshalState(r) }
+
+// MarshalState implements round.Snapshotter.
+func (r *round5) MarshalState() ([]byte, error) { return marshalState(r) }
+
+// RestoreState implements round.Restorer.
+func (r *round1) RestoreState(number round.Number, data []byte) (round.Session, error) {
+	s, err := round.UnmarshalState(r.Group(), data)
+	if err != nil {
+		return nil, fmt.Errorf("keygen: %w", err)
+	}
+	s.RestoreSession(r.Helper)
+	restored, err := r.restore(s, number)
+	if err != nil {
+		return nil, fmt.Errorf("keygen: %w", err)
+	}
+	if err = s.Err(); err != nil {
+		return nil, fmt.Errorf("keygen: %w", err)
+	}
+	return restored, nil
+}
+
+// restore rebuilds the rounds following r until the given round number.
+func (r *round1) restore(s *round.State, number round.Number) (round.Session, error) {
+	r.getState(s)
+	if number == 1 {
+		return r, nil
+	}
+	r2 := &round2{round1: r}
+	r2.getState(s)
+	if number == 2 {
+		return r2, nil
+	}
+	r3 := &round3{round2: r2}
+	r3.getState(s)
+	if number == 3 {
+		return r3, nil
+	}
+	r4 := &round4{round3: r3}
+	r4.getState(s)
+	if number == 4 {
+		return r4, nil
+	}
+	r5 := &round5{round4: r4}
+	if err := r5.getState(s); err != nil {
+		return nil, err
+	}
+	if number == 5 {
+		return r5, nil
+	}
+	return nil, fmt.Errorf("cannot restore round %d", number)
+}
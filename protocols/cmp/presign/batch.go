@@ -0,0 +1,349 @@
+package presign
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/taurusgroup/multi-party-sig/internal/round"
+	"github.com/taurusgroup/multi-party-sig/pkg/ecdsa"
+	"github.com/taurusgroup/multi-party-sig/pkg/hash"
+	"github.com/taurusgroup/multi-party-sig/pkg/party"
+	"github.com/taurusgroup/multi-party-sig/pkg/pool"
+	"github.com/taurusgroup/multi-party-sig/pkg/protocol"
+	"github.com/taurusgroup/multi-party-sig/protocols/cmp/config"
+)
+
+const protocolBatchID = "cmp/presign-batch"
+
+var (
+	_ round.Round             = (*batch)(nil)
+	_ round.BroadcastRound    = (*batchBroadcast)(nil)
+	_ hash.WriterToWithDomain = batchSize(0)
+)
+
+// StartPresignBatch returns a StartFunc which generates k presignatures in the rounds of a single Presign.
+//
+// Each presignature is produced by an independent execution of the presign protocol with its own session ID,
+// and the messages of all executions are packed together in every round.
+//
+// The executions are run in parallel on pl, but the proofs of a single execution are computed sequentially
+// by the worker running it, since a pool cannot be used from its own workers. The proofs are therefore only
+// spread over the whole pool when k is at least the number of its workers.
+func StartPresignBatch(c *config.Config, signers []party.ID, k int, pl *pool.Pool) protocol.StartFunc {
+	return func(sessionID []byte) (round.Session, error) {
+		if c == nil {
+			return nil, errors.New("presign: config is nil")
+		}
+		if k <= 0 {
+			return nil, fmt.Errorf("presign: invalid batch size %d", k)
+		}
+
+		info := round.Info{
+			ProtocolID:       protocolBatchID,
+			FinalRoundNumber: protocolOfflineRounds,
+			SelfID:           c.ID,
+			PartyIDs:         signers,
+			Threshold:        c.Threshold,
+			Group:            c.Group,
+		}
+		helper, err := round.NewSession(info, sessionID, pl, c, batchSize(k))
+		if err != nil {
+			return nil, fmt.Errorf("presign: %w", err)
+		}
+
+		// the executions are run in parallel on the pool, which cannot be used from its own workers,
+		// so they are started without it, and the proofs of each execution are computed by the worker running it.
+		sessions := make([]round.Session, k)
+		for i := range sessions {
+			h := helper.Hash()
+			_ = h.WriteAny(batchSize(i))
+			sessions[i], err = StartPresign(c, signers, nil, nil)(h.Sum())
+			if err != nil {
+				return nil, err
+			}
+		}
+		return &batch{
+			Helper:   helper,
+			Pool:     pl,
+			Sessions: sessions,
+		}, nil
+	}
+}
+
+// batchSize is the number of presignatures in a batch, or the index of an execution in it.
+type batchSize uint32
+
+// WriteTo implements io.WriterTo.
+func (b batchSize) WriteTo(w io.Writer) (int64, error) {
+	var buf [4]byte
+	binary.BigEndian.PutUint32(buf[:], uint32(b))
+	n, err := w.Write(buf[:])
+	return int64(n), err
+}
+
+// Domain implements hash.WriterToWithDomain.
+func (batchSize) Domain() string { return "Presign Batch" }
+
+// batch runs the same round of several presign executions.
+type batch struct {
+	*round.Helper
+
+	// Pool is used to verify, store and finalize the messages of the executions in parallel.
+	Pool *pool.Pool
+
+	// Sessions[i] is the current round of the i-th execution.
+	// Executions which have terminated are kept as a round.Output.
+	Sessions []round.Session
+}
+
+// batchBroadcast is a batch whose executions expect a broadcast message in this round.
+type batchBroadcast struct {
+	*batch
+}
+
+// batchMessage packs the messages of all executions sent to the same party, or broadcast.
+type batchMessage struct {
+	// Contents[i] is the encoded content sent by the i-th execution, which is nil if it sent nothing.
+	Contents [][]byte
+
+	number   round.Number
+	reliable bool
+}
+
+// RoundNumber implements round.Content.
+func (m *batchMessage) RoundNumber() round.Number { return m.number }
+
+// Reliable implements round.BroadcastContent.
+func (m *batchMessage) Reliable() bool { return m.reliable }
+
+// unpack decodes the content of the i-th execution into content, which is returned.
+func (m *batchMessage) unpack(i int, content round.Content) (round.Content, error) {
+	if i >= len(m.Contents) || m.Contents[i] == nil {
+		return nil, round.ErrNilFields
+	}
+	if err := cbor.Unmarshal(m.Contents[i], content); err != nil {
+		return nil, err
+	}
+	return content, nil
+}
+
+// active returns true if the i-th execution has not terminated.
+func (r *batch) active(i int) bool {
+	_, ok := r.Sessions[i].(*round.Output)
+	return !ok
+}
+
+// parallelize calls f for all active executions in parallel on the pool, and returns the first error.
+func (r *batch) parallelize(f func(i int, s round.Session) error) error {
+	errs := r.Pool.Parallelize(len(r.Sessions), func(i int) interface{} {
+		if !r.active(i) {
+			return nil
+		}
+		if err := f(i, r.Sessions[i]); err != nil {
+			return fmt.Errorf("presignature %d: %w", i, err)
+		}
+		return nil
+	})
+	for _, err := range errs {
+		if err != nil {
+			return err.(error)
+		}
+	}
+	return nil
+}
+
+// StoreBroadcastMessage implements round.BroadcastRound.
+func (r *batchBroadcast) StoreBroadcastMessage(msg round.Message) error {
+	body, ok := msg.Content.(*batchMessage)
+	if !ok || body == nil || len(body.Contents) != len(r.Sessions) {
+		return round.ErrInvalidContent
+	}
+	return r.parallelize(func(i int, s round.Session) error {
+		b, ok := s.(round.BroadcastRound)
+		if !ok {
+			return errors.New("got broadcast message when none was expected")
+		}
+		content, err := body.unpack(i, b.BroadcastContent())
+		if err != nil {
+			return err
+		}
+		return b.StoreBroadcastMessage(round.Message{From: msg.From, To: msg.To, Broadcast: true, Content: content})
+	})
+}
+
+// VerifyMessage implements round.Round.
+func (r *batch) VerifyMessage(msg round.Message) error {
+	body, ok := msg.Content.(*batchMessage)
+	if !ok || body == nil || len(body.Contents) != len(r.Sessions) {
+		return round.ErrInvalidContent
+	}
+	return r.parallelize(func(i int, s round.Session) error {
+		content, err := body.unpack(i, s.MessageContent())
+		if err != nil {
+			return err
+		}
+		return s.VerifyMessage(round.Message{From: msg.From, To: msg.To, Content: content})
+	})
+}
+
+// StoreMessage implements round.Round.
+func (r *batch) StoreMessage(msg round.Message) error {
+	body := msg.Content.(*batchMessage)
+	return r.parallelize(func(i int, s round.Session) error {
+		content, err := body.unpack(i, s.MessageContent())
+		if err != nil {
+			return err
+		}
+		return s.StoreMessage(round.Message{From: msg.From, To: msg.To, Content: content})
+	})
+}
+
+// Finalize implements round.Round
+//
+// - finalize all executions in parallel
+// - abort if any of them aborted
+// - pack the messages of all executions per receiver.
+func (r *batch) Finalize(out chan<- *round.Message) (round.Session, error) {
+	type finalized struct {
+		session round.Session
+		msgs    []*round.Message
+		err     error
+	}
+	results := r.Pool.Parallelize(len(r.Sessions), func(i int) interface{} {
+		if !r.active(i) {
+			return finalized{session: r.Sessions[i]}
+		}
+		innerOut := make(chan *round.Message, 2*r.N())
+		next, err := r.Sessions[i].Finalize(innerOut)
+		close(innerOut)
+		result := finalized{session: next, err: err}
+		for msg := range innerOut {
+			result.msgs = append(result.msgs, msg)
+		}
+		return result
+	})
+
+	sessions := make([]round.Session, len(r.Sessions))
+	for i, raw := range results {
+		result := raw.(finalized)
+		if result.err != nil {
+			return r, fmt.Errorf("presignature %d: %w", i, result.err)
+		}
+		if abort, ok := result.session.(*round.Abort); ok {
+			return r.AbortRound(fmt.Errorf("presignature %d: %w", i, abort.Err), abort.Culprits...), nil
+		}
+		sessions[i] = result.session
+	}
+	next := &batch{
+		Helper:   r.Helper,
+		Pool:     r.Pool,
+		Sessions: sessions,
+	}
+
+	var number round.Number
+	done := true
+	for i, s := range sessions {
+		if !next.active(i) {
+			continue
+		}
+		if done {
+			number, done = s.Number(), false
+		} else if s.Number() != number {
+			return r, errors.New("presign: executions are in different rounds")
+		}
+	}
+	if done {
+		preSignatures := make([]*ecdsa.PreSignature, len(sessions))
+		for i, s := range sessions {
+			preSignature, ok := s.(*round.Output).Result.(*ecdsa.PreSignature)
+			if !ok {
+				return r, fmt.Errorf("presignature %d: unexpected result", i)
+			}
+			preSignatures[i] = preSignature
+		}
+		return r.ResultRound(preSignatures), nil
+	}
+
+	// pack the messages
+	var broadcast *batchMessage
+	direct := make(map[party.ID]*batchMessage, r.N()-1)
+	for i, raw := range results {
+		for _, msg := range raw.(finalized).msgs {
+			data, err := cbor.Marshal(msg.Content)
+			if err != nil {
+				return r, err
+			}
+			var packed *batchMessage
+			if msg.Broadcast {
+				if broadcast == nil {
+					content, _ := msg.Content.(round.BroadcastContent)
+					broadcast = &batchMessage{
+						Contents: make([][]byte, len(sessions)),
+						number:   number,
+						reliable: content != nil && content.Reliable(),
+					}
+				}
+				packed = broadcast
+			} else {
+				if direct[msg.To] == nil {
+					direct[msg.To] = &batchMessage{Contents: make([][]byte, len(sessions)), number: number}
+				}
+				packed = direct[msg.To]
+			}
+			packed.Contents[i] = data
+		}
+	}
+	if broadcast != nil {
+		if err := r.BroadcastMessage(out, broadcast); err != nil {
+			return r, err
+		}
+	}
+	for _, j := range r.OtherPartyIDs() {
+		if direct[j] == nil {
+			continue
+		}
+		if err := r.SendMessage(out, direct[j], j); err != nil {
+			return r, err
+		}
+	}
+
+	if _, ok := next.firstActive().(round.BroadcastRound); ok {
+		return &batchBroadcast{batch: next}, nil
+	}
+	return next, nil
+}
+
+// firstActive returns the current round of the first execution which has not terminated.
+func (r *batch) firstActive() round.Session {
+	for i, s := range r.Sessions {
+		if r.active(i) {
+			return s
+		}
+	}
+	return nil
+}
+
+// MessageContent implements round.Round.
+func (r *batch) MessageContent() round.Content {
+	for i, s := range r.Sessions {
+		if r.active(i) && s.MessageContent() != nil {
+			return &batchMessage{number: r.Number()}
+		}
+	}
+	return nil
+}
+
+// BroadcastContent implements round.BroadcastRound.
+func (r *batchBroadcast) BroadcastContent() round.BroadcastContent {
+	content := r.firstActive().(round.BroadcastRound).BroadcastContent()
+	if content == nil {
+		return nil
+	}
+	return &batchMessage{number: r.Number(), reliable: content.Reliable()}
+}
+
+// Number implements round.Round.
+func (r *batch) Number() round.Number { return r.firstActive().Number() }
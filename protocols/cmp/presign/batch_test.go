@@ -0,0 +1,58 @@
+package presign
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/taurusgroup/multi-party-sig/internal/round"
+	"github.com/taurusgroup/multi-party-sig/internal/test"
+	"github.com/taurusgroup/multi-party-sig/pkg/ecdsa"
+	"github.com/taurusgroup/multi-party-sig/pkg/party"
+	"github.com/taurusgroup/multi-party-sig/pkg/pool"
+)
+
+func TestBatch(t *testing.T) {
+	k := 3
+	rounds := make([]round.Session, 0, N)
+	for _, c := range configs {
+		pl := pool.NewPool(1)
+		defer pl.TearDown()
+		r, err := StartPresignBatch(c, partyIDs, k, pl)(nil)
+		require.NoError(t, err, "round creation should not result in an error")
+		rounds = append(rounds, r)
+	}
+
+	for {
+		err, done := test.Rounds(rounds, nil)
+		require.NoError(t, err, "failed to process round")
+		if done {
+			break
+		}
+	}
+
+	batches := make(map[party.ID][]*ecdsa.PreSignature, N)
+	for _, r := range rounds {
+		require.IsType(t, &round.Output{}, r)
+		preSignatures, ok := r.(*round.Output).Result.([]*ecdsa.PreSignature)
+		require.True(t, ok, "result should be []*ecdsa.PreSignature")
+		require.Len(t, preSignatures, k)
+		batches[r.SelfID()] = preSignatures
+	}
+
+	for i := 0; i < k; i++ {
+		shares := make(map[party.ID]ecdsa.SignatureShare, N)
+		var preSignature *ecdsa.PreSignature
+		for id, preSignatures := range batches {
+			preSignature = preSignatures[i]
+			require.NoError(t, preSignature.Validate())
+			assert.Equal(t, batches[partyIDs[0]][i].ID, preSignature.ID)
+			if i > 0 {
+				assert.NotEqual(t, preSignatures[0].ID, preSignature.ID)
+			}
+			shares[id] = preSignature.SignatureShare(messageHash)
+		}
+		signature := preSignature.Signature(shares)
+		assert.True(t, signature.Verify(configs[partyIDs[0]].PublicPoint(), messageHash))
+	}
+}
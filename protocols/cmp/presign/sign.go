@@ -50,6 +50,9 @@ func StartPresign(c *config.Config, signers []party.ID, message []byte, pl *pool
 		if err != nil {
 			return nil, fmt.Errorf("sign.Create: %w", err)
 		}
+		if !curve.SupportsECDSA(helper.Group()) {
+			return nil, fmt.Errorf("sign.Create: ECDSA is not supported over %s", helper.Group().Name())
+		}
 
 		if !c.CanSign(helper.PartyIDs()) {
 			return nil, errors.New("sign.Create: signers is not a valid signing subset")
@@ -134,6 +137,9 @@ func StartPresignOnline(c *config.Config, preSignature *ecdsa.PreSignature, mess
 		if err != nil {
 			return nil, fmt.Errorf("sign.Create: %w", err)
 		}
+		if !curve.SupportsECDSA(helper.Group()) {
+			return nil, fmt.Errorf("sign.Create: ECDSA is not supported over %s", helper.Group().Name())
+		}
 
 		return &sign1{
 			Helper:       helper,
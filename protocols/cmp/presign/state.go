@@ -0,0 +1,370 @@
+package presign
+
+import (
+	"fmt"
+
+	"github.com/cronokirby/safenum"
+	"github.com/taurusgroup/multi-party-sig/internal/elgamal"
+	"github.com/taurusgroup/multi-party-sig/internal/round"
+	"github.com/taurusgroup/multi-party-sig/internal/types"
+	"github.com/taurusgroup/multi-party-sig/pkg/ecdsa"
+	"github.com/taurusgroup/multi-party-sig/pkg/hash"
+	"github.com/taurusgroup/multi-party-sig/pkg/math/curve"
+	"github.com/taurusgroup/multi-party-sig/pkg/paillier"
+	"github.com/taurusgroup/multi-party-sig/pkg/party"
+)
+
+// These assert that our rounds can be snapshot and restored.
+var (
+	_ round.Restorer    = (*presign1)(nil)
+	_ round.Restorer    = (*sign1)(nil)
+	_ round.Snapshotter = (*presign1)(nil)
+	_ round.Snapshotter = (*presign2)(nil)
+	_ round.Snapshotter = (*presign3)(nil)
+	_ round.Snapshotter = (*presign4)(nil)
+	_ round.Snapshotter = (*presign5)(nil)
+	_ round.Snapshotter = (*presign6)(nil)
+	_ round.Snapshotter = (*presign7)(nil)
+	_ round.Snapshotter = (*abort1)(nil)
+	_ round.Snapshotter = (*abort2)(nil)
+	_ round.Snapshotter = (*sign1)(nil)
+	_ round.Snapshotter = (*sign2)(nil)
+)
+
+// The fields of presign1 are all derived from the arguments of the StartFunc, so only the session is stored.
+func (r *presign1) putState(s *round.State) { s.PutSession(r.Helper) }
+
+func (r *presign2) putState(s *round.State) {
+	r.presign1.putState(s)
+	s.Put("K", r.K)
+	s.Put("G", r.G)
+	s.Put("GammaShare", r.GammaShare)
+	s.Put("KShare", r.KShare)
+	s.Put("KNonce", r.KNonce)
+	s.Put("GNonce", r.GNonce)
+	s.Put("ElGamalKNonce", r.ElGamalKNonce)
+	s.Put("ElGamalK", r.ElGamalK)
+	s.Put("PresignatureID", r.PresignatureID)
+	s.Put("CommitmentID", r.CommitmentID)
+	s.Put("DecommitmentID", r.DecommitmentID)
+}
+
+func (r *presign2) getState(s *round.State) {
+	r.K = map[party.ID]*paillier.Ciphertext{}
+	s.Get("K", &r.K)
+	r.G = map[party.ID]*paillier.Ciphertext{}
+	s.Get("G", &r.G)
+	r.GammaShare = new(safenum.Int)
+	s.Get("GammaShare", r.GammaShare)
+	r.KShare = s.Scalar("KShare")
+	r.KNonce = new(safenum.Nat)
+	s.Get("KNonce", r.KNonce)
+	r.GNonce = new(safenum.Nat)
+	s.Get("GNonce", r.GNonce)
+	r.ElGamalKNonce = s.Scalar("ElGamalKNonce")
+	r.ElGamalK = map[party.ID]*elgamal.Ciphertext{}
+	s.Map("ElGamalK", func(id party.ID) interface{} {
+		r.ElGamalK[id] = elgamal.Empty(r.Group())
+		return r.ElGamalK[id]
+	})
+	r.PresignatureID = map[party.ID]types.RID{}
+	s.Get("PresignatureID", &r.PresignatureID)
+	r.CommitmentID = map[party.ID]hash.Commitment{}
+	s.Get("CommitmentID", &r.CommitmentID)
+	s.Get("DecommitmentID", &r.DecommitmentID)
+}
+
+func (r *presign3) putState(s *round.State) {
+	r.presign2.putState(s)
+	s.Put("DeltaShareBeta", r.DeltaShareBeta)
+	s.Put("ChiShareBeta", r.ChiShareBeta)
+	s.Put("DeltaCiphertext", r.DeltaCiphertext)
+	s.Put("ChiCiphertext", r.ChiCiphertext)
+}
+
+func (r *presign3) getState(s *round.State) {
+	r.DeltaShareBeta = map[party.ID]*safenum.Int{}
+	s.Get("DeltaShareBeta", &r.DeltaShareBeta)
+	r.ChiShareBeta = map[party.ID]*safenum.Int{}
+	s.Get("ChiShareBeta", &r.ChiShareBeta)
+	r.DeltaCiphertext = map[party.ID]map[party.ID]*paillier.Ciphertext{}
+	s.Get("DeltaCiphertext", &r.DeltaCiphertext)
+	r.ChiCiphertext = map[party.ID]map[party.ID]*paillier.Ciphertext{}
+	s.Get("ChiCiphertext", &r.ChiCiphertext)
+}
+
+func (r *presign4) putState(s *round.State) {
+	r.presign3.putState(s)
+	s.Put("DeltaShareAlpha", r.DeltaShareAlpha)
+	s.Put("ChiShareAlpha", r.ChiShareAlpha)
+	s.Put("ElGamalChiNonce", r.ElGamalChiNonce)
+	s.Put("ElGamalChi", r.ElGamalChi)
+	s.Put("DeltaShares", r.DeltaShares)
+	s.Put("ChiShare", r.ChiShare)
+}
+
+func (r *presign4) getState(s *round.State) {
+	r.DeltaShareAlpha = map[party.ID]*safenum.Int{}
+	s.Get("DeltaShareAlpha", &r.DeltaShareAlpha)
+	r.ChiShareAlpha = map[party.ID]*safenum.Int{}
+	s.Get("ChiShareAlpha", &r.ChiShareAlpha)
+	r.ElGamalChiNonce = s.Scalar("ElGamalChiNonce")
+	r.ElGamalChi = map[party.ID]*elgamal.Ciphertext{}
+	s.Map("ElGamalChi", func(id party.ID) interface{} {
+		r.ElGamalChi[id] = elgamal.Empty(r.Group())
+		return r.ElGamalChi[id]
+	})
+	r.DeltaShares = s.ScalarMap("DeltaShares")
+	r.ChiShare = s.Scalar("ChiShare")
+}
+
+func (r *presign5) putState(s *round.State) {
+	r.presign4.putState(s)
+	s.Put("BigGammaShare", r.BigGammaShare)
+}
+
+func (r *presign5) getState(s *round.State) {
+	r.BigGammaShare = s.PointMap("BigGammaShare")
+}
+
+func (r *presign6) putState(s *round.State) {
+	r.presign5.putState(s)
+	s.Put("BigDeltaShares", r.BigDeltaShares)
+	s.Put("Gamma", r.Gamma)
+}
+
+func (r *presign6) getState(s *round.State) {
+	r.BigDeltaShares = s.PointMap("BigDeltaShares")
+	r.Gamma = s.Point("Gamma")
+}
+
+func (r *presign7) putState(s *round.State) {
+	r.presign6.putState(s)
+	s.Put("Delta", r.Delta)
+	s.Put("S", r.S)
+	s.Put("R", r.R)
+	s.Put("RBar", r.RBar)
+}
+
+func (r *presign7) getState(s *round.State) {
+	r.Delta = s.Scalar("Delta")
+	r.S = s.PointMap("S")
+	r.R = s.Point("R")
+	r.RBar = s.PointMap("RBar")
+}
+
+func (r *abort1) putState(s *round.State) {
+	r.presign6.putState(s)
+	s.Put("GammaShares", r.GammaShares)
+	s.Put("KShares", r.KShares)
+	s.Put("DeltaAlphas", r.DeltaAlphas)
+}
+
+func (r *abort1) getState(s *round.State) {
+	r.GammaShares = map[party.ID]*safenum.Int{}
+	s.Get("GammaShares", &r.GammaShares)
+	r.KShares = map[party.ID]*safenum.Int{}
+	s.Get("KShares", &r.KShares)
+	r.DeltaAlphas = map[party.ID]map[party.ID]*safenum.Int{}
+	s.Get("DeltaAlphas", &r.DeltaAlphas)
+}
+
+func (r *abort2) putState(s *round.State) {
+	r.presign7.putState(s)
+	s.Put("YHat", r.YHat)
+	s.Put("KShares", r.KShares)
+	s.Put("ChiAlphas", r.ChiAlphas)
+}
+
+func (r *abort2) getState(s *round.State) {
+	r.YHat = s.PointMap("YHat")
+	r.KShares = s.ScalarMap("KShares")
+
+	// the scalars are decoded from their binary encoding, since they must be created by the group.
+	var chiAlphas map[party.ID]map[party.ID][]byte
+	s.Get("ChiAlphas", &chiAlphas)
+	r.ChiAlphas = make(map[party.ID]map[party.ID]curve.Scalar, len(chiAlphas))
+	for j, alphas := range chiAlphas {
+		r.ChiAlphas[j] = make(map[party.ID]curve.Scalar, len(alphas))
+		for k, data := range alphas {
+			alpha := r.Group().NewScalar()
+			if err := alpha.UnmarshalBinary(data); err != nil {
+				s.Fail(fmt.Errorf("state: ChiAlphas: %w", err))
+				return
+			}
+			r.ChiAlphas[j][k] = alpha
+		}
+	}
+}
+
+// sign1 is also created at the end of presign7, so its presignature is stored instead of being rederived.
+func (r *sign1) putState(s *round.State) {
+	s.PutSession(r.Helper)
+	s.Put("PreSignature", r.PreSignature)
+}
+
+func (r *sign1) getState(s *round.State) {
+	r.PreSignature = ecdsa.EmptyPreSignature(r.Group())
+	s.Get("PreSignature", r.PreSignature)
+}
+
+func (r *sign2) putState(s *round.State) {
+	r.sign1.putState(s)
+	s.Put("SigmaShares", r.SigmaShares)
+}
+
+func (r *sign2) getState(s *round.State) {
+	r.SigmaShares = s.ScalarMap("SigmaShares")
+}
+
+type stateRound interface {
+	round.Session
+	putState(s *round.State)
+}
+
+func marshalState(r stateRound) ([]byte, error) {
+	s := round.NewState(r.Group())
+	r.putState(s)
+	return s.MarshalBinary()
+}
+
+// MarshalState implements round.Snapshotter.
+func (r *presign1) MarshalState() ([]byte, error) { return marshalState(r) }
+
+// MarshalState implements round.Snapshotter.
+func (r *presign2) MarshalState() ([]byte, error) { return marshalState(r) }
+
+// MarshalState implements round.Snapshotter.
+func (r *presign3) MarshalState() ([]byte, error) { return marshalState(r) }
+
+// MarshalState implements round.Snapshotter.
+func (r *presign4) MarshalState() ([]byte, error) { return marshalState(r) }
+
+// MarshalState implements round.Snapshotter.
+func (r *presign5) MarshalState() ([]byte, error) { return marshalState(r) }
+
+// MarshalState implements round.Snapshotter.
+func (r *presign6) MarshalState() ([]byte, error) { return marshalState(r) }
+
+// MarshalState implements round.Snapshotter.
+func (r *presign7) MarshalState() ([]byte, error) { return marshalState(r) }
+
+// MarshalState implements round.Snapshotter.
+func (r *abort1) MarshalState() ([]byte, error) { return marshalState(r) }
+
+// MarshalState implements round.Snapshotter.
+func (r *abort2) MarshalState() ([]byte, error) { return marshalState(r) }
+
+// MarshalState implements round.Snapshotter.
+func (r *sign1) MarshalState() ([]byte, error) { return marshalState(r) }
+
+// MarshalState implements round.Snapshotter.
+func (r *sign2) MarshalState() ([]byte, error) { return marshalState(r) }
+
+type restorer interface {
+	round.Session
+	restore(s *round.State, number round.Number) (round.Session, error)
+}
+
+func restoreState(r restorer, number round.Number, data []byte) (round.Session, error) {
+	s, err := round.UnmarshalState(r.Group(), data)
+	if err != nil {
+		return nil, fmt.Errorf("presign: %w", err)
+	}
+	restored, err := r.restore(s, number)
+	if err != nil {
+		return nil, fmt.Errorf("presign: %w", err)
+	}
+	if err = s.Err(); err != nil {
+		return nil, fmt.Errorf("presign: %w", err)
+	}
+	return restored, nil
+}
+
+// RestoreState implements round.Restorer.
+func (r *presign1) RestoreState(number round.Number, data []byte) (round.Session, error) {
+	return restoreState(r, number, data)
+}
+
+// RestoreState implements round.Restorer.
+func (r *sign1) RestoreState(number round.Number, data []byte) (round.Session, error) {
+	return restoreState(r, number, data)
+}
+
+// restore rebuilds the rounds following r until the given round number.
+//
+// Rounds 7 and 8 are ambiguous, since the abort rounds and the signing round share their numbers.
+// They are told apart by the fields which only they store.
+func (r *presign1) restore(s *round.State, number round.Number) (round.Session, error) {
+	s.RestoreSession(r.Helper)
+	if number == 1 {
+		return r, nil
+	}
+	if number == 8 && s.Has("SigmaShares") {
+		r1 := &sign1{
+			Helper:    r.Helper,
+			PublicKey: r.PublicKey,
+			Message:   r.Message,
+		}
+		r1.getState(s)
+		r2 := &sign2{sign1: r1}
+		r2.getState(s)
+		return r2, nil
+	}
+	r2 := &presign2{presign1: r}
+	r2.getState(s)
+	if number == 2 {
+		return r2, nil
+	}
+	r3 := &presign3{presign2: r2}
+	r3.getState(s)
+	if number == 3 {
+		return r3, nil
+	}
+	r4 := &presign4{presign3: r3}
+	r4.getState(s)
+	if number == 4 {
+		return r4, nil
+	}
+	r5 := &presign5{presign4: r4}
+	r5.getState(s)
+	if number == 5 {
+		return r5, nil
+	}
+	r6 := &presign6{presign5: r5}
+	r6.getState(s)
+	if number == 6 {
+		return r6, nil
+	}
+	if number == 7 && s.Has("GammaShares") {
+		a1 := &abort1{presign6: r6}
+		a1.getState(s)
+		return a1, nil
+	}
+	r7 := &presign7{presign6: r6}
+	r7.getState(s)
+	if number == 7 {
+		return r7, nil
+	}
+	if number == 8 {
+		a2 := &abort2{presign7: r7}
+		a2.getState(s)
+		return a2, nil
+	}
+	return nil, fmt.Errorf("cannot restore round %d", number)
+}
+
+// restore rebuilds the rounds following r until the given round number.
+func (r *sign1) restore(s *round.State, number round.Number) (round.Session, error) {
+	s.RestoreSession(r.Helper)
+	if number == 1 {
+		return r, nil
+	}
+	r.getState(s)
+	r2 := &sign2{sign1: r}
+	r2.getState(s)
+	if number == 8 {
+		return r2, nil
+	}
+	return nil, fmt.Errorf("cannot restore round %d", number)
+}
@@ -0,0 +1,372 @@
+package cmp
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/taurusgroup/multi-party-sig/pkg/ecdsa"
+	"github.com/taurusgroup/multi-party-sig/pkg/math/curve"
+	"github.com/taurusgroup/multi-party-sig/pkg/party"
+)
+
+var (
+	// ErrPresignatureNotFound is returned when no unused presignature is available.
+	ErrPresignatureNotFound = errors.New("presign store: presignature not found")
+	// ErrPresignatureConsumed is returned when a presignature was already used, or is being stored again.
+	ErrPresignatureConsumed = errors.New("presign store: presignature already consumed")
+)
+
+// PresignStore holds the presignatures generated by Presign until they are used by PresignOnline.
+//
+// A presignature must never be used for two different messages, since this leaks the secret key.
+// Implementations therefore mark a presignature as consumed before returning it,
+// and refuse to store or return it again.
+type PresignStore interface {
+	// Put stores an unused presignature.
+	Put(preSignature *ecdsa.PreSignature) error
+	// Take returns an unused presignature for the given signers, and marks it as consumed.
+	Take(signers []party.ID) (*ecdsa.PreSignature, error)
+	// Consume returns the presignature with the given ID, and marks it as consumed.
+	// All signers should consume the same presignature, for example the one taken by a coordinator.
+	Consume(id []byte) (*ecdsa.PreSignature, error)
+	// Count returns the number of unused presignatures for the given signers.
+	Count(signers []party.ID) (int, error)
+}
+
+// signersKey returns the key under which presignatures for a set of signers are indexed.
+func signersKey(signers []party.ID) string {
+	return party.NewIDSlice(signers).String()
+}
+
+// memoryPresignStore is a PresignStore which keeps presignatures in memory.
+type memoryPresignStore struct {
+	mtx sync.Mutex
+	// unused[key][id] is an unused presignature for the signers with the given key.
+	unused map[string]map[string]*ecdsa.PreSignature
+	// consumed contains the IDs of all presignatures which were returned.
+	consumed map[string]bool
+}
+
+// NewMemoryPresignStore returns a PresignStore which keeps presignatures in memory.
+// Presignatures are lost when the process exits, which also prevents their reuse.
+func NewMemoryPresignStore() PresignStore {
+	return &memoryPresignStore{
+		unused:   map[string]map[string]*ecdsa.PreSignature{},
+		consumed: map[string]bool{},
+	}
+}
+
+// Put implements PresignStore.
+func (s *memoryPresignStore) Put(preSignature *ecdsa.PreSignature) error {
+	if err := preSignature.Validate(); err != nil {
+		return err
+	}
+	id, key := string(preSignature.ID), signersKey(preSignature.SignerIDs())
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if s.consumed[id] {
+		return ErrPresignatureConsumed
+	}
+	if s.unused[key] == nil {
+		s.unused[key] = map[string]*ecdsa.PreSignature{}
+	}
+	s.unused[key][id] = preSignature
+	return nil
+}
+
+// Take implements PresignStore.
+func (s *memoryPresignStore) Take(signers []party.ID) (*ecdsa.PreSignature, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	for id, preSignature := range s.unused[signersKey(signers)] {
+		return s.consume(id, preSignature), nil
+	}
+	return nil, ErrPresignatureNotFound
+}
+
+// Consume implements PresignStore.
+func (s *memoryPresignStore) Consume(id []byte) (*ecdsa.PreSignature, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if s.consumed[string(id)] {
+		return nil, ErrPresignatureConsumed
+	}
+	for _, unused := range s.unused {
+		if preSignature, ok := unused[string(id)]; ok {
+			return s.consume(string(id), preSignature), nil
+		}
+	}
+	return nil, ErrPresignatureNotFound
+}
+
+// consume marks the presignature as consumed. The caller must hold s.mtx.
+func (s *memoryPresignStore) consume(id string, preSignature *ecdsa.PreSignature) *ecdsa.PreSignature {
+	s.consumed[id] = true
+	delete(s.unused[signersKey(preSignature.SignerIDs())], id)
+	return preSignature
+}
+
+// Count implements PresignStore.
+func (s *memoryPresignStore) Count(signers []party.ID) (int, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return len(s.unused[signersKey(signers)]), nil
+}
+
+const (
+	presignatureExtension = ".presig"
+	consumedExtension     = ".consumed"
+)
+
+// filePresignStore is a PresignStore which keeps each presignature in a separate file of a directory.
+//
+// A presignature is consumed by atomically renaming its file, after which its content is erased.
+// The renamed file is kept, so that the presignature cannot be stored again.
+type filePresignStore struct {
+	mtx   sync.Mutex
+	group curve.Curve
+	dir   string
+	// index[key] contains the IDs of the unused presignatures for the signers with the given key.
+	index map[string]map[string]bool
+}
+
+// NewFilePresignStore returns a PresignStore which keeps presignatures in dir, which is created if necessary.
+// The presignatures already in dir are indexed, and those consumed before are never returned again.
+//
+// The files contain secret key material, and dir should only be accessible to this party.
+func NewFilePresignStore(group curve.Curve, dir string) (PresignStore, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("presign store: %w", err)
+	}
+	s := &filePresignStore{
+		group: group,
+		dir:   dir,
+		index: map[string]map[string]bool{},
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("presign store: %w", err)
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasSuffix(name, presignatureExtension) {
+			continue
+		}
+		preSignature, err := s.read(filepath.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+		s.add(preSignature)
+	}
+	return s, nil
+}
+
+func (s *filePresignStore) path(id []byte, extension string) string {
+	return filepath.Join(s.dir, hex.EncodeToString(id)+extension)
+}
+
+func (s *filePresignStore) read(path string) (*ecdsa.PreSignature, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("presign store: %w", err)
+	}
+	preSignature := ecdsa.EmptyPreSignature(s.group)
+	if err = cbor.Unmarshal(data, preSignature); err != nil {
+		return nil, fmt.Errorf("presign store: %s: %w", filepath.Base(path), err)
+	}
+	return preSignature, nil
+}
+
+// add indexes an unused presignature. The caller must hold s.mtx.
+func (s *filePresignStore) add(preSignature *ecdsa.PreSignature) {
+	key := signersKey(preSignature.SignerIDs())
+	if s.index[key] == nil {
+		s.index[key] = map[string]bool{}
+	}
+	s.index[key][string(preSignature.ID)] = true
+}
+
+// Put implements PresignStore.
+func (s *filePresignStore) Put(preSignature *ecdsa.PreSignature) error {
+	if err := preSignature.Validate(); err != nil {
+		return err
+	}
+	data, err := cbor.Marshal(preSignature)
+	if err != nil {
+		return fmt.Errorf("presign store: %w", err)
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if _, err = os.Stat(s.path(preSignature.ID, consumedExtension)); err == nil {
+		return ErrPresignatureConsumed
+	}
+
+	// write to a temporary file first, so that only complete presignatures are indexed on restart
+	tmp, err := os.CreateTemp(s.dir, "tmp-*")
+	if err != nil {
+		return fmt.Errorf("presign store: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err = tmp.Write(data); err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), s.path(preSignature.ID, presignatureExtension))
+	}
+	if err == nil {
+		err = s.syncDir()
+	}
+	if err != nil {
+		return fmt.Errorf("presign store: %w", err)
+	}
+	s.add(preSignature)
+	return nil
+}
+
+// Take implements PresignStore.
+func (s *filePresignStore) Take(signers []party.ID) (*ecdsa.PreSignature, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	for id := range s.index[signersKey(signers)] {
+		preSignature, err := s.consume([]byte(id))
+		if errors.Is(err, ErrPresignatureNotFound) {
+			// consumed by another process sharing the directory
+			continue
+		}
+		return preSignature, err
+	}
+	return nil, ErrPresignatureNotFound
+}
+
+// Consume implements PresignStore.
+func (s *filePresignStore) Consume(id []byte) (*ecdsa.PreSignature, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if _, err := os.Stat(s.path(id, consumedExtension)); err == nil {
+		return nil, ErrPresignatureConsumed
+	}
+	return s.consume(id)
+}
+
+// consume claims the presignature by renaming its file, reads it, and erases the file's content.
+// The caller must hold s.mtx.
+func (s *filePresignStore) consume(id []byte) (*ecdsa.PreSignature, error) {
+	for _, ids := range s.index {
+		delete(ids, string(id))
+	}
+	consumedPath := s.path(id, consumedExtension)
+	if err := os.Rename(s.path(id, presignatureExtension), consumedPath); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, ErrPresignatureNotFound
+		}
+		return nil, fmt.Errorf("presign store: %w", err)
+	}
+	// the rename must be durable before the presignature is used, or it could be used again after a crash
+	if err := s.syncDir(); err != nil {
+		return nil, fmt.Errorf("presign store: %w", err)
+	}
+	preSignature, err := s.read(consumedPath)
+	if truncateErr := os.Truncate(consumedPath, 0); err == nil && truncateErr != nil {
+		err = fmt.Errorf("presign store: %w", truncateErr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return preSignature, nil
+}
+
+// syncDir flushes the directory entries of s.dir to disk, so that a previous rename survives a crash.
+func (s *filePresignStore) syncDir() error {
+	dir, err := os.Open(s.dir)
+	if err != nil {
+		return err
+	}
+	err = dir.Sync()
+	if closeErr := dir.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// Count implements PresignStore.
+func (s *filePresignStore) Count(signers []party.ID) (int, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return len(s.index[signersKey(signers)]), nil
+}
+
+// PresignFunc generates a new presignature among signers, for example by running Presign with a protocol.Handler.
+type PresignFunc func(ctx context.Context, signers []party.ID) (*ecdsa.PreSignature, error)
+
+// PresignFiller keeps a number of unused presignatures in a PresignStore for each set of signers.
+type PresignFiller struct {
+	// Store receives the generated presignatures.
+	Store PresignStore
+	// Presign generates a presignature for a set of signers.
+	Presign PresignFunc
+	// Signers are the sets of signers for which presignatures are generated.
+	Signers [][]party.ID
+	// Target is the number of unused presignatures kept for each set of signers.
+	Target int
+	// Interval is the time between two checks of the store, and defaults to one second.
+	Interval time.Duration
+}
+
+// Run fills the store until ctx is done, and returns the first error encountered.
+func (f *PresignFiller) Run(ctx context.Context) error {
+	interval := f.Interval
+	if interval <= 0 {
+		interval = time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := f.Fill(ctx); err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Fill generates presignatures until the store contains Target unused ones for each set of signers.
+func (f *PresignFiller) Fill(ctx context.Context) error {
+	for _, signers := range f.Signers {
+		for {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			n, err := f.Store.Count(signers)
+			if err != nil {
+				return err
+			}
+			if n >= f.Target {
+				break
+			}
+			preSignature, err := f.Presign(ctx, signers)
+			if err != nil {
+				return fmt.Errorf("presign filler: %w", err)
+			}
+			if err = f.Store.Put(preSignature); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package cmp
+
+import (
+	"context"
+	"crypto/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/taurusgroup/multi-party-sig/internal/test"
+	"github.com/taurusgroup/multi-party-sig/internal/types"
+	"github.com/taurusgroup/multi-party-sig/pkg/ecdsa"
+	"github.com/taurusgroup/multi-party-sig/pkg/math/curve"
+	"github.com/taurusgroup/multi-party-sig/pkg/math/sample"
+	"github.com/taurusgroup/multi-party-sig/pkg/party"
+)
+
+// randomPreSignature returns a presignature which is valid, but cannot be used to sign.
+func randomPreSignature(t *testing.T, group curve.Curve, signers []party.ID) *ecdsa.PreSignature {
+	id, err := types.NewRID(rand.Reader)
+	require.NoError(t, err)
+	RBar := make(map[party.ID]curve.Point, len(signers))
+	S := make(map[party.ID]curve.Point, len(signers))
+	for _, j := range signers {
+		RBar[j] = sample.Scalar(rand.Reader, group).ActOnBase()
+		S[j] = sample.Scalar(rand.Reader, group).ActOnBase()
+	}
+	return &ecdsa.PreSignature{
+		ID:       id,
+		R:        sample.Scalar(rand.Reader, group).ActOnBase(),
+		RBar:     party.NewPointMap(RBar),
+		S:        party.NewPointMap(S),
+		KShare:   sample.Scalar(rand.Reader, group),
+		ChiShare: sample.Scalar(rand.Reader, group),
+	}
+}
+
+func testPresignStore(t *testing.T, s PresignStore, reopen func() PresignStore) {
+	group := curve.Secp256k1{}
+	signers := test.PartyIDs(3)
+	other := signers[:2]
+
+	first := randomPreSignature(t, group, signers)
+	second := randomPreSignature(t, group, signers)
+	require.NoError(t, s.Put(first))
+	require.NoError(t, s.Put(second))
+	require.NoError(t, s.Put(randomPreSignature(t, group, other)))
+
+	n, err := s.Count(signers)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	consumed, err := s.Consume(first.ID)
+	require.NoError(t, err)
+	assert.Equal(t, first.ID, consumed.ID)
+	_, err = s.Consume(first.ID)
+	assert.ErrorIs(t, err, ErrPresignatureConsumed)
+	assert.ErrorIs(t, s.Put(first), ErrPresignatureConsumed, "consumed presignatures cannot be stored again")
+
+	s = reopen()
+	_, err = s.Consume(first.ID)
+	assert.ErrorIs(t, err, ErrPresignatureConsumed)
+
+	taken, err := s.Take(signers)
+	require.NoError(t, err)
+	assert.Equal(t, second.ID, taken.ID)
+	assert.True(t, second.KShare.Equal(taken.KShare))
+	_, err = s.Take(signers)
+	assert.ErrorIs(t, err, ErrPresignatureNotFound)
+
+	n, err = s.Count(other)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+}
+
+func TestMemoryPresignStore(t *testing.T) {
+	s := NewMemoryPresignStore()
+	testPresignStore(t, s, func() PresignStore { return s })
+}
+
+func TestFilePresignStore(t *testing.T) {
+	group := curve.Secp256k1{}
+	dir := t.TempDir()
+	open := func() PresignStore {
+		s, err := NewFilePresignStore(group, dir)
+		require.NoError(t, err)
+		return s
+	}
+	testPresignStore(t, open(), open)
+}
+
+func TestPresignFiller(t *testing.T) {
+	group := curve.Secp256k1{}
+	signers := [][]party.ID{test.PartyIDs(3), test.PartyIDs(2)}
+	s := NewMemoryPresignStore()
+	f := &PresignFiller{
+		Store: s,
+		Presign: func(_ context.Context, signers []party.ID) (*ecdsa.PreSignature, error) {
+			return randomPreSignature(t, group, signers), nil
+		},
+		Signers: signers,
+		Target:  3,
+	}
+	require.NoError(t, f.Fill(context.Background()))
+	_, err := s.Take(signers[0])
+	require.NoError(t, err)
+	require.NoError(t, f.Fill(context.Background()))
+	for _, ids := range signers {
+		n, err := s.Count(ids)
+		require.NoError(t, err)
+		assert.Equal(t, 3, n)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, f.Run(ctx), context.Canceled)
+}
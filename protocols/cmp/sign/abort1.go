@@ -0,0 +1,221 @@
+package sign
+
+import (
+	"errors"
+
+	"github.com/cronokirby/safenum"
+	"github.com/taurusgroup/multi-party-sig/internal/round"
+	"github.com/taurusgroup/multi-party-sig/pkg/math/curve"
+	"github.com/taurusgroup/multi-party-sig/pkg/paillier"
+	"github.com/taurusgroup/multi-party-sig/pkg/party"
+	zkdec "github.com/taurusgroup/multi-party-sig/pkg/zk/dec"
+	zkmulstar "github.com/taurusgroup/multi-party-sig/pkg/zk/mulstar"
+)
+
+var _ round.Round = (*abort1)(nil)
+
+// abort1 is entered instead of round5 when Δ ≠ [δ]G, and identifies the parties who sent an incorrect δⱼ.
+type abort1 struct {
+	*round4
+	// DeltaH[j] = Hⱼ = Encⱼ(kⱼ⋅γⱼ)
+	DeltaH map[party.ID]*paillier.Ciphertext
+	// Culprits[j] is true if j failed to prove that δⱼ is correct.
+	Culprits map[party.ID]bool
+}
+
+type broadcastAbort1 struct {
+	round.NormalBroadcastContent
+	// H = Hᵢ = Encᵢ(kᵢ⋅γᵢ)
+	H *paillier.Ciphertext
+}
+
+type messageAbort1 struct {
+	// HProof proves that Hᵢ = kᵢ⋅γᵢ, where Γᵢ = [γᵢ]G.
+	HProof *zkmulstar.Proof
+	// DeltaProof proves that Encᵢ(δᵢ) = Hᵢ ⊕ ∑ⱼ (Dⱼᵢ ⊖ Fᵢⱼ) decrypts to δᵢ.
+	DeltaProof *zkdec.Proof
+}
+
+// StoreBroadcastMessage implements round.BroadcastRound.
+//
+// - store Hⱼ.
+func (r *abort1) StoreBroadcastMessage(msg round.Message) error {
+	body, ok := msg.Content.(*broadcastAbort1)
+	if !ok || body == nil {
+		return round.ErrInvalidContent
+	}
+	if !r.Paillier[msg.From].ValidateCiphertexts(body.H) {
+		return errors.New("invalid H")
+	}
+	r.DeltaH[msg.From] = body.H
+	return nil
+}
+
+// VerifyMessage implements round.Round.
+func (r *abort1) VerifyMessage(msg round.Message) error {
+	body, ok := msg.Content.(*messageAbort1)
+	if !ok || body == nil {
+		return round.ErrInvalidContent
+	}
+	if body.HProof == nil || body.DeltaProof == nil {
+		return round.ErrNilFields
+	}
+	return nil
+}
+
+// StoreMessage implements round.Round.
+//
+// - verify the proofs that Hⱼ and δⱼ are correct, and mark j as a culprit otherwise.
+func (r *abort1) StoreMessage(msg round.Message) error {
+	from, to, body := msg.From, msg.To, msg.Content.(*messageAbort1)
+
+	if !body.HProof.Verify(r.Group(), r.HashForID(from), zkmulstar.Public{
+		C:        r.K[from],
+		D:        r.DeltaH[from],
+		X:        r.BigGammaShare[from],
+		Verifier: r.Paillier[from],
+		Aux:      r.Pedersen[to],
+	}) {
+		r.Culprits[from] = true
+		return nil
+	}
+
+	if !body.DeltaProof.Verify(r.HashForID(from), zkdec.Public{
+		C:      r.mtaCiphertext(from, r.DeltaH[from], r.DeltaD, r.DeltaF),
+		X:      r.DeltaShares[from],
+		Prover: r.Paillier[from],
+		Aux:    r.Pedersen[to],
+	}) {
+		r.Culprits[from] = true
+	}
+	return nil
+}
+
+// Finalize implements round.Round
+//
+// - abort with the parties who could not prove that their δⱼ is correct.
+func (r *abort1) Finalize(chan<- *round.Message) (round.Session, error) {
+	var culprits []party.ID
+	for _, j := range r.OtherPartyIDs() {
+		if r.Culprits[j] {
+			culprits = append(culprits, j)
+		}
+	}
+	if len(culprits) == 0 {
+		return r.AbortRound(errors.New("abort1: Δ ≠ δ•G, but no culprit was detected")), nil
+	}
+	return r.AbortRound(errors.New("abort1: detected culprit"), culprits...), nil
+}
+
+// MessageContent implements round.Round.
+func (r *abort1) MessageContent() round.Content {
+	return &messageAbort1{
+		HProof:     zkmulstar.Empty(r.Group()),
+		DeltaProof: zkdec.Empty(r.Group()),
+	}
+}
+
+// RoundNumber implements round.Content.
+func (messageAbort1) RoundNumber() round.Number { return 5 }
+
+// RoundNumber implements round.Content.
+func (broadcastAbort1) RoundNumber() round.Number { return 5 }
+
+// BroadcastContent implements round.BroadcastRound.
+func (abort1) BroadcastContent() round.BroadcastContent { return &broadcastAbort1{} }
+
+// Number implements round.Round.
+func (abort1) Number() round.Number { return 5 }
+
+// abort1 proves that our δᵢ is correct, and returns the abort1 round.
+//
+// - Hᵢ = (γᵢ ⊙ Kᵢ) ⊕ Encᵢ(0;ρ),
+// - prove that Hᵢ is correct with Π(mul*) and that Hᵢ ⊕ ∑ⱼ (Dⱼᵢ ⊖ Fᵢⱼ) decrypts to δᵢ with Π(dec).
+func (r *round4) abort1(out chan<- *round.Message) (round.Session, error) {
+	self := r.SelfID()
+	public := r.Paillier[self]
+	H := r.K[self].Clone().Mul(public, r.GammaShare)
+	nonce := H.Randomize(public, nil)
+
+	proofs, err := r.proveShare(r.GammaShare, r.BigGammaShare[self], H, nonce,
+		r.mtaCiphertext(self, H, r.DeltaD, r.DeltaF), r.DeltaShares[self])
+	if err != nil {
+		return r, err
+	}
+	if err = r.BroadcastMessage(out, &broadcastAbort1{H: H}); err != nil {
+		return r, err
+	}
+	for j, p := range proofs {
+		if err = r.SendMessage(out, &messageAbort1{HProof: p.mul, DeltaProof: p.dec}, j); err != nil {
+			return r, err
+		}
+	}
+	return &abort1{
+		round4:   r,
+		DeltaH:   map[party.ID]*paillier.Ciphertext{self: H},
+		Culprits: map[party.ID]bool{},
+	}, nil
+}
+
+// shareProofs are the proofs that a share is correct, for a given verifier.
+type shareProofs struct {
+	mul *zkmulstar.Proof
+	dec *zkdec.Proof
+}
+
+// proveShare proves to each other party that H = x ⊙ Kᵢ, where X = [x]G and H was randomized with nonce,
+// and that ct decrypts to share.
+func (r *round3) proveShare(x *safenum.Int, X curve.Point, H *paillier.Ciphertext, nonce *safenum.Nat,
+	ct *paillier.Ciphertext, share curve.Scalar) (map[party.ID]shareProofs, error) {
+	self := r.SelfID()
+	plaintext, plaintextNonce, err := r.SecretPaillier.DecWithRandomness(ct)
+	if err != nil {
+		return nil, err
+	}
+
+	otherIDs := r.OtherPartyIDs()
+	results := r.Pool.Parallelize(len(otherIDs), func(i int) interface{} {
+		j := otherIDs[i]
+		return shareProofs{
+			mul: zkmulstar.NewProof(r.Group(), r.HashForID(self), zkmulstar.Public{
+				C:        r.K[self],
+				D:        H,
+				X:        X,
+				Verifier: r.Paillier[self],
+				Aux:      r.Pedersen[j],
+			}, zkmulstar.Private{
+				X:   x,
+				Rho: nonce,
+			}),
+			dec: zkdec.NewProof(r.Group(), r.HashForID(self), zkdec.Public{
+				C:      ct,
+				X:      share,
+				Prover: r.Paillier[self],
+				Aux:    r.Pedersen[j],
+			}, zkdec.Private{
+				Y:   plaintext,
+				Rho: plaintextNonce,
+			}),
+		}
+	})
+	proofs := make(map[party.ID]shareProofs, len(otherIDs))
+	for i, j := range otherIDs {
+		proofs[j] = results[i].(shareProofs)
+	}
+	return proofs, nil
+}
+
+// mtaCiphertext returns Encⱼ(H + ∑ₖ (αⱼₖ + βⱼₖ)) = H ⊕ ∑ₖ (Dₖⱼ ⊖ Fⱼₖ), computed from the broadcast MtA shares.
+func (r *round3) mtaCiphertext(j party.ID, H *paillier.Ciphertext, D, F map[party.ID]map[party.ID]*paillier.Ciphertext) *paillier.Ciphertext {
+	public := r.Paillier[j]
+	minusOne := new(safenum.Int).SetUint64(1).Neg(1)
+	ct := H.Clone()
+	for _, k := range r.PartyIDs() {
+		if k == j {
+			continue
+		}
+		ct.Add(public, D[k][j])
+		ct.Add(public, F[j][k].Clone().Mul(public, minusOne))
+	}
+	return ct
+}
@@ -0,0 +1,165 @@
+package sign
+
+import (
+	"errors"
+
+	"github.com/taurusgroup/multi-party-sig/internal/round"
+	"github.com/taurusgroup/multi-party-sig/pkg/math/curve"
+	"github.com/taurusgroup/multi-party-sig/pkg/paillier"
+	"github.com/taurusgroup/multi-party-sig/pkg/party"
+	zkdec "github.com/taurusgroup/multi-party-sig/pkg/zk/dec"
+	zkmulstar "github.com/taurusgroup/multi-party-sig/pkg/zk/mulstar"
+)
+
+var _ round.Round = (*abort2)(nil)
+
+// abort2 is entered after round5 when the signature is invalid, and identifies the parties who sent an incorrect σⱼ.
+type abort2 struct {
+	*round5
+	// ChiH[j] = Ĥⱼ = Encⱼ(kⱼ⋅xⱼ)
+	ChiH map[party.ID]*paillier.Ciphertext
+	// Culprits[j] is true if j failed to prove that σⱼ is correct.
+	Culprits map[party.ID]bool
+}
+
+type broadcastAbort2 struct {
+	round.NormalBroadcastContent
+	// H = Ĥᵢ = Encᵢ(kᵢ⋅xᵢ)
+	H *paillier.Ciphertext
+}
+
+type messageAbort2 struct {
+	// HProof proves that Ĥᵢ = kᵢ⋅xᵢ, where Xᵢ = [xᵢ]G.
+	HProof *zkmulstar.Proof
+	// SigmaProof proves that Encᵢ(σᵢ) = (m ⊙ Kᵢ) ⊕ r ⊙ (Ĥᵢ ⊕ ∑ⱼ (D̂ⱼᵢ ⊖ F̂ᵢⱼ)) decrypts to σᵢ.
+	SigmaProof *zkdec.Proof
+}
+
+// StoreBroadcastMessage implements round.BroadcastRound.
+//
+// - store Ĥⱼ.
+func (r *abort2) StoreBroadcastMessage(msg round.Message) error {
+	body, ok := msg.Content.(*broadcastAbort2)
+	if !ok || body == nil {
+		return round.ErrInvalidContent
+	}
+	if !r.Paillier[msg.From].ValidateCiphertexts(body.H) {
+		return errors.New("invalid H")
+	}
+	r.ChiH[msg.From] = body.H
+	return nil
+}
+
+// VerifyMessage implements round.Round.
+func (r *abort2) VerifyMessage(msg round.Message) error {
+	body, ok := msg.Content.(*messageAbort2)
+	if !ok || body == nil {
+		return round.ErrInvalidContent
+	}
+	if body.HProof == nil || body.SigmaProof == nil {
+		return round.ErrNilFields
+	}
+	return nil
+}
+
+// StoreMessage implements round.Round.
+//
+// - verify the proofs that Ĥⱼ and σⱼ are correct, and mark j as a culprit otherwise.
+func (r *abort2) StoreMessage(msg round.Message) error {
+	from, to, body := msg.From, msg.To, msg.Content.(*messageAbort2)
+
+	if !body.HProof.Verify(r.Group(), r.HashForID(from), zkmulstar.Public{
+		C:        r.K[from],
+		D:        r.ChiH[from],
+		X:        r.ECDSA[from],
+		Verifier: r.Paillier[from],
+		Aux:      r.Pedersen[to],
+	}) {
+		r.Culprits[from] = true
+		return nil
+	}
+
+	if !body.SigmaProof.Verify(r.HashForID(from), zkdec.Public{
+		C:      r.sigmaCiphertext(from, r.ChiH[from]),
+		X:      r.SigmaShares[from],
+		Prover: r.Paillier[from],
+		Aux:    r.Pedersen[to],
+	}) {
+		r.Culprits[from] = true
+	}
+	return nil
+}
+
+// Finalize implements round.Round
+//
+// - abort with the parties who could not prove that their σⱼ is correct.
+func (r *abort2) Finalize(chan<- *round.Message) (round.Session, error) {
+	var culprits []party.ID
+	for _, j := range r.OtherPartyIDs() {
+		if r.Culprits[j] {
+			culprits = append(culprits, j)
+		}
+	}
+	if len(culprits) == 0 {
+		return r.AbortRound(errors.New("abort2: signature failed to verify, but no culprit was detected")), nil
+	}
+	return r.AbortRound(errors.New("abort2: detected culprit"), culprits...), nil
+}
+
+// MessageContent implements round.Round.
+func (r *abort2) MessageContent() round.Content {
+	return &messageAbort2{
+		HProof:     zkmulstar.Empty(r.Group()),
+		SigmaProof: zkdec.Empty(r.Group()),
+	}
+}
+
+// RoundNumber implements round.Content.
+func (messageAbort2) RoundNumber() round.Number { return 6 }
+
+// RoundNumber implements round.Content.
+func (broadcastAbort2) RoundNumber() round.Number { return 6 }
+
+// BroadcastContent implements round.BroadcastRound.
+func (abort2) BroadcastContent() round.BroadcastContent { return &broadcastAbort2{} }
+
+// Number implements round.Round.
+func (abort2) Number() round.Number { return 6 }
+
+// abort2 proves that our σᵢ is correct, and returns the abort2 round.
+//
+// - Ĥᵢ = (xᵢ ⊙ Kᵢ) ⊕ Encᵢ(0;ρ),
+// - prove that Ĥᵢ is correct with Π(mul*) and that Encᵢ(σᵢ) decrypts to σᵢ with Π(dec).
+func (r *round5) abort2(out chan<- *round.Message) (round.Session, error) {
+	self := r.SelfID()
+	public := r.Paillier[self]
+	secret := curve.MakeInt(r.SecretECDSA)
+	H := r.K[self].Clone().Mul(public, secret)
+	nonce := H.Randomize(public, nil)
+
+	proofs, err := r.proveShare(secret, r.ECDSA[self], H, nonce, r.sigmaCiphertext(self, H), r.SigmaShares[self])
+	if err != nil {
+		return r, err
+	}
+	if err = r.BroadcastMessage(out, &broadcastAbort2{H: H}); err != nil {
+		return r, err
+	}
+	for j, p := range proofs {
+		if err = r.SendMessage(out, &messageAbort2{HProof: p.mul, SigmaProof: p.dec}, j); err != nil {
+			return r, err
+		}
+	}
+	return &abort2{
+		round5:   r,
+		ChiH:     map[party.ID]*paillier.Ciphertext{self: H},
+		Culprits: map[party.ID]bool{},
+	}, nil
+}
+
+// sigmaCiphertext returns Encⱼ(σⱼ) = (m ⊙ Kⱼ) ⊕ r ⊙ Encⱼ(χⱼ), where Encⱼ(χⱼ) = H ⊕ ∑ₖ (D̂ₖⱼ ⊖ F̂ⱼₖ).
+func (r *round5) sigmaCiphertext(j party.ID, H *paillier.Ciphertext) *paillier.Ciphertext {
+	public := r.Paillier[j]
+	m := curve.MakeInt(curve.FromHash(r.Group(), r.Message))
+	ct := r.mtaCiphertext(j, H, r.ChiD, r.ChiF).Mul(public, curve.MakeInt(r.R))
+	return ct.Add(public, r.K[j].Clone().Mul(public, m))
+}
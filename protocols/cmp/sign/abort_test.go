@@ -0,0 +1,157 @@
+package sign
+
+import (
+	mrand "math/rand"
+	"testing"
+
+	"github.com/cronokirby/safenum"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/taurusgroup/multi-party-sig/internal/round"
+	"github.com/taurusgroup/multi-party-sig/internal/test"
+	"github.com/taurusgroup/multi-party-sig/pkg/math/curve"
+	"github.com/taurusgroup/multi-party-sig/pkg/party"
+	"github.com/taurusgroup/multi-party-sig/pkg/pool"
+)
+
+// cheater is the party modified by TestRule.
+const cheater party.ID = "a"
+
+var oneNat = new(safenum.Nat).SetUint64(1)
+
+type TestRule struct {
+	AfterFinalize func(rNext round.Session)
+	BeforeSend    func(rNext round.Session, to party.ID, content round.Content)
+}
+
+func (tr *TestRule) ModifyBefore(round.Session) {}
+
+func (tr *TestRule) ModifyAfter(rNext round.Session) {
+	if rNext.SelfID() != cheater {
+		return
+	}
+	if tr.AfterFinalize != nil {
+		tr.AfterFinalize(rNext)
+	}
+}
+
+func (tr *TestRule) ModifyContent(rNext round.Session, to party.ID, content round.Content) {
+	if rNext.SelfID() != cheater {
+		return
+	}
+	if tr.BeforeSend != nil {
+		tr.BeforeSend(rNext, to, content)
+	}
+}
+
+func TestIdentifiableAbort(t *testing.T) {
+	pl := pool.NewPool(0)
+	defer pl.TearDown()
+	group := curve.Secp256k1{}
+
+	N := 3
+	configs, partyIDs := test.GenerateConfig(group, N, N-1, mrand.New(mrand.NewSource(1)), pl)
+	message := []byte("hello")
+
+	tests := []struct {
+		name string
+		r    TestRule
+	}{
+		{
+			"round 4 add one to delta share",
+			TestRule{
+				AfterFinalize: func(rNext round.Session) {
+					if r, ok := rNext.(*round4); ok {
+						one := r.Group().NewScalar().SetNat(oneNat)
+						r.DeltaShares[r.SelfID()] = r.Group().NewScalar().Set(r.DeltaShares[r.SelfID()]).Add(one)
+					}
+				},
+				BeforeSend: func(rNext round.Session, _ party.ID, content round.Content) {
+					r, okR := rNext.(*round4)
+					c, okC := content.(*broadcast4)
+					if okR && okC {
+						one := r.Group().NewScalar().SetNat(oneNat)
+						c.DeltaShare = r.Group().NewScalar().Set(c.DeltaShare).Add(one)
+					}
+				},
+			},
+		},
+		{
+			"round 5 add one to sigma share",
+			TestRule{
+				AfterFinalize: func(rNext round.Session) {
+					if r, ok := rNext.(*round5); ok {
+						one := r.Group().NewScalar().SetNat(oneNat)
+						r.SigmaShares[r.SelfID()] = r.Group().NewScalar().Set(r.SigmaShares[r.SelfID()]).Add(one)
+					}
+				},
+				BeforeSend: func(rNext round.Session, _ party.ID, content round.Content) {
+					r, okR := rNext.(*round5)
+					c, okC := content.(*broadcast5)
+					if okR && okC {
+						one := r.Group().NewScalar().SetNat(oneNat)
+						c.SigmaShare = r.Group().NewScalar().Set(c.SigmaShare).Add(one)
+					}
+				},
+			},
+		},
+		{
+			"round 4 modify chi share",
+			TestRule{
+				AfterFinalize: func(rNext round.Session) {
+					if r, ok := rNext.(*round4); ok {
+						one := r.Group().NewScalar().SetNat(oneNat)
+						r.ChiShare = r.Group().NewScalar().Set(r.ChiShare).Add(one)
+					}
+				},
+			},
+		},
+	}
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			rounds := make([]round.Session, 0, N)
+			for _, id := range partyIDs {
+				r, err := StartSign(configs[id], partyIDs, message, pl)(nil)
+				require.NoError(t, err)
+				rounds = append(rounds, r)
+			}
+			for {
+				err, done := test.Rounds(rounds, &testCase.r)
+				require.NoError(t, err)
+				if done {
+					break
+				}
+			}
+			for _, r := range rounds {
+				require.IsType(t, &round.Abort{}, r)
+				if r.SelfID() == cheater {
+					continue
+				}
+				assert.Equal(t, []party.ID{cheater}, r.(*round.Abort).Culprits)
+			}
+		})
+	}
+}
+
+func TestAbortWithoutCulprits(t *testing.T) {
+	pl := pool.NewPool(0)
+	defer pl.TearDown()
+	group := curve.Secp256k1{}
+
+	configs, partyIDs := test.GenerateConfig(group, 2, 1, mrand.New(mrand.NewSource(1)), pl)
+	start, err := StartSign(configs[partyIDs[0]], partyIDs, []byte("hello"), pl)(nil)
+	require.NoError(t, err)
+	r4 := &round4{round3: &round3{round2: &round2{round1: start.(*round1)}}}
+
+	for _, r := range []round.Round{
+		&abort1{round4: r4, Culprits: map[party.ID]bool{}},
+		&abort2{round5: &round5{round4: r4}, Culprits: map[party.ID]bool{}},
+	} {
+		next, err := r.Finalize(nil)
+		require.NoError(t, err)
+		require.IsType(t, &round.Abort{}, next)
+		abort := next.(*round.Abort)
+		assert.Empty(t, abort.Culprits)
+		assert.NotContains(t, abort.Err.Error(), "detected culprit")
+	}
+}
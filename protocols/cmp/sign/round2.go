@@ -102,19 +102,18 @@ func (round2) StoreMessage(round.Message) error { return nil }
 
 // Finalize implements round.Round
 //
-// - compute Hash(ssid, K₁, G₁, …, Kₙ, Gₙ).
+// - compute the MtA shares Dᵢⱼ, Fᵢⱼ, D̂ᵢⱼ, F̂ᵢⱼ, and broadcast them along with Γᵢ,
+// so that they can be used to identify a party sending inconsistent shares.
 func (r *round2) Finalize(out chan<- *round.Message) (round.Session, error) {
-	if err := r.BroadcastMessage(out, &broadcast3{
-		BigGammaShare: r.BigGammaShare[r.SelfID()],
-	}); err != nil {
-		return r, err
-	}
-
 	otherIDs := r.OtherPartyIDs()
 	type mtaOut struct {
-		err       error
 		DeltaBeta *safenum.Int
 		ChiBeta   *safenum.Int
+		msg       *message3
+		DeltaD    *paillier.Ciphertext
+		DeltaF    *paillier.Ciphertext
+		ChiD      *paillier.Ciphertext
+		ChiF      *paillier.Ciphertext
 	}
 	mtaOuts := r.Pool.Parallelize(len(otherIDs), func(i int) interface{} {
 		j := otherIDs[i]
@@ -137,39 +136,60 @@ func (r *round2) Finalize(out chan<- *round.Message) (round.Session, error) {
 				Rho: r.GNonce,
 			})
 
-		err := r.SendMessage(out, &message3{
-			DeltaD:     DeltaD,
-			DeltaF:     DeltaF,
-			DeltaProof: DeltaProof,
-			ChiD:       ChiD,
-			ChiF:       ChiF,
-			ChiProof:   ChiProof,
-			ProofLog:   proof,
-		}, j)
 		return mtaOut{
-			err:       err,
 			DeltaBeta: DeltaBeta,
 			ChiBeta:   ChiBeta,
+			msg: &message3{
+				DeltaProof: DeltaProof,
+				ChiProof:   ChiProof,
+				ProofLog:   proof,
+			},
+			DeltaD: DeltaD,
+			DeltaF: DeltaF,
+			ChiD:   ChiD,
+			ChiF:   ChiF,
 		}
 	})
-	DeltaShareBetas := make(map[party.ID]*safenum.Int, len(otherIDs)-1)
-	ChiShareBetas := make(map[party.ID]*safenum.Int, len(otherIDs)-1)
+	DeltaShareBetas := make(map[party.ID]*safenum.Int, len(otherIDs))
+	ChiShareBetas := make(map[party.ID]*safenum.Int, len(otherIDs))
+	broadcastMsg := &broadcast3{
+		BigGammaShare: r.BigGammaShare[r.SelfID()],
+		DeltaD:        make(map[party.ID]*paillier.Ciphertext, len(otherIDs)),
+		DeltaF:        make(map[party.ID]*paillier.Ciphertext, len(otherIDs)),
+		ChiD:          make(map[party.ID]*paillier.Ciphertext, len(otherIDs)),
+		ChiF:          make(map[party.ID]*paillier.Ciphertext, len(otherIDs)),
+	}
 	for idx, mtaOutRaw := range mtaOuts {
 		j := otherIDs[idx]
 		m := mtaOutRaw.(mtaOut)
-		if m.err != nil {
-			return r, m.err
-		}
 		DeltaShareBetas[j] = m.DeltaBeta
 		ChiShareBetas[j] = m.ChiBeta
+		broadcastMsg.DeltaD[j] = m.DeltaD
+		broadcastMsg.DeltaF[j] = m.DeltaF
+		broadcastMsg.ChiD[j] = m.ChiD
+		broadcastMsg.ChiF[j] = m.ChiF
+	}
+
+	if err := r.BroadcastMessage(out, broadcastMsg); err != nil {
+		return r, err
+	}
+	for idx, mtaOutRaw := range mtaOuts {
+		if err := r.SendMessage(out, mtaOutRaw.(mtaOut).msg, otherIDs[idx]); err != nil {
+			return r, err
+		}
 	}
 
+	self := r.SelfID()
 	return &round3{
 		round2:          r,
 		DeltaShareBeta:  DeltaShareBetas,
 		ChiShareBeta:    ChiShareBetas,
 		DeltaShareAlpha: map[party.ID]*safenum.Int{},
 		ChiShareAlpha:   map[party.ID]*safenum.Int{},
+		DeltaD:          map[party.ID]map[party.ID]*paillier.Ciphertext{self: broadcastMsg.DeltaD},
+		DeltaF:          map[party.ID]map[party.ID]*paillier.Ciphertext{self: broadcastMsg.DeltaF},
+		ChiD:            map[party.ID]map[party.ID]*paillier.Ciphertext{self: broadcastMsg.ChiD},
+		ChiF:            map[party.ID]map[party.ID]*paillier.Ciphertext{self: broadcastMsg.ChiF},
 	}, nil
 }
 
@@ -26,14 +26,19 @@ type round3 struct {
 	ChiShareAlpha map[party.ID]*safenum.Int
 	// ChiShareBeta[j] = β̂ᵢⱼ
 	ChiShareBeta map[party.ID]*safenum.Int
+
+	// DeltaD[j][k] = Dⱼₖ = Encₖ(γⱼ⋅kₖ - βⱼₖ)
+	DeltaD map[party.ID]map[party.ID]*paillier.Ciphertext
+	// DeltaF[j][k] = Fⱼₖ = Encⱼ(-βⱼₖ)
+	DeltaF map[party.ID]map[party.ID]*paillier.Ciphertext
+	// ChiD[j][k] = D̂ⱼₖ = Encₖ(xⱼ⋅kₖ - β̂ⱼₖ)
+	ChiD map[party.ID]map[party.ID]*paillier.Ciphertext
+	// ChiF[j][k] = F̂ⱼₖ = Encⱼ(-β̂ⱼₖ)
+	ChiF map[party.ID]map[party.ID]*paillier.Ciphertext
 }
 
 type message3 struct {
-	DeltaD     *paillier.Ciphertext // DeltaD = Dᵢⱼ
-	DeltaF     *paillier.Ciphertext // DeltaF = Fᵢⱼ
 	DeltaProof *zkaffg.Proof
-	ChiD       *paillier.Ciphertext // DeltaD = D̂_{ij}
-	ChiF       *paillier.Ciphertext // ChiF = F̂ᵢⱼ
 	ChiProof   *zkaffg.Proof
 	ProofLog   *zklogstar.Proof
 }
@@ -41,20 +46,40 @@ type message3 struct {
 type broadcast3 struct {
 	round.NormalBroadcastContent
 	BigGammaShare curve.Point // BigGammaShare = Γⱼ
+	// The MtA shares are broadcast so that all parties can check the shares δⱼ and σⱼ if the protocol fails.
+	DeltaD map[party.ID]*paillier.Ciphertext // DeltaD[k] = Dⱼₖ
+	DeltaF map[party.ID]*paillier.Ciphertext // DeltaF[k] = Fⱼₖ
+	ChiD   map[party.ID]*paillier.Ciphertext // ChiD[k] = D̂ⱼₖ
+	ChiF   map[party.ID]*paillier.Ciphertext // ChiF[k] = F̂ⱼₖ
 }
 
 // StoreBroadcastMessage implements round.BroadcastRound.
 //
-// - store Γⱼ
+// - store Γⱼ, and the MtA shares Dⱼₖ, Fⱼₖ, D̂ⱼₖ, F̂ⱼₖ.
 func (r *round3) StoreBroadcastMessage(msg round.Message) error {
+	from := msg.From
 	body, ok := msg.Content.(*broadcast3)
 	if !ok || body == nil {
 		return round.ErrInvalidContent
 	}
-	if body.BigGammaShare.IsIdentity() {
+	if body.BigGammaShare.IsIdentity() ||
+		body.DeltaD == nil || body.DeltaF == nil || body.ChiD == nil || body.ChiF == nil {
 		return round.ErrNilFields
 	}
-	r.BigGammaShare[msg.From] = body.BigGammaShare
+	for _, k := range r.PartyIDs() {
+		if k == from {
+			continue
+		}
+		if !r.Paillier[k].ValidateCiphertexts(body.DeltaD[k], body.ChiD[k]) ||
+			!r.Paillier[from].ValidateCiphertexts(body.DeltaF[k], body.ChiF[k]) {
+			return errors.New("received invalid ciphertext")
+		}
+	}
+	r.BigGammaShare[from] = body.BigGammaShare
+	r.DeltaD[from] = body.DeltaD
+	r.DeltaF[from] = body.DeltaF
+	r.ChiD[from] = body.ChiD
+	r.ChiF[from] = body.ChiF
 	return nil
 }
 
@@ -70,8 +95,8 @@ func (r *round3) VerifyMessage(msg round.Message) error {
 
 	if !body.DeltaProof.Verify(r.HashForID(from), zkaffg.Public{
 		Kv:       r.K[to],
-		Dv:       body.DeltaD,
-		Fp:       body.DeltaF,
+		Dv:       r.DeltaD[from][to],
+		Fp:       r.DeltaF[from][to],
 		Xp:       r.BigGammaShare[from],
 		Prover:   r.Paillier[from],
 		Verifier: r.Paillier[to],
@@ -82,8 +107,8 @@ func (r *round3) VerifyMessage(msg round.Message) error {
 
 	if !body.ChiProof.Verify(r.HashForID(from), zkaffg.Public{
 		Kv:       r.K[to],
-		Dv:       body.ChiD,
-		Fp:       body.ChiF,
+		Dv:       r.ChiD[from][to],
+		Fp:       r.ChiF[from][to],
 		Xp:       r.ECDSA[from],
 		Prover:   r.Paillier[from],
 		Verifier: r.Paillier[to],
@@ -109,15 +134,15 @@ func (r *round3) VerifyMessage(msg round.Message) error {
 // - Decrypt MtA shares,
 // - save αᵢⱼ, α̂ᵢⱼ.
 func (r *round3) StoreMessage(msg round.Message) error {
-	from, body := msg.From, msg.Content.(*message3)
+	from, to := msg.From, msg.To
 
 	// αᵢⱼ
-	DeltaShareAlpha, err := r.SecretPaillier.Dec(body.DeltaD)
+	DeltaShareAlpha, err := r.SecretPaillier.Dec(r.DeltaD[from][to])
 	if err != nil {
 		return fmt.Errorf("failed to decrypt alpha share for delta: %w", err)
 	}
 	// α̂ᵢⱼ
-	ChiShareAlpha, err := r.SecretPaillier.Dec(body.ChiD)
+	ChiShareAlpha, err := r.SecretPaillier.Dec(r.ChiD[from][to])
 	if err != nil {
 		return fmt.Errorf("failed to decrypt alpha share for chi: %w", err)
 	}
@@ -87,7 +87,7 @@ func (round4) StoreMessage(round.Message) error {
 //
 // - set δ = ∑ⱼ δⱼ
 // - set Δ = ∑ⱼ Δⱼ
-// - verify Δ = [δ]G
+// - verify Δ = [δ]G, and otherwise prove that δᵢ is correct in abort1
 // - compute σᵢ = rχᵢ + kᵢm.
 func (r *round4) Finalize(out chan<- *round.Message) (round.Session, error) {
 	// δ = ∑ⱼ δⱼ
@@ -102,7 +102,7 @@ func (r *round4) Finalize(out chan<- *round.Message) (round.Session, error) {
 	// Δ == [δ]G
 	deltaComputed := Delta.ActOnBase()
 	if !deltaComputed.Equal(BigDelta) {
-		return r.AbortRound(errors.New("computed Δ is inconsistent with [δ]G")), nil
+		return r.abort1(out)
 	}
 
 	deltaInv := r.Group().NewScalar().Set(Delta).Invert() // δ⁻¹
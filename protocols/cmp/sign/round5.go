@@ -1,8 +1,6 @@
 package sign
 
 import (
-	"errors"
-
 	"github.com/taurusgroup/multi-party-sig/internal/round"
 	"github.com/taurusgroup/multi-party-sig/pkg/ecdsa"
 	"github.com/taurusgroup/multi-party-sig/pkg/math/curve"
@@ -62,8 +60,8 @@ func (round5) StoreMessage(round.Message) error { return nil }
 // Finalize implements round.Round
 //
 // - compute σ = ∑ⱼ σⱼ
-// - verify signature.
-func (r *round5) Finalize(chan<- *round.Message) (round.Session, error) {
+// - verify signature, and otherwise prove that σᵢ is correct in abort2.
+func (r *round5) Finalize(out chan<- *round.Message) (round.Session, error) {
 	// compute σ = ∑ⱼ σⱼ
 	Sigma := r.Group().NewScalar()
 	for _, j := range r.PartyIDs() {
@@ -76,7 +74,7 @@ func (r *round5) Finalize(chan<- *round.Message) (round.Session, error) {
 	}
 
 	if !signature.Verify(r.PublicKey, r.Message) {
-		return r.AbortRound(errors.New("failed to validate signature")), nil
+		return r.abort2(out)
 	}
 
 	return r.ResultRound(signature), nil
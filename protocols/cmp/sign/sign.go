@@ -19,7 +19,7 @@ import (
 // protocolSignID for the "3 round" variant using echo broadcast.
 const (
 	protocolSignID                  = "cmp/sign"
-	protocolSignRounds round.Number = 5
+	protocolSignRounds round.Number = 6
 )
 
 func StartSign(config *config.Config, signers []party.ID, message []byte, pl *pool.Pool) protocol.StartFunc {
@@ -44,6 +44,9 @@ func StartSign(config *config.Config, signers []party.ID, message []byte, pl *po
 		if err != nil {
 			return nil, fmt.Errorf("sign.Create: %w", err)
 		}
+		if !curve.SupportsECDSA(helper.Group()) {
+			return nil, fmt.Errorf("sign.Create: ECDSA is not supported over %s", helper.Group().Name())
+		}
 
 		if !config.CanSign(helper.PartyIDs()) {
 			return nil, errors.New("sign.Create: signers is not a valid signing subset")
@@ -1,6 +1,10 @@
 package sign
 
 import (
+	stdecdsa "crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"math/big"
 	mrand "math/rand"
 	"testing"
 
@@ -57,3 +61,52 @@ func TestRound(t *testing.T) {
 		assert.True(t, signature.Verify(publicPoint, messageHash), "expected valid signature")
 	}
 }
+
+func TestRoundP256(t *testing.T) {
+	pl := pool.NewPool(0)
+	defer pl.TearDown()
+	group := curve.P256{}
+
+	N := 3
+	T := N - 1
+
+	configs, partyIDs := test.GenerateConfig(group, N, T, mrand.New(mrand.NewSource(1)), pl)
+	publicPoint := configs[partyIDs[0]].PublicPoint()
+	publicKey := &stdecdsa.PublicKey{
+		Curve: elliptic.P256(),
+		X:     new(big.Int).SetBytes(publicPoint.XBytes()),
+		Y:     new(big.Int).SetBytes(publicPoint.YBytes()),
+	}
+
+	messageHash := sha256.Sum256([]byte("hello"))
+
+	rounds := make([]round.Session, 0, N)
+	for _, partyID := range partyIDs {
+		r, err := StartSign(configs[partyID], partyIDs, messageHash[:], pl)(nil)
+		require.NoError(t, err, "round creation should not result in an error")
+		rounds = append(rounds, r)
+	}
+
+	for {
+		err, done := test.Rounds(rounds, nil)
+		require.NoError(t, err, "failed to process round")
+		if done {
+			break
+		}
+	}
+
+	for _, r := range rounds {
+		require.IsType(t, &round.Output{}, r, "expected result round")
+		resultRound := r.(*round.Output)
+		require.IsType(t, &ecdsa.Signature{}, resultRound.Result, "expected ecdsa signature result")
+		signature := resultRound.Result.(*ecdsa.Signature)
+		assert.True(t, signature.Verify(publicPoint, messageHash[:]), "expected valid signature")
+
+		rBytes, err := signature.R.XScalar().MarshalBinary()
+		require.NoError(t, err)
+		sBytes, err := signature.S.MarshalBinary()
+		require.NoError(t, err)
+		valid := stdecdsa.Verify(publicKey, messageHash[:], new(big.Int).SetBytes(rBytes), new(big.Int).SetBytes(sBytes))
+		assert.True(t, valid, "expected signature to verify with crypto/ecdsa")
+	}
+}
@@ -0,0 +1,228 @@
+package sign
+
+import (
+	"fmt"
+
+	"github.com/cronokirby/safenum"
+	"github.com/taurusgroup/multi-party-sig/internal/round"
+	"github.com/taurusgroup/multi-party-sig/pkg/paillier"
+	"github.com/taurusgroup/multi-party-sig/pkg/party"
+)
+
+// These assert that our rounds can be snapshot and restored.
+var (
+	_ round.Restorer    = (*round1)(nil)
+	_ round.Snapshotter = (*round1)(nil)
+	_ round.Snapshotter = (*round2)(nil)
+	_ round.Snapshotter = (*round3)(nil)
+	_ round.Snapshotter = (*round4)(nil)
+	_ round.Snapshotter = (*round5)(nil)
+	_ round.Snapshotter = (*abort1)(nil)
+	_ round.Snapshotter = (*abort2)(nil)
+)
+
+// The fields of round1 are all derived from the arguments of the StartFunc, so only the session is stored.
+func (r *round1) putState(s *round.State) { s.PutSession(r.Helper) }
+
+func (r *round2) putState(s *round.State) {
+	r.round1.putState(s)
+	s.Put("K", r.K)
+	s.Put("G", r.G)
+	s.Put("BigGammaShare", r.BigGammaShare)
+	s.Put("GammaShare", r.GammaShare)
+	s.Put("KShare", r.KShare)
+	s.Put("KNonce", r.KNonce)
+	s.Put("GNonce", r.GNonce)
+}
+
+func (r *round2) getState(s *round.State) {
+	r.K = map[party.ID]*paillier.Ciphertext{}
+	s.Get("K", &r.K)
+	r.G = map[party.ID]*paillier.Ciphertext{}
+	s.Get("G", &r.G)
+	r.BigGammaShare = s.PointMap("BigGammaShare")
+	r.GammaShare = new(safenum.Int)
+	s.Get("GammaShare", r.GammaShare)
+	r.KShare = s.Scalar("KShare")
+	r.KNonce = new(safenum.Nat)
+	s.Get("KNonce", r.KNonce)
+	r.GNonce = new(safenum.Nat)
+	s.Get("GNonce", r.GNonce)
+}
+
+func (r *round3) putState(s *round.State) {
+	r.round2.putState(s)
+	s.Put("DeltaShareAlpha", r.DeltaShareAlpha)
+	s.Put("DeltaShareBeta", r.DeltaShareBeta)
+	s.Put("ChiShareAlpha", r.ChiShareAlpha)
+	s.Put("ChiShareBeta", r.ChiShareBeta)
+	s.Put("DeltaD", r.DeltaD)
+	s.Put("DeltaF", r.DeltaF)
+	s.Put("ChiD", r.ChiD)
+	s.Put("ChiF", r.ChiF)
+}
+
+func (r *round3) getState(s *round.State) {
+	r.DeltaShareAlpha = map[party.ID]*safenum.Int{}
+	s.Get("DeltaShareAlpha", &r.DeltaShareAlpha)
+	r.DeltaShareBeta = map[party.ID]*safenum.Int{}
+	s.Get("DeltaShareBeta", &r.DeltaShareBeta)
+	r.ChiShareAlpha = map[party.ID]*safenum.Int{}
+	s.Get("ChiShareAlpha", &r.ChiShareAlpha)
+	r.ChiShareBeta = map[party.ID]*safenum.Int{}
+	s.Get("ChiShareBeta", &r.ChiShareBeta)
+	r.DeltaD = map[party.ID]map[party.ID]*paillier.Ciphertext{}
+	s.Get("DeltaD", &r.DeltaD)
+	r.DeltaF = map[party.ID]map[party.ID]*paillier.Ciphertext{}
+	s.Get("DeltaF", &r.DeltaF)
+	r.ChiD = map[party.ID]map[party.ID]*paillier.Ciphertext{}
+	s.Get("ChiD", &r.ChiD)
+	r.ChiF = map[party.ID]map[party.ID]*paillier.Ciphertext{}
+	s.Get("ChiF", &r.ChiF)
+}
+
+func (r *round4) putState(s *round.State) {
+	r.round3.putState(s)
+	s.Put("DeltaShares", r.DeltaShares)
+	s.Put("BigDeltaShares", r.BigDeltaShares)
+	s.Put("Gamma", r.Gamma)
+	s.Put("ChiShare", r.ChiShare)
+}
+
+func (r *round4) getState(s *round.State) {
+	r.DeltaShares = s.ScalarMap("DeltaShares")
+	r.BigDeltaShares = s.PointMap("BigDeltaShares")
+	r.Gamma = s.Point("Gamma")
+	r.ChiShare = s.Scalar("ChiShare")
+}
+
+func (r *round5) putState(s *round.State) {
+	r.round4.putState(s)
+	s.Put("SigmaShares", r.SigmaShares)
+	s.Put("Delta", r.Delta)
+	s.Put("BigDelta", r.BigDelta)
+	s.Put("BigR", r.BigR)
+	s.Put("R", r.R)
+}
+
+func (r *round5) getState(s *round.State) {
+	r.SigmaShares = s.ScalarMap("SigmaShares")
+	r.Delta = s.Scalar("Delta")
+	r.BigDelta = s.Point("BigDelta")
+	r.BigR = s.Point("BigR")
+	r.R = s.Scalar("R")
+}
+
+func (r *abort1) putState(s *round.State) {
+	r.round4.putState(s)
+	s.Put("DeltaH", r.DeltaH)
+	s.Put("Culprits", r.Culprits)
+}
+
+func (r *abort1) getState(s *round.State) {
+	r.DeltaH = map[party.ID]*paillier.Ciphertext{}
+	s.Get("DeltaH", &r.DeltaH)
+	r.Culprits = map[party.ID]bool{}
+	s.Get("Culprits", &r.Culprits)
+}
+
+func (r *abort2) putState(s *round.State) {
+	r.round5.putState(s)
+	s.Put("ChiH", r.ChiH)
+	s.Put("Culprits", r.Culprits)
+}
+
+func (r *abort2) getState(s *round.State) {
+	r.ChiH = map[party.ID]*paillier.Ciphertext{}
+	s.Get("ChiH", &r.ChiH)
+	r.Culprits = map[party.ID]bool{}
+	s.Get("Culprits", &r.Culprits)
+}
+
+type stateRound interface {
+	round.Session
+	putState(s *round.State)
+}
+
+func marshalState(r stateRound) ([]byte, error) {
+	s := round.NewState(r.Group())
+	r.putState(s)
+	return s.MarshalBinary()
+}
+
+// MarshalState implements round.Snapshotter.
+func (r *round1) MarshalState() ([]byte, error) { return marshalState(r) }
+
+// MarshalState implements round.Snapshotter.
+func (r *round2) MarshalState() ([]byte, error) { return marshalState(r) }
+
+// MarshalState implements round.Snapshotter.
+func (r *round3) MarshalState() ([]byte, error) { return marshalState(r) }
+
+// MarshalState implements round.Snapshotter.
+func (r *round4) MarshalState() ([]byte, error) { return marshalState(r) }
+
+// MarshalState implements round.Snapshotter.
+func (r *round5) MarshalState() ([]byte, error) { return marshalState(r) }
+
+// MarshalState implements round.Snapshotter.
+func (r *abort1) MarshalState() ([]byte, error) { return marshalState(r) }
+
+// MarshalState implements round.Snapshotter.
+func (r *abort2) MarshalState() ([]byte, error) { return marshalState(r) }
+
+// RestoreState implements round.Restorer.
+func (r *round1) RestoreState(number round.Number, data []byte) (round.Session, error) {
+	s, err := round.UnmarshalState(r.Group(), data)
+	if err != nil {
+		return nil, fmt.Errorf("sign: %w", err)
+	}
+	s.RestoreSession(r.Helper)
+	restored, err := r.restore(s, number)
+	if err != nil {
+		return nil, fmt.Errorf("sign: %w", err)
+	}
+	if err = s.Err(); err != nil {
+		return nil, fmt.Errorf("sign: %w", err)
+	}
+	return restored, nil
+}
+
+// restore rebuilds the rounds following r until the given round number.
+// Round 5 is abort1 instead of round5 if the state contains the fields of abort1.
+func (r *round1) restore(s *round.State, number round.Number) (round.Session, error) {
+	if number == 1 {
+		return r, nil
+	}
+	r2 := &round2{round1: r}
+	r2.getState(s)
+	if number == 2 {
+		return r2, nil
+	}
+	r3 := &round3{round2: r2}
+	r3.getState(s)
+	if number == 3 {
+		return r3, nil
+	}
+	r4 := &round4{round3: r3}
+	r4.getState(s)
+	if number == 4 {
+		return r4, nil
+	}
+	if number == 5 && s.Has("DeltaH") {
+		a1 := &abort1{round4: r4}
+		a1.getState(s)
+		return a1, nil
+	}
+	r5 := &round5{round4: r4}
+	r5.getState(s)
+	if number == 5 {
+		return r5, nil
+	}
+	a2 := &abort2{round5: r5}
+	a2.getState(s)
+	if number == 6 {
+		return a2, nil
+	}
+	return nil, fmt.Errorf("cannot restore round %d", number)
+}
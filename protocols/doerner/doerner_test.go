@@ -144,6 +144,18 @@ func checkKeygenOutput(t *testing.T, configSender *ConfigSender, configReceiver
 	require.True(t, bytes.Equal(configSender.ChainKey, configReceiver.ChainKey))
 }
 
+func TestUnsupportedCurve(t *testing.T) {
+	partyIDs := test.PartyIDs(2)
+	for _, group := range []curve.Curve{curve.Ed25519{}, curve.Ristretto255{}} {
+		_, err := Keygen(group, true, partyIDs[0], partyIDs[1], nil)(nil)
+		require.ErrorContains(t, err, "ECDSA")
+		_, err = SignReceiver(EmptyConfigReceiver(group), partyIDs[0], partyIDs[1], []byte("hash"), nil)(nil)
+		require.ErrorContains(t, err, "ECDSA")
+		_, err = SignSender(EmptyConfigSender(group), partyIDs[1], partyIDs[0], []byte("hash"), nil)(nil)
+		require.ErrorContains(t, err, "ECDSA")
+	}
+}
+
 func TestSign(t *testing.T) {
 	partyIDs := test.PartyIDs(2)
 
@@ -168,6 +180,85 @@ func TestSign(t *testing.T) {
 	require.True(t, sig.Verify(configReceiver.Public, testHash))
 }
 
+// runWithRestart runs the two handlers step by step, restarting party restart from a snapshot after the given number of steps.
+func runWithRestart(t *testing.T, handlers map[party.ID]protocol.Handler, restart party.ID, steps int, create protocol.StartFunc) {
+	key := make([]byte, protocol.SnapshotKeySize)
+	for _, h := range handlers {
+		require.NoError(t, h.(*protocol.TwoPartyHandler).SetSnapshotKey(key))
+	}
+	for i := 0; i < steps; i++ {
+		require.True(t, test.Step(handlers))
+	}
+	snapshot, err := handlers[restart].(*protocol.TwoPartyHandler).Snapshot()
+	require.NoError(t, err)
+	handlers[restart], err = protocol.RestoreTwoPartyHandler(snapshot, key, create, protocol.NewMemorySnapshotLog())
+	require.NoError(t, err)
+	for test.Step(handlers) {
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	partyIDs := test.PartyIDs(2)
+	pl := pool.NewPool(0)
+	defer pl.TearDown()
+
+	keygenFor := func(id party.ID) protocol.StartFunc {
+		if id == partyIDs[0] {
+			return Keygen(testGroup, true, partyIDs[0], partyIDs[1], pl)
+		}
+		return Keygen(testGroup, false, partyIDs[1], partyIDs[0], pl)
+	}
+	var (
+		configSender   *ConfigSender
+		configReceiver *ConfigReceiver
+	)
+	// The parties take turns, so restarting after up to 3 steps covers every round either of them can be in.
+	for _, restart := range partyIDs {
+		for steps := 0; steps < 4; steps++ {
+			handlers := map[party.ID]protocol.Handler{}
+			for i, id := range partyIDs {
+				h, err := protocol.NewTwoPartyHandler(keygenFor(id), []byte("session"), i == 0)
+				require.NoError(t, err)
+				handlers[id] = h
+			}
+			runWithRestart(t, handlers, restart, steps, keygenFor(restart))
+
+			r, err := handlers[partyIDs[0]].Result()
+			require.NoError(t, err)
+			configReceiver = r.(*ConfigReceiver)
+			r, err = handlers[partyIDs[1]].Result()
+			require.NoError(t, err)
+			configSender = r.(*ConfigSender)
+			checkKeygenOutput(t, configSender, configReceiver)
+		}
+	}
+
+	signFor := func(id party.ID) protocol.StartFunc {
+		if id == partyIDs[0] {
+			return SignReceiver(configReceiver, partyIDs[0], partyIDs[1], testHash, pl)
+		}
+		return SignSender(configSender, partyIDs[1], partyIDs[0], testHash, pl)
+	}
+	// The Receiver finishes after 2 steps.
+	for _, restart := range partyIDs {
+		for steps := 0; steps < 2; steps++ {
+			handlers := map[party.ID]protocol.Handler{}
+			for _, id := range partyIDs {
+				h, err := protocol.NewTwoPartyHandler(signFor(id), []byte("session"), true)
+				require.NoError(t, err)
+				handlers[id] = h
+			}
+			runWithRestart(t, handlers, restart, steps, signFor(restart))
+
+			for _, h := range handlers {
+				r, err := h.Result()
+				require.NoError(t, err)
+				require.True(t, r.(*ecdsa.Signature).Verify(configSender.Public, testHash))
+			}
+		}
+	}
+}
+
 func BenchmarkSign(t *testing.B) {
 	t.StopTimer()
 	partyIDs := test.PartyIDs(2)
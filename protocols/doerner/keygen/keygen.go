@@ -115,7 +115,12 @@ func StartKeygen(group curve.Curve, receiver bool, selfID, otherID party.ID, sec
 		if err != nil {
 			return nil, fmt.Errorf("keygen.StartKeygen: %w", err)
 		}
+		if !curve.SupportsECDSA(helper.Group()) {
+			return nil, fmt.Errorf("keygen.StartKeygen: ECDSA is not supported over %s", helper.Group().Name())
+		}
 
+		// the StartFunc may be called more than once, so the captured arguments must not be modified.
+		secretShare := secretShare
 		refresh := true
 		if secretShare == nil && public == nil {
 			secretShare = sample.Scalar(rand.Reader, group)
@@ -0,0 +1,205 @@
+package keygen
+
+import (
+	"fmt"
+
+	"github.com/taurusgroup/multi-party-sig/internal/round"
+	zksch "github.com/taurusgroup/multi-party-sig/pkg/zk/sch"
+)
+
+// These assert that our rounds can be snapshot and restored.
+var (
+	_ round.Restorer    = (*round1R)(nil)
+	_ round.Restorer    = (*round1S)(nil)
+	_ round.Snapshotter = (*round1R)(nil)
+	_ round.Snapshotter = (*round2R)(nil)
+	_ round.Snapshotter = (*round3R)(nil)
+	_ round.Snapshotter = (*round1S)(nil)
+	_ round.Snapshotter = (*round2S)(nil)
+	_ round.Snapshotter = (*round3S)(nil)
+)
+
+// The secret share is sampled by the StartFunc when generating a new key, so it is stored along with the session.
+// The shares and public key are updated when refreshing, so they are stored again by later rounds.
+func (r *round1R) putState(s *round.State) {
+	s.PutSession(r.Helper)
+	s.Put("secretShare", r.secretShare)
+	s.Put("publicShare", r.publicShare)
+	s.Put("public", r.public)
+}
+
+func (r *round1R) getState(s *round.State) {
+	r.secretShare = s.Scalar("secretShare")
+	r.publicShare = s.Point("publicShare")
+	r.public = s.Point("public")
+}
+
+// The oblivious transfer setup is only stored from round2R on, since it starts in round1R.Finalize.
+func (r *round2R) putState(s *round.State) {
+	r.round1R.putState(s)
+	s.Put("proof", r.proof)
+	s.Put("decommit", r.decommit)
+	s.Put("chainKeyDecommit", r.chainKeyDecommit)
+	s.Put("refreshDecommit", r.refreshDecommit)
+	s.Put("refreshScalar", r.refreshScalar)
+	s.Put("ourChainKey", r.ourChainKey)
+	s.Put("chainKey", r.chainKey)
+	putOT(s, "receiver", r.receiver.MarshalBinary)
+}
+
+func (r *round2R) getState(s *round.State) {
+	r.proof = zksch.EmptyProof(r.Group())
+	s.Get("proof", r.proof)
+	s.Get("decommit", &r.decommit)
+	s.Get("chainKeyDecommit", &r.chainKeyDecommit)
+	s.Get("refreshDecommit", &r.refreshDecommit)
+	r.refreshScalar = s.Scalar("refreshScalar")
+	s.Get("ourChainKey", &r.ourChainKey)
+	s.Get("chainKey", &r.chainKey)
+	getOT(s, "receiver", r.receiver.UnmarshalBinary)
+}
+
+// The fields of round3R are only set when storing the Sender's message, which is not done yet.
+func (r *round3R) putState(s *round.State) { r.round2R.putState(s) }
+
+func (r *round1S) putState(s *round.State) {
+	s.PutSession(r.Helper)
+	s.Put("secretShare", r.secretShare)
+	s.Put("publicShare", r.publicShare)
+	s.Put("public", r.public)
+	s.Put("receiverCommit", r.receiverCommit)
+	s.Put("chainKeyCommit", r.chainKeyCommit)
+	s.Put("refreshCommit", r.refreshCommit)
+}
+
+func (r *round1S) getState(s *round.State) {
+	r.secretShare = s.Scalar("secretShare")
+	r.publicShare = s.Point("publicShare")
+	r.public = s.Point("public")
+	s.Get("receiverCommit", &r.receiverCommit)
+	s.Get("chainKeyCommit", &r.chainKeyCommit)
+	s.Get("refreshCommit", &r.refreshCommit)
+}
+
+// The oblivious transfer setup is only stored from round2S on, since it starts in round1S.StoreMessage.
+func (r *round2S) putState(s *round.State) {
+	r.round1S.putState(s)
+	s.Put("chainKey", r.chainKey)
+	s.Put("refreshScalar", r.refreshScalar)
+	putOT(s, "sender", r.sender.MarshalBinary)
+}
+
+func (r *round2S) getState(s *round.State) {
+	s.Get("chainKey", &r.chainKey)
+	r.refreshScalar = s.Scalar("refreshScalar")
+	getOT(s, "sender", r.sender.UnmarshalBinary)
+}
+
+// The fields of round3S are only set when storing the Receiver's message, which is not done yet.
+func (r *round3S) putState(s *round.State) { r.round2S.putState(s) }
+
+// putOT stores the encoding of the state of an oblivious transfer.
+func putOT(s *round.State, name string, marshal func() ([]byte, error)) {
+	data, err := marshal()
+	if err != nil {
+		s.Fail(fmt.Errorf("state: %s: %w", name, err))
+		return
+	}
+	s.Put(name, data)
+}
+
+// getOT restores the state of an oblivious transfer stored by putOT.
+func getOT(s *round.State, name string, unmarshal func([]byte) error) {
+	var data []byte
+	s.Get(name, &data)
+	if s.Err() != nil {
+		return
+	}
+	if err := unmarshal(data); err != nil {
+		s.Fail(fmt.Errorf("state: %s: %w", name, err))
+	}
+}
+
+type stateRound interface {
+	round.Session
+	putState(s *round.State)
+}
+
+func marshalState(r stateRound) ([]byte, error) {
+	s := round.NewState(r.Group())
+	r.putState(s)
+	return s.MarshalBinary()
+}
+
+// MarshalState implements round.Snapshotter.
+func (r *round1R) MarshalState() ([]byte, error) { return marshalState(r) }
+
+// MarshalState implements round.Snapshotter.
+func (r *round2R) MarshalState() ([]byte, error) { return marshalState(r) }
+
+// MarshalState implements round.Snapshotter.
+func (r *round3R) MarshalState() ([]byte, error) { return marshalState(r) }
+
+// MarshalState implements round.Snapshotter.
+func (r *round1S) MarshalState() ([]byte, error) { return marshalState(r) }
+
+// MarshalState implements round.Snapshotter.
+func (r *round2S) MarshalState() ([]byte, error) { return marshalState(r) }
+
+// MarshalState implements round.Snapshotter.
+func (r *round3S) MarshalState() ([]byte, error) { return marshalState(r) }
+
+// RestoreState implements round.Restorer.
+func (r *round1R) RestoreState(number round.Number, data []byte) (round.Session, error) {
+	return restoreState(r.Helper, data, func(s *round.State) (round.Session, error) {
+		r.getState(s)
+		if number == 1 {
+			return r, nil
+		}
+		r2 := &round2R{round1R: r}
+		r2.getState(s)
+		if number == 2 {
+			return r2, nil
+		}
+		if number == 3 {
+			return &round3R{round2R: r2}, nil
+		}
+		return nil, fmt.Errorf("cannot restore round %d", number)
+	})
+}
+
+// RestoreState implements round.Restorer.
+func (r *round1S) RestoreState(number round.Number, data []byte) (round.Session, error) {
+	return restoreState(r.Helper, data, func(s *round.State) (round.Session, error) {
+		r.getState(s)
+		if number == 1 {
+			return r, nil
+		}
+		r2 := &round2S{round1S: r}
+		r2.getState(s)
+		if number == 2 {
+			return r2, nil
+		}
+		if number == 3 {
+			return &round3S{round2S: r2}, nil
+		}
+		return nil, fmt.Errorf("cannot restore round %d", number)
+	})
+}
+
+// restoreState decodes data, restores the session h, and then calls restore to rebuild the rounds.
+func restoreState(h *round.Helper, data []byte, restore func(s *round.State) (round.Session, error)) (round.Session, error) {
+	s, err := round.UnmarshalState(h.Group(), data)
+	if err != nil {
+		return nil, fmt.Errorf("keygen: %w", err)
+	}
+	s.RestoreSession(h)
+	restored, err := restore(s)
+	if err != nil {
+		return nil, fmt.Errorf("keygen: %w", err)
+	}
+	if err = s.Err(); err != nil {
+		return nil, fmt.Errorf("keygen: %w", err)
+	}
+	return restored, nil
+}
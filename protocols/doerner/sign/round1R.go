@@ -37,19 +37,16 @@ func (r *round1R) Finalize(out chan<- *round.Message) (round.Session, error) {
 	kB := sample.Scalar(rand.Reader, r.Group())
 	D := kB.ActOnBase()
 	kB.Invert()
-	tag0 := &hash.BytesWithDomain{TheDomain: "Multiply0", Bytes: nil}
-	multiply0, err := ot.NewMultiplyReceiver(r.Hash().Fork(tag0), r.config.Setup, kB)
+	multiply0, err := ot.NewMultiplyReceiver(r.multiplyHash(0), r.config.Setup, kB)
 	if err != nil {
 		return r, err
 	}
-	tag1 := &hash.BytesWithDomain{TheDomain: "Multiply1", Bytes: nil}
-	multiply1, err := ot.NewMultiplyReceiver(r.Hash().Fork(tag1), r.config.Setup, kB)
+	multiply1, err := ot.NewMultiplyReceiver(r.multiplyHash(1), r.config.Setup, kB)
 	if err != nil {
 		return r, err
 	}
 	beta := r.Group().NewScalar().Set(r.config.SecretShare).Mul(kB)
-	tag2 := &hash.BytesWithDomain{TheDomain: "Multiply1", Bytes: nil}
-	multiply2, err := ot.NewMultiplyReceiver(r.Hash().Fork(tag2), r.config.Setup, beta)
+	multiply2, err := ot.NewMultiplyReceiver(r.multiplyHash(2), r.config.Setup, beta)
 	if err != nil {
 		return r, err
 	}
@@ -62,6 +59,14 @@ func (r *round1R) Finalize(out chan<- *round.Message) (round.Session, error) {
 	return &round2R{round1R: r, kBInv: kB, D: D, multiply0: multiply0, multiply1: multiply1, multiply2: multiply2}, nil
 }
 
+// multiplyHash returns the hash used by the i-th multiplication.
+//
+// The domains must match the ones used by the Sender in round1S.Finalize.
+func (r *round1R) multiplyHash(i int) *hash.Hash {
+	domains := [3]string{"Multiply0", "Multiply1", "Multiply1"}
+	return r.Hash().Fork(&hash.BytesWithDomain{TheDomain: domains[i], Bytes: nil})
+}
+
 func (round1R) MessageContent() round.Content { return nil }
 
 func (round1R) Number() round.Number { return 1 }
@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/taurusgroup/multi-party-sig/internal/round"
+	"github.com/taurusgroup/multi-party-sig/pkg/math/curve"
 	"github.com/taurusgroup/multi-party-sig/pkg/party"
 	"github.com/taurusgroup/multi-party-sig/pkg/pool"
 	"github.com/taurusgroup/multi-party-sig/pkg/protocol"
@@ -31,6 +32,9 @@ func StartSignReceiver(config *keygen.ConfigReceiver, selfID, otherID party.ID,
 		if err != nil {
 			return nil, fmt.Errorf("keygen.StartKeygen: %w", err)
 		}
+		if !curve.SupportsECDSA(helper.Group()) {
+			return nil, fmt.Errorf("sign: ECDSA is not supported over %s", helper.Group().Name())
+		}
 
 		return &round1R{Helper: helper, config: config, hash: hash}, nil
 	}
@@ -57,6 +61,9 @@ func StartSignSender(config *keygen.ConfigSender, selfID, otherID party.ID, hash
 		if err != nil {
 			return nil, fmt.Errorf("keygen.StartKeygen: %w", err)
 		}
+		if !curve.SupportsECDSA(helper.Group()) {
+			return nil, fmt.Errorf("sign: ECDSA is not supported over %s", helper.Group().Name())
+		}
 
 		return &round1S{Helper: helper, config: config, hash: hash}, nil
 	}
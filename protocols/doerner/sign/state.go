@@ -0,0 +1,129 @@
+package sign
+
+import (
+	"fmt"
+
+	"github.com/taurusgroup/multi-party-sig/internal/ot"
+	"github.com/taurusgroup/multi-party-sig/internal/round"
+)
+
+// These assert that our rounds can be snapshot and restored.
+var (
+	_ round.Restorer    = (*round1R)(nil)
+	_ round.Restorer    = (*round1S)(nil)
+	_ round.Snapshotter = (*round1R)(nil)
+	_ round.Snapshotter = (*round2R)(nil)
+	_ round.Snapshotter = (*round1S)(nil)
+	_ round.Snapshotter = (*round2S)(nil)
+)
+
+// The fields of round1R are all derived from the arguments of the StartFunc, so only the session is stored.
+func (r *round1R) putState(s *round.State) { s.PutSession(r.Helper) }
+
+// The multiplications are stored after their first round, along with the hash state they have written.
+func (r *round2R) putState(s *round.State) {
+	r.round1R.putState(s)
+	s.Put("kBInv", r.kBInv)
+	s.Put("D", r.D)
+	for i, multiply := range []*ot.MultiplyReceiver{r.multiply0, r.multiply1, r.multiply2} {
+		data, err := multiply.MarshalBinary()
+		if err != nil {
+			s.Fail(fmt.Errorf("state: multiply%d: %w", i, err))
+			return
+		}
+		s.Put(fmt.Sprintf("multiply%d", i), data)
+	}
+}
+
+func (r *round2R) getState(s *round.State) {
+	r.kBInv = s.Scalar("kBInv")
+	r.D = s.Point("D")
+	multiplies := []**ot.MultiplyReceiver{&r.multiply0, &r.multiply1, &r.multiply2}
+	for i, multiply := range multiplies {
+		var data []byte
+		s.Get(fmt.Sprintf("multiply%d", i), &data)
+		if s.Err() != nil {
+			return
+		}
+		var err error
+		*multiply, err = ot.RestoreMultiplyReceiver(r.multiplyHash(i), r.config.Setup, r.Group(), data)
+		if err != nil {
+			s.Fail(fmt.Errorf("state: multiply%d: %w", i, err))
+			return
+		}
+	}
+}
+
+// The Sender runs its multiplications in a single round, so only the session is stored.
+func (r *round1S) putState(s *round.State) { s.PutSession(r.Helper) }
+
+// The signature is only set when storing the Receiver's message, which is not done yet.
+func (r *round2S) putState(s *round.State) { r.round1S.putState(s) }
+
+type stateRound interface {
+	round.Session
+	putState(s *round.State)
+}
+
+func marshalState(r stateRound) ([]byte, error) {
+	s := round.NewState(r.Group())
+	r.putState(s)
+	return s.MarshalBinary()
+}
+
+// MarshalState implements round.Snapshotter.
+func (r *round1R) MarshalState() ([]byte, error) { return marshalState(r) }
+
+// MarshalState implements round.Snapshotter.
+func (r *round2R) MarshalState() ([]byte, error) { return marshalState(r) }
+
+// MarshalState implements round.Snapshotter.
+func (r *round1S) MarshalState() ([]byte, error) { return marshalState(r) }
+
+// MarshalState implements round.Snapshotter.
+func (r *round2S) MarshalState() ([]byte, error) { return marshalState(r) }
+
+// RestoreState implements round.Restorer.
+func (r *round1R) RestoreState(number round.Number, data []byte) (round.Session, error) {
+	return restoreState(r.Helper, data, func(s *round.State) (round.Session, error) {
+		switch number {
+		case 1:
+			return r, nil
+		case 2:
+			r2 := &round2R{round1R: r}
+			r2.getState(s)
+			return r2, nil
+		}
+		return nil, fmt.Errorf("cannot restore round %d", number)
+	})
+}
+
+// RestoreState implements round.Restorer.
+func (r *round1S) RestoreState(number round.Number, data []byte) (round.Session, error) {
+	return restoreState(r.Helper, data, func(s *round.State) (round.Session, error) {
+		switch number {
+		case 1:
+			return r, nil
+		case 2:
+			return &round2S{round1S: r}, nil
+		}
+		return nil, fmt.Errorf("cannot restore round %d", number)
+	})
+}
+
+// restoreState decodes data, restores the session h, and then calls restore to rebuild the rounds.
+func restoreState(h *round.Helper, data []byte, restore func(s *round.State) (round.Session, error)) (round.Session, error) {
+	s, err := round.UnmarshalState(h.Group(), data)
+	if err != nil {
+		return nil, fmt.Errorf("sign: %w", err)
+	}
+	s.RestoreSession(h)
+	restored, err := restore(s)
+	if err != nil {
+		return nil, fmt.Errorf("sign: %w", err)
+	}
+	if err = s.Err(); err != nil {
+		return nil, fmt.Errorf("sign: %w", err)
+	}
+	return restored, nil
+}
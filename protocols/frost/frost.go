@@ -2,6 +2,7 @@ package frost
 
 import (
 	"github.com/taurusgroup/multi-party-sig/internal/round"
+	"github.com/taurusgroup/multi-party-sig/pkg/export"
 	"github.com/taurusgroup/multi-party-sig/pkg/math/curve"
 	"github.com/taurusgroup/multi-party-sig/pkg/party"
 	"github.com/taurusgroup/multi-party-sig/pkg/protocol"
@@ -13,6 +14,18 @@ type (
 	Config        = keygen.Config
 	TaprootConfig = keygen.TaprootConfig
 	Signature     = sign.Signature
+	// RFC9591Signature is the result of SignRFC9591.
+	RFC9591Signature = sign.RFC9591Signature
+	Commitment       = sign.Commitment
+	Nonces           = sign.Nonces
+	ROASTCoordinator = sign.ROASTCoordinator
+	ROASTSigner      = sign.ROASTSigner
+	SignRequest      = sign.SignRequest
+	SignResponse     = sign.SignResponse
+	// PreSignature is the result of SignAdaptor.
+	PreSignature = sign.PreSignature
+	// TaprootPreSignature is the result of SignTaprootAdaptor.
+	TaprootPreSignature = sign.TaprootPreSignature
 )
 
 // EmptyConfig creates an empty Config with a specific group.
@@ -101,32 +114,206 @@ func RefreshTaproot(config *TaprootConfig, participants []party.ID) protocol.Sta
 // Instead, each participant independently verifies and broadcasts items as necessary.
 //
 // Differences stemming from this change are commented throughout the protocol.
+//
+// Over curve.Ed25519, the challenge is computed as in RFC 8032, and messageHash should be the message itself.
+// The resulting signature can then be encoded with Signature.MarshalEd25519, and verified by crypto/ed25519.Verify.
 func Sign(config *Config, signers []party.ID, messageHash []byte) protocol.StartFunc {
 	return sign.StartSignCommon(false, config, signers, messageHash)
 }
 
+// SignRFC9591 is like Sign, but follows the FROST ciphersuite of RFC 9591 for the group of config,
+// so that the signature can be verified by other implementations of that ciphersuite.
+//
+// message is the message itself, rather than its hash, and the identifiers of the RFC are the
+// party IDs of the signers, interpreted as scalars.
+//
+// The supported ciphersuites are:
+//   - FROST(Ed25519, SHA-512) over curve.Ed25519, whose signatures are valid for crypto/ed25519.Verify,
+//   - FROST(ristretto255, SHA-512) over curve.Ristretto255,
+//   - FROST(P-256, SHA-256) over curve.P256,
+//   - FROST(secp256k1, SHA-256) over curve.Secp256k1.
+//
+// See: https://www.rfc-editor.org/rfc/rfc9591.html
+func SignRFC9591(config *Config, signers []party.ID, message []byte) protocol.StartFunc {
+	return sign.StartSignRFC9591(config, signers, message)
+}
+
+// Preprocess generates count pairs of nonces ahead of time, which can later be used by SignWithCommitments.
+//
+// The Commitments are public, and should be published to the party coordinating signatures,
+// which stores them until they are used.
+// The Nonces are secret, and kept by this party until they are consumed by SignWithCommitments.
+//
+// This corresponds to the preprocessing stage of Figure 2 of the Frost paper:
+//   https://eprint.iacr.org/2020/852.pdf
+func Preprocess(config *Config, count int) (*Nonces, []*Commitment, error) {
+	return sign.Preprocess(config, count)
+}
+
+// SignWithCommitments is like Sign, but uses one Commitment generated by Preprocess for each signer,
+// so that the signature is produced after a single round of communication.
+//
+// commitments must contain exactly one Commitment for each signer, and every signer must receive the same commitments.
+// The nonces behind the Commitment of this party are deleted from nonces when the protocol starts,
+// and the protocol fails if they were already used, since reusing nonces would leak the private share.
+func SignWithCommitments(config *Config, nonces *Nonces, commitments []*Commitment, messageHash []byte) protocol.StartFunc {
+	return sign.StartSignWithCommitments(config, nonces, commitments, messageHash)
+}
+
+// EmptyNonces creates an empty Nonces with a specific group, ready for unmarshalling.
+func EmptyNonces(group curve.Curve) *Nonces {
+	return sign.EmptyNonces(group)
+}
+
+// EmptyCommitment creates an empty Commitment with a specific group, ready for unmarshalling.
+func EmptyCommitment(group curve.Curve) *Commitment {
+	return sign.EmptyCommitment(group)
+}
+
+// NewROASTCoordinator creates the coordinator of a robust signature of messageHash with ROAST,
+// for the key with the given threshold, public key, and verification shares.
+//
+// Instead of a fixed set of signers, the coordinator keeps starting sessions with the first threshold + 1
+// signers which responded, excluding those which sent an invalid response. As long as threshold + 1 honest
+// signers respond, a Signature is eventually produced, even if other signers are slow, unresponsive or malicious.
+//
+// Each signer must create a ROASTSigner with NewROASTSigner, and send its Commitment to the coordinator.
+// Then, each SignRequest returned by the coordinator is answered by its signers with ROASTSigner.Sign,
+// and their SignResponses are handed back to the coordinator.
+//
+// See: https://eprint.iacr.org/2022/550.pdf
+func NewROASTCoordinator(threshold int, publicKey curve.Point, verificationShares *party.PointMap, messageHash []byte) *ROASTCoordinator {
+	return sign.NewROASTCoordinator(threshold, publicKey, verificationShares, messageHash)
+}
+
+// NewROASTSigner creates a signer answering the requests of a ROASTCoordinator for messageHash,
+// along with its first Commitment, which must be sent to the coordinator.
+func NewROASTSigner(config *Config, messageHash []byte) (*ROASTSigner, *Commitment, error) {
+	return sign.NewROASTSigner(config, messageHash)
+}
+
+// EmptySignRequest creates an empty SignRequest with a specific group, ready for unmarshalling.
+func EmptySignRequest(group curve.Curve) *SignRequest {
+	return sign.EmptySignRequest(group)
+}
+
+// EmptySignResponse creates an empty SignResponse with a specific group, ready for unmarshalling.
+func EmptySignResponse(group curve.Curve) *SignResponse {
+	return sign.EmptySignResponse(group)
+}
+
 // SignTaproot is like Sign, but will generate a Taproot / BIP-340 compatible signature.
 //
 // This needs to result of a Taproot compatible key generation phase, naturally.
 //
 // See: https://github.com/bitcoin/bips/blob/master/bip-0340.mediawiki
 func SignTaproot(config *TaprootConfig, signers []party.ID, messageHash []byte) protocol.StartFunc {
-	publicKey, err := curve.Secp256k1{}.LiftX(config.PublicKey)
+	normalResult, err := taprootToConfig(config)
 	if err != nil {
 		return func([]byte) (round.Session, error) {
 			return nil, err
 		}
 	}
+	return sign.StartSignCommon(true, normalResult, signers, messageHash)
+}
+
+// taprootToConfig converts a TaprootConfig into the equivalent Config, with a full public key point.
+func taprootToConfig(config *TaprootConfig) (*Config, error) {
+	publicKey, err := curve.Secp256k1{}.LiftX(config.PublicKey)
+	if err != nil {
+		return nil, err
+	}
 	genericVerificationShares := make(map[party.ID]curve.Point)
 	for k, v := range config.VerificationShares {
 		genericVerificationShares[k] = v
 	}
-	normalResult := &keygen.Config{
+	return &keygen.Config{
 		ID:                 config.ID,
 		Threshold:          config.Threshold,
 		PrivateShare:       config.PrivateShare,
 		PublicKey:          publicKey,
 		VerificationShares: party.NewPointMap(genericVerificationShares),
+	}, nil
+}
+
+// SignTaprootKeyPath is like SignTaproot, but signs for a key path spend of the BIP-341 output key
+// committing to merkleRoot, whose internal key is the public key of config.
+//
+// merkleRoot can be empty if the output key commits to no scripts, as recommended by BIP-86.
+// The resulting signature verifies against the PublicKey of config.Tweak(merkleRoot).
+//
+// See: https://github.com/bitcoin/bips/blob/master/bip-0341.mediawiki
+func SignTaprootKeyPath(config *TaprootConfig, merkleRoot []byte, signers []party.ID, messageHash []byte) protocol.StartFunc {
+	tweaked, err := config.Tweak(merkleRoot)
+	if err != nil {
+		return func([]byte) (round.Session, error) {
+			return nil, err
+		}
 	}
-	return sign.StartSignCommon(true, normalResult, signers, messageHash)
+	return SignTaproot(tweaked, signers, messageHash)
+}
+
+// SignAdaptor is like Sign, but generates a pre-signature for the adaptor point T = t • G, instead of a signature.
+//
+// The resulting PreSignature can be checked with PreSignature.Verify, and only someone knowing t
+// can turn it into a valid Signature, with PreSignature.Complete. In turn, anyone holding both the
+// PreSignature and the completed Signature learns t, with PreSignature.Extract.
+//
+// This allows atomic swaps: the other party of the swap pre-signs its side for the same T,
+// so that publishing our completed signature reveals t, and lets them complete their own.
+func SignAdaptor(config *Config, signers []party.ID, messageHash []byte, T curve.Point) protocol.StartFunc {
+	return sign.StartSignAdaptor(false, config, signers, messageHash, T)
+}
+
+// SignTaprootAdaptor is like SignAdaptor, but generates a TaprootPreSignature,
+// which completes into a Taproot / BIP-340 compatible signature.
+//
+// The secret t can be extracted from the completed taproot.Signature with TaprootPreSignature.Extract.
+func SignTaprootAdaptor(config *TaprootConfig, signers []party.ID, messageHash []byte, T curve.Point) protocol.StartFunc {
+	normalResult, err := taprootToConfig(config)
+	if err != nil {
+		return func([]byte) (round.Session, error) {
+			return nil, err
+		}
+	}
+	return sign.StartSignAdaptor(true, normalResult, signers, messageHash, T)
+}
+
+// EmptyPreSignature creates an empty PreSignature with a specific group, ready for unmarshalling.
+func EmptyPreSignature(group curve.Curve) *PreSignature {
+	return sign.EmptyPreSignature(group)
+}
+
+// ExportShare encrypts the private share of config to recipient, the public key of whoever reconstructs the secret key.
+//
+// All parties contributing to the same export use the same ceremonyID, which is never reused.
+// The secret key is recovered from t+1 contributions with ExportKey(config).Reconstruct.
+func ExportShare(config *Config, ceremonyID []byte, recipient curve.Point) (*export.Contribution, error) {
+	return export.Encrypt(ceremonyID, config.ID, config.PrivateShare, recipient)
+}
+
+// ExportKey returns the public data of the key of config, against which exported shares are verified.
+func ExportKey(config *Config) *export.Key {
+	return &export.Key{
+		Group:        config.Curve(),
+		Threshold:    config.Threshold,
+		PublicKey:    config.PublicKey,
+		PublicShares: config.VerificationShares.Points,
+	}
+}
+
+// ExportShareTaproot is like ExportShare, but for a TaprootConfig.
+func ExportShareTaproot(config *TaprootConfig, ceremonyID []byte, recipient curve.Point) (*export.Contribution, error) {
+	return export.Encrypt(ceremonyID, config.ID, config.PrivateShare, recipient)
+}
+
+// ExportKeyTaproot is like ExportKey, but for a TaprootConfig.
+//
+// The reconstructed secret key is the one whose public key has an even y coordinate, as in BIP-340.
+func ExportKeyTaproot(config *TaprootConfig) (*export.Key, error) {
+	normal, err := taprootToConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	return ExportKey(normal), nil
 }
@@ -2,6 +2,8 @@ package frost
 
 import (
 	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
 	"fmt"
 	"sync"
 	"testing"
@@ -9,7 +11,9 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/taurusgroup/multi-party-sig/internal/test"
+	"github.com/taurusgroup/multi-party-sig/pkg/export"
 	"github.com/taurusgroup/multi-party-sig/pkg/math/curve"
+	"github.com/taurusgroup/multi-party-sig/pkg/math/sample"
 	"github.com/taurusgroup/multi-party-sig/pkg/party"
 	"github.com/taurusgroup/multi-party-sig/pkg/protocol"
 	"github.com/taurusgroup/multi-party-sig/pkg/taproot"
@@ -94,3 +98,419 @@ func TestFrost(t *testing.T) {
 	}
 	wg.Wait()
 }
+
+func TestSnapshot(t *testing.T) {
+	N := 3
+	T := N - 1
+	partyIDs := test.PartyIDs(N)
+	key := make([]byte, protocol.SnapshotKeySize)
+
+	handlers := make(map[party.ID]protocol.Handler, N)
+	for _, id := range partyIDs {
+		h, err := protocol.NewMultiHandler(Keygen(curve.Secp256k1{}, id, partyIDs, T), nil)
+		require.NoError(t, err)
+		require.NoError(t, h.SetSnapshotKey(key))
+		handlers[id] = h
+	}
+	require.True(t, test.Step(handlers))
+
+	// simulate a crash of the first party, after it entered round 3.
+	restart := partyIDs[0]
+	log := protocol.NewMemorySnapshotLog()
+	snapshot, err := handlers[restart].(*protocol.MultiHandler).Snapshot()
+	require.NoError(t, err)
+	handlers[restart], err = protocol.RestoreMultiHandler(snapshot, key, Keygen(curve.Secp256k1{}, restart, partyIDs, T), log)
+	require.NoError(t, err)
+
+	for test.Step(handlers) {
+	}
+
+	configs := make(map[party.ID]*Config, N)
+	for id, h := range handlers {
+		r, err := h.Result()
+		require.NoError(t, err)
+		require.IsType(t, &Config{}, r)
+		configs[id] = r.(*Config)
+	}
+	for _, c := range configs {
+		assert.True(t, configs[restart].PublicKey.Equal(c.PublicKey))
+	}
+
+	message := []byte("hello")
+	for _, id := range partyIDs {
+		h, err := protocol.NewMultiHandler(Sign(configs[id], partyIDs, message), nil)
+		require.NoError(t, err)
+		require.NoError(t, h.SetSnapshotKey(key))
+		handlers[id] = h
+	}
+	require.True(t, test.Step(handlers))
+
+	snapshot, err = handlers[restart].(*protocol.MultiHandler).Snapshot()
+	require.NoError(t, err)
+	handlers[restart], err = protocol.RestoreMultiHandler(snapshot, key, Sign(configs[restart], partyIDs, message), log)
+	require.NoError(t, err)
+
+	// a second handler restored from the same nonces would leak the secret key
+	_, err = protocol.RestoreMultiHandler(snapshot, key, Sign(configs[restart], partyIDs, message), log)
+	assert.ErrorIs(t, err, protocol.ErrSnapshotConsumed)
+	other, err := handlers[restart].(*protocol.MultiHandler).Snapshot()
+	require.NoError(t, err)
+	_, err = protocol.RestoreMultiHandler(other, key, Sign(configs[restart], partyIDs, message), log)
+	require.NoError(t, err, "a snapshot of the restored handler can be restored once more")
+
+	for test.Step(handlers) {
+	}
+	for _, h := range handlers {
+		signResult, err := h.Result()
+		require.NoError(t, err)
+		require.IsType(t, Signature{}, signResult)
+		assert.True(t, signResult.(Signature).Verify(configs[restart].PublicKey, message))
+	}
+}
+
+// keygenWith runs a keygen over group between 3 parties, and returns the configs of all parties.
+func keygenWith(t *testing.T, group curve.Curve) (party.IDSlice, map[party.ID]*Config) {
+	N := 3
+	T := N - 1
+	partyIDs := test.PartyIDs(N)
+
+	handlers := make(map[party.ID]protocol.Handler, N)
+	for _, id := range partyIDs {
+		h, err := protocol.NewMultiHandler(Keygen(group, id, partyIDs, T), nil)
+		require.NoError(t, err)
+		handlers[id] = h
+	}
+	for test.Step(handlers) {
+	}
+	configs := make(map[party.ID]*Config, N)
+	for id, h := range handlers {
+		r, err := h.Result()
+		require.NoError(t, err)
+		require.IsType(t, &Config{}, r)
+		configs[id] = r.(*Config)
+	}
+	return partyIDs, configs
+}
+
+// signWith runs a keygen over group followed by a signature of message, and returns the results of all parties.
+func signWith(t *testing.T, group curve.Curve, message []byte) (map[party.ID]*Config, map[party.ID]Signature) {
+	partyIDs, configs := keygenWith(t, group)
+	N := len(partyIDs)
+
+	handlers := make(map[party.ID]protocol.Handler, N)
+	for _, id := range partyIDs {
+		h, err := protocol.NewMultiHandler(Sign(configs[id], partyIDs, message), nil)
+		require.NoError(t, err)
+		handlers[id] = h
+	}
+	for test.Step(handlers) {
+	}
+	signatures := make(map[party.ID]Signature, N)
+	for id, h := range handlers {
+		r, err := h.Result()
+		require.NoError(t, err)
+		require.IsType(t, Signature{}, r)
+		signatures[id] = r.(Signature)
+		assert.True(t, signatures[id].Verify(configs[id].PublicKey, message))
+	}
+	return configs, signatures
+}
+
+func TestFrostEd25519(t *testing.T) {
+	message := []byte("hello")
+	configs, signatures := signWith(t, curve.Ed25519{}, message)
+	for id, signature := range signatures {
+		public, err := configs[id].PublicKey.MarshalBinary()
+		require.NoError(t, err)
+		sig, err := signature.MarshalEd25519()
+		require.NoError(t, err)
+		assert.True(t, ed25519.Verify(public, message, sig))
+		assert.False(t, ed25519.Verify(public, []byte("other"), sig))
+	}
+}
+
+func TestFrostP256(t *testing.T) {
+	signWith(t, curve.P256{}, []byte("hello"))
+}
+
+func TestFrostRFC9591(t *testing.T) {
+	message := []byte("hello")
+	for _, group := range []curve.Curve{curve.Ed25519{}, curve.Ristretto255{}, curve.P256{}, curve.Secp256k1{}} {
+		partyIDs, configs := keygenWith(t, group)
+
+		handlers := make(map[party.ID]protocol.Handler, len(partyIDs))
+		for _, id := range partyIDs {
+			h, err := protocol.NewMultiHandler(SignRFC9591(configs[id], partyIDs, message), nil)
+			require.NoError(t, err)
+			handlers[id] = h
+		}
+		for test.Step(handlers) {
+		}
+		var first RFC9591Signature
+		for id, h := range handlers {
+			r, err := h.Result()
+			require.NoError(t, err, group.Name())
+			require.IsType(t, RFC9591Signature{}, r)
+			signature := r.(RFC9591Signature)
+			assert.True(t, signature.Verify(configs[id].PublicKey, message), group.Name())
+			assert.False(t, signature.Verify(configs[id].PublicKey, []byte("other")), group.Name())
+			if first == nil {
+				first = signature
+			}
+			assert.Equal(t, first, signature)
+		}
+
+		if _, ok := group.(curve.Ed25519); ok {
+			public, err := configs[partyIDs[0]].PublicKey.MarshalBinary()
+			require.NoError(t, err)
+			assert.True(t, ed25519.Verify(public, message, first))
+		}
+	}
+}
+
+func TestFrostPreprocess(t *testing.T) {
+	message := []byte("hello")
+	partyIDs, configs := keygenWith(t, curve.Secp256k1{})
+
+	nonces := make(map[party.ID]*Nonces, len(partyIDs))
+	published := make(map[party.ID][]*Commitment, len(partyIDs))
+	for _, id := range partyIDs {
+		n, commitments, err := Preprocess(configs[id], 2)
+		require.NoError(t, err)
+		require.Len(t, commitments, 2)
+		nonces[id], published[id] = n, commitments
+	}
+
+	sign := func(index int) map[party.ID]protocol.Handler {
+		commitments := make([]*Commitment, 0, len(partyIDs))
+		for _, id := range partyIDs {
+			commitments = append(commitments, published[id][index])
+		}
+		handlers := make(map[party.ID]protocol.Handler, len(partyIDs))
+		for _, id := range partyIDs {
+			h, err := protocol.NewMultiHandler(SignWithCommitments(configs[id], nonces[id], commitments, message), nil)
+			require.NoError(t, err)
+			handlers[id] = h
+		}
+		// a single round of messages is enough
+		require.True(t, test.Step(handlers))
+		return handlers
+	}
+
+	for index := 0; index < 2; index++ {
+		for id, h := range sign(index) {
+			r, err := h.Result()
+			require.NoError(t, err)
+			require.IsType(t, Signature{}, r)
+			assert.True(t, r.(Signature).Verify(configs[id].PublicKey, message))
+			assert.Equal(t, 1-index, nonces[id].Len())
+		}
+	}
+
+	// the nonces of every commitment have been used, so they cannot be used again
+	commitments := []*Commitment{published[partyIDs[0]][0], published[partyIDs[1]][0], published[partyIDs[2]][0]}
+	_, err := protocol.NewMultiHandler(SignWithCommitments(configs[partyIDs[0]], nonces[partyIDs[0]], commitments, message), nil)
+	assert.Error(t, err)
+
+	// nonces survive serialization
+	n, commitments2, err := Preprocess(configs[partyIDs[0]], 1)
+	require.NoError(t, err)
+	data, err := n.MarshalBinary()
+	require.NoError(t, err)
+	decoded := EmptyNonces(curve.Secp256k1{})
+	require.NoError(t, decoded.UnmarshalBinary(data))
+	assert.True(t, decoded.Contains(commitments2[0]))
+	assert.False(t, decoded.Contains(published[partyIDs[0]][1]))
+}
+
+func TestFrostTaprootKeyPath(t *testing.T) {
+	N := 3
+	T := N - 1
+	message := []byte("hello")
+	partyIDs := test.PartyIDs(N)
+
+	handlers := make(map[party.ID]protocol.Handler, N)
+	for _, id := range partyIDs {
+		h, err := protocol.NewMultiHandler(KeygenTaproot(id, partyIDs, T), nil)
+		require.NoError(t, err)
+		handlers[id] = h
+	}
+	for test.Step(handlers) {
+	}
+	configs := make(map[party.ID]*TaprootConfig, N)
+	for id, h := range handlers {
+		r, err := h.Result()
+		require.NoError(t, err)
+		require.IsType(t, &TaprootConfig{}, r)
+		configs[id] = r.(*TaprootConfig)
+	}
+
+	merkleRoot := bytes.Repeat([]byte{0x42}, taproot.MerkleRootLen)
+	for _, root := range [][]byte{nil, merkleRoot} {
+		internal := configs[partyIDs[0]].PublicKey
+		output, _, err := internal.Tweak(root)
+		require.NoError(t, err)
+		for _, id := range partyIDs {
+			tweaked, err := configs[id].Tweak(root)
+			require.NoError(t, err)
+			assert.Equal(t, output, tweaked.PublicKey)
+		}
+
+		for _, id := range partyIDs {
+			h, err := protocol.NewMultiHandler(SignTaprootKeyPath(configs[id], root, partyIDs, message), nil)
+			require.NoError(t, err)
+			handlers[id] = h
+		}
+		for test.Step(handlers) {
+		}
+		for _, h := range handlers {
+			r, err := h.Result()
+			require.NoError(t, err)
+			require.IsType(t, taproot.Signature{}, r)
+			assert.True(t, output.Verify(r.(taproot.Signature), message))
+			assert.False(t, internal.Verify(r.(taproot.Signature), message))
+		}
+	}
+}
+
+func TestFrostTaprootAdaptor(t *testing.T) {
+	N := 3
+	T := N - 1
+	message := []byte("hello")
+	partyIDs := test.PartyIDs(N)
+
+	handlers := make(map[party.ID]protocol.Handler, N)
+	for _, id := range partyIDs {
+		h, err := protocol.NewMultiHandler(KeygenTaproot(id, partyIDs, T), nil)
+		require.NoError(t, err)
+		handlers[id] = h
+	}
+	for test.Step(handlers) {
+	}
+	configs := make(map[party.ID]*TaprootConfig, N)
+	for id, h := range handlers {
+		r, err := h.Result()
+		require.NoError(t, err)
+		require.IsType(t, &TaprootConfig{}, r)
+		configs[id] = r.(*TaprootConfig)
+	}
+	public := configs[partyIDs[0]].PublicKey
+
+	adaptorSecret := sample.Scalar(rand.Reader, curve.Secp256k1{}).(*curve.Secp256k1Scalar)
+	adaptorPoint := adaptorSecret.ActOnBase()
+	for _, id := range partyIDs {
+		h, err := protocol.NewMultiHandler(SignTaprootAdaptor(configs[id], partyIDs, message, adaptorPoint), nil)
+		require.NoError(t, err)
+		handlers[id] = h
+	}
+	for test.Step(handlers) {
+	}
+	for _, h := range handlers {
+		r, err := h.Result()
+		require.NoError(t, err)
+		require.IsType(t, TaprootPreSignature{}, r)
+		preSig := r.(TaprootPreSignature)
+		assert.True(t, preSig.Verify(public, message))
+
+		sig, err := preSig.Complete(adaptorSecret)
+		require.NoError(t, err)
+		assert.True(t, public.Verify(sig, message))
+		extracted, err := preSig.Extract(sig)
+		require.NoError(t, err)
+		assert.True(t, adaptorSecret.Equal(extracted))
+	}
+}
+
+func TestFrostAdaptorMismatch(t *testing.T) {
+	N := 3
+	T := N - 1
+	message := []byte("hello")
+	partyIDs := test.PartyIDs(N)
+	group := curve.Secp256k1{}
+
+	handlers := make(map[party.ID]protocol.Handler, N)
+	for _, id := range partyIDs {
+		h, err := protocol.NewMultiHandler(Keygen(group, id, partyIDs, T), nil)
+		require.NoError(t, err)
+		handlers[id] = h
+	}
+	for test.Step(handlers) {
+	}
+
+	// the last signer was given a different adaptor point than the others.
+	adaptorPoint := sample.Scalar(rand.Reader, group).ActOnBase()
+	otherPoint := sample.Scalar(rand.Reader, group).ActOnBase()
+	odd := partyIDs[N-1]
+	multiHandlers := make(map[party.ID]*protocol.MultiHandler, N)
+	for _, id := range partyIDs {
+		r, err := handlers[id].Result()
+		require.NoError(t, err)
+		point := adaptorPoint
+		if id == odd {
+			point = otherPoint
+		}
+		h, err := protocol.NewMultiHandler(SignAdaptor(r.(*Config), partyIDs, message, point), nil)
+		require.NoError(t, err)
+		handlers[id] = h
+		multiHandlers[id] = h
+	}
+
+	// the first message of the odd signer belongs to another session, so the others reject it.
+	msg := <-handlers[odd].Listen()
+	for _, id := range partyIDs {
+		if id != odd {
+			assert.False(t, multiHandlers[id].CanAccept(msg))
+		}
+	}
+	for test.Step(handlers) {
+	}
+	for _, h := range handlers {
+		_, err := h.Result()
+		assert.Error(t, err, "no party should get past the first round")
+	}
+}
+
+func TestExportShare(t *testing.T) {
+	ceremonyID := []byte("export")
+	recipientSecret := sample.Scalar(rand.Reader, curve.Secp256k1{})
+	recipient := recipientSecret.ActOnBase()
+
+	partyIDs, configs := keygenWith(t, curve.Secp256k1{})
+	contributions := make([]*export.Contribution, 0, len(partyIDs))
+	for _, id := range partyIDs {
+		c, err := ExportShare(configs[id], ceremonyID, recipient)
+		require.NoError(t, err)
+		contributions = append(contributions, c)
+	}
+	secret, _, err := ExportKey(configs[partyIDs[0]]).Reconstruct(recipientSecret, ceremonyID, contributions)
+	require.NoError(t, err)
+	assert.True(t, secret.ActOnBase().Equal(configs[partyIDs[0]].PublicKey))
+
+	handlers := make(map[party.ID]protocol.Handler, len(partyIDs))
+	for _, id := range partyIDs {
+		h, err := protocol.NewMultiHandler(KeygenTaproot(id, partyIDs, len(partyIDs)-1), nil)
+		require.NoError(t, err)
+		handlers[id] = h
+	}
+	for test.Step(handlers) {
+	}
+	contributions = contributions[:0]
+	var key *export.Key
+	var publicKey taproot.PublicKey
+	for _, id := range partyIDs {
+		r, err := handlers[id].Result()
+		require.NoError(t, err)
+		config := r.(*TaprootConfig)
+		c, err := ExportShareTaproot(config, ceremonyID, recipient)
+		require.NoError(t, err)
+		contributions = append(contributions, c)
+		if key == nil {
+			key, err = ExportKeyTaproot(config)
+			require.NoError(t, err)
+			publicKey = config.PublicKey
+		}
+	}
+	secret, _, err = key.Reconstruct(recipientSecret, ceremonyID, contributions)
+	require.NoError(t, err)
+	assert.Equal(t, []byte(publicKey), secret.ActOnBase().XBytes())
+}
@@ -157,7 +157,28 @@ func (r *TaprootConfig) Derive(adjust *curve.Secp256k1Scalar, newChainKey []byte
 	if len(newChainKey) != params.SecBytes {
 		return nil, fmt.Errorf("expecte %d bytes for chain key, found %d", params.SecBytes, len(newChainKey))
 	}
+	return r.add(adjust, newChainKey)
+}
+
+// Tweak adjusts the shares to represent the BIP-341 output key Q = P + hash_TapTweak(P || merkleRoot) * G,
+// where P is the current public key, which becomes the internal key.
+//
+// merkleRoot is the root of the script tree committed to by Q, and can be empty if there are no scripts,
+// as recommended by BIP-86. Signatures produced with the resulting config are valid for a key path spend,
+// and verify against its PublicKey.
+//
+// See: https://github.com/bitcoin/bips/blob/master/bip-0341.mediawiki#constructing-and-spending-taproot-outputs
+func (r *TaprootConfig) Tweak(merkleRoot []byte) (*TaprootConfig, error) {
+	tweak, err := taproot.TweakScalar(r.PublicKey, merkleRoot)
+	if err != nil {
+		return nil, err
+	}
+	return r.add(tweak, r.ChainKey)
+}
 
+// add returns the config for the key P + adjust * G, where P is the current public key,
+// negating everything if the result has an odd y coordinate.
+func (r *TaprootConfig) add(adjust *curve.Secp256k1Scalar, newChainKey []byte) (*TaprootConfig, error) {
 	adjustG := adjust.ActOnBase()
 	verificationShares := make(map[party.ID]*curve.Secp256k1Point, len(r.VerificationShares))
 	for k, v := range r.VerificationShares {
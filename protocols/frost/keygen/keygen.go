@@ -34,9 +34,9 @@ func StartKeygenCommon(taproot bool, group curve.Curve, participants []party.ID,
 			Group:            group,
 		}
 		if taproot {
-			info.ProtocolID = protocolID
-		} else {
 			info.ProtocolID = protocolIDTaproot
+		} else {
+			info.ProtocolID = protocolID
 		}
 
 		helper, err := round.NewSession(info, sessionID, nil)
@@ -49,6 +49,8 @@ func StartKeygenCommon(taproot bool, group curve.Curve, participants []party.ID,
 			verificationSharesCopy[k] = v
 		}
 
+		// the StartFunc may be called more than once, so the captured arguments must not be modified.
+		privateShare, publicKey := privateShare, publicKey
 		refresh := true
 		if privateShare == nil || publicKey == nil {
 			refresh = false
@@ -164,3 +164,14 @@ func TestKeygenTaproot(t *testing.T) {
 
 	checkOutputTaproot(t, rounds, partyIDs)
 }
+
+func TestStartKeygenTwice(t *testing.T) {
+	group := curve.Secp256k1{}
+	partyIDs := test.PartyIDs(2)
+	start := StartKeygenCommon(false, group, partyIDs, 1, partyIDs[0], nil, nil, nil)
+	for i := 0; i < 2; i++ {
+		r, err := start(nil)
+		require.NoError(t, err)
+		assert.False(t, r.(*round1).refresh, "the second call should not turn into a refresh")
+	}
+}
@@ -0,0 +1,110 @@
+package keygen
+
+import (
+	"fmt"
+
+	"github.com/taurusgroup/multi-party-sig/internal/round"
+	"github.com/taurusgroup/multi-party-sig/internal/types"
+	"github.com/taurusgroup/multi-party-sig/pkg/hash"
+	"github.com/taurusgroup/multi-party-sig/pkg/math/polynomial"
+	"github.com/taurusgroup/multi-party-sig/pkg/party"
+)
+
+// These assert that our rounds can be snapshot and restored.
+var (
+	_ round.Restorer    = (*round1)(nil)
+	_ round.Snapshotter = (*round1)(nil)
+	_ round.Snapshotter = (*round2)(nil)
+	_ round.Snapshotter = (*round3)(nil)
+)
+
+// The fields of round1 are all derived from the arguments of the StartFunc, so only the session is stored.
+func (r *round1) putState(s *round.State) { s.PutSession(r.Helper) }
+
+func (r *round2) putState(s *round.State) {
+	r.round1.putState(s)
+	s.Put("f_i", r.f_i)
+	s.Put("Phi", r.Phi)
+	s.Put("ChainKeyDecommitment", r.ChainKeyDecommitment)
+	s.Put("ChainKeys", r.ChainKeys)
+	s.Put("ChainKeyCommitments", r.ChainKeyCommitments)
+}
+
+func (r *round2) getState(s *round.State) {
+	r.f_i = polynomial.EmptyPolynomial(r.Group())
+	s.Get("f_i", r.f_i)
+	r.Phi = map[party.ID]*polynomial.Exponent{}
+	s.Map("Phi", func(id party.ID) interface{} {
+		r.Phi[id] = polynomial.EmptyExponent(r.Group())
+		return r.Phi[id]
+	})
+	s.Get("ChainKeyDecommitment", &r.ChainKeyDecommitment)
+	r.ChainKeys = map[party.ID]types.RID{}
+	s.Get("ChainKeys", &r.ChainKeys)
+	r.ChainKeyCommitments = map[party.ID]hash.Commitment{}
+	s.Get("ChainKeyCommitments", &r.ChainKeyCommitments)
+}
+
+func (r *round3) putState(s *round.State) {
+	r.round2.putState(s)
+	s.Put("shareFrom", r.shareFrom)
+}
+
+func (r *round3) getState(s *round.State) {
+	r.shareFrom = s.ScalarMap("shareFrom")
+}
+
+type stateRound interface {
+	round.Session
+	putState(s *round.State)
+}
+
+func marshalState(r stateRound) ([]byte, error) {
+	s := round.NewState(r.Group())
+	r.putState(s)
+	return s.MarshalBinary()
+}
+
+// MarshalState implements round.Snapshotter.
+func (r *round1) MarshalState() ([]byte, error) { return marshalState(r) }
+
+// MarshalState implements round.Snapshotter.
+func (r *round2) MarshalState() ([]byte, error) { return marshalState(r) }
+
+// MarshalState implements round.Snapshotter.
+func (r *round3) MarshalState() ([]byte, error) { return marshalState(r) }
+
+// RestoreState implements round.Restorer.
+func (r *round1) RestoreState(number round.Number, data []byte) (round.Session, error) {
+	s, err := round.UnmarshalState(r.Group(), data)
+	if err != nil {
+		return nil, fmt.Errorf("keygen: %w", err)
+	}
+	s.RestoreSession(r.Helper)
+	restored, err := r.restore(s, number)
+	if err != nil {
+		return nil, fmt.Errorf("keygen: %w", err)
+	}
+	if err = s.Err(); err != nil {
+		return nil, fmt.Errorf("keygen: %w", err)
+	}
+	return restored, nil
+}
+
+// restore rebuilds the rounds following r until the given round number.
+func (r *round1) restore(s *round.State, number round.Number) (round.Session, error) {
+	if number == 1 {
+		return r, nil
+	}
+	r2 := &round2{round1: r}
+	r2.getState(s)
+	if number == 2 {
+		return r2, nil
+	}
+	r3 := &round3{round2: r2}
+	r3.getState(s)
+	if number == 3 {
+		return r3, nil
+	}
+	return nil, fmt.Errorf("cannot restore round %d", number)
+}
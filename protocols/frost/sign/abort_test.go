@@ -0,0 +1,89 @@
+package sign
+
+import (
+	"crypto/rand"
+	"testing"
+
+	"github.com/cronokirby/safenum"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/taurusgroup/multi-party-sig/internal/round"
+	"github.com/taurusgroup/multi-party-sig/internal/test"
+	"github.com/taurusgroup/multi-party-sig/pkg/math/curve"
+	"github.com/taurusgroup/multi-party-sig/pkg/math/polynomial"
+	"github.com/taurusgroup/multi-party-sig/pkg/math/sample"
+	"github.com/taurusgroup/multi-party-sig/pkg/party"
+	"github.com/taurusgroup/multi-party-sig/protocols/frost/keygen"
+)
+
+var oneNat = new(safenum.Nat).SetUint64(1)
+
+// TestRule adds one to the response zᵢ broadcast by each of the cheaters.
+type TestRule struct {
+	cheaters party.IDSlice
+}
+
+func (tr *TestRule) ModifyBefore(round.Session) {}
+
+func (tr *TestRule) ModifyAfter(round.Session) {}
+
+func (tr *TestRule) ModifyContent(rNext round.Session, _ party.ID, content round.Content) {
+	c, ok := content.(*broadcast3)
+	if !ok || !tr.cheaters.Contains(rNext.SelfID()) {
+		return
+	}
+	one := rNext.Group().NewScalar().SetNat(oneNat)
+	c.Z_i = rNext.Group().NewScalar().Set(c.Z_i).Add(one)
+}
+
+func TestIdentifiableAbort(t *testing.T) {
+	group := curve.Secp256k1{}
+	N := 5
+	threshold := 3
+	partyIDs := test.PartyIDs(N)
+	cheaters := party.NewIDSlice([]party.ID{partyIDs[1], partyIDs[3]})
+
+	secret := sample.Scalar(rand.Reader, group)
+	f := polynomial.NewPolynomial(group, threshold, secret)
+	verificationShares := make(map[party.ID]curve.Point, N)
+	for _, id := range partyIDs {
+		verificationShares[id] = f.Evaluate(id.Scalar(group)).ActOnBase()
+	}
+
+	adaptorPoint := sample.Scalar(rand.Reader, group).ActOnBase()
+	for _, T := range []curve.Point{nil, adaptorPoint} {
+		for _, taproot := range []bool{false, true} {
+			rounds := make([]round.Session, 0, N)
+			for _, id := range partyIDs {
+				config := &keygen.Config{
+					ID:                 id,
+					Threshold:          threshold,
+					PublicKey:          secret.ActOnBase(),
+					PrivateShare:       f.Evaluate(id.Scalar(group)),
+					VerificationShares: party.NewPointMap(verificationShares),
+				}
+				start := StartSignCommon(taproot, config, partyIDs, []byte("hello"))
+				if T != nil {
+					start = StartSignAdaptor(taproot, config, partyIDs, []byte("hello"), T)
+				}
+				r, err := start(nil)
+				require.NoError(t, err)
+				rounds = append(rounds, r)
+			}
+			for {
+				err, done := test.Rounds(rounds, &TestRule{cheaters: cheaters})
+				require.NoError(t, err)
+				if done {
+					break
+				}
+			}
+			for _, r := range rounds {
+				require.IsType(t, &round.Abort{}, r)
+				if cheaters.Contains(r.SelfID()) {
+					continue
+				}
+				assert.Equal(t, []party.ID(cheaters), r.(*round.Abort).Culprits)
+			}
+		}
+	}
+}
@@ -0,0 +1,230 @@
+package sign
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/cronokirby/safenum"
+	"github.com/fxamacker/cbor/v2"
+	"github.com/taurusgroup/multi-party-sig/pkg/math/curve"
+	"github.com/taurusgroup/multi-party-sig/pkg/taproot"
+)
+
+// PreSignature is an adaptor signature of a message, for an adaptor point T = t • G.
+//
+// This pre-signature claims to satisfy:
+//
+//    z * G = R - T + H(R, Y, m) * Y
+//
+// for a public key Y, so that (R, z + t) is a valid Signature.
+// Only someone knowing t can complete it, and anyone holding both the PreSignature and
+// the completed Signature learns t, which is what makes atomic swaps possible.
+type PreSignature struct {
+	// R is the commitment point of the completed signature, which includes T.
+	R curve.Point
+	// T is the adaptor point.
+	T curve.Point
+	// z is the response scalar, short of t.
+	z curve.Scalar
+}
+
+// EmptyPreSignature creates an empty PreSignature with a specific group, ready for unmarshalling.
+func EmptyPreSignature(group curve.Curve) *PreSignature {
+	return &PreSignature{R: group.NewPoint(), T: group.NewPoint(), z: group.NewScalar()}
+}
+
+// Verify checks if the pre-signature equation actually holds.
+//
+// Note that m is the hash of a message, and not the message itself.
+func (sig PreSignature) Verify(public curve.Point, m []byte) bool {
+	challenge := computeChallenge(sig.R, public, m)
+
+	expected := challenge.Act(public)
+	expected = expected.Add(sig.R).Sub(sig.T)
+
+	actual := sig.z.ActOnBase()
+
+	return expected.Equal(actual)
+}
+
+// Complete turns the pre-signature into a Signature, using the discrete log t of T.
+func (sig PreSignature) Complete(t curve.Scalar) (Signature, error) {
+	if !t.ActOnBase().Equal(sig.T) {
+		return Signature{}, errors.New("sign: secret doesn't match the adaptor point")
+	}
+	z := t.Curve().NewScalar().Set(sig.z).Add(t)
+	return Signature{R: sig.R, z: z}, nil
+}
+
+// Extract returns the discrete log t of T, from a Signature completing this pre-signature.
+func (sig PreSignature) Extract(completed Signature) (curve.Scalar, error) {
+	if !completed.R.Equal(sig.R) {
+		return nil, errors.New("sign: signature doesn't complete the pre-signature")
+	}
+	t := sig.z.Curve().NewScalar().Set(sig.z).Negate().Add(completed.z)
+	if !t.ActOnBase().Equal(sig.T) {
+		return nil, errors.New("sign: signature doesn't complete the pre-signature")
+	}
+	return t, nil
+}
+
+// preSignatureData is the serialized form of PreSignature.
+type preSignatureData struct {
+	R, T curve.Point
+	Z    curve.Scalar
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+func (sig PreSignature) MarshalBinary() ([]byte, error) {
+	return cbor.Marshal(preSignatureData{R: sig.R, T: sig.T, Z: sig.z})
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+//
+// sig must have been created by EmptyPreSignature.
+func (sig *PreSignature) UnmarshalBinary(data []byte) error {
+	if sig.R == nil || sig.T == nil || sig.z == nil {
+		return errors.New("sign: PreSignature must be initialized with EmptyPreSignature")
+	}
+	if err := cbor.Unmarshal(data, &preSignatureData{R: sig.R, T: sig.T, Z: sig.z}); err != nil {
+		return err
+	}
+	if sig.R.IsIdentity() || sig.T.IsIdentity() {
+		return errors.New("sign: invalid pre-signature")
+	}
+	return nil
+}
+
+// TaprootPreSignatureLen is the number of bytes in an encoded TaprootPreSignature.
+const TaprootPreSignatureLen = 98
+
+// TaprootPreSignature is an adaptor signature of a message, for an adaptor point T = t • G,
+// which completes into a taproot.Signature.
+//
+// BIP-340 requires the commitment point of the signature to have an even y coordinate.
+// This is taken into account by negating the nonces when R has an odd y coordinate,
+// so that with g = ±1 accordingly, this pre-signature claims to satisfy:
+//
+//    z * G = g * (R - T) + H(g * R, Y, m) * Y
+//
+// and (g * R, z + g * t) is a valid taproot.Signature.
+type TaprootPreSignature struct {
+	// R is the commitment point of the completed signature, which includes T, before adjusting its parity.
+	R *curve.Secp256k1Point
+	// T is the adaptor point.
+	T *curve.Secp256k1Point
+	// z is the response scalar, short of g * t.
+	z *curve.Secp256k1Scalar
+}
+
+// parity returns g = ±1 such that g * R has an even y coordinate.
+func (sig TaprootPreSignature) parity() curve.Scalar {
+	g := new(curve.Secp256k1Scalar).SetNat(new(safenum.Nat).SetUint64(1))
+	if !sig.R.HasEvenY() {
+		g.Negate()
+	}
+	return g
+}
+
+// Verify checks if the pre-signature equation actually holds, for the x-only public key of a taproot signature.
+//
+// Note that m is the hash of a message, and not the message itself.
+func (sig TaprootPreSignature) Verify(public taproot.PublicKey, m []byte) bool {
+	Y, err := curve.Secp256k1{}.LiftX(public)
+	if err != nil {
+		return false
+	}
+	cHash := taproot.TaggedHash("BIP0340/challenge", sig.R.XBytes(), public, m)
+	challenge := new(curve.Secp256k1Scalar).SetNat(new(safenum.Nat).SetBytes(cHash))
+
+	expected := sig.parity().Act(sig.R.Sub(sig.T))
+	expected = expected.Add(challenge.Act(Y))
+
+	actual := sig.z.ActOnBase()
+
+	return expected.Equal(actual)
+}
+
+// Complete turns the pre-signature into a taproot.Signature, using the discrete log t of T.
+func (sig TaprootPreSignature) Complete(t *curve.Secp256k1Scalar) (taproot.Signature, error) {
+	if !t.ActOnBase().Equal(sig.T) {
+		return nil, errors.New("sign: secret doesn't match the adaptor point")
+	}
+	z := sig.parity().Mul(t).Add(sig.z)
+	zBytes, err := z.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	out := taproot.Signature(make([]byte, 0, taproot.SignatureLen))
+	out = append(out, sig.R.XBytes()...)
+	out = append(out, zBytes...)
+	return out, nil
+}
+
+// Extract returns the discrete log t of T, from a taproot.Signature completing this pre-signature.
+func (sig TaprootPreSignature) Extract(completed taproot.Signature) (*curve.Secp256k1Scalar, error) {
+	if len(completed) != taproot.SignatureLen || !bytes.Equal(completed[:32], sig.R.XBytes()) {
+		return nil, errors.New("sign: signature doesn't complete the pre-signature")
+	}
+	s := new(curve.Secp256k1Scalar)
+	if err := s.UnmarshalBinary(completed[32:]); err != nil {
+		return nil, fmt.Errorf("sign: %w", err)
+	}
+	t := sig.parity().Mul(s.Sub(sig.z)).(*curve.Secp256k1Scalar)
+	if !t.ActOnBase().Equal(sig.T) {
+		return nil, errors.New("sign: signature doesn't complete the pre-signature")
+	}
+	return t, nil
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+//
+// The encoding is R || T || z, with the points in compressed form.
+func (sig TaprootPreSignature) MarshalBinary() ([]byte, error) {
+	out := make([]byte, 0, TaprootPreSignatureLen)
+	for _, v := range []interface{ MarshalBinary() ([]byte, error) }{sig.R, sig.T, sig.z} {
+		data, err := v.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, data...)
+	}
+	return out, nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+func (sig *TaprootPreSignature) UnmarshalBinary(data []byte) error {
+	if len(data) != TaprootPreSignatureLen {
+		return fmt.Errorf("sign: invalid pre-signature length %d", len(data))
+	}
+	R, T, z := new(curve.Secp256k1Point), new(curve.Secp256k1Point), new(curve.Secp256k1Scalar)
+	if err := R.UnmarshalBinary(data[:33]); err != nil {
+		return fmt.Errorf("sign: %w", err)
+	}
+	if err := T.UnmarshalBinary(data[33:66]); err != nil {
+		return fmt.Errorf("sign: %w", err)
+	}
+	if err := z.UnmarshalBinary(data[66:]); err != nil {
+		return fmt.Errorf("sign: %w", err)
+	}
+	if R.IsIdentity() || T.IsIdentity() {
+		return errors.New("sign: invalid pre-signature")
+	}
+	sig.R, sig.T, sig.z = R, T, z
+	return nil
+}
+
+// validateAdaptorPoint checks that T can be used as an adaptor point for signatures over group.
+func validateAdaptorPoint(taprootSig bool, group curve.Curve, T curve.Point) error {
+	if T == nil || T.IsIdentity() {
+		return errors.New("invalid adaptor point")
+	}
+	if T.Curve().Name() != group.Name() {
+		return fmt.Errorf("adaptor point is not over %s", group.Name())
+	}
+	if _, ok := T.(*curve.Secp256k1Point); taprootSig && !ok {
+		return errors.New("adaptor point is not over secp256k1")
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package sign
+
+import (
+	"crypto/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/taurusgroup/multi-party-sig/internal/round"
+	"github.com/taurusgroup/multi-party-sig/internal/test"
+	"github.com/taurusgroup/multi-party-sig/pkg/math/curve"
+	"github.com/taurusgroup/multi-party-sig/pkg/math/polynomial"
+	"github.com/taurusgroup/multi-party-sig/pkg/math/sample"
+	"github.com/taurusgroup/multi-party-sig/pkg/party"
+	"github.com/taurusgroup/multi-party-sig/pkg/taproot"
+	"github.com/taurusgroup/multi-party-sig/protocols/frost/keygen"
+)
+
+// runAdaptor runs StartSignAdaptor among the first threshold + 1 parties, and returns the result of each.
+func runAdaptor(t *testing.T, taprootSig bool, group curve.Curve, secret curve.Scalar, threshold int, m []byte, T curve.Point) []interface{} {
+	partyIDs := test.PartyIDs(threshold + 2)
+	signers := partyIDs[:threshold+1]
+
+	f := polynomial.NewPolynomial(group, threshold, secret)
+	verificationShares := make(map[party.ID]curve.Point, len(partyIDs))
+	for _, id := range partyIDs {
+		verificationShares[id] = f.Evaluate(id.Scalar(group)).ActOnBase()
+	}
+
+	rounds := make([]round.Session, 0, len(signers))
+	for _, id := range signers {
+		config := &keygen.Config{
+			ID:                 id,
+			Threshold:          threshold,
+			PublicKey:          secret.ActOnBase(),
+			PrivateShare:       f.Evaluate(id.Scalar(group)),
+			VerificationShares: party.NewPointMap(verificationShares),
+		}
+		r, err := StartSignAdaptor(taprootSig, config, signers, m, T)(nil)
+		require.NoError(t, err, "round creation should not result in an error")
+		rounds = append(rounds, r)
+	}
+	for {
+		err, done := test.Rounds(rounds, nil)
+		require.NoError(t, err, "failed to process round")
+		if done {
+			break
+		}
+	}
+
+	results := make([]interface{}, 0, len(rounds))
+	for _, r := range rounds {
+		require.IsType(t, &round.Output{}, r, "expected result round")
+		results = append(results, r.(*round.Output).Result)
+	}
+	return results
+}
+
+func TestSignAdaptor(t *testing.T) {
+	m := []byte("hello")
+	for _, group := range []curve.Curve{curve.Secp256k1{}, curve.P256{}, curve.Ed25519{}} {
+		secret := sample.Scalar(rand.Reader, group)
+		public := secret.ActOnBase()
+		adaptorSecret := sample.Scalar(rand.Reader, group)
+		T := adaptorSecret.ActOnBase()
+
+		for _, result := range runAdaptor(t, false, group, secret, 2, m, T) {
+			require.IsType(t, PreSignature{}, result, "expected pre-signature result")
+			preSig := result.(PreSignature)
+			assert.True(t, preSig.Verify(public, m), "expected valid pre-signature")
+			assert.False(t, preSig.Verify(T, m))
+
+			_, err := preSig.Complete(sample.Scalar(rand.Reader, group))
+			assert.Error(t, err, "only t completes the pre-signature")
+			sig, err := preSig.Complete(adaptorSecret)
+			require.NoError(t, err)
+			assert.True(t, sig.Verify(public, m), "expected valid signature")
+
+			extracted, err := preSig.Extract(sig)
+			require.NoError(t, err)
+			assert.True(t, adaptorSecret.Equal(extracted))
+
+			data, err := preSig.MarshalBinary()
+			require.NoError(t, err)
+			decoded := EmptyPreSignature(group)
+			require.NoError(t, decoded.UnmarshalBinary(data))
+			assert.True(t, decoded.Verify(public, m))
+		}
+	}
+}
+
+func TestSignTaprootAdaptor(t *testing.T) {
+	group := curve.Secp256k1{}
+	m := []byte("hello")
+
+	secret := sample.Scalar(rand.Reader, group)
+	if !secret.ActOnBase().(*curve.Secp256k1Point).HasEvenY() {
+		secret.Negate()
+	}
+	public := taproot.PublicKey(secret.ActOnBase().(*curve.Secp256k1Point).XBytes())
+
+	// R + T has an odd y coordinate half of the time, so we repeat to cover both cases.
+	for i := 0; i < 8; i++ {
+		adaptorSecret := sample.Scalar(rand.Reader, group).(*curve.Secp256k1Scalar)
+		T := adaptorSecret.ActOnBase()
+
+		for _, result := range runAdaptor(t, true, group, secret, 2, m, T) {
+			require.IsType(t, TaprootPreSignature{}, result, "expected taproot pre-signature result")
+			preSig := result.(TaprootPreSignature)
+			assert.True(t, preSig.Verify(public, m), "expected valid pre-signature")
+			assert.False(t, preSig.Verify(public, []byte("world")))
+
+			_, err := preSig.Complete(sample.Scalar(rand.Reader, group).(*curve.Secp256k1Scalar))
+			assert.Error(t, err, "only t completes the pre-signature")
+			sig, err := preSig.Complete(adaptorSecret)
+			require.NoError(t, err)
+			assert.True(t, public.Verify(sig, m), "expected valid signature")
+
+			extracted, err := preSig.Extract(sig)
+			require.NoError(t, err)
+			assert.True(t, adaptorSecret.Equal(extracted))
+
+			data, err := preSig.MarshalBinary()
+			require.NoError(t, err)
+			require.Len(t, data, TaprootPreSignatureLen)
+			var decoded TaprootPreSignature
+			require.NoError(t, decoded.UnmarshalBinary(data))
+			assert.True(t, decoded.Verify(public, m))
+		}
+	}
+
+	// The adaptor point must be usable by BIP-340.
+	config := &keygen.Config{ID: "a", PublicKey: secret.ActOnBase(), PrivateShare: secret, VerificationShares: party.EmptyPointMap(group)}
+	_, err := StartSignAdaptor(true, config, []party.ID{"a"}, m, curve.P256{}.NewBasePoint())(nil)
+	assert.Error(t, err)
+	_, err = StartSignAdaptor(true, config, []party.ID{"a"}, m, group.NewPoint())(nil)
+	assert.Error(t, err)
+}
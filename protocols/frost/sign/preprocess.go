@@ -0,0 +1,194 @@
+package sign
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+
+	"github.com/taurusgroup/multi-party-sig/internal/preprocess"
+	"github.com/taurusgroup/multi-party-sig/internal/round"
+	"github.com/taurusgroup/multi-party-sig/pkg/math/curve"
+	"github.com/taurusgroup/multi-party-sig/pkg/math/sample"
+	"github.com/taurusgroup/multi-party-sig/pkg/party"
+	"github.com/taurusgroup/multi-party-sig/pkg/protocol"
+	"github.com/taurusgroup/multi-party-sig/protocols/frost/keygen"
+	"github.com/zeebo/blake3"
+)
+
+// protocolIDPreprocessed identifies signatures using preprocessed commitments.
+const protocolIDPreprocessed = "frost/sign-threshold-preprocessed"
+
+// Commitment is a pair of nonce commitments (Dᵢ, Eᵢ) = (dᵢ•G, eᵢ•G), published by a signer ahead of time.
+//
+// This corresponds to the list Lᵢ published in the preprocessing stage of Figure 2 of the Frost paper:
+//   https://eprint.iacr.org/2020/852.pdf
+type Commitment struct {
+	// ID is the signer holding the corresponding nonces.
+	ID party.ID
+	// D is the commitment to the first nonce.
+	D curve.Point
+	// E is the commitment to the second nonce.
+	E curve.Point
+}
+
+// EmptyCommitment creates an empty Commitment with a specific group, ready for unmarshalling.
+func EmptyCommitment(group curve.Curve) *Commitment {
+	return &Commitment{D: group.NewPoint(), E: group.NewPoint()}
+}
+
+// Nonces holds the secret nonces (dᵢ, eᵢ) behind the Commitments of a signer, until they are used.
+//
+// Each pair of nonces can only be used once, so a Nonces which is stored must be persisted again after every signature,
+// and never restored from an older copy.
+//
+// It is safe for concurrent use.
+type Nonces struct {
+	nonces *preprocess.Nonces
+}
+
+// EmptyNonces creates an empty Nonces with a specific group, ready for unmarshalling.
+func EmptyNonces(group curve.Curve) *Nonces {
+	return &Nonces{nonces: preprocess.NewNonces(group, "")}
+}
+
+// Preprocess generates count pairs of nonces for the party owning config.
+//
+// The Commitments must be published to whoever coordinates signatures,
+// while the Nonces must be kept secret by the party.
+func Preprocess(config *keygen.Config, count int) (*Nonces, []*Commitment, error) {
+	if count <= 0 {
+		return nil, nil, fmt.Errorf("sign.Preprocess: invalid count %d", count)
+	}
+	group := config.Curve()
+
+	// We use the same hedged process as round1, albeit without the message which is not known yet:
+	//
+	//   hk = KDF(s_i)
+	//   (d_i, e_i) = H_hk(a)
+	//
+	// for fresh randomness a.
+	s_iBytes, err := config.PrivateShare.MarshalBinary()
+	if err != nil {
+		return nil, nil, fmt.Errorf("sign.Preprocess: %w", err)
+	}
+	hashKey := make([]byte, 32)
+	blake3.DeriveKey(deriveHashKeyContext, s_iBytes, hashKey)
+
+	nonces := &Nonces{nonces: preprocess.NewNonces(group, config.ID)}
+	commitments := make([]*Commitment, 0, count)
+	for len(commitments) < count {
+		nonceHasher, _ := blake3.NewKeyed(hashKey)
+		a := make([]byte, 32)
+		if _, err = rand.Read(a); err != nil {
+			return nil, nil, fmt.Errorf("sign.Preprocess: %w", err)
+		}
+		_, _ = nonceHasher.Write(a)
+		nonceDigest := nonceHasher.Digest()
+		d_i := sample.ScalarUnit(nonceDigest, group)
+		e_i := sample.ScalarUnit(nonceDigest, group)
+
+		c := &Commitment{ID: config.ID, D: d_i.ActOnBase(), E: e_i.ActOnBase()}
+		nonces.nonces.Add(c.D, d_i, e_i)
+		commitments = append(commitments, c)
+	}
+	return nonces, commitments, nil
+}
+
+// Len returns the number of unused pairs of nonces.
+func (n *Nonces) Len() int { return n.nonces.Len() }
+
+// Contains returns true if the nonces behind c are held, and unused.
+func (n *Nonces) Contains(c *Commitment) bool { return n.nonces.Contains(c.ID, c.D) }
+
+// take removes and returns the nonces behind c.
+func (n *Nonces) take(c *Commitment) (d_i, e_i curve.Scalar, err error) {
+	return n.nonces.Take(c.ID, c.D)
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+func (n *Nonces) MarshalBinary() ([]byte, error) { return n.nonces.MarshalBinary() }
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+//
+// The Nonces must have been created with EmptyNonces.
+func (n *Nonces) UnmarshalBinary(data []byte) error { return n.nonces.UnmarshalBinary(data) }
+
+// StartSignWithCommitments is like StartSignCommon, but uses Commitments published in advance,
+// so that the protocol only needs a single round of communication.
+//
+// commitments contains exactly one Commitment of each signer, the one of this party being taken from nonces.
+// Its nonces are removed from nonces before the protocol starts, even if it fails later on.
+func StartSignWithCommitments(result *keygen.Config, nonces *Nonces, commitments []*Commitment, messageHash []byte) protocol.StartFunc {
+	return func(sessionID []byte) (round.Session, error) {
+		signers, D, E, err := parseCommitments(result, commitments)
+		if err != nil {
+			return nil, fmt.Errorf("sign.StartSignWithCommitments: %w", err)
+		}
+		var own *Commitment
+		for _, c := range commitments {
+			if c.ID == result.ID {
+				own = c
+			}
+		}
+		if own == nil {
+			return nil, errors.New("sign.StartSignWithCommitments: no commitment of this party")
+		}
+
+		r1, err := newRound1(protocolIDPreprocessed, false, nil, nil, result, signers, messageHash, sessionID)
+		if err != nil {
+			return nil, fmt.Errorf("sign.StartSignWithCommitments: %w", err)
+		}
+
+		d_i, e_i, err := nonces.take(own)
+		if err != nil {
+			return nil, fmt.Errorf("sign.StartSignWithCommitments: %w", err)
+		}
+		return &preprocess.Round{
+			Helper: r1.Helper,
+			Next: &round2{
+				round1: r1,
+				d_i:    d_i,
+				e_i:    e_i,
+				D:      D,
+				E:      E,
+			},
+		}, nil
+	}
+}
+
+// parseCommitments validates the commitments of a signature with the key of result,
+// and returns the sorted signers, along with their commitments Dₗ and Eₗ.
+func parseCommitments(result *keygen.Config, commitments []*Commitment) (party.IDSlice, map[party.ID]curve.Point, map[party.ID]curve.Point, error) {
+	group := result.Curve()
+	signers := make([]party.ID, 0, len(commitments))
+	D := make(map[party.ID]curve.Point, len(commitments))
+	E := make(map[party.ID]curve.Point, len(commitments))
+	for _, c := range commitments {
+		if err := validateCommitment(group, result.VerificationShares.Points, c); err != nil {
+			return nil, nil, nil, err
+		}
+		if _, ok := D[c.ID]; ok {
+			return nil, nil, nil, fmt.Errorf("several commitments of %v", c.ID)
+		}
+		signers = append(signers, c.ID)
+		D[c.ID], E[c.ID] = c.D, c.E
+	}
+	return party.NewIDSlice(signers), D, E, nil
+}
+
+// validateCommitment checks that c is a valid commitment over group, of a party with a verification share.
+func validateCommitment(group curve.Curve, verificationShares map[party.ID]curve.Point, c *Commitment) error {
+	if c == nil || c.D == nil || c.E == nil {
+		return errors.New("nil commitment")
+	}
+	if c.D.IsIdentity() || c.E.IsIdentity() {
+		return fmt.Errorf("commitment of %v is the identity point", c.ID)
+	}
+	if c.D.Curve().Name() != group.Name() || c.E.Curve().Name() != group.Name() {
+		return fmt.Errorf("commitment of %v is not over %s", c.ID, group.Name())
+	}
+	if _, ok := verificationShares[c.ID]; !ok {
+		return fmt.Errorf("unknown signer %v", c.ID)
+	}
+	return nil
+}
@@ -0,0 +1,325 @@
+package sign
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"fmt"
+	"sort"
+
+	"github.com/cronokirby/safenum"
+	"github.com/taurusgroup/multi-party-sig/pkg/math/curve"
+)
+
+// ciphersuite is a FROST ciphersuite, as defined in Section 6 of RFC 9591:
+//   https://www.rfc-editor.org/rfc/rfc9591.html#section-6
+//
+// It fixes the encoding of group elements and scalars, as well as the hash functions
+// used to derive nonces, binding factors and challenges, so that signatures produced in the
+// RFC 9591 mode interoperate with other implementations of the same ciphersuite.
+type ciphersuite interface {
+	// H1 is used to derive binding factors.
+	H1(m []byte) curve.Scalar
+	// H2 is used to derive the challenge.
+	H2(m []byte) curve.Scalar
+	// H3 is used to derive nonces.
+	H3(m []byte) curve.Scalar
+	// H4 is used to hash the message.
+	H4(m []byte) []byte
+	// H5 is used to hash the list of nonce commitments.
+	H5(m []byte) []byte
+	// SerializeElement returns the encoding of a group element.
+	SerializeElement(curve.Point) []byte
+	// SerializeScalar returns the encoding of a scalar.
+	SerializeScalar(curve.Scalar) []byte
+	// DeserializeScalar decodes a scalar, rejecting non-canonical encodings.
+	DeserializeScalar([]byte) (curve.Scalar, error)
+}
+
+// ciphersuiteFor returns the RFC 9591 ciphersuite defined over group.
+func ciphersuiteFor(group curve.Curve) (ciphersuite, error) {
+	switch group.(type) {
+	case curve.Ed25519:
+		return ed25519SHA512{sha512Ciphersuite{group: group, contextString: "FROST-ED25519-SHA512-v1"}}, nil
+	case curve.Ristretto255:
+		return sha512Ciphersuite{group: group, contextString: "FROST-RISTRETTO255-SHA512-v1"}, nil
+	case curve.P256:
+		return sha256Ciphersuite{group: group, contextString: "FROST-P256-SHA256-v1"}, nil
+	case curve.Secp256k1:
+		return sha256Ciphersuite{group: group, contextString: "FROST-secp256k1-SHA256-v1"}, nil
+	default:
+		return nil, fmt.Errorf("no RFC 9591 ciphersuite over %s", group.Name())
+	}
+}
+
+// sha512Ciphersuite is FROST(ristretto255, SHA-512), described in Section 6.2 of RFC 9591,
+// and the basis of FROST(Ed25519, SHA-512).
+//
+// Scalars are encoded in little endian, and hashed to with a 64 byte digest interpreted in little endian.
+type sha512Ciphersuite struct {
+	group         curve.Curve
+	contextString string
+}
+
+func (cs sha512Ciphersuite) hash(tag string, m []byte) []byte {
+	h := sha512.New()
+	_, _ = h.Write([]byte(cs.contextString))
+	_, _ = h.Write([]byte(tag))
+	_, _ = h.Write(m)
+	return h.Sum(nil)
+}
+
+func (cs sha512Ciphersuite) H1(m []byte) curve.Scalar {
+	return scalarFromLittleEndian(cs.group, cs.hash("rho", m))
+}
+
+func (cs sha512Ciphersuite) H2(m []byte) curve.Scalar {
+	return scalarFromLittleEndian(cs.group, cs.hash("chal", m))
+}
+
+func (cs sha512Ciphersuite) H3(m []byte) curve.Scalar {
+	return scalarFromLittleEndian(cs.group, cs.hash("nonce", m))
+}
+
+func (cs sha512Ciphersuite) H4(m []byte) []byte {
+	return cs.hash("msg", m)
+}
+
+func (cs sha512Ciphersuite) H5(m []byte) []byte {
+	return cs.hash("com", m)
+}
+
+func (sha512Ciphersuite) SerializeElement(p curve.Point) []byte {
+	data, _ := p.MarshalBinary()
+	return data
+}
+
+func (sha512Ciphersuite) SerializeScalar(s curve.Scalar) []byte {
+	return littleEndian(s)
+}
+
+func (cs sha512Ciphersuite) DeserializeScalar(data []byte) (curve.Scalar, error) {
+	return fromLittleEndian(cs.group, data)
+}
+
+// ed25519SHA512 is FROST(Ed25519, SHA-512), described in Section 6.1 of RFC 9591.
+type ed25519SHA512 struct {
+	sha512Ciphersuite
+}
+
+// H2 omits the context string, so that the challenge is the one of RFC 8032,
+// and signatures can be verified as regular Ed25519 signatures.
+func (cs ed25519SHA512) H2(m []byte) curve.Scalar {
+	digest := sha512.Sum512(m)
+	return scalarFromLittleEndian(cs.group, digest[:])
+}
+
+// sha256Ciphersuite is FROST(P-256, SHA-256) or FROST(secp256k1, SHA-256),
+// described in Sections 6.4 and 6.5 of RFC 9591.
+//
+// Elements are encoded in the compressed format of SEC 1, and scalars in big endian,
+// which matches the MarshalBinary methods of curve.P256 and curve.Secp256k1.
+type sha256Ciphersuite struct {
+	group         curve.Curve
+	contextString string
+}
+
+// hashToField implements hash_to_field of RFC 9380 with expand_message_xmd and SHA-256,
+// producing a single scalar, with L = 48 and DST = contextString || tag.
+func (cs sha256Ciphersuite) hashToField(tag string, m []byte) curve.Scalar {
+	uniform := expandMessageXMD(m, []byte(cs.contextString+tag), 48)
+	return cs.group.NewScalar().SetNat(new(safenum.Nat).SetBytes(uniform))
+}
+
+func (cs sha256Ciphersuite) hash(tag string, m []byte) []byte {
+	h := sha256.New()
+	_, _ = h.Write([]byte(cs.contextString))
+	_, _ = h.Write([]byte(tag))
+	_, _ = h.Write(m)
+	return h.Sum(nil)
+}
+
+func (cs sha256Ciphersuite) H1(m []byte) curve.Scalar {
+	return cs.hashToField("rho", m)
+}
+
+func (cs sha256Ciphersuite) H2(m []byte) curve.Scalar {
+	return cs.hashToField("chal", m)
+}
+
+func (cs sha256Ciphersuite) H3(m []byte) curve.Scalar {
+	return cs.hashToField("nonce", m)
+}
+
+func (cs sha256Ciphersuite) H4(m []byte) []byte {
+	return cs.hash("msg", m)
+}
+
+func (cs sha256Ciphersuite) H5(m []byte) []byte {
+	return cs.hash("com", m)
+}
+
+func (sha256Ciphersuite) SerializeElement(p curve.Point) []byte {
+	data, _ := p.MarshalBinary()
+	return data
+}
+
+func (sha256Ciphersuite) SerializeScalar(s curve.Scalar) []byte {
+	data, _ := s.MarshalBinary()
+	return data
+}
+
+func (cs sha256Ciphersuite) DeserializeScalar(data []byte) (curve.Scalar, error) {
+	s := cs.group.NewScalar()
+	if err := s.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// expandMessageXMD implements expand_message_xmd of Section 5.3.1 of RFC 9380 with SHA-256,
+// for outputs of at most 255 * 32 bytes.
+func expandMessageXMD(msg, dst []byte, length int) []byte {
+	dstPrime := append(append([]byte{}, dst...), byte(len(dst)))
+	ell := (length + sha256.Size - 1) / sha256.Size
+
+	h := sha256.New()
+	_, _ = h.Write(make([]byte, sha256.BlockSize))
+	_, _ = h.Write(msg)
+	_, _ = h.Write([]byte{byte(length >> 8), byte(length), 0})
+	_, _ = h.Write(dstPrime)
+	b0 := h.Sum(nil)
+
+	out := make([]byte, 0, ell*sha256.Size)
+	b := make([]byte, sha256.Size)
+	for i := 1; i <= ell; i++ {
+		// b₁ = H(b₀ || 1 || DST'), bᵢ = H((b₀ ⊕ bᵢ₋₁) || i || DST')
+		for j := range b {
+			b[j] ^= b0[j]
+		}
+		h.Reset()
+		_, _ = h.Write(b)
+		_, _ = h.Write([]byte{byte(i)})
+		_, _ = h.Write(dstPrime)
+		b = h.Sum(nil)
+		out = append(out, b...)
+	}
+	return out[:length]
+}
+
+// scalarFromLittleEndian interprets data as a little endian integer, and reduces it modulo the order of group.
+func scalarFromLittleEndian(group curve.Curve, data []byte) curve.Scalar {
+	reversed := make([]byte, len(data))
+	for i := range data {
+		reversed[len(data)-1-i] = data[i]
+	}
+	return group.NewScalar().SetNat(new(safenum.Nat).SetBytes(reversed))
+}
+
+// littleEndian returns the little endian encoding of a scalar.
+func littleEndian(s curve.Scalar) []byte {
+	data, _ := s.MarshalBinary()
+	for i, j := 0, len(data)-1; i < j; i, j = i+1, j-1 {
+		data[i], data[j] = data[j], data[i]
+	}
+	return data
+}
+
+// fromLittleEndian decodes a scalar from its canonical little endian encoding.
+func fromLittleEndian(group curve.Curve, data []byte) (curve.Scalar, error) {
+	reversed := make([]byte, len(data))
+	for i := range data {
+		reversed[len(data)-1-i] = data[i]
+	}
+	s := group.NewScalar()
+	if err := s.UnmarshalBinary(reversed); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// commitment is the pair of nonce commitments (Dᵢ, Eᵢ) published by the signer with identifier i.
+type commitment struct {
+	// ID is the identifier of the signer, as a scalar.
+	ID curve.Scalar
+	// D is the hiding nonce commitment.
+	D curve.Point
+	// E is the binding nonce commitment.
+	E curve.Point
+}
+
+// sortCommitments sorts commitments by increasing identifier, as required by RFC 9591.
+//
+// This differs from the ordering of party.IDSlice, which compares identifiers as strings.
+func sortCommitments(commitments []commitment) {
+	sort.Slice(commitments, func(i, j int) bool {
+		a, _ := commitments[i].ID.MarshalBinary()
+		b, _ := commitments[j].ID.MarshalBinary()
+		return bytes.Compare(a, b) < 0
+	})
+}
+
+// nonceGenerate derives a nonce from 32 bytes of randomness and a secret share, as in Section 4.1 of RFC 9591.
+func nonceGenerate(cs ciphersuite, secret curve.Scalar, random []byte) curve.Scalar {
+	return cs.H3(append(append([]byte{}, random...), cs.SerializeScalar(secret)...))
+}
+
+// computeBindingFactors returns the binding factor ρᵢ of each signer, in the same order as commitments,
+// which must be sorted.
+//
+// This follows Section 4.4 of RFC 9591.
+func computeBindingFactors(cs ciphersuite, Y curve.Point, message []byte, commitments []commitment) []curve.Scalar {
+	var encodedCommitments []byte
+	for _, c := range commitments {
+		encodedCommitments = append(encodedCommitments, cs.SerializeScalar(c.ID)...)
+		encodedCommitments = append(encodedCommitments, cs.SerializeElement(c.D)...)
+		encodedCommitments = append(encodedCommitments, cs.SerializeElement(c.E)...)
+	}
+	prefix := cs.SerializeElement(Y)
+	prefix = append(prefix, cs.H4(message)...)
+	prefix = append(prefix, cs.H5(encodedCommitments)...)
+
+	rho := make([]curve.Scalar, len(commitments))
+	for i, c := range commitments {
+		input := append(append([]byte{}, prefix...), cs.SerializeScalar(c.ID)...)
+		rho[i] = cs.H1(input)
+	}
+	return rho
+}
+
+// computeRFC9591Challenge returns c = H2(R || Y || m), as in Section 4.6 of RFC 9591.
+func computeRFC9591Challenge(cs ciphersuite, R, Y curve.Point, message []byte) curve.Scalar {
+	input := cs.SerializeElement(R)
+	input = append(input, cs.SerializeElement(Y)...)
+	input = append(input, message...)
+	return cs.H2(input)
+}
+
+// RFC9591Signature is a Schnorr signature produced by StartSignRFC9591,
+// encoded as SerializeElement(R) || SerializeScalar(z) in the ciphersuite of the key.
+type RFC9591Signature []byte
+
+// Verify checks the signature over message against the public key, following Section 6 of RFC 9591.
+//
+// The ciphersuite is determined by the group of public.
+func (sig RFC9591Signature) Verify(public curve.Point, message []byte) bool {
+	group := public.Curve()
+	cs, err := ciphersuiteFor(group)
+	if err != nil {
+		return false
+	}
+	elementLen := len(cs.SerializeElement(group.NewBasePoint()))
+	if len(sig) != elementLen+len(cs.SerializeScalar(group.NewScalar())) {
+		return false
+	}
+	R := group.NewPoint()
+	if err = R.UnmarshalBinary(sig[:elementLen]); err != nil {
+		return false
+	}
+	z, err := cs.DeserializeScalar(sig[elementLen:])
+	if err != nil {
+		return false
+	}
+	c := computeRFC9591Challenge(cs, R, public, message)
+	return z.ActOnBase().Equal(c.Act(public).Add(R))
+}
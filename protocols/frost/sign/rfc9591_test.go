@@ -0,0 +1,250 @@
+package sign
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/taurusgroup/multi-party-sig/pkg/math/curve"
+	"github.com/taurusgroup/multi-party-sig/pkg/math/polynomial"
+	"github.com/taurusgroup/multi-party-sig/pkg/party"
+)
+
+// rfc9591Participant holds the values of a signer in a test vector of Appendix E of RFC 9591.
+type rfc9591Participant struct {
+	identifier        byte
+	share             string
+	hidingRandomness  string
+	bindingRandomness string
+	hidingNonce       string
+	bindingNonce      string
+	bindingFactor     string
+	sigShare          string
+}
+
+// rfc9591Vector is a test vector of Appendix E of RFC 9591, with a message signed by 2 out of 3 participants.
+type rfc9591Vector struct {
+	group          curve.Curve
+	groupSecretKey string
+	groupPublicKey string
+	message        string
+	participants   []rfc9591Participant
+	sig            string
+}
+
+func decodeHex(t *testing.T, s string) []byte {
+	data, err := hex.DecodeString(s)
+	require.NoError(t, err)
+	return data
+}
+
+func checkRFC9591Vector(t *testing.T, v rfc9591Vector) RFC9591Signature {
+	group := v.group
+	cs, err := ciphersuiteFor(group)
+	require.NoError(t, err)
+	scalar := func(s string) curve.Scalar {
+		out, err := cs.DeserializeScalar(decodeHex(t, s))
+		require.NoError(t, err)
+		return out
+	}
+	assertScalar := func(expected string, actual curve.Scalar) {
+		assert.Equal(t, expected, hex.EncodeToString(cs.SerializeScalar(actual)))
+	}
+
+	Y := group.NewPoint()
+	require.NoError(t, Y.UnmarshalBinary(decodeHex(t, v.groupPublicKey)))
+	assert.True(t, scalar(v.groupSecretKey).ActOnBase().Equal(Y))
+	message := decodeHex(t, v.message)
+
+	ids := make([]party.ID, 0, len(v.participants))
+	shares := make(map[party.ID]curve.Scalar)
+	hiding := make(map[party.ID]curve.Scalar)
+	binding := make(map[party.ID]curve.Scalar)
+	commitments := make([]commitment, 0, len(v.participants))
+	for _, p := range v.participants {
+		id := party.ID([]byte{p.identifier})
+		ids = append(ids, id)
+		shares[id] = scalar(p.share)
+		hiding[id] = nonceGenerate(cs, shares[id], decodeHex(t, p.hidingRandomness))
+		binding[id] = nonceGenerate(cs, shares[id], decodeHex(t, p.bindingRandomness))
+		assertScalar(p.hidingNonce, hiding[id])
+		assertScalar(p.bindingNonce, binding[id])
+		commitments = append(commitments, commitment{
+			ID: id.Scalar(group),
+			D:  hiding[id].ActOnBase(),
+			E:  binding[id].ActOnBase(),
+		})
+	}
+	sortCommitments(commitments)
+
+	rho := computeBindingFactors(cs, Y, message, commitments)
+	R := group.NewPoint()
+	for i, p := range v.participants {
+		assertScalar(p.bindingFactor, rho[i])
+		R = R.Add(rho[i].Act(commitments[i].E)).Add(commitments[i].D)
+	}
+	c := computeRFC9591Challenge(cs, R, Y, message)
+
+	lambdas := polynomial.Lagrange(group, ids)
+	z := group.NewScalar()
+	for i, p := range v.participants {
+		id := ids[i]
+		z_i := group.NewScalar().Set(lambdas[id]).Mul(shares[id]).Mul(c)
+		z_i.Add(hiding[id]).Add(group.NewScalar().Set(rho[i]).Mul(binding[id]))
+		assertScalar(p.sigShare, z_i)
+		z.Add(z_i)
+	}
+
+	sig := RFC9591Signature(cs.SerializeElement(R))
+	sig = append(sig, cs.SerializeScalar(z)...)
+	assert.Equal(t, v.sig, hex.EncodeToString(sig))
+	assert.True(t, sig.Verify(Y, message))
+	assert.False(t, sig.Verify(Y, []byte("other message")))
+	return sig
+}
+
+func TestRFC9591Ristretto255(t *testing.T) {
+	checkRFC9591Vector(t, rfc9591Vector{
+		group:          curve.Ristretto255{},
+		groupSecretKey: "1b25a55e463cfd15cf14a5d3acc3d15053f08da49c8afcf3ab265f2ebc4f970b",
+		groupPublicKey: "e2a62f39eede11269e3bd5a7d97554f5ca384f9f6d3dd9c3c0d05083c7254f57",
+		message:        "74657374",
+		participants: []rfc9591Participant{
+			{
+				identifier:        1,
+				share:             "5c3430d391552f6e60ecdc093ff9f6f4488756aa6cebdbad75a768010b8f830e",
+				hidingRandomness:  "f595a133b4d95c6e1f79887220c8b275ce6277e7f68a6640e1e7140f9be2fb5c",
+				bindingRandomness: "34dd1001360e3513cb37bebfabe7be4a32c5bb91ba19fbd4360d039111f0fbdc",
+				hidingNonce:       "214f2cabb86ed71427ea7ad4283b0fae26b6746c801ce824b83ceb2b99278c03",
+				bindingNonce:      "c9b8f5e16770d15603f744f8694c44e335e8faef00dad182b8d7a34a62552f0c",
+				bindingFactor:     "8967fd70fa06a58e5912603317fa94c77626395a695a0e4e4efc4476662eba0c",
+				sigShare:          "9285f875923ce7e0c491a592e9ea1865ec1b823ead4854b48c8a46287749ee09",
+			},
+			{
+				identifier:        3,
+				share:             "f17e505f0e2581c6acfe54d3846a622834b5e7b50cad9a2109a97ba7a80d5c04",
+				hidingRandomness:  "daa0cf42a32617786d390e0c7edfbf2efbd428037069357b5173ae61d6dd5d5e",
+				bindingRandomness: "b4387e72b2e4108ce4168931cc2c7fcce5f345a5297368952c18b5fc8473f050",
+				hidingNonce:       "3f7927872b0f9051dd98dd73eb2b91494173bbe0feb65a3e7e58d3e2318fa40f",
+				bindingNonce:      "ffd79445fb8030f0a3ddd3861aa4b42b618759282bfe24f1f9304c7009728305",
+				bindingFactor:     "f2c1bb7c33a10511158c2f1766a4a5fadf9f86f2a92692ed333128277cc31006",
+				sigShare:          "7cb211fe0e3d59d25db6e36b3fb32344794139602a7b24f1ae0dc4e26ad7b908",
+			},
+		},
+		sig: "fc45655fbc66bbffad654ea4ce5fdae253a49a64ace25d9adb62010dd9fb2555" +
+			"2164141787162e5b4cab915b4aa45d94655dbb9ed7c378a53b980a0be220a802",
+	})
+}
+
+func TestRFC9591Ed25519(t *testing.T) {
+	v := rfc9591Vector{
+		group:          curve.Ed25519{},
+		groupSecretKey: "7b1c33d3f5291d85de664833beb1ad469f7fb6025a0ec78b3a790c6e13a98304",
+		groupPublicKey: "15d21ccd7ee42959562fc8aa63224c8851fb3ec85a3faf66040d380fb9738673",
+		message:        "74657374",
+		participants: []rfc9591Participant{
+			{
+				identifier:        1,
+				share:             "929dcc590407aae7d388761cddb0c0db6f5627aea8e217f4a033f2ec83d93509",
+				hidingRandomness:  "0fd2e39e111cdc266f6c0f4d0fd45c947761f1f5d3cb583dfcb9bbaf8d4c9fec",
+				bindingRandomness: "69cd85f631d5f7f2721ed5e40519b1366f340a87c2f6856363dbdcda348a7501",
+				hidingNonce:       "812d6104142944d5a55924de6d49940956206909f2acaeedecda2b726e630407",
+				bindingNonce:      "b1110165fc2334149750b28dd813a39244f315cff14d4e89e6142f262ed83301",
+				bindingFactor:     "f2cb9d7dd9beff688da6fcc83fa89046b3479417f47f55600b106760eb3b5603",
+				sigShare:          "001719ab5a53ee1a12095cd088fd149702c0720ce5fd2f29dbecf24b7281b603",
+			},
+			{
+				identifier:        3,
+				share:             "d3cb090a075eb154e82fdb4b3cb507f110040905468bb9c46da8bdea643a9a02",
+				hidingRandomness:  "86d64a260059e495d0fb4fcc17ea3da7452391baa494d4b00321098ed2a0062f",
+				bindingRandomness: "13e6b25afb2eba51716a9a7d44130c0dbae0004a9ef8d7b5550c8a0e07c61775",
+				hidingNonce:       "c256de65476204095ebdc01bd11dc10e57b36bc96284595b8215222374f99c0e",
+				bindingNonce:      "243d71944d929063bc51205714ae3c2218bd3451d0214dfb5aeec2a90c35180d",
+				bindingFactor:     "b087686bf35a13f3dc78e780a34b0fe8a77fef1b9938c563f5573d71d8d7890f",
+				sigShare:          "bd86125de990acc5e1f13781d8e32c03a9bbd4c53539bbc106058bfd14326007",
+			},
+		},
+		sig: "36282629c383bb820a88b71cae937d41f2f2adfcc3d02e55507e2fb9e2dd3cbe" +
+			"bd9d2b0844e49ae0f3fa935161e1419aab7b47d21a37ebeae1f17d4987b3160b",
+	}
+	sig := checkRFC9591Vector(t, v)
+	// the signature is a regular Ed25519 signature
+	assert.True(t, ed25519.Verify(decodeHex(t, v.groupPublicKey), decodeHex(t, v.message), sig))
+}
+
+func TestRFC9591Secp256k1(t *testing.T) {
+	checkRFC9591Vector(t, rfc9591Vector{
+		group:          curve.Secp256k1{},
+		groupSecretKey: "0d004150d27c3bf2a42f312683d35fac7394b1e9e318249c1bfe7f0795a83114",
+		groupPublicKey: "02f37c34b66ced1fb51c34a90bdae006901f10625cc06c4f64663b0eae87d87b4f",
+		message:        "74657374",
+		participants: []rfc9591Participant{
+			{
+				identifier:        1,
+				share:             "08f89ffe80ac94dcb920c26f3f46140bfc7f95b493f8310f5fc1ea2b01f4254c",
+				hidingRandomness:  "7ea5ed09af19f6ff21040c07ec2d2adbd35b759da5a401d4c99dd26b82391cb2",
+				bindingRandomness: "47acab018f116020c10cb9b9abdc7ac10aae1b48ca6e36dc15acb6ec9be5cdc5",
+				hidingNonce:       "841d3a6450d7580b4da83c8e618414d0f024391f2aeb511d7579224420aa81f0",
+				bindingNonce:      "8d2624f532af631377f33cf44b5ac5f849067cae2eacb88680a31e77c79b5a80",
+				bindingFactor:     "3e08fe561e075c653cbfd46908a10e7637c70c74f0a77d5fd45d1a750c739ec6",
+				sigShare:          "c4fce1775a1e141fb579944166eab0d65eefe7b98d480a569bbbfcb14f91c197",
+			},
+			{
+				identifier:        3,
+				share:             "00e95d59dd0d46b0e303e500b62b7ccb0e555d49f5b849f5e748c071da8c0dbc",
+				hidingRandomness:  "e6cc56ccbd0502b3f6f831d91e2ebd01c4de0479e0191b66895a4ffd9b68d544",
+				bindingRandomness: "7203d55eb82a5ca0d7d83674541ab55f6e76f1b85391d2c13706a89a064fd5b9",
+				hidingNonce:       "2b19b13f193f4ce83a399362a90cdc1e0ddcd83e57089a7af0bdca71d47869b2",
+				bindingNonce:      "7a443bde83dc63ef52dda354005225ba0e553243402a4705ce28ffaafe0f5b98",
+				bindingFactor:     "93f79041bb3fd266105be251adaeb5fd7f8b104fb554a4ba9a0becea48ddbfd7",
+				sigShare:          "0160fd0d388932f4826d2ebcd6b9eaba734f7c71cf25b4279a4ca2581e47b18d",
+			},
+		},
+		sig: "0205b6d04d3774c8929413e3c76024d54149c372d57aae62574ed74319b5ea14d0" +
+			"c65dde8492a7471437e6c2fe3da49b90d23f642b5c6dbe7e36089f096dd97324",
+	})
+}
+
+func TestRFC9591P256(t *testing.T) {
+	checkRFC9591Vector(t, rfc9591Vector{
+		group:          curve.P256{},
+		groupSecretKey: "8ba9bba2e0fd8c4767154d35a0b7562244a4aaf6f36c8fb8735fa48b301bd8de",
+		groupPublicKey: "023a309ad94e9fe8a7ba45dfc58f38bf091959d3c99cfbd02b4dc00585ec45ab70",
+		message:        "74657374",
+		participants: []rfc9591Participant{
+			{
+				identifier:        1,
+				share:             "0c9c1a0fe806c184add50bbdcac913dda73e482daf95dcb9f35dbb0d8a9f7731",
+				hidingRandomness:  "ec4c891c85fee802a9d757a67d1252e7f4e5efb8a538991ac18fbd0e06fb6fd3",
+				bindingRandomness: "9334e29d09061223f69a09421715a347e4e6deba77444c8f42b0c833f80f4ef9",
+				hidingNonce:       "9f0542a5ba879a58f255c09f06da7102ef6a2dec6279700c656d58394d8facd4",
+				bindingNonce:      "6513dfe7429aa2fc972c69bb495b27118c45bbc6e654bb9dc9be55385b55c0d7",
+				bindingFactor:     "7925f0d4693f204e6e59233e92227c7124664a99739d2c06b81cf64ddf90559e",
+				sigShare:          "400308eaed7a2ddee02a265abe6a1cfe04d946ee8720768899619cfabe7a3aeb",
+			},
+			{
+				identifier:        3,
+				share:             "0e80d6e8f6192c003b5488ce1eec8f5429587d48cf001541e713b2d53c09d928",
+				hidingRandomness:  "c0451c5a0a5480d6c1f860e5db7d655233dca2669fd90ff048454b8ce983367b",
+				bindingRandomness: "2ba5f7793ae700e40e78937a82f407dd35e847e33d1e607b5c7eb6ed2a8ed799",
+				hidingNonce:       "f73444a8972bcda9e506bbca3d2b1c083c10facdf4bb5d47fef7c2dc1d9f2a0d",
+				bindingNonce:      "44c6a29075d6e7e4f8b97796205f9e22062e7835141470afe9417fd317c1c303",
+				bindingFactor:     "e10d24a8a403723bcb6f9bb4c537f316593683b472f7a89f166630dde11822c4",
+				sigShare:          "561da3c179edbb0502d941bb3e3ace3c37d122aaa46fb54499f15f3a3331de44",
+			},
+		},
+		sig: "026d8d434874f87bdb7bc0dfd239b2c00639044f9dcb195e9a04426f70bfa4b70d" +
+			"9620acac6767e8e3e3036815fca4eb3a3caa69992b902bcd3352fc34f1ac192f",
+	})
+}
+
+// TestExpandMessageXMD uses the vectors of Appendix K.1 of RFC 9380.
+func TestExpandMessageXMD(t *testing.T) {
+	dst := []byte("QUUX-V01-CS02-with-expander-SHA256-128")
+	assert.Equal(t, "68a985b87eb6b46952128911f2a4412bbc302a9d759667f87f7a21d803f07235",
+		hex.EncodeToString(expandMessageXMD(nil, dst, 32)))
+	assert.Equal(t, "d8ccab23b5985ccea865c6c97b6e5b8350e794e603b4b97902f53a8a0d605615",
+		hex.EncodeToString(expandMessageXMD([]byte("abc"), dst, 32)))
+}
@@ -0,0 +1,323 @@
+package sign
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/taurusgroup/multi-party-sig/pkg/math/curve"
+	"github.com/taurusgroup/multi-party-sig/pkg/party"
+	"github.com/taurusgroup/multi-party-sig/pkg/protocol"
+	"github.com/taurusgroup/multi-party-sig/protocols/frost/keygen"
+)
+
+// This file implements ROAST, a wrapper around FROST which makes signing robust and asynchronous:
+//   https://eprint.iacr.org/2022/550.pdf
+//
+// A coordinator keeps starting FROST sessions with the first threshold + 1 signers which are not
+// already busy in a session. Each signer responds with its share zᵢ, along with a fresh commitment
+// for the next session it will take part in. Signers sending an invalid share are excluded,
+// and signers which never respond only stall the sessions they are part of.
+// As long as threshold + 1 honest signers respond, one of the sessions is guaranteed to complete.
+//
+// Since signers only respond to a session once their previous response was received,
+// each signer is part of at most one pending session, and the coordinator starts at most
+// n - threshold sessions in total.
+
+// SignRequest asks the signers of a session started by a ROASTCoordinator for their response.
+type SignRequest struct {
+	group curve.Curve
+	// SessionID identifies the session within the ROASTCoordinator.
+	SessionID int
+	// Commitments contains the Commitment of each signer of the session.
+	Commitments []*Commitment
+}
+
+// EmptySignRequest creates an empty SignRequest with a specific group, ready for unmarshalling.
+func EmptySignRequest(group curve.Curve) *SignRequest {
+	return &SignRequest{group: group}
+}
+
+// Signers returns the parties which must receive the request.
+func (req *SignRequest) Signers() party.IDSlice {
+	signers := make([]party.ID, 0, len(req.Commitments))
+	for _, c := range req.Commitments {
+		signers = append(signers, c.ID)
+	}
+	return party.NewIDSlice(signers)
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+func (req *SignRequest) MarshalBinary() ([]byte, error) {
+	type signRequest SignRequest
+	return cbor.Marshal((*signRequest)(req))
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+//
+// The SignRequest must have been created with EmptySignRequest.
+func (req *SignRequest) UnmarshalBinary(data []byte) error {
+	var raw struct {
+		SessionID   int
+		Commitments []cbor.RawMessage
+	}
+	if err := cbor.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	req.SessionID = raw.SessionID
+	req.Commitments = make([]*Commitment, 0, len(raw.Commitments))
+	for _, rawCommitment := range raw.Commitments {
+		c := EmptyCommitment(req.group)
+		if err := cbor.Unmarshal(rawCommitment, c); err != nil {
+			return err
+		}
+		req.Commitments = append(req.Commitments, c)
+	}
+	return nil
+}
+
+// SignResponse is the answer of a signer to a SignRequest.
+type SignResponse struct {
+	// SessionID is the SessionID of the request.
+	SessionID int
+	// Z_i is the response zᵢ of the signer.
+	Z_i curve.Scalar
+	// Next is a fresh Commitment of the signer, for the next session it takes part in.
+	Next *Commitment
+}
+
+// EmptySignResponse creates an empty SignResponse with a specific group, ready for unmarshalling.
+func EmptySignResponse(group curve.Curve) *SignResponse {
+	return &SignResponse{Z_i: group.NewScalar(), Next: EmptyCommitment(group)}
+}
+
+// ROASTSigner answers the SignRequests of a ROASTCoordinator, for a single message.
+//
+// It is safe for concurrent use.
+type ROASTSigner struct {
+	mtx         sync.Mutex
+	config      *keygen.Config
+	messageHash []byte
+	nonces      *Nonces
+	// commitment is the last Commitment sent to the coordinator, and the only one we accept to sign with.
+	commitment *Commitment
+}
+
+// NewROASTSigner creates a ROASTSigner for the party owning config, agreeing to sign messageHash only.
+//
+// The returned Commitment must be sent to the coordinator, with ROASTCoordinator.AddCommitment.
+func NewROASTSigner(config *keygen.Config, messageHash []byte) (*ROASTSigner, *Commitment, error) {
+	nonces, commitments, err := Preprocess(config, 1)
+	if err != nil {
+		return nil, nil, fmt.Errorf("sign.NewROASTSigner: %w", err)
+	}
+	s := &ROASTSigner{
+		config:      config,
+		messageHash: messageHash,
+		nonces:      nonces,
+		commitment:  commitments[0],
+	}
+	return s, commitments[0], nil
+}
+
+// Sign returns the response of this party to req, which must use the last Commitment of this party.
+//
+// This is the equivalent of round2 for sessions of a ROASTCoordinator.
+func (s *ROASTSigner) Sign(req *SignRequest) (*SignResponse, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	signers, D, E, err := parseCommitments(s.config, req.Commitments)
+	if err != nil {
+		return nil, fmt.Errorf("sign.ROASTSigner: %w", err)
+	}
+	if len(signers) <= s.config.Threshold {
+		return nil, fmt.Errorf("sign.ROASTSigner: %d signers are not enough", len(signers))
+	}
+	own := &Commitment{ID: s.config.ID, D: D[s.config.ID], E: E[s.config.ID]}
+	if own.D == nil || !own.D.Equal(s.commitment.D) || !own.E.Equal(s.commitment.E) {
+		return nil, errors.New("sign.ROASTSigner: request does not use the last commitment of this party")
+	}
+	d_i, e_i, err := s.nonces.take(own)
+	if err != nil {
+		return nil, fmt.Errorf("sign.ROASTSigner: %w", err)
+	}
+	nonces, commitments, err := Preprocess(s.config, 1)
+	if err != nil {
+		return nil, fmt.Errorf("sign.ROASTSigner: %w", err)
+	}
+	s.nonces, s.commitment = nonces, commitments[0]
+
+	v := computeSigningValues(false, nil, nil, s.config.PublicKey, s.messageHash, signers, D, E)
+	return &SignResponse{
+		SessionID: req.SessionID,
+		Z_i:       v.response(s.config.ID, s.config.PrivateShare, d_i, e_i),
+		Next:      s.commitment,
+	}, nil
+}
+
+// ROASTCoordinator drives ROAST sessions until a Signature of a single message is produced.
+//
+// It is safe for concurrent use.
+type ROASTCoordinator struct {
+	mtx                sync.Mutex
+	group              curve.Curve
+	threshold          int
+	publicKey          curve.Point
+	verificationShares map[party.ID]curve.Point
+	messageHash        []byte
+	// commitments[i] is the last Commitment received from i, which is not used by a session yet.
+	commitments map[party.ID]*Commitment
+	// responsive are the signers which are not part of a pending session, in the order they responded.
+	responsive []party.ID
+	// malicious are the signers which sent an invalid response, and are ignored from then on.
+	malicious map[party.ID]bool
+	// sessionOf[i] is the session whose response we expect from i.
+	sessionOf map[party.ID]int
+	sessions  []*roastSession
+	signature *Signature
+}
+
+// roastSession is a FROST session started by a ROASTCoordinator.
+type roastSession struct {
+	*signingValues
+	signers party.IDSlice
+	// z[l] = zₗ is the valid response of l.
+	z map[party.ID]curve.Scalar
+}
+
+// NewROASTCoordinator creates a ROASTCoordinator producing a Signature of messageHash,
+// for the key with the given threshold, public key, and verification shares.
+func NewROASTCoordinator(threshold int, publicKey curve.Point, verificationShares *party.PointMap, messageHash []byte) *ROASTCoordinator {
+	return &ROASTCoordinator{
+		group:              publicKey.Curve(),
+		threshold:          threshold,
+		publicKey:          publicKey,
+		verificationShares: verificationShares.Points,
+		messageHash:        messageHash,
+		commitments:        map[party.ID]*Commitment{},
+		malicious:          map[party.ID]bool{},
+		sessionOf:          map[party.ID]int{},
+	}
+}
+
+// AddCommitment registers the first Commitment of a signer, as returned by NewROASTSigner.
+//
+// If threshold + 1 signers are now available, a new session is started, and the returned SignRequest
+// must be sent to each of its signers.
+func (c *ROASTCoordinator) AddCommitment(commitment *Commitment) (*SignRequest, error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if err := validateCommitment(c.group, c.verificationShares, commitment); err != nil {
+		return nil, fmt.Errorf("sign.ROASTCoordinator: %w", err)
+	}
+	id := commitment.ID
+	if _, ok := c.sessionOf[id]; ok || c.commitments[id] != nil || c.malicious[id] {
+		return nil, fmt.Errorf("sign.ROASTCoordinator: unexpected commitment of %v", id)
+	}
+	if c.signature != nil {
+		return nil, nil
+	}
+	c.commitments[id] = commitment
+	return c.markResponsive(id), nil
+}
+
+// AddResponse handles the response of a signer to a SignRequest.
+//
+// Once all the signers of a session have responded, the resulting Signature is returned.
+// Otherwise, if threshold + 1 signers are now available, a new session is started, and the returned
+// SignRequest must be sent to each of its signers.
+//
+// A protocol.Error is returned if the response is invalid, in which case the signer is excluded
+// from all future sessions. The coordinator can still be used after an error.
+func (c *ROASTCoordinator) AddResponse(from party.ID, resp *SignResponse) (*Signature, *SignRequest, error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if c.signature != nil {
+		return c.signature, nil, nil
+	}
+	sessionID, ok := c.sessionOf[from]
+	if !ok || resp == nil || resp.SessionID != sessionID {
+		return nil, nil, fmt.Errorf("sign.ROASTCoordinator: unexpected response from %v", from)
+	}
+	session := c.sessions[sessionID]
+
+	// The same check as in round3: zₗ • G = Rₗ + c * λₗ * Yₗ.
+	if resp.Z_i == nil || resp.Z_i.Curve().Name() != c.group.Name() || !session.verifyResponse(from, c.verificationShares[from], resp.Z_i) {
+		return nil, nil, c.markMalicious(from, errors.New("invalid response"))
+	}
+	if err := validateCommitment(c.group, c.verificationShares, resp.Next); err != nil {
+		return nil, nil, c.markMalicious(from, err)
+	}
+	if resp.Next.ID != from {
+		return nil, nil, c.markMalicious(from, fmt.Errorf("commitment of %v", resp.Next.ID))
+	}
+	delete(c.sessionOf, from)
+	session.z[from] = resp.Z_i
+	c.commitments[from] = resp.Next
+
+	if len(session.z) == len(session.signers) {
+		z := c.group.NewScalar()
+		for _, z_l := range session.z {
+			z.Add(z_l)
+		}
+		sig := Signature{R: session.R, z: z}
+		if !sig.Verify(c.publicKey, c.messageHash) {
+			return nil, nil, errors.New("sign.ROASTCoordinator: generated signature failed to verify")
+		}
+		c.signature = &sig
+		return c.signature, nil, nil
+	}
+	return nil, c.markResponsive(from), nil
+}
+
+// Malicious returns the signers which have been excluded for sending an invalid response.
+func (c *ROASTCoordinator) Malicious() party.IDSlice {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	malicious := make([]party.ID, 0, len(c.malicious))
+	for id := range c.malicious {
+		malicious = append(malicious, id)
+	}
+	return party.NewIDSlice(malicious)
+}
+
+// markResponsive adds id to the responsive signers, and starts a new session once there are threshold + 1 of them.
+func (c *ROASTCoordinator) markResponsive(id party.ID) *SignRequest {
+	c.responsive = append(c.responsive, id)
+	if len(c.responsive) <= c.threshold {
+		return nil
+	}
+
+	req := &SignRequest{group: c.group, SessionID: len(c.sessions)}
+	D := make(map[party.ID]curve.Point, len(c.responsive))
+	E := make(map[party.ID]curve.Point, len(c.responsive))
+	for _, l := range c.responsive {
+		commitment := c.commitments[l]
+		req.Commitments = append(req.Commitments, commitment)
+		D[l], E[l] = commitment.D, commitment.E
+		c.sessionOf[l] = req.SessionID
+		delete(c.commitments, l)
+	}
+	signers := party.NewIDSlice(c.responsive)
+	c.sessions = append(c.sessions, &roastSession{
+		signingValues: computeSigningValues(false, nil, nil, c.publicKey, c.messageHash, signers, D, E),
+		signers:       signers,
+		z:             map[party.ID]curve.Scalar{},
+	})
+	c.responsive = nil
+	return req
+}
+
+// markMalicious excludes id from all future sessions, and returns the corresponding error.
+func (c *ROASTCoordinator) markMalicious(id party.ID, err error) error {
+	c.malicious[id] = true
+	delete(c.sessionOf, id)
+	if len(c.verificationShares)-len(c.malicious) <= c.threshold {
+		err = fmt.Errorf("%w, and fewer than %d honest signers remain", err, c.threshold+1)
+	}
+	return protocol.Error{Culprits: []party.ID{id}, Err: fmt.Errorf("sign.ROASTCoordinator: %w", err)}
+}
@@ -0,0 +1,102 @@
+package sign
+
+import (
+	"crypto/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/taurusgroup/multi-party-sig/internal/test"
+	"github.com/taurusgroup/multi-party-sig/pkg/math/curve"
+	"github.com/taurusgroup/multi-party-sig/pkg/math/polynomial"
+	"github.com/taurusgroup/multi-party-sig/pkg/math/sample"
+	"github.com/taurusgroup/multi-party-sig/pkg/party"
+	"github.com/taurusgroup/multi-party-sig/pkg/protocol"
+	"github.com/taurusgroup/multi-party-sig/protocols/frost/keygen"
+)
+
+func TestROAST(t *testing.T) {
+	group := curve.Secp256k1{}
+	N := 7
+	threshold := 2
+	partyIDs := test.PartyIDs(N)
+	// The first signers are all part of the first session, and the next ones of the second.
+	malicious := party.NewIDSlice([]party.ID{partyIDs[0], partyIDs[1]})
+	unresponsive := party.NewIDSlice([]party.ID{partyIDs[2], partyIDs[3]})
+	message := []byte("hello")
+
+	secret := sample.Scalar(rand.Reader, group)
+	f := polynomial.NewPolynomial(group, threshold, secret)
+	publicKey := secret.ActOnBase()
+	verificationShares := make(map[party.ID]curve.Point, N)
+	for _, id := range partyIDs {
+		verificationShares[id] = f.Evaluate(id.Scalar(group)).ActOnBase()
+	}
+
+	coordinator := NewROASTCoordinator(threshold, publicKey, party.NewPointMap(verificationShares), message)
+	signers := make(map[party.ID]*ROASTSigner, N)
+	var requests []*SignRequest
+	for _, id := range partyIDs {
+		config := &keygen.Config{
+			ID:                 id,
+			Threshold:          threshold,
+			PublicKey:          publicKey,
+			PrivateShare:       f.Evaluate(id.Scalar(group)),
+			VerificationShares: party.NewPointMap(verificationShares),
+		}
+		signer, commitment, err := NewROASTSigner(config, message)
+		require.NoError(t, err)
+		signers[id] = signer
+		req, err := coordinator.AddCommitment(commitment)
+		require.NoError(t, err)
+		if req != nil {
+			requests = append(requests, req)
+		}
+	}
+
+	var sig *Signature
+	answered := make(map[party.ID]*SignRequest)
+	for len(requests) > 0 && sig == nil {
+		req := requests[0]
+		requests = requests[1:]
+		data, err := req.MarshalBinary()
+		require.NoError(t, err)
+		received := EmptySignRequest(group)
+		require.NoError(t, received.UnmarshalBinary(data))
+		for _, id := range received.Signers() {
+			if unresponsive.Contains(id) {
+				continue
+			}
+			resp, err := signers[id].Sign(received)
+			require.NoError(t, err)
+			answered[id] = received
+			if malicious.Contains(id) {
+				resp.Z_i.Add(group.NewScalar().SetNat(oneNat))
+			}
+			var next *SignRequest
+			sig, next, err = coordinator.AddResponse(id, resp)
+			if malicious.Contains(id) {
+				require.Error(t, err)
+				require.IsType(t, protocol.Error{}, err)
+				assert.Equal(t, []party.ID{id}, err.(protocol.Error).Culprits)
+				continue
+			}
+			require.NoError(t, err)
+			if next != nil {
+				requests = append(requests, next)
+			}
+			if sig != nil {
+				break
+			}
+		}
+	}
+	require.NotNil(t, sig, "expected a signature from the honest signers")
+	assert.True(t, sig.Verify(publicKey, message))
+	assert.Equal(t, malicious, coordinator.Malicious())
+
+	// A request can only be answered once, since the nonces are deleted.
+	for id, req := range answered {
+		_, err := signers[id].Sign(req)
+		assert.Error(t, err)
+	}
+}
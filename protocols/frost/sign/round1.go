@@ -27,6 +27,11 @@ type round1 struct {
 	// and we need to make sure to generate our challenge in the correct way. Naturally,
 	// we also return a taproot.Signature instead a generic signature.
 	taproot bool
+	// suite is the RFC 9591 ciphersuite used to derive nonces, binding factors and the challenge.
+	//
+	// If nil, we use the hash functions of this library instead, and the result is a Signature.
+	// Otherwise, M is the message itself, and we return an RFC9591Signature.
+	suite ciphersuite
 	// M is the hash of the message we're signing.
 	//
 	// This plays the same role as m in the Frost paper. One slight difference
@@ -42,6 +47,10 @@ type round1 struct {
 	YShares map[party.ID]curve.Point
 	// s_i = sᵢ is our private secret share
 	s_i curve.Scalar
+	// T is the adaptor point, if we're producing a pre-signature instead of a signature.
+	//
+	// It is added to the group commitment, so that the response z is short of the discrete log t of T.
+	T curve.Point
 }
 
 // VerifyMessage implements round.Round.
@@ -56,6 +65,41 @@ func (r *round1) Finalize(out chan<- *round.Message) (round.Session, error) {
 	// to generate two nonces (dᵢ, eᵢ) in Z/(q)ˣ, then two commitments
 	// Dᵢ = dᵢ * G, Eᵢ = eᵢ * G, and then broadcast them.
 
+	d_i, e_i, err := r.generateNonces()
+	if err != nil {
+		return r, err
+	}
+
+	D_i := d_i.ActOnBase()
+	E_i := e_i.ActOnBase()
+
+	// Broadcast the commitments
+	err = r.BroadcastMessage(out, &broadcast2{D_i: D_i, E_i: E_i})
+	if err != nil {
+		return r, err
+	}
+	return &round2{
+		round1: r,
+		d_i:    d_i,
+		e_i:    e_i,
+		D:      map[party.ID]curve.Point{r.SelfID(): D_i},
+		E:      map[party.ID]curve.Point{r.SelfID(): E_i},
+	}, nil
+}
+
+// generateNonces returns the pair of nonces (dᵢ, eᵢ).
+func (r *round1) generateNonces() (curve.Scalar, curve.Scalar, error) {
+	if r.suite != nil {
+		// RFC 9591 derives each nonce as H3(a, sᵢ), for fresh randomness a,
+		// which is also a hedged deterministic process.
+		a := make([]byte, 32)
+		_, _ = rand.Read(a)
+		d_i := nonceGenerate(r.suite, r.s_i, a)
+		_, _ = rand.Read(a)
+		e_i := nonceGenerate(r.suite, r.s_i, a)
+		return d_i, e_i, nil
+	}
+
 	// We use a hedged deterministic process, instead of simply sampling (d_i, e_i):
 	//
 	//   a = random()
@@ -66,7 +110,7 @@ func (r *round1) Finalize(out chan<- *round.Message) (round.Session, error) {
 	// and fault attacks against the hash function, because of the randomness.
 	s_iBytes, err := r.s_i.MarshalBinary()
 	if err != nil {
-		return r, err
+		return nil, nil, err
 	}
 
 	hashKey := make([]byte, 32)
@@ -81,22 +125,7 @@ func (r *round1) Finalize(out chan<- *round.Message) (round.Session, error) {
 
 	d_i := sample.ScalarUnit(nonceDigest, r.Group())
 	e_i := sample.ScalarUnit(nonceDigest, r.Group())
-
-	D_i := d_i.ActOnBase()
-	E_i := e_i.ActOnBase()
-
-	// Broadcast the commitments
-	err = r.BroadcastMessage(out, &broadcast2{D_i: D_i, E_i: E_i})
-	if err != nil {
-		return r, err
-	}
-	return &round2{
-		round1: r,
-		d_i:    d_i,
-		e_i:    e_i,
-		D:      map[party.ID]curve.Point{r.SelfID(): D_i},
-		E:      map[party.ID]curve.Point{r.SelfID(): E_i},
-	}, nil
+	return d_i, e_i, nil
 }
 
 // MessageContent implements round.Round.
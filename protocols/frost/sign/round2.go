@@ -82,6 +82,58 @@ func (round2) StoreMessage(round.Message) error { return nil }
 func (r *round2) Finalize(out chan<- *round.Message) (round.Session, error) {
 	// This essentially follows parts of Figure 3.
 
+	// 4. is done by computeSigningValues.
+	v := computeSigningValues(r.taproot, r.suite, r.T, r.Y, r.M, r.PartyIDs(), r.D, r.E)
+
+	// 5. "Each Pᵢ computes their response using their long-lived secret share sᵢ
+	// by computing zᵢ = dᵢ + (eᵢ ρᵢ) + λᵢ sᵢ c, using S to determine
+	// the ith lagrange coefficient λᵢ"
+	z_i := v.response(r.SelfID(), r.s_i, r.d_i, r.e_i)
+
+	// 6. "Each Pᵢ securely deletes ((dᵢ, Dᵢ), (eᵢ, Eᵢ)) from their local storage,
+	// and returns zᵢ to SA."
+	//
+	// Since we don't have a signing authority, we instead broadcast zᵢ.
+
+	// TODO: Securely delete the nonces.
+
+	// Broadcast our response
+	err := r.BroadcastMessage(out, &broadcast3{Z_i: z_i})
+	if err != nil {
+		return r, err
+	}
+
+	return &round3{
+		round2:        r,
+		signingValues: v,
+		z:             map[party.ID]curve.Scalar{r.SelfID(): z_i},
+	}, nil
+}
+
+// signingValues are the values derived from the commitments of the signers,
+// which every participant computes identically.
+type signingValues struct {
+	// R is the group commitment.
+	R curve.Point
+	// RShares[l] = Rₗ = Dₗ + ρₗ * Eₗ is the contribution of l to the group commitment.
+	RShares map[party.ID]curve.Point
+	// rho[l] = ρₗ is the binding value of l.
+	rho map[party.ID]curve.Scalar
+	// c is the challenge.
+	c curve.Scalar
+	// Lambda[l] = λₗ is the Lagrange coefficient of l.
+	Lambda map[party.ID]curve.Scalar
+	// negated indicates that R, and thus the nonces, were negated to have an even y coordinate.
+	//
+	// With an adaptor point, this is decided by the parity of R + T.
+	negated bool
+}
+
+// computeSigningValues derives the signingValues of a signature of M by the given signers,
+// whose commitments are D and E.
+//
+// If T is not nil, the adaptor point T is added to the group commitment, which yields a pre-signature.
+func computeSigningValues(taprootSig bool, suite ciphersuite, T, Y curve.Point, M messageHash, signers party.IDSlice, D, E map[party.ID]curve.Point) *signingValues {
 	// 4. "Each Pᵢ then computes the set of binding values ρₗ = H₁(l, m, B).
 	// Each Pᵢ then derives the group commitment R = ∑ₗ Dₗ + ρₗ * Eₗ and
 	// the challenge c = H₂(R, Y, m)."
@@ -90,86 +142,109 @@ func (r *round2) Finalize(out chan<- *round.Message) (round.Session, error) {
 	// state after H(m, B), instead of rehashing them each time.
 	//
 	// We also use a hash of the message, instead of the message directly.
-
-	rho := make(map[party.ID]curve.Scalar)
-	// This calculates H(m, B), allowing us to avoid re-hashing this data for
-	// each extra party l.
-	rhoPreHash := hash.New()
-	_ = rhoPreHash.WriteAny(r.M)
-	for _, l := range r.PartyIDs() {
-		_ = rhoPreHash.WriteAny(r.D[l], r.E[l])
+	group := Y.Curve()
+	v := &signingValues{
+		R:       group.NewPoint(),
+		RShares: make(map[party.ID]curve.Point, len(signers)),
+		rho:     bindingFactors(suite, Y, M, signers, D, E),
+		// Lambdas[i] = λᵢ
+		Lambda: polynomial.Lagrange(group, signers),
 	}
-	for _, l := range r.PartyIDs() {
-		rhoHash := rhoPreHash.Clone()
-		_ = rhoHash.WriteAny(l)
-		rho[l] = sample.Scalar(rhoHash.Digest(), r.Group())
+	for _, l := range signers {
+		v.RShares[l] = v.rho[l].Act(E[l])
+		v.RShares[l] = v.RShares[l].Add(D[l])
+		v.R = v.R.Add(v.RShares[l])
 	}
-
-	R := r.Group().NewPoint()
-	RShares := make(map[party.ID]curve.Point)
-	for _, l := range r.PartyIDs() {
-		RShares[l] = rho[l].Act(r.E[l])
-		RShares[l] = RShares[l].Add(r.D[l])
-		R = R.Add(RShares[l])
+	if T != nil {
+		// The challenge commits to R + T, while only R is covered by the responses.
+		v.R = v.R.Add(T)
 	}
-	var c curve.Scalar
-	if r.taproot {
+	if taprootSig {
 		// BIP-340 adjustment: We need R to have an even y coordinate. This means
 		// conditionally negating k = ∑ᵢ (dᵢ + (eᵢ ρᵢ)), which we can accomplish
 		// by negating our dᵢ, eᵢ, if necessary. This entails negating the RShares
 		// as well.
-		RSecp := R.(*curve.Secp256k1Point)
+		RSecp := v.R.(*curve.Secp256k1Point)
 		if !RSecp.HasEvenY() {
-			r.d_i.Negate()
-			r.e_i.Negate()
-			for _, l := range r.PartyIDs() {
-				RShares[l] = RShares[l].Negate()
+			v.negated = true
+			v.R = v.R.Negate()
+			for _, l := range signers {
+				v.RShares[l] = v.RShares[l].Negate()
 			}
 		}
 
 		// BIP-340 adjustment: we need to calculate our hash as specified in:
 		// https://github.com/bitcoin/bips/blob/master/bip-0340.mediawiki#default-signing
 		RBytes := RSecp.XBytes()
-		PBytes := r.Y.(*curve.Secp256k1Point).XBytes()
-		cHash := taproot.TaggedHash("BIP0340/challenge", RBytes, PBytes, r.M)
-		c = r.Group().NewScalar().SetNat(new(safenum.Nat).SetBytes(cHash))
+		PBytes := Y.(*curve.Secp256k1Point).XBytes()
+		cHash := taproot.TaggedHash("BIP0340/challenge", RBytes, PBytes, M)
+		v.c = group.NewScalar().SetNat(new(safenum.Nat).SetBytes(cHash))
+	} else if suite != nil {
+		v.c = computeRFC9591Challenge(suite, v.R, Y, M)
 	} else {
-		cHash := hash.New()
-		_ = cHash.WriteAny(R, r.Y, r.M)
-		c = sample.Scalar(cHash.Digest(), r.Group())
+		v.c = computeChallenge(v.R, Y, M)
 	}
+	return v
+}
 
-	// Lambdas[i] = λᵢ
-	Lambdas := polynomial.Lagrange(r.Group(), r.PartyIDs())
-	// 5. "Each Pᵢ computes their response using their long-lived secret share sᵢ
-	// by computing zᵢ = dᵢ + (eᵢ ρᵢ) + λᵢ sᵢ c, using S to determine
-	// the ith lagrange coefficient λᵢ"
-	z_i := r.Group().NewScalar().Set(Lambdas[r.SelfID()]).Mul(r.s_i).Mul(c)
-	z_i.Add(r.d_i)
-	ed := r.Group().NewScalar().Set(rho[r.SelfID()]).Mul(r.e_i)
+// response returns zᵢ = dᵢ + (eᵢ ρᵢ) + λᵢ sᵢ c, the response of signer i with nonces dᵢ, eᵢ and secret share sᵢ.
+func (v *signingValues) response(i party.ID, s_i, d_i, e_i curve.Scalar) curve.Scalar {
+	group := s_i.Curve()
+	d_i = group.NewScalar().Set(d_i)
+	e_i = group.NewScalar().Set(e_i)
+	if v.negated {
+		d_i.Negate()
+		e_i.Negate()
+	}
+	z_i := group.NewScalar().Set(v.Lambda[i]).Mul(s_i).Mul(v.c)
+	z_i.Add(d_i)
+	ed := group.NewScalar().Set(v.rho[i]).Mul(e_i)
 	z_i.Add(ed)
+	return z_i
+}
 
-	// 6. "Each Pᵢ securely deletes ((dᵢ, Dᵢ), (eᵢ, Eᵢ)) from their local storage,
-	// and returns zᵢ to SA."
-	//
-	// Since we don't have a signing authority, we instead broadcast zᵢ.
-
-	// TODO: Securely delete the nonces.
+// verifyResponse checks that zₗ • G = Rₗ + c * λₗ * Yₗ, for the response zₗ of l, whose verification share is Yₗ.
+func (v *signingValues) verifyResponse(l party.ID, Y_l curve.Point, z_l curve.Scalar) bool {
+	expected := v.c.Act(v.Lambda[l].Act(Y_l)).Add(v.RShares[l])
+	return z_l.ActOnBase().Equal(expected)
+}
 
-	// Broadcast our response
-	err := r.BroadcastMessage(out, &broadcast3{Z_i: z_i})
-	if err != nil {
-		return r, err
+// bindingFactors returns the binding value ρₗ of each signer l.
+func bindingFactors(suite ciphersuite, Y curve.Point, M messageHash, signers party.IDSlice, D, E map[party.ID]curve.Point) map[party.ID]curve.Scalar {
+	group := Y.Curve()
+	rho := make(map[party.ID]curve.Scalar, len(signers))
+	if suite != nil {
+		// RFC 9591 hashes the commitments sorted by the numerical value of
+		// the identifiers, which are our party IDs interpreted as scalars.
+		commitments := make([]commitment, 0, len(signers))
+		ids := make(map[string]party.ID, len(signers))
+		for _, l := range signers {
+			id := l.Scalar(group)
+			idBytes, _ := id.MarshalBinary()
+			ids[string(idBytes)] = l
+			commitments = append(commitments, commitment{ID: id, D: D[l], E: E[l]})
+		}
+		sortCommitments(commitments)
+		for i, rho_l := range computeBindingFactors(suite, Y, M, commitments) {
+			idBytes, _ := commitments[i].ID.MarshalBinary()
+			rho[ids[string(idBytes)]] = rho_l
+		}
+		return rho
 	}
 
-	return &round3{
-		round2:  r,
-		R:       R,
-		RShares: RShares,
-		c:       c,
-		z:       map[party.ID]curve.Scalar{r.SelfID(): z_i},
-		Lambda:  Lambdas,
-	}, nil
+	// This calculates H(m, B), allowing us to avoid re-hashing this data for
+	// each extra party l.
+	rhoPreHash := hash.New()
+	_ = rhoPreHash.WriteAny(M)
+	for _, l := range signers {
+		_ = rhoPreHash.WriteAny(D[l], E[l])
+	}
+	for _, l := range signers {
+		rhoHash := rhoPreHash.Clone()
+		_ = rhoHash.WriteAny(l)
+		rho[l] = sample.Scalar(rhoHash.Digest(), group)
+	}
+	return rho
 }
 
 // MessageContent implements round.Round.
@@ -16,22 +16,14 @@ import (
 // Instead, each participant calculates the signature on their own.
 type round3 struct {
 	*round2
-	// R is the group commitment, and the first part of the consortium signature
-	R curve.Point
-	// RShares is the fraction each participant contributes to the group commitment
-	//
-	// This corresponds to R_i in the Frost paper
-	RShares map[party.ID]curve.Point
-	// c is the challenge, computed as H(R, Y, m).
-	c curve.Scalar
+	// signingValues contains the group commitment R, which is the first part of the consortium signature,
+	// the fraction Rₗ each participant contributes to it, the challenge c, and the Lagrange coefficients λₗ
+	// of the parties participating in this session.
+	*signingValues
 	// z contains the response from each participant
 	//
 	// z[i] corresponds to zᵢ in the Frost paper
 	z map[party.ID]curve.Scalar
-
-	// Lambda contains all Lagrange coefficients of the parties participating in this session.
-	// Lambda[l] = λₗ
-	Lambda map[party.ID]curve.Scalar
 }
 
 type broadcast3 struct {
@@ -53,26 +45,8 @@ func (r *round3) StoreBroadcastMessage(msg round.Message) error {
 		return round.ErrNilFields
 	}
 
-	// These steps come from Figure 3 of the Frost paper.
-
-	// 7.b "Verify the validity of each response by checking
-	//
-	//    zᵢ • G = Rᵢ + c * λᵢ * Yᵢ
-	//
-	// for each share zᵢ, i in S. If the equality does not hold, identify and report the
-	// misbehaving participant, and then abort. Otherwise, continue."
-	//
-	// Note that step 7.a is an artifact of having a signing authority. In our case,
-	// we've already computed everything that step computes.
-
-	expected := r.c.Act(r.Lambda[from].Act(r.YShares[from])).Add(r.RShares[from])
-
-	actual := body.Z_i.ActOnBase()
-
-	if !actual.Equal(expected) {
-		return fmt.Errorf("failed to verify response from %v", from)
-	}
-
+	// The response is verified in Finalize, only if the signature turns out to be invalid,
+	// so that we can identify every misbehaving participant at once.
 	r.z[from] = body.Z_i
 
 	return nil
@@ -94,6 +68,10 @@ func (r *round3) Finalize(chan<- *round.Message) (round.Session, error) {
 		z.Add(z_l)
 	}
 
+	if r.T != nil {
+		return r.preSignature(z), nil
+	}
+
 	// The format of our signature depends on using taproot, naturally
 	if r.taproot {
 		sig := taproot.Signature(make([]byte, 0, taproot.SignatureLen))
@@ -107,7 +85,16 @@ func (r *round3) Finalize(chan<- *round.Message) (round.Session, error) {
 		taprootPub := taproot.PublicKey(r.Y.(*curve.Secp256k1Point).XBytes())
 
 		if !taprootPub.Verify(sig, r.M) {
-			return r.AbortRound(fmt.Errorf("generated signature failed to verify")), nil
+			return r.abort(), nil
+		}
+
+		return r.ResultRound(sig), nil
+	} else if r.suite != nil {
+		sig := RFC9591Signature(r.suite.SerializeElement(r.R))
+		sig = append(sig, r.suite.SerializeScalar(z)...)
+
+		if !sig.Verify(r.Y, r.M) {
+			return r.abort(), nil
 		}
 
 		return r.ResultRound(sig), nil
@@ -118,13 +105,71 @@ func (r *round3) Finalize(chan<- *round.Message) (round.Session, error) {
 		}
 
 		if !sig.Verify(r.Y, r.M) {
-			return r.AbortRound(fmt.Errorf("generated signature failed to verify")), nil
+			return r.abort(), nil
 		}
 
 		return r.ResultRound(sig), nil
 	}
 }
 
+// preSignature returns the result round with the pre-signature for the adaptor point T,
+// whose response is z, or an abort round if it is invalid.
+func (r *round3) preSignature(z curve.Scalar) round.Session {
+	if r.taproot {
+		// R was negated along with the nonces if R + T has an odd y coordinate,
+		// but the pre-signature holds R + T itself.
+		R := r.R
+		if r.negated {
+			R = R.Negate()
+		}
+		sig := TaprootPreSignature{
+			R: R.(*curve.Secp256k1Point),
+			T: r.T.(*curve.Secp256k1Point),
+			z: z.(*curve.Secp256k1Scalar),
+		}
+		if !sig.Verify(r.Y.(*curve.Secp256k1Point).XBytes(), r.M) {
+			return r.abort()
+		}
+		return r.ResultRound(sig)
+	}
+
+	sig := PreSignature{
+		R: r.R,
+		T: r.T,
+		z: z,
+	}
+	if !sig.Verify(r.Y, r.M) {
+		return r.abort()
+	}
+	return r.ResultRound(sig)
+}
+
+// abort returns an abort round, blaming every participant whose response is invalid.
+func (r *round3) abort() round.Session {
+	// 7.b "Verify the validity of each response by checking
+	//
+	//    zᵢ • G = Rᵢ + c * λᵢ * Yᵢ
+	//
+	// for each share zᵢ, i in S. If the equality does not hold, identify and report the
+	// misbehaving participant, and then abort. Otherwise, continue."
+	//
+	// Note that step 7.a is an artifact of having a signing authority. In our case,
+	// we've already computed everything that step computes.
+	//
+	// Rᵢ = Dᵢ + ρᵢ * Eᵢ already includes the binding factor, and was negated along
+	// with R for taproot if necessary.
+	var culprits []party.ID
+	for _, l := range r.PartyIDs() {
+		if !r.verifyResponse(l, r.YShares[l], r.z[l]) {
+			culprits = append(culprits, l)
+		}
+	}
+	if len(culprits) == 0 {
+		return r.AbortRound(fmt.Errorf("generated signature failed to verify"))
+	}
+	return r.AbortRound(fmt.Errorf("failed to verify responses from %v", culprits), culprits...)
+}
+
 // MessageContent implements round.Round.
 func (round3) MessageContent() round.Content { return nil }
 
@@ -4,6 +4,8 @@ import (
 	"fmt"
 
 	"github.com/taurusgroup/multi-party-sig/internal/round"
+	"github.com/taurusgroup/multi-party-sig/pkg/hash"
+	"github.com/taurusgroup/multi-party-sig/pkg/math/curve"
 	"github.com/taurusgroup/multi-party-sig/pkg/party"
 	"github.com/taurusgroup/multi-party-sig/pkg/protocol"
 	"github.com/taurusgroup/multi-party-sig/protocols/frost/keygen"
@@ -13,36 +15,102 @@ const (
 	// Frost Sign with Threshold.
 	protocolID        = "frost/sign-threshold"
 	protocolIDTaproot = "frost/sign-threshold-taproot"
+	protocolIDRFC9591 = "frost/sign-threshold-rfc9591"
+	// protocolIDAdaptor and protocolIDTaprootAdaptor identify pre-signatures for an adaptor point.
+	protocolIDAdaptor        = "frost/sign-threshold-adaptor"
+	protocolIDTaprootAdaptor = "frost/sign-threshold-taproot-adaptor"
 	// This protocol has 3 concrete rounds.
 	protocolRounds round.Number = 3
 )
 
 func StartSignCommon(taproot bool, result *keygen.Config, signers []party.ID, messageHash []byte) protocol.StartFunc {
-	return func(sessionID []byte) (round.Session, error) {
-		info := round.Info{
-			FinalRoundNumber: protocolRounds,
-			SelfID:           result.ID,
-			PartyIDs:         signers,
-			Threshold:        result.Threshold,
-			Group:            result.PublicKey.Curve(),
+	return startSign(taproot, nil, nil, result, signers, messageHash)
+}
+
+// StartSignAdaptor produces a pre-signature of messageHash for the adaptor point T = t • G,
+// instead of a signature.
+//
+// The result is a PreSignature, or a TaprootPreSignature if taproot is set, which can only be completed
+// into a valid signature by someone knowing t. Conversely, t can be extracted from the completed signature.
+func StartSignAdaptor(taproot bool, result *keygen.Config, signers []party.ID, messageHash []byte, T curve.Point) protocol.StartFunc {
+	if err := validateAdaptorPoint(taproot, result.Curve(), T); err != nil {
+		return func([]byte) (round.Session, error) {
+			return nil, fmt.Errorf("sign.StartSignAdaptor: %w", err)
 		}
-		if taproot {
-			info.ProtocolID = protocolIDTaproot
-		} else {
-			info.ProtocolID = protocolID
+	}
+	return startSign(taproot, nil, T, result, signers, messageHash)
+}
+
+// StartSignRFC9591 produces an RFC9591Signature of message, in the ciphersuite defined over the group of result.
+//
+// Nonces, binding factors and the challenge are computed as specified in RFC 9591, instead of
+// with the hash functions of this library.
+func StartSignRFC9591(result *keygen.Config, signers []party.ID, message []byte) protocol.StartFunc {
+	suite, err := ciphersuiteFor(result.Curve())
+	if err != nil {
+		return func([]byte) (round.Session, error) {
+			return nil, fmt.Errorf("sign.StartSignRFC9591: %w", err)
 		}
+	}
+	return startSign(false, suite, nil, result, signers, message)
+}
 
-		helper, err := round.NewSession(info, sessionID, nil)
+func startSign(taproot bool, suite ciphersuite, T curve.Point, result *keygen.Config, signers []party.ID, messageHash []byte) protocol.StartFunc {
+	return func(sessionID []byte) (round.Session, error) {
+		protocolID := protocolID
+		switch {
+		case taproot && T != nil:
+			protocolID = protocolIDTaprootAdaptor
+		case taproot:
+			protocolID = protocolIDTaproot
+		case T != nil:
+			protocolID = protocolIDAdaptor
+		case suite != nil:
+			protocolID = protocolIDRFC9591
+		}
+		r, err := newRound1(protocolID, taproot, suite, T, result, signers, messageHash, sessionID)
 		if err != nil {
 			return nil, fmt.Errorf("sign.StartSign: %w", err)
 		}
-		return &round1{
-			Helper:  helper,
-			taproot: taproot,
-			M:       messageHash,
-			Y:       result.PublicKey,
-			YShares: result.VerificationShares.Points,
-			s_i:     result.PrivateShare,
-		}, nil
+		return r, nil
+	}
+}
+
+// newRound1 creates the first round of a signature with the given protocol ID.
+//
+// If T is not nil, it is included in the session ID, so that signers which disagree on the adaptor point abort in the first round.
+func newRound1(protocolID string, taproot bool, suite ciphersuite, T curve.Point, result *keygen.Config, signers []party.ID, messageHash, sessionID []byte) (*round1, error) {
+	info := round.Info{
+		ProtocolID:       protocolID,
+		FinalRoundNumber: protocolRounds,
+		SelfID:           result.ID,
+		PartyIDs:         signers,
+		Threshold:        result.Threshold,
+		Group:            result.PublicKey.Curve(),
+	}
+	var auxInfo []hash.WriterToWithDomain
+	if T != nil {
+		data, err := T.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		auxInfo = append(auxInfo, &hash.BytesWithDomain{
+			TheDomain: "Adaptor Point",
+			Bytes:     data,
+		})
+	}
+	helper, err := round.NewSession(info, sessionID, nil, auxInfo...)
+	if err != nil {
+		return nil, err
 	}
+	return &round1{
+		Helper:  helper,
+		taproot: taproot,
+		suite:   suite,
+		M:       messageHash,
+		Y:       result.PublicKey,
+		YShares: result.VerificationShares.Points,
+		s_i:     result.PrivateShare,
+		T:       T,
+	}, nil
 }
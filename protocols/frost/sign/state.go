@@ -0,0 +1,109 @@
+package sign
+
+import (
+	"fmt"
+
+	"github.com/taurusgroup/multi-party-sig/internal/round"
+)
+
+// These assert that our rounds can be snapshot and restored.
+var (
+	_ round.Restorer    = (*round1)(nil)
+	_ round.Snapshotter = (*round1)(nil)
+	_ round.Snapshotter = (*round2)(nil)
+	_ round.Snapshotter = (*round3)(nil)
+)
+
+// The fields of round1 are all derived from the arguments of the StartFunc, so only the session is stored.
+func (r *round1) putState(s *round.State) { s.PutSession(r.Helper) }
+
+func (r *round2) putState(s *round.State) {
+	r.round1.putState(s)
+	s.Put("d_i", r.d_i)
+	s.Put("e_i", r.e_i)
+	s.Put("D", r.D)
+	s.Put("E", r.E)
+}
+
+func (r *round2) getState(s *round.State) {
+	r.d_i = s.Scalar("d_i")
+	r.e_i = s.Scalar("e_i")
+	r.D = s.PointMap("D")
+	r.E = s.PointMap("E")
+}
+
+func (r *round3) putState(s *round.State) {
+	r.round2.putState(s)
+	s.Put("R", r.R)
+	s.Put("RShares", r.RShares)
+	s.Put("c", r.c)
+	s.Put("z", r.z)
+	s.Put("Lambda", r.Lambda)
+	s.Put("negated", r.negated)
+}
+
+// The binding values are not needed after round2, so they are not stored.
+func (r *round3) getState(s *round.State) {
+	r.signingValues = &signingValues{}
+	r.R = s.Point("R")
+	r.RShares = s.PointMap("RShares")
+	r.c = s.Scalar("c")
+	r.z = s.ScalarMap("z")
+	r.Lambda = s.ScalarMap("Lambda")
+	s.Get("negated", &r.negated)
+}
+
+type stateRound interface {
+	round.Session
+	putState(s *round.State)
+}
+
+func marshalState(r stateRound) ([]byte, error) {
+	s := round.NewState(r.Group())
+	r.putState(s)
+	return s.MarshalBinary()
+}
+
+// MarshalState implements round.Snapshotter.
+func (r *round1) MarshalState() ([]byte, error) { return marshalState(r) }
+
+// MarshalState implements round.Snapshotter.
+func (r *round2) MarshalState() ([]byte, error) { return marshalState(r) }
+
+// MarshalState implements round.Snapshotter.
+func (r *round3) MarshalState() ([]byte, error) { return marshalState(r) }
+
+// RestoreState implements round.Restorer.
+func (r *round1) RestoreState(number round.Number, data []byte) (round.Session, error) {
+	s, err := round.UnmarshalState(r.Group(), data)
+	if err != nil {
+		return nil, fmt.Errorf("sign: %w", err)
+	}
+	s.RestoreSession(r.Helper)
+	restored, err := r.restore(s, number)
+	if err != nil {
+		return nil, fmt.Errorf("sign: %w", err)
+	}
+	if err = s.Err(); err != nil {
+		return nil, fmt.Errorf("sign: %w", err)
+	}
+	return restored, nil
+}
+
+// restore rebuilds the rounds following r until the given round number.
+func (r *round1) restore(s *round.State, number round.Number) (round.Session, error) {
+	if number == 1 {
+		return r, nil
+	}
+	r2 := &round2{round1: r}
+	r2.getState(s)
+	if number == 2 {
+		return r2, nil
+	}
+	r3 := &round3{round2: r2}
+	r3.getState(s)
+	if number == 3 {
+		return r3, nil
+	}
+	return nil, fmt.Errorf("cannot restore round %d", number)
+}
@@ -1,8 +1,11 @@
 package sign
 
 import (
+	"crypto/sha512"
+	"errors"
 	"io"
 
+	"github.com/cronokirby/safenum"
 	"github.com/taurusgroup/multi-party-sig/pkg/hash"
 	"github.com/taurusgroup/multi-party-sig/pkg/math/curve"
 	"github.com/taurusgroup/multi-party-sig/pkg/math/sample"
@@ -43,11 +46,7 @@ type Signature struct {
 //
 // Note that m is the hash of a message, and not the message itself.
 func (sig Signature) Verify(public curve.Point, m []byte) bool {
-	group := public.Curve()
-
-	challengeHash := hash.New()
-	_ = challengeHash.WriteAny(sig.R, public, messageHash(m))
-	challenge := sample.Scalar(challengeHash.Digest(), group)
+	challenge := computeChallenge(sig.R, public, m)
 
 	expected := challenge.Act(public)
 	expected = expected.Add(sig.R)
@@ -56,3 +55,50 @@ func (sig Signature) Verify(public curve.Point, m []byte) bool {
 
 	return expected.Equal(actual)
 }
+
+// MarshalEd25519 encodes a signature over curve.Ed25519 as R || z in the 64 byte format of RFC 8032,
+// which can be verified with crypto/ed25519.Verify.
+func (sig Signature) MarshalEd25519() ([]byte, error) {
+	if _, ok := sig.R.(*curve.Ed25519Point); !ok {
+		return nil, errors.New("sign: signature is not over ed25519")
+	}
+	out, err := sig.R.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	zBytes, err := sig.z.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	// RFC 8032 encodes scalars in little endian
+	for i := len(zBytes) - 1; i >= 0; i-- {
+		out = append(out, zBytes[i])
+	}
+	return out, nil
+}
+
+// computeChallenge returns the challenge c = H(R, Y, m).
+//
+// Over curve.Ed25519, c = SHA-512(R || Y || m) is computed as in RFC 8032,
+// so that the signature can be verified by any Ed25519 implementation.
+// In that case m is the message itself, rather than its hash.
+func computeChallenge(R, Y curve.Point, m []byte) curve.Scalar {
+	group := Y.Curve()
+	if _, ok := group.(curve.Ed25519); ok {
+		h := sha512.New()
+		for _, p := range []curve.Point{R, Y} {
+			data, _ := p.MarshalBinary()
+			_, _ = h.Write(data)
+		}
+		_, _ = h.Write(m)
+		digest := h.Sum(nil)
+		// the digest is interpreted as a little endian integer
+		for i, j := 0, len(digest)-1; i < j; i, j = i+1, j-1 {
+			digest[i], digest[j] = digest[j], digest[i]
+		}
+		return group.NewScalar().SetNat(new(safenum.Nat).SetBytes(digest))
+	}
+	challengeHash := hash.New()
+	_ = challengeHash.WriteAny(R, Y, messageHash(m))
+	return sample.Scalar(challengeHash.Digest(), group)
+}
@@ -0,0 +1,66 @@
+package musig2
+
+import (
+	"github.com/taurusgroup/multi-party-sig/pkg/math/curve"
+	"github.com/taurusgroup/multi-party-sig/pkg/protocol"
+	"github.com/taurusgroup/multi-party-sig/protocols/musig2/sign"
+)
+
+type (
+	Config        = sign.Config
+	Tweak         = sign.Tweak
+	KeyAggContext = sign.KeyAggContext
+	PubNonce      = sign.PubNonce
+	Nonces        = sign.Nonces
+)
+
+// AggregateKeys computes the aggregate public key of a set of public keys, as in KeyAgg of BIP-327.
+//
+// The keys are sorted first, so that their order doesn't matter.
+//
+// See: https://github.com/bitcoin/bips/blob/master/bip-0327.mediawiki#key-aggregation
+func AggregateKeys(pubkeys []*curve.Secp256k1Point) (*KeyAggContext, error) {
+	return sign.AggregateKeys(pubkeys)
+}
+
+// Sign initiates the MuSig2 protocol for producing an n-of-n Schnorr signature.
+//
+// config contains the secret key of this participant, and the public keys of all the participants,
+// each of which must take part in the signature.
+//
+// messageHash is the message a signature should be generated for.
+//
+// The result is a taproot.Signature, valid for the aggregate public key returned by config.PublicKey(),
+// which includes the tweaks of config.
+//
+// This protocol follows BIP-327, except that there is no central aggregator:
+// every participant aggregates the public nonces and partial signatures itself.
+// If the signature is invalid, every participant whose partial signature is invalid is blamed.
+//
+// See: https://github.com/bitcoin/bips/blob/master/bip-0327.mediawiki
+func Sign(config *Config, messageHash []byte) protocol.StartFunc {
+	return sign.StartSign(config, messageHash)
+}
+
+// Preprocess generates count pairs of nonces ahead of time, which can later be used by SignWithNonces.
+//
+// The PubNonces are public, and should be published to the other participants.
+// The Nonces are secret, and kept by this participant until they are consumed by SignWithNonces.
+func Preprocess(config *Config, count int) (*Nonces, []*PubNonce, error) {
+	return sign.Preprocess(config, count)
+}
+
+// SignWithNonces is like Sign, but uses one PubNonce generated by Preprocess for each participant,
+// so that the signature is produced after a single round of communication.
+//
+// pubNonces must contain exactly one PubNonce for each participant, and every participant must receive the same pubNonces.
+// The nonces behind the PubNonce of this participant are deleted from nonces when the protocol starts,
+// and the protocol fails if they were already used, since reusing nonces would leak the secret key.
+func SignWithNonces(config *Config, nonces *Nonces, pubNonces []*PubNonce, messageHash []byte) protocol.StartFunc {
+	return sign.StartSignWithNonces(config, nonces, pubNonces, messageHash)
+}
+
+// EmptyNonces creates an empty Nonces, ready for unmarshalling.
+func EmptyNonces() *Nonces {
+	return sign.EmptyNonces()
+}
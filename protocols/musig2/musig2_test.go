@@ -0,0 +1,62 @@
+package musig2
+
+import (
+	"crypto/rand"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/taurusgroup/multi-party-sig/internal/test"
+	"github.com/taurusgroup/multi-party-sig/pkg/math/curve"
+	"github.com/taurusgroup/multi-party-sig/pkg/math/sample"
+	"github.com/taurusgroup/multi-party-sig/pkg/party"
+	"github.com/taurusgroup/multi-party-sig/pkg/protocol"
+	"github.com/taurusgroup/multi-party-sig/pkg/taproot"
+)
+
+func do(t *testing.T, config *Config, message []byte, n *test.Network, wg *sync.WaitGroup) {
+	defer wg.Done()
+	public, err := config.PublicKey()
+	require.NoError(t, err)
+
+	h, err := protocol.NewMultiHandler(Sign(config, message), nil)
+	require.NoError(t, err)
+	test.HandlerLoop(config.ID, h, n)
+	signResult, err := h.Result()
+	require.NoError(t, err)
+	require.IsType(t, taproot.Signature{}, signResult)
+	assert.True(t, public.Verify(signResult.(taproot.Signature), message))
+}
+
+func TestMuSig2(t *testing.T) {
+	N := 4
+	partyIDs := test.PartyIDs(N)
+	message := []byte("hello")
+
+	secretKeys := make(map[party.ID]*curve.Secp256k1Scalar, N)
+	publicKeys := make(map[party.ID]*curve.Secp256k1Point, N)
+	for _, id := range partyIDs {
+		secretKeys[id] = sample.Scalar(rand.Reader, curve.Secp256k1{}).(*curve.Secp256k1Scalar)
+		publicKeys[id] = secretKeys[id].ActOnBase().(*curve.Secp256k1Point)
+	}
+	keys := make([]*curve.Secp256k1Point, 0, N)
+	for _, id := range partyIDs {
+		keys = append(keys, publicKeys[id])
+	}
+	ctx, err := AggregateKeys(keys)
+	require.NoError(t, err)
+
+	n := test.NewNetwork(partyIDs)
+	var wg sync.WaitGroup
+	for _, id := range partyIDs {
+		config := &Config{ID: id, SecretKey: secretKeys[id], PublicKeys: publicKeys}
+		public, err := config.PublicKey()
+		require.NoError(t, err)
+		assert.Equal(t, ctx.TaprootPublicKey(), public)
+
+		wg.Add(1)
+		go do(t, config, message, n, &wg)
+	}
+	wg.Wait()
+}
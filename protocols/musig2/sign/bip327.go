@@ -0,0 +1,324 @@
+package sign
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/cronokirby/safenum"
+	"github.com/taurusgroup/multi-party-sig/pkg/math/curve"
+	"github.com/taurusgroup/multi-party-sig/pkg/taproot"
+)
+
+// This file implements the algorithms of BIP-327, which specifies MuSig2 over secp256k1:
+//   https://github.com/bitcoin/bips/blob/master/bip-0327.mediawiki
+//
+// The names of the functions and variables follow the specification, with keys being
+// encoded as 33 byte compressed points, like curve.Secp256k1Point.MarshalBinary.
+
+// PubNonceLen is the number of bytes in an encoded public nonce, or in an aggregate nonce.
+const PubNonceLen = 66
+
+// scalarFromHash interprets a hash as a big endian integer, reduced modulo the order of the group.
+func scalarFromHash(h []byte) curve.Scalar {
+	return curve.Secp256k1{}.NewScalar().SetNat(new(safenum.Nat).SetBytes(h))
+}
+
+// cbytes returns the 33 byte compressed encoding of P.
+func cbytes(P curve.Point) []byte {
+	data, _ := P.MarshalBinary()
+	return data
+}
+
+// cbytesExt is like cbytes, but encodes the identity as 33 zero bytes.
+func cbytesExt(P curve.Point) []byte {
+	if P.IsIdentity() {
+		return make([]byte, 33)
+	}
+	return cbytes(P)
+}
+
+// cpointExt decodes a point encoded with cbytesExt.
+func cpointExt(data []byte) (*curve.Secp256k1Point, error) {
+	if bytes.Equal(data, make([]byte, 33)) {
+		return curve.Secp256k1{}.NewPoint().(*curve.Secp256k1Point), nil
+	}
+	P := new(curve.Secp256k1Point)
+	if err := P.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+	return P, nil
+}
+
+// xbytes returns the 32 byte encoding of the x coordinate of P.
+func xbytes(P curve.Point) []byte {
+	return P.(*curve.Secp256k1Point).XBytes()
+}
+
+// KeySort sorts public keys by their compressed encoding, so that the aggregate key doesn't depend
+// on the order in which they are given.
+func KeySort(pubkeys []*curve.Secp256k1Point) []*curve.Secp256k1Point {
+	sorted := append([]*curve.Secp256k1Point{}, pubkeys...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return bytes.Compare(cbytes(sorted[i]), cbytes(sorted[j])) < 0
+	})
+	return sorted
+}
+
+// KeyAggContext is the aggregate of a list of public keys, possibly tweaked afterwards.
+type KeyAggContext struct {
+	// pubkeys are the encodings of the aggregated keys, in order.
+	pubkeys [][]byte
+	// L = hash_KeyAgg list(pk₁ || … || pkᵤ)
+	L []byte
+	// pk2 is the first key different from pk₁, which gets a coefficient of 1, or nil.
+	pk2 []byte
+	// Q is the aggregate public key, with all tweaks applied.
+	Q *curve.Secp256k1Point
+	// gacc is ±1, accumulating the negations of Q made by x-only tweaks.
+	gacc curve.Scalar
+	// tacc is the accumulated tweak.
+	tacc curve.Scalar
+}
+
+// AggregateKeys aggregates public keys into a single one, following KeyAgg after sorting them with KeySort.
+func AggregateKeys(pubkeys []*curve.Secp256k1Point) (*KeyAggContext, error) {
+	return keyAgg(KeySort(pubkeys))
+}
+
+// keyAgg aggregates public keys in the given order.
+func keyAgg(pubkeys []*curve.Secp256k1Point) (*KeyAggContext, error) {
+	if len(pubkeys) == 0 {
+		return nil, errors.New("musig2: no public keys to aggregate")
+	}
+	group := curve.Secp256k1{}
+	ctx := &KeyAggContext{
+		pubkeys: make([][]byte, 0, len(pubkeys)),
+		gacc:    group.NewScalar().SetNat(new(safenum.Nat).SetUint64(1)),
+		tacc:    group.NewScalar(),
+	}
+	for _, P := range pubkeys {
+		if P == nil || P.IsIdentity() {
+			return nil, errors.New("musig2: invalid public key")
+		}
+		ctx.pubkeys = append(ctx.pubkeys, cbytes(P))
+	}
+	ctx.L = taproot.TaggedHash("KeyAgg list", ctx.pubkeys...)
+	for _, pk := range ctx.pubkeys[1:] {
+		if !bytes.Equal(pk, ctx.pubkeys[0]) {
+			ctx.pk2 = pk
+			break
+		}
+	}
+
+	Q := group.NewPoint()
+	for i, P := range pubkeys {
+		Q = Q.Add(ctx.coefficient(ctx.pubkeys[i]).Act(P))
+	}
+	if Q.IsIdentity() {
+		return nil, errors.New("musig2: aggregate key is the identity")
+	}
+	ctx.Q = Q.(*curve.Secp256k1Point)
+	return ctx, nil
+}
+
+// coefficient returns the coefficient a of the key encoded as pk, in KeyAggCoeffInternal.
+func (ctx *KeyAggContext) coefficient(pk []byte) curve.Scalar {
+	if ctx.pk2 != nil && bytes.Equal(pk, ctx.pk2) {
+		return curve.Secp256k1{}.NewScalar().SetNat(new(safenum.Nat).SetUint64(1))
+	}
+	return scalarFromHash(taproot.TaggedHash("KeyAgg coefficient", ctx.L, pk))
+}
+
+// contains returns true if pk is one of the aggregated keys.
+func (ctx *KeyAggContext) contains(pk []byte) bool {
+	for _, pk_i := range ctx.pubkeys {
+		if bytes.Equal(pk, pk_i) {
+			return true
+		}
+	}
+	return false
+}
+
+// ApplyTweak returns the context of the key Q' = g * Q + t * G, where Q is the current key and t the tweak.
+//
+// For a plain tweak, as used by BIP-32 derivation, g = 1. For an x-only tweak, as used by BIP-341,
+// g = -1 if Q has an odd y coordinate, so that the tweak is applied to the even key with the same x coordinate.
+func (ctx *KeyAggContext) ApplyTweak(tweak []byte, xOnly bool) (*KeyAggContext, error) {
+	t := new(curve.Secp256k1Scalar)
+	if err := t.UnmarshalBinary(tweak); err != nil {
+		return nil, fmt.Errorf("musig2: invalid tweak: %w", err)
+	}
+	group := curve.Secp256k1{}
+	out := *ctx
+	out.gacc = group.NewScalar().Set(ctx.gacc)
+	out.tacc = group.NewScalar().Set(ctx.tacc)
+	Q := curve.Point(ctx.Q)
+	if xOnly && !ctx.Q.HasEvenY() {
+		Q = Q.Negate()
+		out.gacc.Negate()
+		out.tacc.Negate()
+	}
+	Q = Q.Add(t.ActOnBase())
+	if Q.IsIdentity() {
+		return nil, errors.New("musig2: tweaked key is the identity")
+	}
+	out.Q = Q.(*curve.Secp256k1Point)
+	out.tacc.Add(t)
+	return &out, nil
+}
+
+// ApplyTaprootTweak applies the x-only tweak of BIP-341, committing the key to the script tree with the given
+// merkle root, or to no scripts if merkleRoot is empty.
+//
+// See taproot.TweakScalar.
+func (ctx *KeyAggContext) ApplyTaprootTweak(merkleRoot []byte) (*KeyAggContext, error) {
+	t, err := taproot.TweakScalar(ctx.TaprootPublicKey(), merkleRoot)
+	if err != nil {
+		return nil, fmt.Errorf("musig2: %w", err)
+	}
+	tweak, _ := t.MarshalBinary()
+	return ctx.ApplyTweak(tweak, true)
+}
+
+// PublicKey returns the aggregate public key.
+func (ctx *KeyAggContext) PublicKey() *curve.Secp256k1Point {
+	return new(curve.Secp256k1Point).Set(ctx.Q).(*curve.Secp256k1Point)
+}
+
+// TaprootPublicKey returns the x-only aggregate public key, against which signatures are verified.
+func (ctx *KeyAggContext) TaprootPublicKey() taproot.PublicKey {
+	return xbytes(ctx.Q)
+}
+
+// nonceGen implements NonceGen, returning the secret nonces k₁, k₂.
+//
+// rand must be 32 fresh random bytes, and msg is only included if hasMsg is set.
+func nonceGen(rand []byte, sk curve.Scalar, pk, aggpk []byte, hasMsg bool, msg, extraIn []byte) (curve.Scalar, curve.Scalar, error) {
+	skBytes, err := sk.MarshalBinary()
+	if err != nil {
+		return nil, nil, err
+	}
+	// As in BIP-340, the secret key is masked by the hash of the randomness.
+	aux := taproot.TaggedHash("MuSig/aux", rand)
+	randXOR := make([]byte, 32)
+	for i := range randXOR {
+		randXOR[i] = skBytes[i] ^ aux[i]
+	}
+
+	var msgPrefixed []byte
+	if hasMsg {
+		msgPrefixed = []byte{1}
+		msgPrefixed = binary.BigEndian.AppendUint64(msgPrefixed, uint64(len(msg)))
+		msgPrefixed = append(msgPrefixed, msg...)
+	} else {
+		msgPrefixed = []byte{0}
+	}
+	extraPrefixed := binary.BigEndian.AppendUint32(nil, uint32(len(extraIn)))
+	extraPrefixed = append(extraPrefixed, extraIn...)
+
+	var k [2]curve.Scalar
+	for i := range k {
+		k[i] = scalarFromHash(taproot.TaggedHash("MuSig/nonce",
+			randXOR,
+			[]byte{byte(len(pk))}, pk,
+			[]byte{byte(len(aggpk))}, aggpk,
+			msgPrefixed,
+			extraPrefixed,
+			[]byte{byte(i)},
+		))
+		if k[i].IsZero() {
+			return nil, nil, errors.New("musig2: nonce is zero")
+		}
+	}
+	return k[0], k[1], nil
+}
+
+// session holds the values computed by GetSessionValues, which are common to all signers.
+type session struct {
+	ctx *KeyAggContext
+	// R1, R2 are the two parts of the aggregate nonce.
+	R1, R2 curve.Point
+	m      []byte
+	// b is the nonce coefficient.
+	b curve.Scalar
+	// R is the final nonce of the signature.
+	R *curve.Secp256k1Point
+	// e is the challenge.
+	e curve.Scalar
+}
+
+// newSession computes the session values for signing m with the aggregate nonce (R1, R2).
+func newSession(ctx *KeyAggContext, R1, R2 curve.Point, m []byte) *session {
+	s := &session{ctx: ctx, R1: R1, R2: R2, m: m}
+	s.b = scalarFromHash(taproot.TaggedHash("MuSig/noncecoef", cbytesExt(R1), cbytesExt(R2), xbytes(ctx.Q), m))
+	R := R1.Add(s.b.Act(R2))
+	if R.IsIdentity() {
+		R = curve.Secp256k1{}.NewBasePoint()
+	}
+	s.R = R.(*curve.Secp256k1Point)
+	s.e = scalarFromHash(taproot.TaggedHash("BIP0340/challenge", xbytes(s.R), xbytes(ctx.Q), m))
+	return s
+}
+
+// g returns 1 if Q has an even y coordinate, and -1 otherwise.
+func (s *session) g() curve.Scalar {
+	g := curve.Secp256k1{}.NewScalar().SetNat(new(safenum.Nat).SetUint64(1))
+	if !s.ctx.Q.HasEvenY() {
+		g.Negate()
+	}
+	return g
+}
+
+// sign implements Sign, returning the partial signature s = k₁ + b * k₂ + e * a * d
+// of the signer with secret key sk, whose public key is encoded as pk.
+func (s *session) sign(k1, k2, sk curve.Scalar, pk []byte) (curve.Scalar, error) {
+	if !s.ctx.contains(pk) {
+		return nil, errors.New("musig2: public key is not part of the aggregate key")
+	}
+	group := curve.Secp256k1{}
+	k1 = group.NewScalar().Set(k1)
+	k2 = group.NewScalar().Set(k2)
+	if !s.R.HasEvenY() {
+		k1.Negate()
+		k2.Negate()
+	}
+	a := s.ctx.coefficient(pk)
+	d := group.NewScalar().Set(sk).Mul(s.g()).Mul(s.ctx.gacc)
+	psig := group.NewScalar().Set(s.e).Mul(a).Mul(d)
+	psig.Add(k1)
+	psig.Add(group.NewScalar().Set(s.b).Mul(k2))
+	return psig, nil
+}
+
+// verify implements PartialSigVerifyInternal, checking the partial signature psig
+// of the signer with public key P and public nonce (R1, R2).
+func (s *session) verify(psig curve.Scalar, R1, R2 curve.Point, P *curve.Secp256k1Point) bool {
+	pk := cbytes(P)
+	if !s.ctx.contains(pk) {
+		return false
+	}
+	Re := R1.Add(s.b.Act(R2))
+	if !s.R.HasEvenY() {
+		Re = Re.Negate()
+	}
+	ag := s.ctx.coefficient(pk).Mul(s.g()).Mul(s.ctx.gacc)
+	expected := Re.Add(s.e.Act(ag.Act(P)))
+	return psig.ActOnBase().Equal(expected)
+}
+
+// aggregate implements PartialSigAgg, returning the signature with the partial signatures psigs.
+func (s *session) aggregate(psigs []curve.Scalar) taproot.Signature {
+	group := curve.Secp256k1{}
+	z := group.NewScalar().Set(s.e).Mul(s.g()).Mul(s.ctx.tacc)
+	for _, psig := range psigs {
+		z.Add(psig)
+	}
+	zBytes, _ := z.MarshalBinary()
+	sig := make([]byte, 0, taproot.SignatureLen)
+	sig = append(sig, xbytes(s.R)...)
+	sig = append(sig, zBytes...)
+	return sig
+}
@@ -0,0 +1,118 @@
+package sign
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/taurusgroup/multi-party-sig/pkg/math/curve"
+)
+
+// The test vectors are taken from BIP-327:
+//   https://github.com/bitcoin/bips/tree/master/bip-0327/vectors
+
+func decodeHex(t *testing.T, s string) []byte {
+	data, err := hex.DecodeString(s)
+	require.NoError(t, err)
+	return data
+}
+
+func decodePoint(t *testing.T, s string) *curve.Secp256k1Point {
+	P, err := cpointExt(decodeHex(t, s))
+	require.NoError(t, err)
+	return P
+}
+
+func decodeScalar(t *testing.T, s string) *curve.Secp256k1Scalar {
+	k := new(curve.Secp256k1Scalar)
+	require.NoError(t, k.UnmarshalBinary(decodeHex(t, s)))
+	return k
+}
+
+func TestKeyAggVectors(t *testing.T) {
+	pubkeys := []*curve.Secp256k1Point{
+		decodePoint(t, "02F9308A019258C31049344F85F89D5229B531C845836F99B08601F113BCE036F9"),
+		decodePoint(t, "03DFF1D77F2A671C5F36183726DB2341BE58FEAE1DA2DECED843240F7B502BA659"),
+		decodePoint(t, "023590A94E768F8E1815C2F24B4D80A8E3149316C3518CE7B7AD338368D038CA66"),
+	}
+	vectors := []struct {
+		indices  []int
+		expected string
+	}{
+		{[]int{0, 1, 2}, "90539EEDE565F5D054F32CC0C220126889ED1E5D193BAF15AEF344FE59D4610C"},
+		{[]int{2, 1, 0}, "6204DE8B083426DC6EAF9502D27024D53FC826BF7D2012148A0575435DF54B2B"},
+		{[]int{0, 0, 0}, "B436E3BAD62B8CD409969A224731C193D051162D8C5AE8B109306127DA3AA935"},
+		{[]int{0, 0, 1, 1}, "69BC22BFA5D106306E48A20679DE1D7389386124D07571D0D872686028C26A3E"},
+	}
+	for _, v := range vectors {
+		keys := make([]*curve.Secp256k1Point, 0, len(v.indices))
+		for _, i := range v.indices {
+			keys = append(keys, pubkeys[i])
+		}
+		ctx, err := keyAgg(keys)
+		require.NoError(t, err)
+		assert.Equal(t, decodeHex(t, v.expected), []byte(ctx.TaprootPublicKey()))
+	}
+
+	// sorting makes the order irrelevant
+	ctx1, err := AggregateKeys(pubkeys)
+	require.NoError(t, err)
+	ctx2, err := AggregateKeys([]*curve.Secp256k1Point{pubkeys[2], pubkeys[0], pubkeys[1]})
+	require.NoError(t, err)
+	assert.True(t, ctx1.PublicKey().Equal(ctx2.PublicKey()))
+}
+
+func TestNonceGenVector(t *testing.T) {
+	sk := decodeScalar(t, "0202020202020202020202020202020202020202020202020202020202020202")
+	pk := decodeHex(t, "024D4B6CD1361032CA9BD2AEB9D900AA4D45D9EAD80AC9423374C451A7254D0766")
+	k1, k2, err := nonceGen(bytes.Repeat([]byte{0x0F}, 32), sk, pk, bytes.Repeat([]byte{0x07}, 32), true, bytes.Repeat([]byte{0x01}, 32), bytes.Repeat([]byte{0x08}, 32))
+	require.NoError(t, err)
+	assert.True(t, decodeScalar(t, "B114E502BEAA4E301DD08A50264172C84E41650E6CB726B410C0694D59EFFB64").Equal(k1))
+	assert.True(t, decodeScalar(t, "95B5CAF28D045B973D63E3C99A44B807BDE375FD6CB39E46DC4A511708D0E9D2").Equal(k2))
+}
+
+func TestSignVerifyVectors(t *testing.T) {
+	sk := decodeScalar(t, "7FB9E0E687ADA1EEBF7ECFE2F21E73EBDB51A7D450948DFE8D76D7F2D1007671")
+	pubkeys := []*curve.Secp256k1Point{
+		decodePoint(t, "03935F972DA013F80AE011890FA89B67A27B7BE6CCB24D3274D18B2D4067F261A9"),
+		decodePoint(t, "02F9308A019258C31049344F85F89D5229B531C845836F99B08601F113BCE036F9"),
+		decodePoint(t, "02DFF1D77F2A671C5F36183726DB2341BE58FEAE1DA2DECED843240F7B502BA661"),
+	}
+	k1 := decodeScalar(t, "508B81A611F100A6B2B6B29656590898AF488BCF2E1F55CF22E5CFB84421FE61")
+	k2 := decodeScalar(t, "FA27FD49B1D50085B481285E1CA205D55C82CC1B31FF5CD54A489829355901F7")
+	pubNonce := decodeHex(t, "0337C87821AFD50A8644D820A8F3E02E499C931865C2360FB43D0A0D20DAFE07EA0287BF891D2A6DEAEBADC909352AA9405D1428C15F4B75F04DAE642A95C2548480")
+	aggNonce := decodeHex(t, "028465FCF0BBDBCF443AABCCE533D42B4B5A10966AC09A49655E8C42DAAB8FCD61037496A3CC86926D452CAFCFD55D25972CA1675D549310DE296BFF42F72EEEA8C9")
+	m := decodeHex(t, "F95466D086770E689964664219266FE5ED215C92AE20BAB5C9D79ADDDDF3C0CF")
+
+	assert.True(t, sk.ActOnBase().Equal(pubkeys[0]))
+	assert.Equal(t, pubNonce, (&PubNonce{
+		R1: k1.ActOnBase().(*curve.Secp256k1Point),
+		R2: k2.ActOnBase().(*curve.Secp256k1Point),
+	}).Bytes())
+	R1, R2 := decodePoint(t, hex.EncodeToString(aggNonce[:33])), decodePoint(t, hex.EncodeToString(aggNonce[33:]))
+
+	vectors := []struct {
+		indices  []int
+		expected string
+	}{
+		{[]int{0, 1, 2}, "012ABBCB52B3016AC03AD82395A1A415C48B93DEF78718E62A7A90052FE224FB"},
+		{[]int{1, 0, 2}, "9FF2F7AAA856150CC8819254218D3ADEEB0535269051897724F9DB3789513A52"},
+		{[]int{1, 2, 0}, "FA23C359F6FAC4E7796BB93BC9F0532A95468C539BA20FF86D7C76ED92227900"},
+	}
+	for _, v := range vectors {
+		keys := make([]*curve.Secp256k1Point, 0, len(v.indices))
+		for _, i := range v.indices {
+			keys = append(keys, pubkeys[i])
+		}
+		ctx, err := keyAgg(keys)
+		require.NoError(t, err)
+		s := newSession(ctx, R1, R2, m)
+		psig, err := s.sign(k1, k2, sk, cbytes(pubkeys[0]))
+		require.NoError(t, err)
+		assert.True(t, decodeScalar(t, v.expected).Equal(psig))
+		assert.True(t, s.verify(psig, decodePoint(t, hex.EncodeToString(pubNonce[:33])), decodePoint(t, hex.EncodeToString(pubNonce[33:])), pubkeys[0]))
+		assert.False(t, s.verify(psig, decodePoint(t, hex.EncodeToString(pubNonce[:33])), decodePoint(t, hex.EncodeToString(pubNonce[33:])), pubkeys[1]))
+	}
+}
@@ -0,0 +1,138 @@
+package sign
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/taurusgroup/multi-party-sig/pkg/math/curve"
+	"github.com/taurusgroup/multi-party-sig/pkg/party"
+	"github.com/taurusgroup/multi-party-sig/pkg/taproot"
+)
+
+// Tweak is a tweak of the aggregate public key, as in BIP-327.
+type Tweak struct {
+	// Value is the 32 byte tweak t.
+	Value []byte
+	// XOnly indicates an x-only tweak, as used by BIP-341, instead of a plain tweak, as used by BIP-32.
+	XOnly bool
+}
+
+// Config contains the key of a participant, along with the public keys of all the participants,
+// from which the aggregate public key is computed.
+//
+// Unlike threshold protocols, there is no key generation: each participant generates its own key,
+// and the public keys are exchanged out of band.
+type Config struct {
+	// ID is the identifier for this participant.
+	ID party.ID
+	// SecretKey is the secret key of this participant.
+	SecretKey *curve.Secp256k1Scalar
+	// PublicKeys contains the public key of each participant, this one included.
+	//
+	// All of them are needed to sign.
+	PublicKeys map[party.ID]*curve.Secp256k1Point
+	// Tweaks are applied in order to the aggregate public key.
+	Tweaks []Tweak
+}
+
+// Validate ensures that the data is consistent.
+func (c *Config) Validate() error {
+	if c.SecretKey == nil || c.SecretKey.IsZero() {
+		return errors.New("musig2.Config: invalid secret key")
+	}
+	P, ok := c.PublicKeys[c.ID]
+	if !ok {
+		return fmt.Errorf("musig2.Config: no public key for %v", c.ID)
+	}
+	if P == nil || !c.SecretKey.ActOnBase().Equal(P) {
+		return errors.New("musig2.Config: public key doesn't match secret key")
+	}
+	if _, err := c.KeyAgg(); err != nil {
+		return fmt.Errorf("musig2.Config: %w", err)
+	}
+	return nil
+}
+
+// PartyIDs returns the IDs of all the participants, sorted.
+func (c *Config) PartyIDs() party.IDSlice {
+	ids := make([]party.ID, 0, len(c.PublicKeys))
+	for id := range c.PublicKeys {
+		ids = append(ids, id)
+	}
+	return party.NewIDSlice(ids)
+}
+
+// KeyAgg returns the aggregate of all the public keys, with the Tweaks applied.
+func (c *Config) KeyAgg() (*KeyAggContext, error) {
+	pubkeys := make([]*curve.Secp256k1Point, 0, len(c.PublicKeys))
+	for _, id := range c.PartyIDs() {
+		pubkeys = append(pubkeys, c.PublicKeys[id])
+	}
+	ctx, err := AggregateKeys(pubkeys)
+	if err != nil {
+		return nil, err
+	}
+	for _, tweak := range c.Tweaks {
+		if ctx, err = ctx.ApplyTweak(tweak.Value, tweak.XOnly); err != nil {
+			return nil, err
+		}
+	}
+	return ctx, nil
+}
+
+// PublicKey returns the x-only aggregate public key, with the Tweaks applied.
+//
+// Signatures produced with this config verify against this key.
+func (c *Config) PublicKey() (taproot.PublicKey, error) {
+	ctx, err := c.KeyAgg()
+	if err != nil {
+		return nil, err
+	}
+	return ctx.TaprootPublicKey(), nil
+}
+
+// Tweak returns a copy of this config, with an additional tweak.
+func (c *Config) Tweak(value []byte, xOnly bool) (*Config, error) {
+	tweaked := c.clone()
+	tweaked.Tweaks = append(tweaked.Tweaks, Tweak{Value: append([]byte{}, value...), XOnly: xOnly})
+	if _, err := tweaked.KeyAgg(); err != nil {
+		return nil, err
+	}
+	return tweaked, nil
+}
+
+// TweakTaproot returns a copy of this config, tweaked to the BIP-341 output key committing to the script tree
+// with the given merkle root, whose internal key is the current aggregate public key.
+//
+// merkleRoot can be empty if the output key commits to no scripts, as recommended by BIP-86.
+// Signatures produced with the resulting config are valid for a key path spend.
+func (c *Config) TweakTaproot(merkleRoot []byte) (*Config, error) {
+	ctx, err := c.KeyAgg()
+	if err != nil {
+		return nil, err
+	}
+	t, err := taproot.TweakScalar(ctx.TaprootPublicKey(), merkleRoot)
+	if err != nil {
+		return nil, err
+	}
+	value, _ := t.MarshalBinary()
+	return c.Tweak(value, true)
+}
+
+// clone returns a deep copy of this config.
+func (c *Config) clone() *Config {
+	publicKeys := make(map[party.ID]*curve.Secp256k1Point, len(c.PublicKeys))
+	for id, P := range c.PublicKeys {
+		publicKeys[id] = P
+	}
+	tweaks := make([]Tweak, 0, len(c.Tweaks)+1)
+	for _, tweak := range c.Tweaks {
+		tweaks = append(tweaks, Tweak{Value: append([]byte{}, tweak.Value...), XOnly: tweak.XOnly})
+	}
+	return &Config{
+		ID:         c.ID,
+		SecretKey:  curve.Secp256k1{}.NewScalar().Set(c.SecretKey).(*curve.Secp256k1Scalar),
+		PublicKeys: publicKeys,
+		Tweaks:     tweaks,
+	}
+}
@@ -0,0 +1,150 @@
+package sign
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+
+	"github.com/taurusgroup/multi-party-sig/internal/preprocess"
+	"github.com/taurusgroup/multi-party-sig/internal/round"
+	"github.com/taurusgroup/multi-party-sig/pkg/math/curve"
+	"github.com/taurusgroup/multi-party-sig/pkg/party"
+	"github.com/taurusgroup/multi-party-sig/pkg/protocol"
+)
+
+// PubNonce is a public nonce (R₁, R₂) = (k₁ * G, k₂ * G), published by a participant ahead of time.
+type PubNonce struct {
+	// ID is the participant holding the corresponding secret nonces.
+	ID party.ID
+	// R1 is the first part of the public nonce.
+	R1 *curve.Secp256k1Point
+	// R2 is the second part of the public nonce.
+	R2 *curve.Secp256k1Point
+}
+
+// Bytes returns the 66 byte encoding of the public nonce, as in BIP-327.
+func (n *PubNonce) Bytes() []byte {
+	return append(cbytes(n.R1), cbytes(n.R2)...)
+}
+
+// Nonces holds the secret nonces (k₁, k₂) behind the PubNonces of a participant, until they are used.
+//
+// Each pair of nonces can only be used once, so a Nonces which is stored must be persisted again after every signature,
+// and never restored from an older copy.
+//
+// It is safe for concurrent use.
+type Nonces struct {
+	nonces *preprocess.Nonces
+}
+
+// EmptyNonces creates an empty Nonces, ready for unmarshalling.
+func EmptyNonces() *Nonces {
+	return &Nonces{nonces: preprocess.NewNonces(curve.Secp256k1{}, "")}
+}
+
+// Preprocess generates count pairs of secret nonces for the participant owning config.
+//
+// The PubNonces must be published to the other participants, while the Nonces must be kept secret.
+// The message is not known yet, so it is not included in NonceGen, which is allowed by BIP-327.
+func Preprocess(config *Config, count int) (*Nonces, []*PubNonce, error) {
+	if count <= 0 {
+		return nil, nil, fmt.Errorf("sign.Preprocess: invalid count %d", count)
+	}
+	if err := config.Validate(); err != nil {
+		return nil, nil, fmt.Errorf("sign.Preprocess: %w", err)
+	}
+	ctx, err := config.KeyAgg()
+	if err != nil {
+		return nil, nil, fmt.Errorf("sign.Preprocess: %w", err)
+	}
+	pk := cbytes(config.PublicKeys[config.ID])
+
+	nonces := &Nonces{nonces: preprocess.NewNonces(curve.Secp256k1{}, config.ID)}
+	pubNonces := make([]*PubNonce, 0, count)
+	for len(pubNonces) < count {
+		randBytes := make([]byte, 32)
+		if _, err = rand.Read(randBytes); err != nil {
+			return nil, nil, fmt.Errorf("sign.Preprocess: %w", err)
+		}
+		k1, k2, err := nonceGen(randBytes, config.SecretKey, pk, xbytes(ctx.Q), false, nil, nil)
+		if err != nil {
+			return nil, nil, fmt.Errorf("sign.Preprocess: %w", err)
+		}
+		n := &PubNonce{
+			ID: config.ID,
+			R1: k1.ActOnBase().(*curve.Secp256k1Point),
+			R2: k2.ActOnBase().(*curve.Secp256k1Point),
+		}
+		nonces.nonces.Add(n.R1, k1, k2)
+		pubNonces = append(pubNonces, n)
+	}
+	return nonces, pubNonces, nil
+}
+
+// Len returns the number of unused pairs of nonces.
+func (n *Nonces) Len() int { return n.nonces.Len() }
+
+// take removes and returns the secret nonces behind pubNonce.
+func (n *Nonces) take(pubNonce *PubNonce) (k1, k2 curve.Scalar, err error) {
+	return n.nonces.Take(pubNonce.ID, pubNonce.R1)
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+func (n *Nonces) MarshalBinary() ([]byte, error) { return n.nonces.MarshalBinary() }
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+func (n *Nonces) UnmarshalBinary(data []byte) error { return n.nonces.UnmarshalBinary(data) }
+
+// StartSignWithNonces is like StartSign, but uses PubNonces published in advance,
+// so that the protocol only needs a single round of communication.
+//
+// pubNonces contains exactly one PubNonce of each participant, the one of this participant being taken from nonces.
+// Its nonces are removed from nonces before the protocol starts, even if it fails later on.
+func StartSignWithNonces(config *Config, nonces *Nonces, pubNonces []*PubNonce, messageHash []byte) protocol.StartFunc {
+	return func(sessionID []byte) (round.Session, error) {
+		R1 := make(map[party.ID]curve.Point, len(pubNonces))
+		R2 := make(map[party.ID]curve.Point, len(pubNonces))
+		var own *PubNonce
+		for _, n := range pubNonces {
+			if n == nil || n.R1 == nil || n.R2 == nil || n.R1.IsIdentity() || n.R2.IsIdentity() {
+				return nil, errors.New("sign.StartSignWithNonces: invalid public nonce")
+			}
+			if _, ok := config.PublicKeys[n.ID]; !ok {
+				return nil, fmt.Errorf("sign.StartSignWithNonces: unknown participant %v", n.ID)
+			}
+			if _, ok := R1[n.ID]; ok {
+				return nil, fmt.Errorf("sign.StartSignWithNonces: several public nonces of %v", n.ID)
+			}
+			R1[n.ID], R2[n.ID] = n.R1, n.R2
+			if n.ID == config.ID {
+				own = n
+			}
+		}
+		if len(R1) != len(config.PublicKeys) {
+			return nil, errors.New("sign.StartSignWithNonces: missing public nonces")
+		}
+		if own == nil {
+			return nil, errors.New("sign.StartSignWithNonces: no public nonce of this participant")
+		}
+
+		r1, err := newRound1(protocolIDPreprocessed, config, messageHash, sessionID)
+		if err != nil {
+			return nil, fmt.Errorf("sign.StartSignWithNonces: %w", err)
+		}
+
+		k1, k2, err := nonces.take(own)
+		if err != nil {
+			return nil, fmt.Errorf("sign.StartSignWithNonces: %w", err)
+		}
+		return &preprocess.Round{
+			Helper: r1.Helper,
+			Next: &round2{
+				round1: r1,
+				k1:     k1,
+				k2:     k2,
+				R1:     R1,
+				R2:     R2,
+			},
+		}, nil
+	}
+}
@@ -0,0 +1,62 @@
+package sign
+
+import (
+	"crypto/rand"
+
+	"github.com/taurusgroup/multi-party-sig/internal/round"
+	"github.com/taurusgroup/multi-party-sig/pkg/math/curve"
+	"github.com/taurusgroup/multi-party-sig/pkg/party"
+)
+
+// This round corresponds to NonceGen in BIP-327, where each participant generates
+// two nonces (k₁, k₂), and broadcasts the public nonce (R₁, R₂) = (k₁ * G, k₂ * G).
+type round1 struct {
+	*round.Helper
+	// ctx is the aggregate public key, with all tweaks applied.
+	ctx *KeyAggContext
+	// M is the hash of the message we're signing.
+	M []byte
+	// PublicKeys contains the individual public key of each participant.
+	PublicKeys map[party.ID]*curve.Secp256k1Point
+	// sk is our secret key.
+	sk curve.Scalar
+}
+
+// VerifyMessage implements round.Round.
+func (round1) VerifyMessage(round.Message) error { return nil }
+
+// StoreMessage implements round.Round.
+func (round1) StoreMessage(round.Message) error { return nil }
+
+// Finalize implements round.Round.
+func (r *round1) Finalize(out chan<- *round.Message) (round.Session, error) {
+	// The session hash is passed as extra input, so that nonces are bound to this execution,
+	// on top of the randomness.
+	randBytes := make([]byte, 32)
+	if _, err := rand.Read(randBytes); err != nil {
+		return r, err
+	}
+	k1, k2, err := nonceGen(randBytes, r.sk, cbytes(r.PublicKeys[r.SelfID()]), xbytes(r.ctx.Q), true, r.M, r.Hash().Sum())
+	if err != nil {
+		return r, err
+	}
+	R1, R2 := k1.ActOnBase(), k2.ActOnBase()
+
+	err = r.BroadcastMessage(out, &broadcast2{R1: R1, R2: R2})
+	if err != nil {
+		return r, err
+	}
+	return &round2{
+		round1: r,
+		k1:     k1,
+		k2:     k2,
+		R1:     map[party.ID]curve.Point{r.SelfID(): R1},
+		R2:     map[party.ID]curve.Point{r.SelfID(): R2},
+	}, nil
+}
+
+// MessageContent implements round.Round.
+func (round1) MessageContent() round.Content { return nil }
+
+// Number implements round.Round.
+func (round1) Number() round.Number { return 1 }
@@ -0,0 +1,92 @@
+package sign
+
+import (
+	"errors"
+
+	"github.com/taurusgroup/multi-party-sig/internal/round"
+	"github.com/taurusgroup/multi-party-sig/pkg/math/curve"
+	"github.com/taurusgroup/multi-party-sig/pkg/party"
+)
+
+// This round corresponds to NonceAgg and Sign in BIP-327.
+//
+// Instead of relying on an aggregator, each participant aggregates the public nonces
+// it receives, and broadcasts its partial signature.
+type round2 struct {
+	*round1
+	// k1, k2 = k₁, k₂ are our secret nonces.
+	k1, k2 curve.Scalar
+	// R1[i], R2[i] = Rᵢ₁, Rᵢ₂ is the public nonce of each participant, ourself included.
+	R1, R2 map[party.ID]curve.Point
+}
+
+type broadcast2 struct {
+	round.ReliableBroadcastContent
+	// R1, R2 is the public nonce of the sender of this message.
+	R1, R2 curve.Point
+}
+
+// StoreBroadcastMessage implements round.BroadcastRound.
+func (r *round2) StoreBroadcastMessage(msg round.Message) error {
+	body, ok := msg.Content.(*broadcast2)
+	if !ok || body == nil {
+		return round.ErrInvalidContent
+	}
+	if body.R1.IsIdentity() || body.R2.IsIdentity() {
+		return errors.New("public nonce is the identity point")
+	}
+	r.R1[msg.From] = body.R1
+	r.R2[msg.From] = body.R2
+	return nil
+}
+
+// VerifyMessage implements round.Round.
+func (round2) VerifyMessage(round.Message) error { return nil }
+
+// StoreMessage implements round.Round.
+func (round2) StoreMessage(round.Message) error { return nil }
+
+// Finalize implements round.Round.
+func (r *round2) Finalize(out chan<- *round.Message) (round.Session, error) {
+	// NonceAgg
+	R1, R2 := r.Group().NewPoint(), r.Group().NewPoint()
+	for _, l := range r.PartyIDs() {
+		R1 = R1.Add(r.R1[l])
+		R2 = R2.Add(r.R2[l])
+	}
+	s := newSession(r.ctx, R1, R2, r.M)
+
+	psig, err := s.sign(r.k1, r.k2, r.sk, cbytes(r.PublicKeys[r.SelfID()]))
+	if err != nil {
+		return r, err
+	}
+	// The nonces must never be used again.
+	r.k1, r.k2 = nil, nil
+
+	err = r.BroadcastMessage(out, &broadcast3{S_i: psig})
+	if err != nil {
+		return r, err
+	}
+	return &round3{
+		round2:  r,
+		session: s,
+		s:       map[party.ID]curve.Scalar{r.SelfID(): psig},
+	}, nil
+}
+
+// MessageContent implements round.Round.
+func (round2) MessageContent() round.Content { return nil }
+
+// RoundNumber implements round.Content.
+func (broadcast2) RoundNumber() round.Number { return 2 }
+
+// BroadcastContent implements round.BroadcastRound.
+func (r *round2) BroadcastContent() round.BroadcastContent {
+	return &broadcast2{
+		R1: r.Group().NewPoint(),
+		R2: r.Group().NewPoint(),
+	}
+}
+
+// Number implements round.Round.
+func (round2) Number() round.Number { return 2 }
@@ -0,0 +1,86 @@
+package sign
+
+import (
+	"fmt"
+
+	"github.com/taurusgroup/multi-party-sig/internal/round"
+	"github.com/taurusgroup/multi-party-sig/pkg/math/curve"
+	"github.com/taurusgroup/multi-party-sig/pkg/party"
+)
+
+// This round corresponds to PartialSigAgg in BIP-327, with each participant
+// aggregating the partial signatures into the final signature.
+type round3 struct {
+	*round2
+	// session holds the values derived from the aggregate nonce.
+	session *session
+	// s[i] = sᵢ is the partial signature of each participant.
+	s map[party.ID]curve.Scalar
+}
+
+type broadcast3 struct {
+	round.NormalBroadcastContent
+	// S_i is the partial signature of the sender of this message.
+	S_i curve.Scalar
+}
+
+// StoreBroadcastMessage implements round.BroadcastRound.
+func (r *round3) StoreBroadcastMessage(msg round.Message) error {
+	body, ok := msg.Content.(*broadcast3)
+	if !ok || body == nil {
+		return round.ErrInvalidContent
+	}
+	if body.S_i == nil {
+		return round.ErrNilFields
+	}
+	// The partial signature is verified in Finalize, only if the signature turns out to be invalid,
+	// so that we can identify every misbehaving participant at once.
+	r.s[msg.From] = body.S_i
+	return nil
+}
+
+// VerifyMessage implements round.Round.
+func (round3) VerifyMessage(round.Message) error { return nil }
+
+// StoreMessage implements round.Round.
+func (round3) StoreMessage(round.Message) error { return nil }
+
+// Finalize implements round.Round.
+func (r *round3) Finalize(chan<- *round.Message) (round.Session, error) {
+	psigs := make([]curve.Scalar, 0, len(r.s))
+	for _, l := range r.PartyIDs() {
+		psigs = append(psigs, r.s[l])
+	}
+	sig := r.session.aggregate(psigs)
+	if r.ctx.TaprootPublicKey().Verify(sig, r.M) {
+		return r.ResultRound(sig), nil
+	}
+
+	// PartialSigVerify
+	var culprits []party.ID
+	for _, l := range r.PartyIDs() {
+		if !r.session.verify(r.s[l], r.R1[l], r.R2[l], r.PublicKeys[l]) {
+			culprits = append(culprits, l)
+		}
+	}
+	if len(culprits) == 0 {
+		return r.AbortRound(fmt.Errorf("generated signature failed to verify")), nil
+	}
+	return r.AbortRound(fmt.Errorf("failed to verify partial signatures from %v", culprits), culprits...), nil
+}
+
+// MessageContent implements round.Round.
+func (round3) MessageContent() round.Content { return nil }
+
+// RoundNumber implements round.Content.
+func (broadcast3) RoundNumber() round.Number { return 3 }
+
+// BroadcastContent implements round.BroadcastRound.
+func (r *round3) BroadcastContent() round.BroadcastContent {
+	return &broadcast3{
+		S_i: r.Group().NewScalar(),
+	}
+}
+
+// Number implements round.Round.
+func (round3) Number() round.Number { return 3 }
@@ -0,0 +1,61 @@
+package sign
+
+import (
+	"fmt"
+
+	"github.com/taurusgroup/multi-party-sig/internal/round"
+	"github.com/taurusgroup/multi-party-sig/pkg/math/curve"
+	"github.com/taurusgroup/multi-party-sig/pkg/protocol"
+)
+
+const (
+	// MuSig2 Sign.
+	protocolID = "musig2/sign"
+	// protocolIDPreprocessed identifies signatures using preprocessed nonces.
+	protocolIDPreprocessed = "musig2/sign-preprocessed"
+	// This protocol has 3 concrete rounds.
+	protocolRounds round.Number = 3
+)
+
+// StartSign produces a taproot.Signature of messageHash, valid for the aggregate public key of config.
+func StartSign(config *Config, messageHash []byte) protocol.StartFunc {
+	return func(sessionID []byte) (round.Session, error) {
+		r, err := newRound1(protocolID, config, messageHash, sessionID)
+		if err != nil {
+			return nil, fmt.Errorf("sign.StartSign: %w", err)
+		}
+		return r, nil
+	}
+}
+
+// newRound1 creates the first round of a signature with the given protocol ID.
+func newRound1(protocolID string, config *Config, messageHash, sessionID []byte) (*round1, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	ctx, err := config.KeyAgg()
+	if err != nil {
+		return nil, err
+	}
+	group := curve.Secp256k1{}
+	info := round.Info{
+		ProtocolID:       protocolID,
+		FinalRoundNumber: protocolRounds,
+		SelfID:           config.ID,
+		PartyIDs:         config.PartyIDs(),
+		// every participant is needed to sign
+		Threshold: len(config.PublicKeys) - 1,
+		Group:     group,
+	}
+	helper, err := round.NewSession(info, sessionID, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &round1{
+		Helper:     helper,
+		ctx:        ctx,
+		M:          messageHash,
+		PublicKeys: config.PublicKeys,
+		sk:         config.SecretKey,
+	}, nil
+}
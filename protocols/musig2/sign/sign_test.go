@@ -0,0 +1,209 @@
+package sign
+
+import (
+	"crypto/rand"
+	"testing"
+
+	"github.com/cronokirby/safenum"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/taurusgroup/multi-party-sig/internal/round"
+	"github.com/taurusgroup/multi-party-sig/internal/test"
+	"github.com/taurusgroup/multi-party-sig/pkg/math/curve"
+	"github.com/taurusgroup/multi-party-sig/pkg/math/sample"
+	"github.com/taurusgroup/multi-party-sig/pkg/party"
+	"github.com/taurusgroup/multi-party-sig/pkg/taproot"
+)
+
+var oneNat = new(safenum.Nat).SetUint64(1)
+
+func newConfigs(partyIDs party.IDSlice) map[party.ID]*Config {
+	group := curve.Secp256k1{}
+	secretKeys := make(map[party.ID]*curve.Secp256k1Scalar, len(partyIDs))
+	publicKeys := make(map[party.ID]*curve.Secp256k1Point, len(partyIDs))
+	for _, id := range partyIDs {
+		secretKeys[id] = sample.Scalar(rand.Reader, group).(*curve.Secp256k1Scalar)
+		publicKeys[id] = secretKeys[id].ActOnBase().(*curve.Secp256k1Point)
+	}
+	configs := make(map[party.ID]*Config, len(partyIDs))
+	for _, id := range partyIDs {
+		configs[id] = &Config{
+			ID:         id,
+			SecretKey:  secretKeys[id],
+			PublicKeys: publicKeys,
+		}
+	}
+	return configs
+}
+
+func runRounds(t *testing.T, rounds []round.Session, rule test.Rule) {
+	for {
+		err, done := test.Rounds(rounds, rule)
+		require.NoError(t, err, "failed to process round")
+		if done {
+			break
+		}
+	}
+}
+
+func checkOutput(t *testing.T, rounds []round.Session, public taproot.PublicKey, m []byte) {
+	for _, r := range rounds {
+		require.IsType(t, &round.Output{}, r, "expected result round")
+		resultRound := r.(*round.Output)
+		require.IsType(t, taproot.Signature{}, resultRound.Result, "expected taproot signature result")
+		signature := resultRound.Result.(taproot.Signature)
+		assert.True(t, public.Verify(signature, m), "expected valid signature")
+	}
+}
+
+func TestSign(t *testing.T) {
+	N := 3
+	partyIDs := test.PartyIDs(N)
+	steak := []byte{0xDE, 0xAD, 0xBE, 0xEF}
+	tweak := make([]byte, 32)
+	_, _ = rand.Read(tweak)
+	merkleRoot := make([]byte, taproot.MerkleRootLen)
+	_, _ = rand.Read(merkleRoot)
+
+	tweaks := map[string]func(*Config) (*Config, error){
+		"none":    func(c *Config) (*Config, error) { return c, nil },
+		"plain":   func(c *Config) (*Config, error) { return c.Tweak(tweak, false) },
+		"x-only":  func(c *Config) (*Config, error) { return c.Tweak(tweak, true) },
+		"taproot": func(c *Config) (*Config, error) { return c.TweakTaproot(merkleRoot) },
+		"mixed": func(c *Config) (*Config, error) {
+			c, err := c.Tweak(tweak, false)
+			if err != nil {
+				return nil, err
+			}
+			return c.TweakTaproot(nil)
+		},
+	}
+
+	configs := newConfigs(partyIDs)
+	for name, apply := range tweaks {
+		t.Run(name, func(t *testing.T) {
+			var public taproot.PublicKey
+			rounds := make([]round.Session, 0, N)
+			for _, id := range partyIDs {
+				config, err := apply(configs[id])
+				require.NoError(t, err)
+				if public == nil {
+					public, err = config.PublicKey()
+					require.NoError(t, err)
+				}
+				r, err := StartSign(config, steak)(nil)
+				require.NoError(t, err, "round creation should not result in an error")
+				rounds = append(rounds, r)
+			}
+			runRounds(t, rounds, nil)
+			checkOutput(t, rounds, public, steak)
+		})
+	}
+}
+
+func TestTweakTaproot(t *testing.T) {
+	configs := newConfigs(test.PartyIDs(3))
+	merkleRoot := make([]byte, taproot.MerkleRootLen)
+	_, _ = rand.Read(merkleRoot)
+	for _, config := range configs {
+		internal, err := config.PublicKey()
+		require.NoError(t, err)
+		expected, _, err := internal.Tweak(merkleRoot)
+		require.NoError(t, err)
+		tweaked, err := config.TweakTaproot(merkleRoot)
+		require.NoError(t, err)
+		output, err := tweaked.PublicKey()
+		require.NoError(t, err)
+		assert.Equal(t, expected, output)
+		assert.Empty(t, config.Tweaks, "the original config should not be modified")
+	}
+}
+
+// TestRule adds one to the partial signature sᵢ broadcast by each of the cheaters.
+type TestRule struct {
+	cheaters party.IDSlice
+}
+
+func (tr *TestRule) ModifyBefore(round.Session) {}
+
+func (tr *TestRule) ModifyAfter(round.Session) {}
+
+func (tr *TestRule) ModifyContent(rNext round.Session, _ party.ID, content round.Content) {
+	c, ok := content.(*broadcast3)
+	if !ok || !tr.cheaters.Contains(rNext.SelfID()) {
+		return
+	}
+	one := rNext.Group().NewScalar().SetNat(oneNat)
+	c.S_i = rNext.Group().NewScalar().Set(c.S_i).Add(one)
+}
+
+func TestIdentifiableAbort(t *testing.T) {
+	N := 4
+	partyIDs := test.PartyIDs(N)
+	cheaters := party.NewIDSlice([]party.ID{partyIDs[0], partyIDs[2]})
+	configs := newConfigs(partyIDs)
+
+	rounds := make([]round.Session, 0, N)
+	for _, id := range partyIDs {
+		r, err := StartSign(configs[id], []byte("hello"))(nil)
+		require.NoError(t, err)
+		rounds = append(rounds, r)
+	}
+	runRounds(t, rounds, &TestRule{cheaters: cheaters})
+	for _, r := range rounds {
+		require.IsType(t, &round.Abort{}, r)
+		if cheaters.Contains(r.SelfID()) {
+			continue
+		}
+		assert.Equal(t, []party.ID(cheaters), r.(*round.Abort).Culprits)
+	}
+}
+
+func TestSignWithNonces(t *testing.T) {
+	N := 3
+	partyIDs := test.PartyIDs(N)
+	configs := newConfigs(partyIDs)
+	m := []byte("hello")
+
+	nonces := make(map[party.ID]*Nonces, N)
+	pubNonces := make(map[party.ID][]*PubNonce, N)
+	for _, id := range partyIDs {
+		var err error
+		nonces[id], pubNonces[id], err = Preprocess(configs[id], 2)
+		require.NoError(t, err)
+	}
+
+	public, err := configs[partyIDs[0]].PublicKey()
+	require.NoError(t, err)
+	for i := 0; i < 2; i++ {
+		chosen := make([]*PubNonce, 0, N)
+		for _, id := range partyIDs {
+			chosen = append(chosen, pubNonces[id][i])
+		}
+		rounds := make([]round.Session, 0, N)
+		for _, id := range partyIDs {
+			// persist and restore the nonces, as would be done between signatures
+			data, err := nonces[id].MarshalBinary()
+			require.NoError(t, err)
+			nonces[id] = EmptyNonces()
+			require.NoError(t, nonces[id].UnmarshalBinary(data))
+
+			r, err := StartSignWithNonces(configs[id], nonces[id], chosen, m)(nil)
+			require.NoError(t, err)
+			rounds = append(rounds, r)
+		}
+		runRounds(t, rounds, nil)
+		checkOutput(t, rounds, public, m)
+
+		// the nonces can't be used twice
+		for _, id := range partyIDs {
+			assert.Equal(t, 1-i, nonces[id].Len())
+			_, err = StartSignWithNonces(configs[id], nonces[id], chosen, m)(nil)
+			assert.Error(t, err)
+		}
+	}
+
+	// a nonce of each participant is needed
+	_, err = StartSignWithNonces(configs[partyIDs[0]], nonces[partyIDs[0]], pubNonces[partyIDs[0]][:1], m)(nil)
+	assert.Error(t, err)
+}